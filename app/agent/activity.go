@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// defaultTimelineLimit 任务详情中默认显示的动态条数
+const defaultTimelineLimit = 10
+
+// addComment 添加任务评论
+func (e *Executor) addComment(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	content, _ := args["content"].(string)
+
+	if err := tm.AddComment(taskID, content); err != nil {
+		return "", fmt.Errorf("failed to add comment: %v", err)
+	}
+	return fmt.Sprintf("💬 已在任务 %s 下添加评论", tm.TaskKey(taskID)), nil
+}
+
+// getRecentChanges 获取当前工作区一段时间内的任务变化（默认今天）
+func (e *Executor) getRecentChanges(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if hours, ok := args["hours"].(float64); ok && hours > 0 {
+		since = now.Add(-time.Duration(hours * float64(time.Hour)))
+	}
+
+	activities := tm.ActivitySince(workspaceID, since)
+	return task.FormatActivityDigest(tm, activities), nil
+}
+
+// formatTaskTimeline 格式化任务最近的动态，limit 不大于 0 时使用默认条数
+func formatTaskTimeline(tm *task.TaskManager, taskID uint, limit int) string {
+	if limit <= 0 {
+		limit = defaultTimelineLimit
+	}
+	activities := tm.GetTaskActivity(taskID, limit)
+	// 按时间正序显示
+	for i, j := 0, len(activities)-1; i < j; i, j = i+1, j-1 {
+		activities[i], activities[j] = activities[j], activities[i]
+	}
+	return "🕘 最近动态:\n" + task.FormatTimeline(activities)
+}
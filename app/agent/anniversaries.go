@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/anniversary"
+)
+
+// addAnniversary 在当前群或私聊登记生日或纪念日
+func (e *Executor) addAnniversary(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("anniversaries can only be added in a chat")
+	}
+	raw, _ := args["date"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return "", fmt.Errorf("date is required")
+	}
+	lunar, _ := args["lunar"].(bool)
+	a, err := anniversary.ParseDate(raw, lunar)
+	if err != nil {
+		return "", err
+	}
+
+	a.ChatID, a.ChatName, a.IsGroup = c.ChatID, c.ChatName, c.IsGroup
+	a.CreatorID, a.CreatorName = c.ID, c.Name
+	a.Contact, _ = args["contact"].(string)
+	a.Title, _ = args["title"].(string)
+	a.LeadDays = anniversary.DefaultLeadDays
+	if lead, ok := args["lead_days"].(float64); ok {
+		a.LeadDays = int(lead)
+	}
+	if err := anniversary.Create(&a, time.Now()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("🎂 已登记 %s（ID: %d），%s 提醒你", a.Describe(), a.ID, a.RemindAt.Format("2006-01-02 15:04")), nil
+}
+
+// listAnniversaries 列出调用者在当前群或私聊登记的纪念日
+func (e *Executor) listAnniversaries(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("anniversaries can only be listed in a chat")
+	}
+	list, err := anniversary.List(c.ChatName, c.IsGroup, c.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return "🎂 你在这里还没有登记生日或纪念日", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎂 你登记的生日和纪念日（%d 个，按下一次的日期排序）：\n", len(list))
+	for _, a := range list {
+		fmt.Fprintf(&b, "%d. %s\n", a.ID, a.Describe())
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// removeAnniversary 删除当前群或私聊中登记的纪念日
+func (e *Executor) removeAnniversary(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("anniversaries can only be removed in a chat")
+	}
+	id, err := parseIDArg(strings.TrimPrefix(fmt.Sprint(args["anniversary_id"]), "#"))
+	if err != nil {
+		return "", err
+	}
+	a, err := anniversary.Remove(id, c.ChatName, c.IsGroup)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ 已删除 %s的%s，不再提醒", a.Contact, a.Title), nil
+}
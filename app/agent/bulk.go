@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// bulkUpdateTasks 批量操作任务：未确认时只预览将要处理的任务，确认后在一个事务中执行
+func (e *Executor) bulkUpdateTasks(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	action, _ := args["action"].(string)
+	req := task.BulkRequest{Action: strings.TrimSpace(action)}
+
+	// 任务范围：查询语句或任务编号列表
+	ids, err := resolveTaskIDList(args, "task_ids")
+	if err != nil {
+		return "", err
+	}
+	req.IDs = ids
+	if query, ok := args["query"].(string); ok && strings.TrimSpace(query) != "" {
+		workspaceID, err := workspaceIDFromArgs(args)
+		if err != nil {
+			return "", err
+		}
+		c := callerFromArgs(args)
+		filter, err := task.ParseQuery(query, task.QueryContext{
+			WorkspaceID: workspaceID,
+			MeID:        c.ID,
+			MeName:      c.Name,
+			Now:         time.Now(),
+		})
+		if err != nil {
+			return "", err
+		}
+		req.Filter = filter
+	}
+
+	switch req.Action {
+	case task.BulkReassign:
+		c := callerFromArgs(args)
+		for _, name := range stringListArg(args, "assignees") {
+			req.Assignees = append(req.Assignees, assigneeActor(c, name))
+		}
+	case task.BulkRelabel:
+		req.AddLabels = stringListArg(args, "add_labels")
+		req.RemoveLabels = stringListArg(args, "remove_labels")
+	case task.BulkShiftDue:
+		if days, ok := args["days"].(float64); ok {
+			req.ShiftDays = int(days)
+		}
+	case task.BulkComplete:
+		req.Force, _ = args["force"].(bool)
+	}
+
+	if confirm, _ := args["confirm"].(bool); !confirm {
+		tasks, err := tm.PreviewBulk(req)
+		if err != nil {
+			return "", err
+		}
+		return task.FormatBulkPreview(req, tasks), nil
+	}
+
+	// 确认执行时按预览的同一批任务执行，并整体记录一条撤销记录
+	tasks, err := tm.PreviewBulk(req)
+	if err != nil {
+		return "", err
+	}
+	if len(tasks) == 0 {
+		return task.FormatBulkPreview(req, tasks), nil
+	}
+	req.Filter = nil
+	req.IDs = make([]uint, len(tasks))
+	for i, t := range tasks {
+		req.IDs[i] = t.ID
+	}
+
+	var undo *task.UndoRecorder
+	if task.BulkUndoable(req.Action) {
+		workspaceID, _ := workspaceIDFromArgs(args)
+		undo = tm.BeginUndo(workspaceID, task.UndoBulk, task.BulkUndoIDs(tasks)...)
+	}
+	report, err := tm.RunBulk(req)
+	if err != nil {
+		return "", fmt.Errorf("bulk operation failed: %v", err)
+	}
+	if undo != nil {
+		undo.Created(report.Created...).Commit()
+	}
+	return task.FormatBulkReport(req, report), nil
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/datetime"
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// weekdayNames 星期的中文名称
+var weekdayNames = []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// setNonWorkingDay 添加或删除当前工作区的自定义休息日
+func (e *Executor) setNonWorkingDay(args map[string]interface{}) (string, error) {
+	current, err := workspaceFromArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if current == nil {
+		return "", fmt.Errorf("unknown workspace")
+	}
+
+	raw, _ := args["date"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return "", fmt.Errorf("date is required")
+	}
+	res, err := dateParserFor(args).Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	date := res.Time
+
+	tm := taskManagerFor(args)
+	if remove, _ := args["remove"].(bool); remove {
+		if err := tm.RemoveNonWorkingDay(current.ID, date); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ 「%s」的 %s 不再是自定义休息日", workspaceDisplayName(current), formatCalendarDate(date)), nil
+	}
+
+	name, _ := args["name"].(string)
+	day, err := tm.AddNonWorkingDay(current.ID, date, name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ 已将 %s 设为「%s」的休息日（%s），按工作日计算截止时间和逾期时会跳过这一天",
+		formatCalendarDate(date), workspaceDisplayName(current), day.Name), nil
+}
+
+// listNonWorkingDays 列出当前工作区近期的休息安排：自定义休息日和法定节假日
+func (e *Executor) listNonWorkingDays(args map[string]interface{}) (string, error) {
+	current, err := workspaceFromArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if current == nil {
+		return "", fmt.Errorf("unknown workspace")
+	}
+
+	today := time.Now().In(callerLocation(args))
+	days, err := task.GetTaskManager().ListNonWorkingDays(current.ID, today)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📅 「%s」的工作日历\n", workspaceDisplayName(current))
+	if len(days) == 0 {
+		b.WriteString("没有自定义休息日\n")
+	} else {
+		b.WriteString("自定义休息日：\n")
+		for _, d := range days {
+			date, err := time.ParseInLocation(datetime.DateLayout, d.Date, today.Location())
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s %s\n", formatCalendarDate(date), d.Name)
+		}
+	}
+
+	cal := datetime.DefaultCalendar()
+	if years := cal.Years(); len(years) > 0 {
+		fmt.Fprintf(&b, "法定节假日和调休数据：%d-%d 年（其他年份只按周末计算）", years[0], years[len(years)-1])
+	} else {
+		b.WriteString("没有法定节假日数据，只按周末计算")
+	}
+	return b.String(), nil
+}
+
+// formatCalendarDate 日期和星期，如 “2026-10-01（周四）”
+func formatCalendarDate(t time.Time) string {
+	return fmt.Sprintf("%s（%s）", t.Format(datetime.DateLayout), weekdayNames[t.Weekday()])
+}
@@ -1,13 +1,17 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/869413421/wechatbot/app/anniversary"
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/datetime"
+	"github.com/869413421/wechatbot/app/notify"
 	"github.com/869413421/wechatbot/app/task"
 )
 
@@ -47,6 +51,76 @@ func (e *Executor) ExecuteCommand(command string, args map[string]interface{}) (
 		return e.getUpcomingTasks(args)
 	case "update_task_dependencies":
 		return e.updateTaskDependencies(args)
+	case "get_ready_tasks":
+		return e.getReadyTasks(args)
+	case "get_execution_order":
+		return e.getExecutionOrder(args)
+	case "get_critical_path":
+		return e.getCriticalPath(args)
+	case "get_at_risk_tasks":
+		return e.getAtRiskTasks(args)
+	case "render_task_graph":
+		return e.renderTaskGraph(args)
+	case "set_task_parent":
+		return e.setTaskParent(args)
+	case "add_checklist_item":
+		return e.addChecklistItem(args)
+	case "check_checklist_item":
+		return e.setChecklistItemChecked(args, true)
+	case "uncheck_checklist_item":
+		return e.setChecklistItemChecked(args, false)
+	case "remove_checklist_item":
+		return e.removeChecklistItem(args)
+	case "share_task":
+		return e.shareTask(args)
+	case "unshare_task":
+		return e.unshareTask(args)
+	case "list_workspaces":
+		return e.listWorkspaces(args)
+	case "set_workspace_prefix":
+		return e.setWorkspacePrefix(args)
+	case "add_comment":
+		return e.addComment(args)
+	case "get_recent_changes":
+		return e.getRecentChanges(args)
+	case "list_trash":
+		return e.listTrash(args)
+	case "restore_task":
+		return e.restoreTask(args)
+	case "undo_last":
+		return e.undoLast(args)
+	case "set_escalation_policy":
+		return e.setEscalationPolicy(args)
+	case "set_non_working_day":
+		return e.setNonWorkingDay(args)
+	case "list_non_working_days":
+		return e.listNonWorkingDays(args)
+	case "set_reminder":
+		return e.setReminder(args)
+	case "list_reminders":
+		return e.listReminders(args)
+	case "cancel_reminder":
+		return e.cancelReminder(args)
+	case "add_anniversary":
+		return e.addAnniversary(args)
+	case "list_anniversaries":
+		return e.listAnniversaries(args)
+	case "remove_anniversary":
+		return e.removeAnniversary(args)
+	case "set_notification_preferences":
+		return e.setNotificationPreferences(args)
+	case "get_notification_preferences":
+		return e.getNotificationPreferences(args)
+	case "query_tasks":
+		return e.queryTasks(args)
+	case "next_page":
+		return e.nextPage(args)
+	case "bulk_update_tasks":
+		return e.bulkUpdateTasks(args)
+	case "label_task":
+		return e.labelTask(args)
+	case "assign_task":
+		return e.assignTask(args)
 	default:
 		return "", fmt.Errorf("unknown command: %s", command)
 	}
@@ -54,7 +128,7 @@ func (e *Executor) ExecuteCommand(command string, args map[string]interface{}) (
 
 // createTask 创建任务
 func (e *Executor) createTask(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
 	// 解析必需参数：content（任务内容）和creator_id（用户ID）
 	content, _ := args["content"].(string)
@@ -68,44 +142,29 @@ func (e *Executor) createTask(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("创建人ID不能为空")
 	}
 
+	// 任务归属当前会话的工作区
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
 	// 解析可选参数：title（由AI推测，如果为空则使用内容预览）
 	title, _ := args["title"].(string)
 
 	// 解析截止时间（可选）
 	var dueTime *time.Time
 	if dueTimeStr, ok := args["due_time"].(string); ok && dueTimeStr != "" {
-		parsedTime, err := e.parseDueTime(dueTimeStr)
+		parsedTime, err := e.parseDueTime(args, dueTimeStr)
 		if err != nil {
-			log.Printf("WARNING: Failed to parse due_time '%s': %v, will set to nil\n", dueTimeStr, err)
-			dueTime = nil
-		} else {
-			dueTime = &parsedTime
+			return "", fmt.Errorf("截止时间无效: %v", err)
 		}
+		dueTime = &parsedTime
 	}
 
 	// 解析依赖任务（可选）
-	var dependencies []uint
-	if deps, ok := args["dependencies"].([]interface{}); ok {
-		for _, dep := range deps {
-			var depID uint
-			switch v := dep.(type) {
-			case string:
-				id, err := strconv.ParseUint(v, 10, 32)
-				if err != nil {
-					log.Printf("WARNING: Invalid dependency ID '%s': %v, skipping\n", v, err)
-					continue
-				}
-				depID = uint(id)
-			case float64:
-				depID = uint(v)
-			case int:
-				depID = uint(v)
-			default:
-				log.Printf("WARNING: Invalid dependency type: %T, skipping\n", v)
-				continue
-			}
-			dependencies = append(dependencies, depID)
-		}
+	dependencies, err := resolveTaskIDList(args, "dependencies")
+	if err != nil {
+		return "", err
 	}
 
 	// 创建任务
@@ -115,7 +174,12 @@ func (e *Executor) createTask(args map[string]interface{}) (string, error) {
 		log.Printf("Raw due_time from AI: '%s'\n", dueTimeStr)
 	}
 	
-	createdTask, err := tm.CreateTask(title, content, creatorID, dueTime, dependencies)
+	var createdTask *task.Task
+	if recurrence, ok := args["recurrence"].(string); ok && recurrence != "" {
+		createdTask, err = tm.CreateRecurringTask(workspaceID, title, content, creatorID, dueTime, dependencies, recurrence)
+	} else {
+		createdTask, err = tm.CreateTask(workspaceID, title, content, creatorID, dueTime, dependencies)
+	}
 	if err != nil {
 		log.Printf("ERROR: CreateTask failed: %v\n", err)
 		return "", fmt.Errorf("创建任务失败: %v", err)
@@ -123,164 +187,130 @@ func (e *Executor) createTask(args map[string]interface{}) (string, error) {
 	
 	log.Printf("CreateTask succeeded, task ID: %d\n", createdTask.ID)
 
-	result := fmt.Sprintf("✅ 任务创建成功！\n%s", task.FormatTaskForDisplayWithManager(createdTask, tm))
-	return result, nil
-}
-
-// parseDueTime 解析截止时间，支持多种格式和自然语言
-// AI应该已经将自然语言转换为标准格式，这里主要处理标准格式，但也支持一些自然语言作为备用
-func (e *Executor) parseDueTime(timeStr string) (time.Time, error) {
-	now := time.Now()
-	timeStr = strings.TrimSpace(timeStr)
-	timeStrLower := strings.ToLower(timeStr)
-	
-	// 处理自然语言（AI应该已经转换，但这里作为备用）
-	if strings.Contains(timeStrLower, "今天") {
-		// 提取时间部分
-		timePart := extractTimeFromString(timeStr)
-		if timePart != "" {
-			return parseTimeForDate(now, timePart)
+	// 作为子任务创建（可选）
+	if parentIDRaw, ok := args["parent_id"]; ok && parentIDRaw != nil && parentIDRaw != "" {
+		parentID, err := resolveTaskID(args, "parent_id")
+		if err != nil {
+			return "", fmt.Errorf("任务 %s 已创建，但设置父任务失败: %v", createdTask.DisplayKey(), err)
 		}
-		// 如果没有时间，默认今天23:59:59
-		return time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.Local), nil
-	}
-	
-	if strings.Contains(timeStrLower, "明天") {
-		tomorrow := now.AddDate(0, 0, 1)
-		timePart := extractTimeFromString(timeStr)
-		if timePart != "" {
-			return parseTimeForDate(tomorrow, timePart)
+		if parentID != 0 {
+			if err := tm.SetTaskParent(createdTask.ID, parentID); err != nil {
+				return "", fmt.Errorf("任务 %s 已创建，但设置父任务失败: %v", createdTask.DisplayKey(), err)
+			}
+			createdTask.ParentID = parentID
 		}
-		return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 23, 59, 59, 0, time.Local), nil
 	}
-	
-	if strings.Contains(timeStrLower, "后天") {
-		dayAfterTomorrow := now.AddDate(0, 0, 2)
-		timePart := extractTimeFromString(timeStr)
-		if timePart != "" {
-			return parseTimeForDate(dayAfterTomorrow, timePart)
+	if autoComplete, ok := args["auto_complete"].(bool); ok && autoComplete {
+		if err := tm.SetAutoComplete(createdTask.ID, true); err == nil {
+			createdTask.AutoComplete = true
 		}
-		return time.Date(dayAfterTomorrow.Year(), dayAfterTomorrow.Month(), dayAfterTomorrow.Day(), 23, 59, 59, 0, time.Local), nil
 	}
-	
-	// 尝试多种标准时间格式
-	formats := []string{
-		"2006-01-02 15:04:05",
-		"2006-01-02 15:04",
-		"2006-01-02",
-		"2006/01/02 15:04:05",
-		"2006/01/02 15:04",
-		"2006/01/02",
-		time.RFC3339,
-		time.RFC3339Nano,
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, timeStr); err == nil {
-			return t, nil
+	if estimate, ok := parseEstimateArg(args); ok {
+		if err := tm.SetTaskEstimate(createdTask.ID, estimate); err == nil {
+			createdTask.EstimateMinutes = int(estimate / time.Minute)
 		}
 	}
-
-	return time.Time{}, fmt.Errorf("无法解析时间格式: %s", timeStr)
-}
-
-// extractTimeFromString 从字符串中提取时间部分（如 "12:00", "15:30", "13点", "下午4点"）
-func extractTimeFromString(s string) string {
-	// 先尝试匹配 HH:MM 或 HH:MM:SS 格式
-	re := regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?`)
-	matches := re.FindStringSubmatch(s)
-	if len(matches) > 0 {
-		return matches[0]
-	}
-	
-	// 匹配中文格式：HH点MM分 或 HH点
-	reCN := regexp.MustCompile(`(\d{1,2})(?:点|时)(?:(\d{2})(?:分)?)?`)
-	matchesCN := reCN.FindStringSubmatch(s)
-	if len(matchesCN) >= 2 {
-		hour := matchesCN[1]
-		minute := "00"
-		if len(matchesCN) >= 3 && matchesCN[2] != "" {
-			minute = matchesCN[2]
+	if raw, ok := args["priority"].(string); ok && raw != "" {
+		if priority, err := task.ParsePriority(raw); err == nil {
+			if err := tm.SetTaskPriority(createdTask.ID, priority); err == nil {
+				createdTask.Priority = priority
+			}
+		} else {
+			log.Printf("WARNING: Ignoring priority '%s': %v\n", raw, err)
 		}
-		return hour + ":" + minute
 	}
-	
-	// 匹配"下午X点"、"上午X点"等
-	rePM := regexp.MustCompile(`(?:下午|晚上)(\d{1,2})(?:点|时)`)
-	matchesPM := rePM.FindStringSubmatch(s)
-	if len(matchesPM) >= 2 {
-		hour, _ := strconv.Atoi(matchesPM[1])
-		if hour < 12 {
-			hour += 12 // 下午转换为24小时制
+
+	if raw, ok := args["reminders"].(string); ok && raw != "" {
+		if offsets, err := task.ParseReminderOffsets(raw); err == nil {
+			if err := tm.SetTaskReminderOffsets(createdTask.ID, offsets); err == nil {
+				if refreshed, exists := tm.GetTask(createdTask.ID); exists {
+					createdTask = refreshed
+				}
+			}
+		} else {
+			log.Printf("WARNING: Ignoring reminders '%s': %v\n", raw, err)
 		}
-		return fmt.Sprintf("%d:00", hour)
 	}
-	
-	reAM := regexp.MustCompile(`(?:上午|早上)(\d{1,2})(?:点|时)`)
-	matchesAM := reAM.FindStringSubmatch(s)
-	if len(matchesAM) >= 2 {
-		return matchesAM[1] + ":00"
-	}
-	
-	return ""
+
+	// 记录撤销信息：撤销创建即把任务移入回收站
+	tm.BeginUndo(workspaceID, task.UndoCreate).Created(createdTask.ID).Commit()
+
+	result := fmt.Sprintf("✅ 任务创建成功！\n%s", task.FormatTaskForDisplayWithManager(createdTask, tm))
+	return result, nil
 }
 
-// parseTimeForDate 为指定日期解析时间字符串
-func parseTimeForDate(date time.Time, timeStr string) (time.Time, error) {
-	parts := strings.Split(timeStr, ":")
-	if len(parts) < 2 {
-		return time.Time{}, fmt.Errorf("invalid time format: %s", timeStr)
-	}
-	
-	hour, err := strconv.Atoi(parts[0])
-	if err != nil || hour < 0 || hour > 23 {
-		return time.Time{}, fmt.Errorf("invalid hour: %s", parts[0])
-	}
-	
-	minute, err := strconv.Atoi(parts[1])
-	if err != nil || minute < 0 || minute > 59 {
-		return time.Time{}, fmt.Errorf("invalid minute: %s", parts[1])
-	}
-	
-	second := 0
-	if len(parts) > 2 {
-		second, _ = strconv.Atoi(parts[2])
+// parseDueTime 解析截止时间，支持标准格式和中文自然语言（如“下周一”“本周五下午”“3天后”），只有日期时取当天 23:59:59
+func (e *Executor) parseDueTime(args map[string]interface{}, timeStr string) (time.Time, error) {
+	return dateParserFor(args).ParseDeadline(timeStr)
+}
+
+// callerLocation 调用者的时区
+func callerLocation(args map[string]interface{}) *time.Location {
+	return notify.GetPreference(callerFromArgs(args).Name).Location()
+}
+
+// dateParserFor 按调用者的时区和当前工作区的工作日历解析时间的解析器
+func dateParserFor(args map[string]interface{}) *datetime.Parser {
+	p := datetime.NewParser(callerLocation(args))
+	if ws, err := workspaceFromArgs(args); err == nil && ws != nil {
+		p.Calendar = task.GetTaskManager().WorkCalendar(ws.ID)
 	}
-	
-	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, 0, time.Local), nil
+	return p
 }
 
 // listTasks 列出任务（支持查看所有任务或按用户筛选）
 func (e *Executor) listTasks(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
 	status, _ := args["status"].(string)
 	creatorID, _ := args["creator_id"].(string)
-	
-	// 如果传入了creator_id，使用它；否则查看所有任务
-	tasks := tm.ListTasks(status, creatorID)
 
-	if len(tasks) == 0 {
-		if creatorID != "" {
-			return "📋 该用户暂无任务", nil
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	// 如果传入了creator_id，使用它；否则查看当前工作区的所有任务
+	filter := task.TaskFilter{WorkspaceID: workspaceID, CreatorID: creatorID}
+	if status != "" {
+		filter.Statuses = []string{status}
+	}
+	if sortArg, ok := args["sort"].(string); ok && sortArg != "" {
+		if filter.SortBy, filter.SortReverse, err = parseSortArg(sortArg); err != nil {
+			return "", err
 		}
-		return "📋 暂无任务", nil
+	}
+	pageSize := 0
+	if size, ok := args["page_size"].(float64); ok {
+		pageSize = int(size)
 	}
 
-	return task.FormatTaskListForDisplay(tasks), nil
+	result, err := showFirstPage(tm, args, "任务列表", filter, pageSize)
+	if err != nil {
+		return "", err
+	}
+	if creatorID != "" && strings.HasPrefix(result, "📋 暂无任务") {
+		return "📋 该用户暂无任务", nil
+	}
+	return result, nil
 }
 
 // getTaskCount 获取任务数量
 func (e *Executor) getTaskCount(args map[string]interface{}) (string, error) {
 	log.Printf("getTaskCount called with args: %v\n", args)
 
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 	log.Printf("TaskManager obtained\n")
 
 	status, _ := args["status"].(string)
 	log.Printf("Getting task count for status: '%s'\n", status)
 
-	count := tm.GetTaskCount(status)
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	count := tm.GetTaskCount(workspaceID, status)
 	log.Printf("Task count retrieved: %d\n", count)
 
 	statusText := map[string]string{
@@ -289,6 +319,7 @@ func (e *Executor) getTaskCount(args map[string]interface{}) (string, error) {
 		task.StatusInProgress: "进行中",
 		task.StatusCompleted:  "已完成",
 		task.StatusCancelled:  "已取消",
+		task.StatusBlocked:    "被阻塞",
 	}
 
 	text := statusText[status]
@@ -303,64 +334,72 @@ func (e *Executor) getTaskCount(args map[string]interface{}) (string, error) {
 
 // updateTaskStatus 更新任务状态
 func (e *Executor) updateTaskStatus(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
-	taskIDRaw := args["task_id"]
 	status, _ := args["status"].(string)
 
 	if status == "" {
 		return "", fmt.Errorf("status is required")
 	}
 
-	// 解析任务ID
-	var taskID uint
-	switch v := taskIDRaw.(type) {
-	case string:
-		id, err := strconv.ParseUint(v, 10, 32)
+	// 解析任务ID（只能操作当前工作区可见的任务）
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	workspaceID, _ := workspaceIDFromArgs(args)
+
+	// 取消整个重复系列
+	scope, _ := args["scope"].(string)
+	if status == task.StatusCancelled && scope == task.ScopeSeries {
+		undo := tm.BeginUndo(workspaceID, task.UndoStatus, tm.SeriesOpenTaskIDs(taskID)...)
+		count, err := tm.CancelSeries(taskID)
 		if err != nil {
-			return "", fmt.Errorf("invalid task_id: %s", v)
+			return "", fmt.Errorf("failed to cancel series: %v", err)
 		}
-		taskID = uint(id)
-	case float64:
-		taskID = uint(v)
-	case int:
-		taskID = uint(v)
-	default:
-		return "", fmt.Errorf("invalid task_id type: %T", v)
+		undo.Commit()
+		return fmt.Sprintf("🔁 已取消整个重复系列，共取消 %d 个未完成的任务", count), nil
+	}
+
+	// 状态变化可能自动完成父任务、生成下一次重复任务，撤销时一并恢复
+	undoIDs := []uint{taskID}
+	if t, exists := tm.GetTask(taskID); exists && t.ParentID != 0 {
+		undoIDs = append(undoIDs, t.ParentID)
 	}
+	undo := tm.BeginUndo(workspaceID, task.UndoStatus, undoIDs...)
+	previousNext, hadNext := tm.NextOccurrence(taskID)
 
-	err := tm.UpdateTaskStatus(taskID, status)
+	force, _ := args["force"].(bool)
+	err = tm.TransitionTaskStatusIfUnchanged(taskID, status, force, versionArg(args))
 	if err != nil {
+		if errors.Is(err, task.ErrConflict) {
+			return "", conflictError(tm, taskID)
+		}
 		return "", fmt.Errorf("failed to update task status: %v", err)
 	}
 
-	return fmt.Sprintf("任务状态已更新为: %s", status), nil
+	next, hasNext := tm.NextOccurrence(taskID)
+	if hasNext && (!hadNext || previousNext.ID != next.ID) {
+		undo.Created(next.ID)
+	}
+	undo.Commit()
+
+	result := fmt.Sprintf("任务状态已更新为: %s", status)
+	if hasNext && next.DueTime != nil {
+		result += fmt.Sprintf("\n🔁 已生成下一次重复任务 (ID: %s)，截止时间: %s", next.DisplayKey(), next.DueTime.Format("2006-01-02 15:04:05"))
+	}
+	return result, nil
 }
 
 // updateTask 更新任务的多个字段（标题、内容、截止时间等）
 func (e *Executor) updateTask(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
-
-	taskIDRaw := args["task_id"]
-	if taskIDRaw == nil {
-		return "", fmt.Errorf("task_id is required")
-	}
+	tm := taskManagerFor(args)
 
-	// 解析任务ID
-	var taskID uint
-	switch v := taskIDRaw.(type) {
-	case string:
-		id, err := strconv.ParseUint(v, 10, 32)
-		if err != nil {
-			return "", fmt.Errorf("invalid task_id: %s", v)
-		}
-		taskID = uint(id)
-	case float64:
-		taskID = uint(v)
-	case int:
-		taskID = uint(v)
-	default:
-		return "", fmt.Errorf("invalid task_id type: %T", v)
+	// 解析任务ID（只能操作当前工作区可见的任务）
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
 	}
 
 	// 解析可选字段
@@ -376,21 +415,95 @@ func (e *Executor) updateTask(args map[string]interface{}) (string, error) {
 
 	var dueTime *time.Time
 	if dueTimeStr, ok := args["due_time"].(string); ok && dueTimeStr != "" {
-		parsedTime, err := e.parseDueTime(dueTimeStr)
+		parsedTime, err := e.parseDueTime(args, dueTimeStr)
 		if err != nil {
-			log.Printf("WARNING: Failed to parse due_time '%s': %v\n", dueTimeStr, err)
-			// 不返回错误，只是不更新截止时间
-		} else {
-			dueTime = &parsedTime
+			return "", fmt.Errorf("截止时间无效: %v", err)
 		}
+		dueTime = &parsedTime
+	}
+
+	recurrence, hasRecurrence := args["recurrence"].(string)
+	scope, _ := args["scope"].(string)
+
+	priorityArg, hasPriority := args["priority"].(string)
+	var priority int
+	if hasPriority {
+		if priority, err = task.ParsePriority(priorityArg); err != nil {
+			return "", err
+		}
+	}
+
+	// 提醒提前量，default 表示恢复默认
+	remindersArg, hasReminders := args["reminders"].(string)
+	var reminderOffsets []time.Duration
+	if hasReminders && remindersArg != "default" && remindersArg != "默认" {
+		if reminderOffsets, err = task.ParseReminderOffsets(remindersArg); err != nil {
+			return "", err
+		}
+	}
+
+	// 系列修改会影响所有未结束的发生，全部记录快照以便撤销
+	workspaceID, _ := workspaceIDFromArgs(args)
+	undoIDs := []uint{taskID}
+	if scope == task.ScopeSeries {
+		undoIDs = tm.SeriesOpenTaskIDs(taskID)
+	}
+	undo := tm.BeginUndo(workspaceID, task.UndoUpdate, undoIDs...)
+
+	// 要清空的字段（如去掉截止时间）
+	clearFields := stringListArg(args, "clear")
+
+	update := task.TaskUpdate{
+		Title:           title,
+		Content:         content,
+		DueTime:         dueTime,
+		Clear:           clearFields,
+		ExpectedVersion: versionArg(args),
 	}
+	if estimate, ok := parseEstimateArg(args); ok {
+		update.Estimate = &estimate
+	}
+	if hasPriority {
+		update.Priority = &priority
+	}
+	if hasReminders {
+		update.ReminderOffsets = &reminderOffsets
+	}
+	hasTaskFields := update.Estimate != nil || update.Priority != nil || update.ReminderOffsets != nil
 
-	// 更新任务
-	err := tm.UpdateTask(taskID, title, content, dueTime)
+	// 更新任务（重复任务可选择只改本次或整个系列）
+	if scope == task.ScopeSeries {
+		if len(clearFields) > 0 {
+			return "", fmt.Errorf("clearing fields is not supported for the whole series")
+		}
+		if title != nil || content != nil || dueTime != nil {
+			err = tm.UpdateTaskSeries(taskID, title, content, dueTime)
+		}
+		// 预计耗时、优先级和提醒只修改本次
+		if err == nil && hasTaskFields {
+			err = tm.UpdateTask(taskID, task.TaskUpdate{
+				Estimate:        update.Estimate,
+				Priority:        update.Priority,
+				ReminderOffsets: update.ReminderOffsets,
+			})
+		}
+	} else if title != nil || content != nil || dueTime != nil || len(clearFields) > 0 || hasTaskFields || !hasRecurrence {
+		err = tm.UpdateTask(taskID, update)
+	}
 	if err != nil {
+		if errors.Is(err, task.ErrConflict) {
+			return "", conflictError(tm, taskID)
+		}
 		return "", fmt.Errorf("failed to update task: %v", err)
 	}
 
+	if hasRecurrence {
+		if err := tm.UpdateTaskRecurrence(taskID, recurrence); err != nil {
+			return "", fmt.Errorf("failed to update task recurrence: %v", err)
+		}
+	}
+	undo.Commit()
+
 	// 获取更新后的任务信息
 	updatedTask, exists := tm.GetTask(taskID)
 	if !exists {
@@ -402,122 +515,78 @@ func (e *Executor) updateTask(args map[string]interface{}) (string, error) {
 
 // getTask 获取单个任务
 func (e *Executor) getTask(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
-	taskIDRaw := args["task_id"]
-	
-	// 解析任务ID
-	var taskID uint
-	switch v := taskIDRaw.(type) {
-	case string:
-		id, err := strconv.ParseUint(v, 10, 32)
-		if err != nil {
-			return "", fmt.Errorf("invalid task_id: %s", v)
-		}
-		taskID = uint(id)
-	case float64:
-		taskID = uint(v)
-	case int:
-		taskID = uint(v)
-	default:
-		return "", fmt.Errorf("invalid task_id type: %T", v)
+	// 解析任务ID（只能操作当前工作区可见的任务）
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
 	}
 
 	t, exists := tm.GetTask(taskID)
 	if !exists {
-		return "", fmt.Errorf("task not found: %d", taskID)
+		return "", fmt.Errorf("task not found: %s", tm.TaskKey(taskID))
 	}
 
-	return task.FormatTaskForDisplayWithManager(t, tm), nil
+	result := task.FormatTaskForDisplayWithManager(t, tm)
+	if history, _ := args["include_history"].(bool); history {
+		limit := 0
+		if l, ok := args["history_limit"].(float64); ok {
+			limit = int(l)
+		}
+		result += "\n\n" + formatTaskTimeline(tm, taskID, limit)
+	}
+	return result, nil
 }
 
 // deleteTask 删除任务
 func (e *Executor) deleteTask(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
-	taskIDRaw := args["task_id"]
-	
-	// 解析任务ID
-	var taskID uint
-	switch v := taskIDRaw.(type) {
-	case string:
-		id, err := strconv.ParseUint(v, 10, 32)
-		if err != nil {
-			return "", fmt.Errorf("invalid task_id: %s", v)
-		}
-		taskID = uint(id)
-	case float64:
-		taskID = uint(v)
-	case int:
-		taskID = uint(v)
-	default:
-		return "", fmt.Errorf("invalid task_id type: %T", v)
+	// 解析任务ID（只能操作当前工作区可见的任务）
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
 	}
 
-	err := tm.DeleteTask(taskID)
+	workspaceID, _ := workspaceIDFromArgs(args)
+	undo := tm.BeginUndo(workspaceID, task.UndoDelete, taskID)
+
+	key := tm.TaskKey(taskID)
+	err = tm.DeleteTask(taskID)
 	if err != nil {
 		return "", fmt.Errorf("failed to delete task: %v", err)
 	}
+	undo.Commit()
 
-	return fmt.Sprintf("任务 %d 已成功删除", taskID), nil
+	return fmt.Sprintf("🗑️ 任务 %s 已移入回收站，%d 天内可以恢复", key, config.LoadConfig().Task.TrashRetentionDays), nil
 }
 
 // updateTaskDependencies 更新任务的依赖关系
 func (e *Executor) updateTaskDependencies(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
-
-	taskIDRaw := args["task_id"]
-	if taskIDRaw == nil {
-		return "", fmt.Errorf("task_id is required")
-	}
+	tm := taskManagerFor(args)
 
-	// 解析任务ID
-	var taskID uint
-	switch v := taskIDRaw.(type) {
-	case string:
-		id, err := strconv.ParseUint(v, 10, 32)
-		if err != nil {
-			return "", fmt.Errorf("invalid task_id: %s", v)
-		}
-		taskID = uint(id)
-	case float64:
-		taskID = uint(v)
-	case int:
-		taskID = uint(v)
-	default:
-		return "", fmt.Errorf("invalid task_id type: %T", v)
+	// 解析任务ID（只能操作当前工作区可见的任务）
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
 	}
 
 	// 解析依赖任务列表
-	var dependencies []uint
-	if deps, ok := args["dependencies"].([]interface{}); ok {
-		for _, dep := range deps {
-			var depID uint
-			switch v := dep.(type) {
-			case string:
-				id, err := strconv.ParseUint(v, 10, 32)
-				if err != nil {
-					log.Printf("WARNING: Invalid dependency ID '%s': %v, skipping\n", v, err)
-					continue
-				}
-				depID = uint(id)
-			case float64:
-				depID = uint(v)
-			case int:
-				depID = uint(v)
-			default:
-				log.Printf("WARNING: Invalid dependency type: %T, skipping\n", v)
-				continue
-			}
-			dependencies = append(dependencies, depID)
-		}
+	dependencies, err := resolveTaskIDList(args, "dependencies")
+	if err != nil {
+		return "", err
 	}
 
+	workspaceID, _ := workspaceIDFromArgs(args)
+	undo := tm.BeginUndo(workspaceID, task.UndoDependencies, taskID)
+
 	// 更新依赖关系
-	err := tm.UpdateTaskDependencies(taskID, dependencies)
+	err = tm.UpdateTaskDependencies(taskID, dependencies)
 	if err != nil {
 		return "", fmt.Errorf("failed to update task dependencies: %v", err)
 	}
+	undo.Commit()
 
 	// 获取更新后的任务信息
 	updatedTask, exists := tm.GetTask(taskID)
@@ -528,48 +597,88 @@ func (e *Executor) updateTaskDependencies(args map[string]interface{}) (string,
 	return fmt.Sprintf("✅ 任务依赖关系已更新！\n%s", task.FormatTaskForDisplayWithManager(updatedTask, tm)), nil
 }
 
-// searchTasks 搜索任务
+// searchTasks 搜索任务（数据库全文检索，可与状态、创建人、时间范围等条件组合）
 func (e *Executor) searchTasks(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
 	// 支持 keyword 和 query 两种参数名（兼容性）
 	keyword, _ := args["keyword"].(string)
 	if keyword == "" {
 		keyword, _ = args["query"].(string)
 	}
-	if keyword == "" {
-		// 如果都没有，列出所有任务
-		return task.FormatTaskListForDisplay(tm.ListTasks("", "")), nil
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
 	}
 
-	// 获取所有任务并过滤
-	allTasks := tm.ListTasks("", "")
-	matchedTasks := make([]*task.Task, 0)
-
-	for _, t := range allTasks {
-		// 在标题和内容中搜索关键词
-		if contains(t.Title, keyword) || contains(t.Content, keyword) {
-			matchedTasks = append(matchedTasks, t)
+	filter := task.TaskFilter{
+		WorkspaceID: workspaceID,
+		Keyword:     keyword,
+	}
+	if status, ok := args["status"].(string); ok && status != "" {
+		for _, s := range strings.Split(status, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				filter.Statuses = append(filter.Statuses, s)
+			}
 		}
 	}
+	filter.CreatorID, _ = args["creator_id"].(string)
+	if limit, ok := args["limit"].(float64); ok {
+		filter.Limit = int(limit)
+	}
 
-	if len(matchedTasks) == 0 {
-		return fmt.Sprintf("未找到包含 '%s' 的任务", keyword), nil
+	// 时间范围
+	for key, target := range map[string]**time.Time{
+		"due_after":      &filter.DueAfter,
+		"due_before":     &filter.DueBefore,
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		raw, ok := args[key].(string)
+		if !ok || raw == "" {
+			continue
+		}
+		parsed, err := e.parseDueTime(args, raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %v", key, err)
+		}
+		*target = &parsed
 	}
 
-	return task.FormatTaskListForDisplay(matchedTasks), nil
-}
+	if keyword == "" && len(filter.Statuses) == 0 && filter.CreatorID == "" &&
+		filter.DueAfter == nil && filter.DueBefore == nil && filter.CreatedAfter == nil && filter.CreatedBefore == nil {
+		// 没有任何条件时，列出当前工作区的所有任务
+		return task.FormatTaskListForDisplay(tm.ListTasks(workspaceID, "", "")), nil
+	}
 
-// contains 检查字符串是否包含子串（不区分大小写）
-func contains(s, substr string) bool {
-	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	results, err := tm.SearchTasks(filter)
+	if err != nil {
+		return "", err
+	}
+	return task.FormatSearchResults(keyword, results), nil
 }
 
 // getOverdueTasks 获取过期任务
 func (e *Executor) getOverdueTasks(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	// 按工作日计算时只列出逾期至少 N 个工作日的任务
+	if workdays, ok := args["min_workdays"].(float64); ok && workdays > 0 {
+		overdueTasks := tm.GetOverdueTasksByWorkdays(workspaceID, int(workdays))
+		if len(overdueTasks) == 0 {
+			return fmt.Sprintf("✅ 没有逾期 %d 个工作日以上的任务", int(workdays)), nil
+		}
+		result := fmt.Sprintf("⚠️ 发现 %d 个逾期 %d 个工作日以上的任务：\n\n", len(overdueTasks), int(workdays))
+		result += task.FormatTaskListForDisplay(overdueTasks)
+		return result, nil
+	}
 
-	overdueTasks := tm.GetOverdueTasks()
+	overdueTasks := tm.GetOverdueTasks(workspaceID)
 
 	if len(overdueTasks) == 0 {
 		return "✅ 没有过期任务", nil
@@ -582,7 +691,7 @@ func (e *Executor) getOverdueTasks(args map[string]interface{}) (string, error)
 
 // getUpcomingTasks 获取即将到期的任务
 func (e *Executor) getUpcomingTasks(args map[string]interface{}) (string, error) {
-	tm := task.GetTaskManager()
+	tm := taskManagerFor(args)
 
 	// 默认24小时内
 	hours := 24.0
@@ -590,7 +699,23 @@ func (e *Executor) getUpcomingTasks(args map[string]interface{}) (string, error)
 		hours = hoursFloat
 	}
 
-	upcomingTasks := tm.GetUpcomingTasks(time.Duration(hours) * time.Hour)
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	// 按工作日计算时到第 N 个工作日结束（跳过周末、节假日和工作区的休息日）
+	if workdays, ok := args["workdays"].(float64); ok && workdays > 0 {
+		upcomingTasks := tm.GetUpcomingTasksInWorkdays(workspaceID, int(workdays))
+		if len(upcomingTasks) == 0 {
+			return fmt.Sprintf("✅ 未来 %d 个工作日内没有即将到期的任务", int(workdays)), nil
+		}
+		result := fmt.Sprintf("⏰ 未来 %d 个工作日内有 %d 个即将到期的任务：\n\n", int(workdays), len(upcomingTasks))
+		result += task.FormatTaskListForDisplay(upcomingTasks)
+		return result, nil
+	}
+
+	upcomingTasks := tm.GetUpcomingTasks(workspaceID, time.Duration(hours)*time.Hour)
 
 	if len(upcomingTasks) == 0 {
 		return fmt.Sprintf("✅ 未来 %.0f 小时内没有即将到期的任务", hours), nil
@@ -601,6 +726,46 @@ func (e *Executor) getUpcomingTasks(args map[string]interface{}) (string, error)
 	return result, nil
 }
 
+// parseIDArg 解析ID参数（支持字符串和数字）
+func parseIDArg(raw interface{}) (uint, error) {
+	switch v := raw.(type) {
+	case string:
+		id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid id: %s", v)
+		}
+		return uint(id), nil
+	case float64:
+		return uint(v), nil
+	case int:
+		return uint(v), nil
+	default:
+		return 0, fmt.Errorf("invalid id type: %T", v)
+	}
+}
+
+// versionArg 读取调用方看到的任务版本号，未提供时返回 0（不检查）
+func versionArg(args map[string]interface{}) uint {
+	raw, ok := args["version"]
+	if !ok || raw == nil || raw == "" {
+		return 0
+	}
+	version, err := parseIDArg(raw)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// conflictError 任务已被他人修改时的错误，附上最新内容以便用户确认后重试
+func conflictError(tm *task.TaskManager, taskID uint) error {
+	latest, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("%v，请重新查看任务后再修改", task.ErrConflict)
+	}
+	return fmt.Errorf("%v，本次修改未保存。最新内容如下，请确认后重试：\n%s", task.ErrConflict, task.FormatTaskForDisplayWithManager(latest, tm))
+}
+
 // GetAvailableCommands 获取可用命令列表
 func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 	return []map[string]interface{}{
@@ -624,36 +789,76 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 					},
 					"due_time": map[string]interface{}{
 						"type":        "string",
-						"description": "预计结束时间（可选），从自然语言中解析，支持格式：2006-01-02 15:04:05、2006-01-02、明天、下周一等。如果用户没有提到截止时间则留空",
+						"description": "预计结束时间（可选），支持 2006-01-02 15:04、2006-01-02 和中文说法，如 明天下午3点、本周五下午、下周一、月底、3天后、12月3号、三点半；只有日期时截止到当天 23:59:59。如果用户没有提到截止时间则留空",
 					},
 					"dependencies": map[string]interface{}{
 						"type":        "array",
-						"description": "前置依赖任务ID列表（可选），任务ID是数字",
+						"description": "前置依赖任务编号列表（可选），如 [\"OPS-3\", \"OPS-5\"]",
 						"items": map[string]interface{}{
-							"type": "number",
+							"type": "string",
 						},
 					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"description": "父任务编号（可选），创建子任务时填写",
+					},
+					"estimate_hours": map[string]interface{}{
+						"type":        "number",
+						"description": "预计耗时（小时，可选），用于关键路径和延期风险分析",
+					},
+					"priority": map[string]interface{}{
+						"type":        "string",
+						"description": "优先级（可选）：low、medium、high、urgent",
+					},
+					"reminders": map[string]interface{}{
+						"type":        "string",
+						"description": "提醒提前量（可选），逗号分隔，如 \"1d,1h,10m\" 表示提前1天、1小时、10分钟提醒，到期时总会再提醒一次；不传使用默认（提前1天和到期时）",
+					},
+					"auto_complete": map[string]interface{}{
+						"type":        "boolean",
+						"description": "所有子任务完成后是否自动完成本任务（可选，默认false）",
+					},
+					"recurrence": map[string]interface{}{
+						"type":        "string",
+						"description": "重复规则（可选），仅当用户明确要求周期性任务时填写（如'每周一提交周报'）。支持简写：daily、weekly、monthly、yearly、workdays、每周一、每周一三五；或RRULE子集，如 FREQ=WEEKLY;BYDAY=MO、FREQ=MONTHLY;BYMONTHDAY=-1（每月最后一天）、FREQ=DAILY;INTERVAL=2;COUNT=10、FREQ=WEEKLY;UNTIL=20261231",
+					},
 				},
 				"required": []string{"content", "creator_id"},
 			},
 		},
 		{
 			"name":        "list_tasks",
-			"description": "列出任务。只在用户明确询问任务列表时使用（如'我的任务'、'列出任务'、'所有任务'等）。普通聊天不使用。支持查看所有任务（团队协作）或特定用户的任务。",
+			"description": "列出任务。只在用户明确询问任务列表时使用（如'我的任务'、'列出任务'、'所有任务'等）。普通聊天不使用。支持查看所有任务（团队协作）或特定用户的任务。每个群和每个私聊是独立的工作区，只会列出当前工作区的任务和共享到当前工作区的任务。",
 			"parameters": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "任务状态筛选：pending（待处理）、in_progress（进行中）、completed（已完成）、cancelled（已取消），为空则列出所有状态的任务",
+						"description": "任务状态筛选：pending（待处理）、in_progress（进行中）、completed（已完成）、cancelled（已取消）、blocked（被未完成的前置任务阻塞），为空则列出所有状态的任务",
 					},
 					"creator_id": map[string]interface{}{
 						"type":        "string",
 						"description": "创建人ID筛选（可选）。如果用户说'我的任务'、'查看我的任务'，传入当前用户ID；如果用户说'所有任务'、'查看所有任务'、'团队任务'等，不传此参数或传空字符串（查看所有任务，团队协作模式）；如果用户指定查看某个人的任务，传入对应的用户ID。如果不传此参数，默认查看所有任务（团队协作模式）。",
 					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "排序（可选）：due（截止时间最早的在前）、priority（优先级最高的在前）、updated（最近修改的在前）、created（最新创建的在前，默认）；前面加 - 表示反向，如 -due",
+					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "每页显示多少个任务（可选），默认10。更多的任务用 next_page 查看",
+					},
 				},
 			},
 		},
+		{
+			"name":        "next_page",
+			"description": "显示上一次任务列表或查询的下一页。用户说'下一页'、'更多'、'继续'时使用。",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 		{
 			"name":        "get_task_count",
 			"description": "获取任务数量统计",
@@ -662,20 +867,28 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 				"properties": map[string]interface{}{
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "任务状态筛选，为空则统计全部任务",
+						"description": "任务状态筛选（pending、in_progress、completed、cancelled、blocked），为空则统计全部任务",
 					},
 				},
 			},
 		},
 		{
 			"name":        "get_task",
-			"description": "查看任务详情。只在用户明确要求查看某个任务时使用。普通聊天不使用。",
+			"description": "查看任务详情。只在用户明确要求查看某个任务时使用。普通聊天不使用。用户询问任务的历史、变更记录或评论时，设置 include_history=true。",
 			"parameters": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{
 						"type":        "string",
-						"description": "任务ID（必需）",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"include_history": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否显示任务最近的动态时间线（状态变化、修改记录、评论），默认false",
+					},
+					"history_limit": map[string]interface{}{
+						"type":        "number",
+						"description": "显示的动态条数（可选），默认10",
 					},
 				},
 				"required": []string{"task_id"},
@@ -683,18 +896,30 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 		},
 		{
 			"name":        "update_task_status",
-			"description": "更新任务状态。只在用户明确要求更新任务状态时使用（如'完成任务X'、'标记为进行中'等）。普通聊天不使用。",
+			"description": "更新任务状态。只在用户明确要求更新任务状态时使用（如'完成任务X'、'标记为进行中'等）。普通聊天不使用。状态转换有限制：已取消的任务只能恢复为pending；前置依赖未完成时不能完成任务，除非用户明确要求强制完成（force=true）。",
 			"parameters": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{
 						"type":        "string",
-						"description": "任务ID（必需）",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
 					},
 					"status": map[string]interface{}{
 						"type":        "string",
 						"description": "新状态：pending（待处理）、in_progress（进行中）、completed（已完成）、cancelled（已取消）",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "重复任务取消范围（可选）：this（只取消这一次，默认，会生成下一次）、series（取消整个重复系列）",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否强制完成（可选，默认false）。只有用户明确要求忽略未完成的前置依赖时才设为true",
+					},
+					"version": map[string]interface{}{
+						"type":        "integer",
+						"description": "用户看到的任务版本号（可选）。传入后如果任务已被他人修改会拒绝本次修改",
+					},
 				},
 				"required": []string{"task_id", "status"},
 			},
@@ -707,7 +932,7 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{
 						"type":        "string",
-						"description": "任务ID（必需）",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
 					},
 					"title": map[string]interface{}{
 						"type":        "string",
@@ -719,7 +944,36 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 					},
 					"due_time": map[string]interface{}{
 						"type":        "string",
-						"description": "截止时间（可选），从自然语言中解析，支持格式：2006-01-02 15:04:05、2006-01-02、明天、下周一、后天12:00等。如果要更新截止时间则提供此字段",
+						"description": "截止时间（可选），格式同 create_task 的 due_time，如 2006-01-02 15:04、下周一、月底、后天12:00。如果要更新截止时间则提供此字段",
+					},
+					"recurrence": map[string]interface{}{
+						"type":        "string",
+						"description": "重复规则（可选），格式同 create_task 的 recurrence；传空字符串表示停止重复",
+					},
+					"estimate_hours": map[string]interface{}{
+						"type":        "number",
+						"description": "预计耗时（小时，可选）",
+					},
+					"priority": map[string]interface{}{
+						"type":        "string",
+						"description": "优先级（可选）：low、medium、high、urgent，传 none 表示清除优先级",
+					},
+					"reminders": map[string]interface{}{
+						"type":        "string",
+						"description": "提醒提前量（可选），逗号分隔，如 \"1d,1h,10m\"（到期时总会提醒）；传 default 恢复默认提醒",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "重复任务的修改范围（可选）：this（只改这一次，默认）、series（修改整个系列中未完成的任务，截止时间按差值平移）",
+					},
+					"clear": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": []string{"due_time", "content"}},
+						"description": "要清空的字段（可选），如用户说'去掉截止时间'时传 [\"due_time\"]",
+					},
+					"version": map[string]interface{}{
+						"type":        "integer",
+						"description": "用户看到的任务版本号（可选，任务详情中的'版本'）。传入后如果任务已被他人修改会拒绝本次修改",
 					},
 				},
 				"required": []string{"task_id"},
@@ -727,13 +981,13 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 		},
 		{
 			"name":        "delete_task",
-			"description": "删除任务。只在用户明确要求删除任务时使用。注意：被依赖的任务无法删除。普通聊天不使用。",
+			"description": "删除任务（移入回收站，保留期内可用 restore_task 恢复）。只在用户明确要求删除任务时使用。注意：被依赖的任务和有子任务的任务无法删除。普通聊天不使用。",
 			"parameters": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{
 						"type":        "string",
-						"description": "要删除的任务ID（必需）",
+						"description": "要删除的任务编号（必需），如 OPS-12",
 					},
 				},
 				"required": []string{"task_id"},
@@ -741,16 +995,43 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 		},
 		{
 			"name":        "search_tasks",
-			"description": "搜索任务。只在用户明确要求搜索任务时使用。普通聊天不使用。",
+			"description": "按关键词搜索任务，可同时按状态、创建人、截止时间和创建时间筛选，结果按相关度排序并高亮匹配的内容。只在用户明确要求搜索或查找任务时使用。普通聊天不使用。",
 			"parameters": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"keyword": map[string]interface{}{
 						"type":        "string",
-						"description": "搜索关键词（必需）",
+						"description": "搜索关键词，多个词用空格分隔（都要匹配），在标题和内容中检索",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "按状态筛选（可选），多个状态用逗号分隔：pending, in_progress, completed, cancelled, blocked",
+					},
+					"creator_id": map[string]interface{}{
+						"type":        "string",
+						"description": "按创建人ID筛选（可选）",
+					},
+					"due_after": map[string]interface{}{
+						"type":        "string",
+						"description": "截止时间不早于（可选），格式 YYYY-MM-DD HH:MM:SS",
+					},
+					"due_before": map[string]interface{}{
+						"type":        "string",
+						"description": "截止时间早于（可选），格式 YYYY-MM-DD HH:MM:SS",
+					},
+					"created_after": map[string]interface{}{
+						"type":        "string",
+						"description": "创建时间不早于（可选），格式 YYYY-MM-DD HH:MM:SS",
+					},
+					"created_before": map[string]interface{}{
+						"type":        "string",
+						"description": "创建时间早于（可选），格式 YYYY-MM-DD HH:MM:SS",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "最多返回多少条（可选），默认20",
 					},
 				},
-				"required": []string{"keyword"},
 			},
 		},
 		{
@@ -758,7 +1039,12 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 			"description": "获取过期任务。只在用户明确询问过期任务时使用。普通聊天不使用。",
 			"parameters": map[string]interface{}{
 				"type": "object",
-				"properties": map[string]interface{}{},
+				"properties": map[string]interface{}{
+					"min_workdays": map[string]interface{}{
+						"type":        "integer",
+						"description": "只列出逾期至少这么多个工作日的任务（可选），如用户问'拖了3个工作日以上的任务'时传 3；按工作日计算时跳过周末、法定节假日和本工作区的休息日",
+					},
+				},
 			},
 		},
 		{
@@ -771,6 +1057,10 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 						"type":        "number",
 						"description": "时间范围（小时），默认为24",
 					},
+					"workdays": map[string]interface{}{
+						"type":        "integer",
+						"description": "时间范围（工作日，可选），如用户问'未来3个工作日内要交的任务'时传 3，到第3个工作日结束；提供时忽略 hours",
+					},
 				},
 			},
 		},
@@ -782,18 +1072,627 @@ func (e *Executor) GetAvailableCommands() []map[string]interface{} {
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{
 						"type":        "string",
-						"description": "任务ID（必需）",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
 					},
 					"dependencies": map[string]interface{}{
 						"type":        "array",
-						"description": "依赖任务ID列表（可选）",
+						"description": "依赖任务编号列表（可选），如 [\"OPS-3\"]",
 						"items": map[string]interface{}{
-							"type": "number",
+							"type": "string",
 						},
 					},
 				},
 				"required": []string{"task_id"},
 			},
 		},
+		{
+			"name":        "get_ready_tasks",
+			"description": "获取现在可以开始的任务（待处理且所有前置依赖都已完成）。用户问'现在能做什么'、'哪些任务可以开始'时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "get_execution_order",
+			"description": "按依赖关系给出未完成任务的执行顺序（拓扑排序）。指定task_id时只给出完成该任务所需的前置任务顺序。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "目标任务编号（可选）",
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_critical_path",
+			"description": "计算完成目标任务的关键路径（决定最早完成时间的依赖链），基于预计耗时和截止时间。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "目标任务编号（必需）",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			"name":        "get_at_risk_tasks",
+			"description": "分析某个任务延期后，哪些下游任务可能无法按截止时间完成。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "可能延期的任务编号（必需）",
+					},
+					"slip_days": map[string]interface{}{
+						"type":        "number",
+						"description": "延期天数（可选）",
+					},
+					"slip_hours": map[string]interface{}{
+						"type":        "number",
+						"description": "延期小时数（可选）",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			"name":        "render_task_graph",
+			"description": "画出任务依赖关系图。默认生成PNG图片直接发送到当前聊天；用户要求文本格式时可导出 dot（Graphviz）或 mermaid。指定task_id时只画该任务的上下游，否则画全部任务。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（可选），只画该任务的依赖子图",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "输出格式（可选）：png（默认，发送图片）、dot、mermaid",
+					},
+					"include_completed": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否包含已完成和已取消的任务（可选，默认false）",
+					},
+				},
+			},
+		},
+		{
+			"name":        "set_task_parent",
+			"description": "设置任务的父任务（把任务变成另一个任务的子任务），或取消父子关系。只在用户明确要求时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "子任务编号（必需）",
+					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"description": "父任务编号（必需），传 0 表示取消父子关系",
+					},
+					"auto_complete": map[string]interface{}{
+						"type":        "boolean",
+						"description": "父任务是否在所有子任务完成后自动完成（可选）",
+					},
+				},
+				"required": []string{"task_id", "parent_id"},
+			},
+		},
+		{
+			"name":        "add_checklist_item",
+			"description": "向任务添加检查项（任务内的轻量清单，不是独立任务）。只在用户明确要求给任务加清单/检查项时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"items": map[string]interface{}{
+						"type":        "array",
+						"description": "检查项内容列表（必需）",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+				},
+				"required": []string{"task_id", "items"},
+			},
+		},
+		{
+			"name":        "check_checklist_item",
+			"description": "勾选任务的某个检查项（标记为已完成）。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"item": map[string]interface{}{
+						"type":        "number",
+						"description": "检查项序号（必需），即任务详情中检查项前面的编号",
+					},
+				},
+				"required": []string{"task_id", "item"},
+			},
+		},
+		{
+			"name":        "uncheck_checklist_item",
+			"description": "取消勾选任务的某个检查项。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"item": map[string]interface{}{
+						"type":        "number",
+						"description": "检查项序号（必需）",
+					},
+				},
+				"required": []string{"task_id", "item"},
+			},
+		},
+		{
+			"name":        "remove_checklist_item",
+			"description": "删除任务的某个检查项。只在用户明确要求删除检查项时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"item": map[string]interface{}{
+						"type":        "number",
+						"description": "检查项序号（必需）",
+					},
+				},
+				"required": []string{"task_id", "item"},
+			},
+		},
+		{
+			"name":        "share_task",
+			"description": "将当前工作区的任务共享到另一个工作区（用户所在的另一个群，或用户自己的个人工作区），共享后对方也能查看和更新该任务。每个群和每个私聊各有独立的任务列表，只有用户明确要求共享时才使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"workspace": map[string]interface{}{
+						"type":        "string",
+						"description": "目标工作区（必需）：群名称，或 personal 表示用户自己的个人工作区",
+					},
+				},
+				"required": []string{"task_id", "workspace"},
+			},
+		},
+		{
+			"name":        "unshare_task",
+			"description": "取消任务到某个工作区的共享。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），即任务列表中显示的ID，如 OPS-12",
+					},
+					"workspace": map[string]interface{}{
+						"type":        "string",
+						"description": "目标工作区（必需）：群名称，或 personal 表示用户自己的个人工作区",
+					},
+				},
+				"required": []string{"task_id", "workspace"},
+			},
+		},
+		{
+			"name":        "list_workspaces",
+			"description": "查看当前工作区以及可以共享任务的群工作区列表。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "add_comment",
+			"description": "在任务下添加评论。只在用户明确要求给任务写评论、备注进展时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），如 OPS-12",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "评论内容（必需）",
+					},
+				},
+				"required": []string{"task_id", "content"},
+			},
+		},
+		{
+			"name":        "get_recent_changes",
+			"description": "查看当前工作区的任务最近有哪些变化（新建、状态变化、修改、评论等）。用户问'今天有什么变化'、'今天谁更新了任务'时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"hours": map[string]interface{}{
+						"type":        "number",
+						"description": "查看最近多少小时的变化（可选），不填则查看今天的变化",
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_trash",
+			"description": "查看当前工作区回收站中已删除的任务。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "restore_task",
+			"description": "从回收站恢复已删除的任务。用户说'恢复任务'、'撤销删除'、'删错了'时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "要恢复的任务编号（必需），可以先用 list_trash 查看",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			"name":        "query_tasks",
+			"description": "用查询语句组合条件筛选任务。用户直接给出查询语句，或同时提出多个筛选条件（状态、负责人、标签、截止时间、排序）时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "查询语句（必需），条件用空格分隔：status:pending|in_progress|completed|cancelled|blocked|open|closed（逗号表示或），assignee:me|none|昵称，creator:me，label:标签，due<2026-11-01、due:today、due:none、created>=-7d，is:overdue|recurring，sort:due|created|key（-表示倒序），limit:20，其他词作为关键词。如 status:open assignee:me due<2026-11-01 label:release sort:due",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			"name":        "bulk_update_tasks",
+			"description": "批量操作多个任务：完成、取消、重新分配负责人、修改标签、平移截止时间。任务用查询语句或编号列表指定。先不带 confirm 调用预览将要处理的任务并告诉用户数量，用户确认后再带 confirm=true 执行。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"complete", "cancel", "reassign", "relabel", "shift_due"},
+						"description": "操作（必需）：complete（完成）、cancel（取消）、reassign（替换负责人）、relabel（增删标签）、shift_due（截止时间平移N天）",
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "用查询语句指定任务（与 task_ids 二选一），语法同 query_tasks，如 status:open label:sprint-12",
+					},
+					"task_ids": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "用任务编号列表指定任务（与 query 二选一），如 [\"OPS-1\", \"OPS-2\"]",
+					},
+					"assignees": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "reassign 时的新负责人昵称，\"我\"表示发消息的用户；空数组表示清空负责人",
+					},
+					"add_labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "relabel 时要添加的标签",
+					},
+					"remove_labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "relabel 时要去掉的标签",
+					},
+					"days": map[string]interface{}{
+						"type":        "integer",
+						"description": "shift_due 时平移的天数，正数推迟，负数提前",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "complete 时是否忽略未完成的前置依赖（默认false）",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否执行（默认false只预览）。只有用户看过预览并确认后才设为true",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		{
+			"name":        "label_task",
+			"description": "修改任务标签。用户要求给任务打标签、加标签、去掉标签时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），如 OPS-12",
+					},
+					"add": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "要添加的标签（可选）",
+					},
+					"remove": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "要去掉的标签（可选）",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "用这些标签替换全部标签（可选），传空数组表示清空标签",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			"name":        "assign_task",
+			"description": "修改任务负责人。用户要求把任务分配给某人、指定负责人、取消某人负责时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "任务编号（必需），如 OPS-12",
+					},
+					"add": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "要添加的负责人昵称（可选），\"我\"表示发消息的用户",
+					},
+					"remove": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "要移除的负责人昵称（可选）",
+					},
+					"assignees": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "用这些人替换全部负责人（可选），传空数组表示清空负责人",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			"name":        "undo_last",
+			"description": "撤销当前用户最近一次的任务操作（创建、修改、更新状态、修改依赖、删除）。用户说'撤销刚才的操作'、'撤回'、'刚才弄错了'时使用。可以连续调用逐步撤销更早的操作。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "任务在这次操作之后又被修改过时是否仍然撤销（会覆盖之后的修改），默认false。只有用户确认覆盖时才设为true",
+					},
+				},
+			},
+		},
+		{
+			"name":        "set_notification_preferences",
+			"description": "修改当前用户的通知偏好：免打扰时段、每日摘要时间、时区、群任务通知的渠道，以及在当前群或私聊静音。只修改用户提到的项。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"quiet_hours": map[string]interface{}{
+						"type":        "string",
+						"description": "免打扰时段，如 \"22:00-08:00\"（可跨午夜）；off 表示关闭免打扰，default 表示恢复默认。免打扰期间的通知会延后到时段结束后发送",
+					},
+					"digest_time": map[string]interface{}{
+						"type":        "string",
+						"description": "每日摘要（今天到期、已逾期、被阻塞的任务和昨天完成的任务）的发送时间，如 \"08:30\"；off 表示不接收每日摘要，default 表示恢复默认",
+					},
+					"timezone": map[string]interface{}{
+						"type":        "string",
+						"description": "时区（IANA名称），如 Asia/Shanghai、America/New_York；default 表示恢复默认",
+					},
+					"channel": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"group", "private"},
+						"description": "群任务的提醒方式：group（在群里 @ 我）或 private（私聊发给我）",
+					},
+					"mute_workspace": map[string]interface{}{
+						"type":        "boolean",
+						"description": "true 表示不再接收当前群或私聊的任务通知，false 表示取消静音",
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_notification_preferences",
+			"description": "查看当前用户的通知偏好（免打扰、每日摘要时间、时区、渠道、静音的工作区）",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "set_workspace_prefix",
+			"description": "修改当前工作区（当前群或私聊）的任务编号前缀，如把编号改成 OPS-1、OPS-2 的形式。只在用户明确要求修改任务编号前缀时使用。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prefix": map[string]interface{}{
+						"type":        "string",
+						"description": "新的前缀（必需），1-8个英文字母或数字，以字母开头，如 OPS",
+					},
+				},
+				"required": []string{"prefix"},
+			},
+		},
+		{
+			"name":        "set_reminder",
+			"description": "设置个人提醒（不是任务），如\"10分钟后提醒我喝水\"、\"每天早上8点提醒我吃药\"。到时间后在当前群或私聊发消息提醒用户。需要记录和跟踪的事情用 create_task。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "提醒内容（必需），如\"喝水\"",
+					},
+					"after": map[string]interface{}{
+						"type":        "string",
+						"description": "多久之后提醒，如 \"10分钟\"、\"2小时\"、\"30m\"（与 at 二选一，相对时间优先用这个）",
+					},
+					"at": map[string]interface{}{
+						"type":        "string",
+						"description": "提醒时间，如 \"YYYY-MM-DD HH:MM\"、\"明天上午9点\"、\"下周一10点\"（与 after 二选一，只说日期时默认上午9点）；重复提醒填第一次提醒的时间",
+					},
+					"recurrence": map[string]interface{}{
+						"type":        "string",
+						"description": "重复规则（可选），如 daily、每周一、workdays、FREQ=WEEKLY;BYDAY=MO,WE；不填表示只提醒一次",
+					},
+				},
+				"required": []string{"text"},
+			},
+		},
+		{
+			"name":        "list_reminders",
+			"description": "列出当前用户在当前群或私聊设置的个人提醒",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "cancel_reminder",
+			"description": "取消当前群或私聊中的个人提醒（只能取消自己设置的提醒）",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reminder_id": map[string]interface{}{
+						"type":        "string",
+						"description": "提醒ID（必需），见 list_reminders 的结果",
+					},
+				},
+				"required": []string{"reminder_id"},
+			},
+		},
+		{
+			"name":        "add_anniversary",
+			"description": "登记生日或纪念日（支持农历），每年到时间后在当前群或私聊提醒用户。用户说'记一下妈妈的生日是农历八月十五'、'提前三天提醒我结婚纪念日5月20日'时使用，不要创建任务或个人提醒。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"contact": map[string]interface{}{
+						"type":        "string",
+						"description": "谁的生日或纪念日（必需），如 妈妈、张三；自己的纪念日填 我",
+					},
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "日期（必需），如 3月8日、1990-03-08、农历八月十五、1960年农历正月初一；知道年份时带上年份可以显示岁数或周年",
+					},
+					"lunar": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否按农历（可选），date 中带'农历'时可以不填",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "纪念日名称（可选），如 生日、结婚纪念日，默认生日",
+					},
+					"lead_days": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("提前几天提醒（可选），0 表示当天提醒，默认 %d，最多 %d", anniversary.DefaultLeadDays, anniversary.MaxLeadDays),
+					},
+				},
+				"required": []string{"contact", "date"},
+			},
+		},
+		{
+			"name":        "list_anniversaries",
+			"description": "列出用户在当前群或私聊登记的生日和纪念日，以及下一次的日期",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "remove_anniversary",
+			"description": "删除当前群或私聊中登记的生日或纪念日",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"anniversary_id": map[string]interface{}{
+						"type":        "string",
+						"description": "纪念日ID（必需），见 list_anniversaries 的结果",
+					},
+				},
+				"required": []string{"anniversary_id"},
+			},
+		},
+		{
+			"name":        "set_escalation_policy",
+			"description": "设置当前群或私聊的逾期升级策略：任务到期时总会提醒负责人，逾期后按步骤再次提醒负责人或在群里 @ 管理员；任务完成或截止时间修改后停止升级。也用于关闭升级。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"steps": map[string]interface{}{
+						"type":        "string",
+						"description": "升级步骤（必需），逗号分隔的逾期时长，默认再次提醒负责人，加 :admin 表示 @ 管理员，如 \"1d,3d:admin\" 表示逾期1天再提醒负责人、逾期3天 @ 管理员；off 表示关闭升级",
+					},
+					"admins": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "升级到管理员时 @ 的群成员昵称（有 :admin 步骤时必需，不提供时沿用之前设置的管理员）",
+					},
+				},
+				"required": []string{"steps"},
+			},
+		},
+		{
+			"name":        "set_non_working_day",
+			"description": "把某天设为当前群或私聊的休息日（如公司年会、团建放假），或取消设置。按工作日计算截止时间（如'三个工作日内'）和逾期时会跳过这些日子；周末和法定节假日不需要设置。",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "日期（必需），如 2026-11-20、下周五、12月3号",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "休息的原因（可选），如 公司年会",
+					},
+					"remove": map[string]interface{}{
+						"type":        "boolean",
+						"description": "为 true 时取消这一天的休息日设置",
+					},
+				},
+				"required": []string{"date"},
+			},
+		},
+		{
+			"name":        "list_non_working_days",
+			"description": "查看当前群或私聊的工作日历：自定义的休息日，以及法定节假日数据覆盖的年份。",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 	}
 }
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// getReadyTasks 获取可以立即开始的任务
+func (e *Executor) getReadyTasks(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	tasks, err := tm.ReadyTasks(workspaceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ready tasks: %v", err)
+	}
+	if len(tasks) == 0 {
+		return "📋 目前没有可以直接开始的任务", nil
+	}
+
+	return fmt.Sprintf("🚀 以下 %d 个任务的前置依赖都已完成，可以开始：\n\n%s", len(tasks), task.FormatTaskListForDisplay(tasks)), nil
+}
+
+// getExecutionOrder 获取任务的拓扑执行顺序
+func (e *Executor) getExecutionOrder(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var targetID uint
+	if raw, ok := args["task_id"]; ok && raw != nil && raw != "" {
+		id, err := resolveTaskID(args, "task_id")
+		if err != nil {
+			return "", err
+		}
+		targetID = id
+	}
+
+	order, err := tm.ExecutionOrder(workspaceID, targetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute execution order: %v", err)
+	}
+	if len(order) == 0 {
+		return "📋 没有未完成的任务", nil
+	}
+
+	result := "🧭 建议执行顺序（先完成前置任务）：\n"
+	if targetID != 0 {
+		result = fmt.Sprintf("🧭 完成任务 %s 的执行顺序：\n", tm.TaskKey(targetID))
+	}
+	for i, t := range order {
+		line := fmt.Sprintf("%d. %s (ID: %s)", i+1, t.Title, t.DisplayKey())
+		if t.DueTime != nil {
+			line += fmt.Sprintf(" | 截止: %s", t.DueTime.Format("2006-01-02 15:04"))
+		}
+		result += line + "\n"
+	}
+	return result, nil
+}
+
+// getCriticalPath 获取到达目标任务的关键路径
+func (e *Executor) getCriticalPath(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	path, err := tm.CriticalPath(workspaceID, taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute critical path: %v", err)
+	}
+
+	target := path[len(path)-1]
+	result := fmt.Sprintf("🛤️ 任务 %s 的关键路径（共 %d 个任务，预计完成: %s）：\n", target.Task.DisplayKey(), len(path), target.Finish.Format("2006-01-02 15:04"))
+	result += task.FormatScheduleForDisplay(path)
+	if target.AtRisk {
+		result += "⚠️ 按当前估计，目标任务无法在截止时间前完成"
+	}
+	return result, nil
+}
+
+// getAtRiskTasks 获取某任务延期后受影响的下游任务
+func (e *Executor) getAtRiskTasks(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	var slip time.Duration
+	if days, ok := args["slip_days"].(float64); ok {
+		slip += time.Duration(days * float64(24*time.Hour))
+	}
+	if hours, ok := args["slip_hours"].(float64); ok {
+		slip += time.Duration(hours * float64(time.Hour))
+	}
+
+	atRisk, err := tm.DownstreamAtRisk(workspaceID, taskID, slip)
+	if err != nil {
+		return "", fmt.Errorf("failed to analyze downstream tasks: %v", err)
+	}
+	if len(atRisk) == 0 {
+		return fmt.Sprintf("✅ 任务 %s 延期后，下游任务仍可按时完成", tm.TaskKey(taskID)), nil
+	}
+
+	return fmt.Sprintf("⚠️ 任务 %s 延期后，以下 %d 个下游任务可能无法按时完成：\n%s", tm.TaskKey(taskID), len(atRisk), task.FormatScheduleForDisplay(atRisk)), nil
+}
+
+// parseEstimateArg 解析预计耗时参数（小时）
+func parseEstimateArg(args map[string]interface{}) (time.Duration, bool) {
+	hours, ok := args["estimate_hours"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(hours * float64(time.Hour)), true
+}
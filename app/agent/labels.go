@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// stringListArg 读取字符串数组参数（兼容模型传逗号分隔的字符串）
+func stringListArg(args map[string]interface{}, key string) []string {
+	var values []string
+	switch v := args[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				values = append(values, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for _, s := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '，' || r == '、' }) {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// labelTask 修改任务标签
+func (e *Executor) labelTask(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	labels := stringListArg(args, "labels")
+	add := stringListArg(args, "add")
+	remove := stringListArg(args, "remove")
+	replace := false
+	if _, ok := args["labels"]; ok {
+		add, replace = labels, true
+	}
+	if !replace && len(add) == 0 && len(remove) == 0 {
+		return "", fmt.Errorf("labels, add or remove is required")
+	}
+
+	if err := tm.UpdateTaskLabels(taskID, add, remove, replace); err != nil {
+		return "", fmt.Errorf("failed to update labels: %v", err)
+	}
+
+	current := tm.GetTaskLabels(taskID)
+	if len(current) == 0 {
+		return fmt.Sprintf("🏷️ 任务 %s 已没有标签", tm.TaskKey(taskID)), nil
+	}
+	return fmt.Sprintf("🏷️ 任务 %s 的标签: #%s", tm.TaskKey(taskID), strings.Join(current, " #")), nil
+}
+
+// assignTask 修改任务负责人
+func (e *Executor) assignTask(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	c := callerFromArgs(args)
+	toActors := func(names []string) []task.Actor {
+		actors := make([]task.Actor, 0, len(names))
+		for _, name := range names {
+			actors = append(actors, assigneeActor(c, name))
+		}
+		return actors
+	}
+	toNames := func(names []string) []string {
+		result := make([]string, 0, len(names))
+		for _, name := range names {
+			result = append(result, assigneeActor(c, name).Name)
+		}
+		return result
+	}
+
+	_, replace := args["assignees"]
+	add := toActors(stringListArg(args, "add"))
+	if replace {
+		add = toActors(stringListArg(args, "assignees"))
+	}
+	remove := toNames(stringListArg(args, "remove"))
+	if !replace && len(add) == 0 && len(remove) == 0 {
+		return "", fmt.Errorf("assignees, add or remove is required")
+	}
+
+	if err := tm.UpdateTaskAssignees(taskID, add, remove, replace); err != nil {
+		return "", fmt.Errorf("failed to update assignees: %v", err)
+	}
+
+	assignees := tm.GetTaskAssignees(taskID)
+	if len(assignees) == 0 {
+		return fmt.Sprintf("👤 任务 %s 已没有负责人", tm.TaskKey(taskID)), nil
+	}
+	names := make([]string, len(assignees))
+	for i, a := range assignees {
+		names[i] = a.UserName
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("👤 任务 %s 的负责人: %s", tm.TaskKey(taskID), strings.Join(names, "、")), nil
+}
+
+// assigneeActor 将负责人名称转换为 Actor，“我”表示调用者本人
+func assigneeActor(c caller, name string) task.Actor {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	switch strings.ToLower(name) {
+	case "me", "我", "自己", "我自己":
+		return task.Actor{ID: c.ID, Name: c.Name}
+	}
+	if name == c.Name {
+		return task.Actor{ID: c.ID, Name: c.Name}
+	}
+	return task.Actor{Name: name}
+}
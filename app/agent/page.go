@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// listSessionTTL 多久之后不再能继续翻页
+const listSessionTTL = 30 * time.Minute
+
+// listSession 每个会话（群或私聊）最近一次任务列表或查询，用于“下一页”
+type listSession struct {
+	Title    string
+	Filter   task.TaskFilter
+	Cursor   string
+	PageSize int
+	Updated  time.Time
+}
+
+var (
+	listSessions   = make(map[string]*listSession)
+	listSessionsMu sync.Mutex
+)
+
+// listSessionKey 会话的键（与会话历史一样，群聊按群区分，私聊按好友区分）
+func listSessionKey(args map[string]interface{}) string {
+	c := callerFromArgs(args)
+	return c.ChatID
+}
+
+// showFirstPage 显示任务列表的第一页，并记住查询条件以便翻页
+func showFirstPage(tm *task.TaskManager, args map[string]interface{}, title string, filter task.TaskFilter, pageSize int) (string, error) {
+	page, err := tm.QueryTasksPage(filter, "", pageSize)
+	if err != nil {
+		return "", err
+	}
+
+	listSessionsMu.Lock()
+	defer listSessionsMu.Unlock()
+	key := listSessionKey(args)
+	if page.NextCursor == "" {
+		delete(listSessions, key)
+	} else {
+		listSessions[key] = &listSession{
+			Title:    title,
+			Filter:   filter,
+			Cursor:   page.NextCursor,
+			PageSize: pageSize,
+			Updated:  time.Now(),
+		}
+	}
+	return task.FormatTaskPageForDisplay(title, page), nil
+}
+
+// nextPage 显示当前会话上一次任务列表或查询的下一页
+func (e *Executor) nextPage(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	key := listSessionKey(args)
+	listSessionsMu.Lock()
+	session, ok := listSessions[key]
+	if ok && time.Since(session.Updated) > listSessionTTL {
+		delete(listSessions, key)
+		ok = false
+	}
+	listSessionsMu.Unlock()
+	if !ok {
+		return "📋 没有可以继续翻页的列表，请先列出或查询任务", nil
+	}
+
+	page, err := tm.QueryTasksPage(session.Filter, session.Cursor, session.PageSize)
+	if err != nil {
+		return "", err
+	}
+
+	listSessionsMu.Lock()
+	if page.NextCursor == "" {
+		delete(listSessions, key)
+	} else {
+		session.Cursor = page.NextCursor
+		session.Updated = time.Now()
+	}
+	listSessionsMu.Unlock()
+	return task.FormatTaskPageForDisplay(session.Title, page), nil
+}
+
+// parseSortArg 解析排序参数，如 due、-created
+func parseSortArg(raw string) (string, bool, error) {
+	raw = strings.TrimSpace(raw)
+	reverse := strings.HasPrefix(raw, "-")
+	switch key := strings.ToLower(strings.TrimPrefix(raw, "-")); key {
+	case task.SortDue, task.SortPriority, task.SortUpdated, task.SortCreated, task.SortKey:
+		return key, reverse, nil
+	default:
+		return "", false, fmt.Errorf("invalid sort %q, use due, priority, updated or created", raw)
+	}
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/notify"
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// isDefaultArg 参数是否表示恢复默认
+func isDefaultArg(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "default", "默认", "恢复默认":
+		return true
+	}
+	return false
+}
+
+// setNotificationPreferences 修改调用者的通知偏好，静音只作用于当前工作区
+func (e *Executor) setNotificationPreferences(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.Name == "" {
+		return "", fmt.Errorf("caller name is required")
+	}
+	pref := notify.GetPreference(c.Name)
+
+	if raw, ok := args["quiet_hours"].(string); ok && raw != "" {
+		switch {
+		case isDefaultArg(raw):
+			pref.QuietStart, pref.QuietEnd, pref.QuietOff = "", "", false
+		case raw == "off" || raw == "关闭" || raw == "无":
+			pref.QuietStart, pref.QuietEnd, pref.QuietOff = "", "", true
+		default:
+			start, end, err := notify.ParseQuietHours(raw)
+			if err != nil {
+				return "", err
+			}
+			pref.QuietStart, pref.QuietEnd, pref.QuietOff = start, end, false
+		}
+	}
+	if raw, ok := args["digest_time"].(string); ok && raw != "" {
+		switch {
+		case isDefaultArg(raw):
+			pref.DigestTime, pref.DigestOff = "", false
+		case raw == "off" || raw == "关闭" || raw == "不要":
+			pref.DigestOff = true
+		default:
+			clock, err := notify.ParseClock(raw)
+			if err != nil {
+				return "", err
+			}
+			pref.DigestTime, pref.DigestOff = clock, false
+		}
+	}
+	if raw, ok := args["timezone"].(string); ok && raw != "" {
+		if isDefaultArg(raw) {
+			pref.Timezone = ""
+		} else {
+			if _, err := time.LoadLocation(raw); err != nil {
+				return "", fmt.Errorf("invalid timezone %q, use IANA names like Asia/Shanghai", raw)
+			}
+			pref.Timezone = raw
+		}
+	}
+	if raw, ok := args["channel"].(string); ok && raw != "" {
+		switch raw {
+		case notify.ChannelPrivate, "私聊":
+			pref.Channel = notify.ChannelPrivate
+		case notify.ChannelGroup, "群聊", "群":
+			pref.Channel = notify.ChannelGroup
+		default:
+			return "", fmt.Errorf("invalid channel %q, use private or group", raw)
+		}
+	}
+	if err := notify.SavePreference(pref); err != nil {
+		return "", err
+	}
+
+	if muted, ok := args["mute_workspace"].(bool); ok {
+		workspaceID, err := workspaceIDFromArgs(args)
+		if err != nil {
+			return "", err
+		}
+		if workspaceID == 0 {
+			return "", fmt.Errorf("no workspace to mute in this chat")
+		}
+		if err := notify.SetMuted(c.Name, workspaceID, muted); err != nil {
+			return "", err
+		}
+	}
+
+	return "✅ 通知设置已更新\n" + formatPreference(c.Name), nil
+}
+
+// getNotificationPreferences 查看调用者的通知偏好
+func (e *Executor) getNotificationPreferences(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.Name == "" {
+		return "", fmt.Errorf("caller name is required")
+	}
+	return "🔔 通知设置\n" + formatPreference(c.Name), nil
+}
+
+// formatPreference 格式化用户的通知偏好（包括生效的默认值）
+func formatPreference(userName string) string {
+	pref := notify.GetPreference(userName)
+
+	var b strings.Builder
+	if start, end, ok := pref.QuietHours(); ok {
+		fmt.Fprintf(&b, "免打扰: %s-%s", start, end)
+		if pref.QuietStart == "" {
+			b.WriteString("（默认）")
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("免打扰: 关闭\n")
+	}
+	if pref.DigestOff {
+		b.WriteString("每日摘要: 关闭\n")
+	} else {
+		fmt.Fprintf(&b, "每日摘要: %s\n", pref.EffectiveDigestTime())
+	}
+	fmt.Fprintf(&b, "时区: %s\n", pref.Location().String())
+	if pref.EffectiveChannel() == notify.ChannelPrivate {
+		b.WriteString("群任务通知: 私聊发给我\n")
+	} else {
+		b.WriteString("群任务通知: 在群里 @ 我\n")
+	}
+
+	muted := notify.MutedWorkspaces(userName)
+	if len(muted) > 0 {
+		tm := task.GetTaskManager()
+		names := make([]string, 0, len(muted))
+		for _, id := range muted {
+			if ws, ok := tm.GetWorkspace(id); ok {
+				names = append(names, ws.Name)
+			}
+		}
+		fmt.Fprintf(&b, "已静音: %s\n", strings.Join(names, "、"))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
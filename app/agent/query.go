@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// queryTasks 按查询语言查询任务，如 status:pending assignee:me due<2026-11-01 label:release sort:due
+func (e *Executor) queryTasks(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	c := callerFromArgs(args)
+	filter, err := task.ParseQuery(query, task.QueryContext{
+		WorkspaceID: workspaceID,
+		MeID:        c.ID,
+		MeName:      c.Name,
+		Now:         time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// limit 作为每页的数量，更多结果通过“下一页”查看
+	pageSize := filter.Limit
+	filter.Limit = 0
+	title := "查询结果"
+	if description := task.DescribeFilter(filter); description != "" {
+		title += ": " + description
+	}
+	return showFirstPage(tm, args, title, *filter, pageSize)
+}
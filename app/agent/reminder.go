@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSnooze 只说“稍后提醒”时推迟的时间
+const defaultSnooze = 30 * time.Minute
+
+// reminderReply 对提醒的回复
+type reminderReply struct {
+	Ack      bool      // 知道了
+	RemindAt time.Time // 稍后提醒的时间
+}
+
+// ackReplies 表示“知道了”的回复
+var ackReplies = map[string]bool{
+	"知道了": true, "知道啦": true, "收到": true, "收到了": true, "好的知道了": true,
+}
+
+var (
+	// 30分钟后再提醒、两小时后提醒我
+	snoozeAfterPattern = regexp.MustCompile(`^([0-9零一二两三四五六七八九十半]+)个?(分钟|分|小时|钟头|天)后(再)?提醒(我)?$`)
+	// 稍后提醒 30分钟、晚点再提醒一小时
+	snoozeLaterPattern = regexp.MustCompile(`^(稍后|晚点|等会|等下|过会)(再)?提醒(我)?([0-9零一二两三四五六七八九十半]+个?(分钟|分|小时|钟头|天))?$`)
+	// 明天再提醒、今晚再提醒
+	snoozeDayPattern = regexp.MustCompile(`^(明天|明早|今晚|下午)(再)?提醒(我)?$`)
+	// 数量和单位
+	amountPattern = regexp.MustCompile(`^([0-9零一二两三四五六七八九十半]+)个?(分钟|分|小时|钟头|天)$`)
+)
+
+// parseReminderReply 识别“稍后提醒 30分钟”“明天再提醒”“知道了”等对提醒的回复
+func parseReminderReply(text string, now time.Time) (reminderReply, bool) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	s = strings.TrimRight(s, "。.!！~～")
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return reminderReply{}, false
+	}
+
+	if ackReplies[s] {
+		return reminderReply{Ack: true}, true
+	}
+	if m := snoozeAfterPattern.FindStringSubmatch(s); m != nil {
+		if d, ok := parseAmount(m[1], m[2]); ok {
+			return reminderReply{RemindAt: now.Add(d)}, true
+		}
+		return reminderReply{}, false
+	}
+	if m := snoozeLaterPattern.FindStringSubmatch(s); m != nil {
+		if m[4] == "" {
+			return reminderReply{RemindAt: now.Add(defaultSnooze)}, true
+		}
+		if am := amountPattern.FindStringSubmatch(m[4]); am != nil {
+			if d, ok := parseAmount(am[1], am[2]); ok {
+				return reminderReply{RemindAt: now.Add(d)}, true
+			}
+		}
+		return reminderReply{}, false
+	}
+	if m := snoozeDayPattern.FindStringSubmatch(s); m != nil {
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		var at time.Time
+		switch m[1] {
+		case "明天", "明早":
+			at = day.AddDate(0, 0, 1).Add(9 * time.Hour)
+		case "今晚":
+			at = day.Add(20 * time.Hour)
+		case "下午":
+			at = day.Add(14 * time.Hour)
+		}
+		if !at.After(now) {
+			return reminderReply{}, false
+		}
+		return reminderReply{RemindAt: at}, true
+	}
+	return reminderReply{}, false
+}
+
+// parseAmount 解析“30”“两”“半”等数量和时间单位
+func parseAmount(amount, unit string) (time.Duration, bool) {
+	var base time.Duration
+	switch unit {
+	case "分钟", "分":
+		base = time.Minute
+	case "小时", "钟头":
+		base = time.Hour
+	case "天":
+		base = 24 * time.Hour
+	default:
+		return 0, false
+	}
+	if amount == "半" {
+		return base / 2, base > time.Minute
+	}
+	n, ok := parseSmallNumber(amount)
+	if !ok || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * base, true
+}
+
+// parseSmallNumber 解析阿拉伯数字或九十九以内的中文数字
+func parseSmallNumber(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	digits := map[rune]int{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10, true
+	case len(runes) == 1:
+		n, ok := digits[runes[0]]
+		return n, ok
+	case len(runes) == 2 && runes[0] == '十':
+		n, ok := digits[runes[1]]
+		return 10 + n, ok
+	case len(runes) == 2 && runes[1] == '十':
+		n, ok := digits[runes[0]]
+		return n * 10, ok
+	case len(runes) == 3 && runes[1] == '十':
+		tens, ok1 := digits[runes[0]]
+		ones, ok2 := digits[runes[2]]
+		return tens*10 + ones, ok1 && ok2
+	}
+	return 0, false
+}
+
+// HandleReminderReply 处理对最近一条提醒的回复（稍后提醒或知道了）
+// 不是这类回复，或者会话中没有待回复的提醒时返回 false，消息按普通对话处理
+func HandleReminderReply(args map[string]interface{}, text string) (string, bool) {
+	now := time.Now()
+	reply, ok := parseReminderReply(text, now)
+	if !ok {
+		return "", false
+	}
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil || workspaceID == 0 {
+		return "", false
+	}
+	c := callerFromArgs(args)
+	assigneeName := ""
+	if !c.IsGroup {
+		assigneeName = c.Name
+	}
+
+	tm := taskManagerFor(args)
+	batch, err := tm.LastUnrepliedReminder(workspaceID, assigneeName, now)
+	if err != nil {
+		log.Printf("ERROR: Failed to find reminder for reply: %v\n", err)
+		return "", false
+	}
+	if batch == nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if reply.Ack {
+		if err := tm.AcknowledgeReminder(batch, now); err != nil {
+			return fmt.Sprintf("操作失败: %v", err), true
+		}
+		b.WriteString("👌 好的，以下任务在截止时间前不再提醒：\n")
+	} else {
+		if err := tm.SnoozeReminder(batch, reply.RemindAt, now); err != nil {
+			return fmt.Sprintf("操作失败: %v", err), true
+		}
+		fmt.Fprintf(&b, "⏰ 好的，将在 %s 再次提醒：\n", reply.RemindAt.Format("01-02 15:04"))
+	}
+	for _, t := range batch.Tasks {
+		fmt.Fprintf(&b, "- %s (ID: %s)\n", t.Title, t.DisplayKey())
+	}
+	return strings.TrimRight(b.String(), "\n"), true
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/869413421/wechatbot/app/reminder"
+)
+
+// defaultReminderHour 只说了日期（如“明天提醒我”）时的提醒时刻
+const defaultReminderHour = 9
+
+// parseReminderDelay 解析“10分钟”“两小时”“半天”或 10m、2h 形式的时长
+func parseReminderDelay(s string) (time.Duration, error) {
+	s = strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(s), "后")), "")
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	if m := amountPattern.FindStringSubmatch(s); m != nil {
+		if d, ok := parseAmount(m[1], m[2]); ok {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid delay %q, use forms like 10分钟, 2小时 or 30m", s)
+}
+
+// reminderWorkspace 当前会话的工作区ID，并认领按会话名称保存的旧提醒
+func reminderWorkspace(args map[string]interface{}) (uint, error) {
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	c := callerFromArgs(args)
+	if err := reminder.AdoptLegacy(workspaceID, c.ChatName, c.IsGroup); err != nil {
+		return 0, err
+	}
+	return workspaceID, nil
+}
+
+// setReminder 在当前群或私聊设置个人提醒
+func (e *Executor) setReminder(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("reminders can only be set in a chat")
+	}
+	text, _ := args["text"].(string)
+
+	now := time.Now()
+	var at time.Time
+	if raw, _ := args["after"].(string); raw != "" {
+		d, err := parseReminderDelay(raw)
+		if err != nil {
+			return "", err
+		}
+		at = now.Add(d)
+	} else if raw, _ := args["at"].(string); raw != "" {
+		res, err := dateParserFor(args).Parse(raw)
+		if err != nil {
+			return "", err
+		}
+		at = res.At(defaultReminderHour, 0, 0)
+	} else {
+		return "", fmt.Errorf("either at or after is required")
+	}
+
+	workspaceID, err := reminderWorkspace(args)
+	if err != nil {
+		return "", err
+	}
+
+	r := &reminder.Reminder{
+		WorkspaceID: workspaceID,
+		ChatID:      c.ChatID,
+		ChatName:    c.ChatName,
+		IsGroup:     c.IsGroup,
+		CreatorID:   c.ID,
+		CreatorName: c.Name,
+		Text:        text,
+		RemindAt:    at,
+	}
+	r.Recurrence, _ = args["recurrence"].(string)
+	if err := reminder.Create(r, now); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("⏰ 好的，%s 提醒你：%s（提醒ID: %d）", r.Describe(), r.Text, r.ID), nil
+}
+
+// listReminders 列出调用者在当前群或私聊设置的提醒
+func (e *Executor) listReminders(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("reminders can only be listed in a chat")
+	}
+	workspaceID, err := reminderWorkspace(args)
+	if err != nil {
+		return "", err
+	}
+	reminders, err := reminder.List(workspaceID, c.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(reminders) == 0 {
+		return "⏰ 你在这里还没有设置提醒", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏰ 你的提醒（%d 个）：\n", len(reminders))
+	for _, r := range reminders {
+		fmt.Fprintf(&b, "%d. %s - %s\n", r.ID, r.Describe(), r.Text)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// cancelReminder 取消当前群或私聊中的提醒
+func (e *Executor) cancelReminder(args map[string]interface{}) (string, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return "", fmt.Errorf("reminders can only be cancelled in a chat")
+	}
+	id, err := parseIDArg(strings.TrimPrefix(fmt.Sprint(args["reminder_id"]), "#"))
+	if err != nil {
+		return "", err
+	}
+	workspaceID, err := reminderWorkspace(args)
+	if err != nil {
+		return "", err
+	}
+	r, err := reminder.Cancel(id, workspaceID, c.Name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ 已取消提醒 %d：%s", r.ID, r.Text), nil
+}
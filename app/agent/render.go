@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/869413421/wechatbot/app/notify"
+	"github.com/869413421/wechatbot/app/render"
+)
+
+// renderTaskGraph 渲染任务依赖图（PNG 图片发送到当前会话，或导出 DOT / Mermaid 文本）
+func (e *Executor) renderTaskGraph(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var taskID uint
+	if raw, ok := args["task_id"]; ok && raw != nil && raw != "" {
+		id, err := resolveTaskID(args, "task_id")
+		if err != nil {
+			return "", err
+		}
+		taskID = id
+	}
+	includeClosed, _ := args["include_completed"].(bool)
+
+	view, tasks, err := tm.DependencyGraphView(workspaceID, taskID, includeClosed)
+	if err != nil {
+		return "", fmt.Errorf("failed to build dependency graph: %v", err)
+	}
+
+	format, _ := args["format"].(string)
+	switch format {
+	case "dot":
+		return "Graphviz DOT:\n" + render.ToDOT(view), nil
+	case "mermaid":
+		return "Mermaid:\n" + render.ToMermaid(view), nil
+	case "", "png", "image":
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+
+	chatID, _ := args["chat_id"].(string)
+	if chatID == "" {
+		return "", fmt.Errorf("无法确定当前会话，不能发送图片，可以改用 dot 或 mermaid 格式")
+	}
+
+	image, err := render.RenderPNG(view)
+	if err != nil {
+		return "", fmt.Errorf("failed to render dependency graph: %v", err)
+	}
+	if err := notify.SendImage(chatID, bytes.NewReader(image)); err != nil {
+		log.Printf("ERROR: Failed to send dependency graph image: %v\n", err)
+		return "", fmt.Errorf("依赖图发送失败: %v，可以改用 dot 或 mermaid 格式", err)
+	}
+
+	// 图片中只能显示编号，标题通过文字图例给出
+	result := fmt.Sprintf("🗺️ 已发送依赖图图片（%d 个任务），图中编号对应：\n", len(tasks))
+	for _, t := range tasks {
+		result += fmt.Sprintf("%s %s\n", t.DisplayKey(), t.Title)
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// setTaskParent 设置父任务
+func (e *Executor) setTaskParent(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	parentID, err := resolveTaskID(args, "parent_id")
+	if err != nil {
+		return "", err
+	}
+
+	if err := tm.SetTaskParent(taskID, parentID); err != nil {
+		return "", fmt.Errorf("failed to set parent task: %v", err)
+	}
+
+	if autoComplete, ok := args["auto_complete"].(bool); ok && parentID != 0 {
+		if err := tm.SetAutoComplete(parentID, autoComplete); err != nil {
+			return "", fmt.Errorf("failed to update auto complete: %v", err)
+		}
+	}
+
+	if parentID == 0 {
+		return fmt.Sprintf("✅ 任务 %s 已不再是子任务", tm.TaskKey(taskID)), nil
+	}
+
+	parent, exists := tm.GetTask(parentID)
+	if !exists {
+		return fmt.Sprintf("✅ 任务 %s 已设为任务 %s 的子任务", tm.TaskKey(taskID), tm.TaskKey(parentID)), nil
+	}
+	return fmt.Sprintf("✅ 任务 %s 已设为子任务\n%s", tm.TaskKey(taskID), task.FormatTaskForDisplayWithManager(parent, tm)), nil
+}
+
+// addChecklistItem 添加检查项
+func (e *Executor) addChecklistItem(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+
+	var contents []string
+	if items, ok := args["items"].([]interface{}); ok {
+		for _, item := range items {
+			if content, ok := item.(string); ok {
+				contents = append(contents, content)
+			}
+		}
+	}
+	// 兼容只传单个 content 的情况
+	if content, ok := args["content"].(string); ok && content != "" {
+		contents = append(contents, content)
+	}
+	if len(contents) == 0 {
+		return "", fmt.Errorf("items is required")
+	}
+
+	added, err := tm.AddChecklistItems(taskID, contents)
+	if err != nil {
+		return "", fmt.Errorf("failed to add checklist items: %v", err)
+	}
+
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Sprintf("✅ 已添加 %d 个检查项", len(added)), nil
+	}
+	return fmt.Sprintf("✅ 已添加 %d 个检查项\n%s", len(added), task.FormatTaskForDisplayWithManager(t, tm)), nil
+}
+
+// setChecklistItemChecked 勾选/取消勾选检查项
+func (e *Executor) setChecklistItemChecked(args map[string]interface{}, checked bool) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	position, err := parseIDArg(args["item"])
+	if err != nil {
+		return "", fmt.Errorf("invalid item: %v", err)
+	}
+
+	item, err := tm.SetChecklistItemChecked(taskID, int(position), checked)
+	if err != nil {
+		return "", fmt.Errorf("failed to update checklist item: %v", err)
+	}
+
+	done, total := task.ChecklistProgress(tm.GetChecklist(taskID))
+	if checked {
+		return fmt.Sprintf("☑ 已勾选: %s（检查项 %d/%d）", item.Content, done, total), nil
+	}
+	return fmt.Sprintf("☐ 已取消勾选: %s（检查项 %d/%d）", item.Content, done, total), nil
+}
+
+// removeChecklistItem 删除检查项
+func (e *Executor) removeChecklistItem(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	position, err := parseIDArg(args["item"])
+	if err != nil {
+		return "", fmt.Errorf("invalid item: %v", err)
+	}
+
+	if err := tm.RemoveChecklistItem(taskID, int(position)); err != nil {
+		return "", fmt.Errorf("failed to remove checklist item: %v", err)
+	}
+	return fmt.Sprintf("✅ 已删除任务 %s 的第 %d 个检查项", tm.TaskKey(taskID), position), nil
+}
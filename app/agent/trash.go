@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// listTrash 列出当前工作区回收站中的任务
+func (e *Executor) listTrash(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	tasks := tm.ListTrash(workspaceID)
+	if len(tasks) == 0 {
+		return "🗑️ 回收站是空的", nil
+	}
+
+	retention := config.LoadConfig().Task.TrashRetentionDays
+	result := fmt.Sprintf("🗑️ 回收站 (共 %d 个，删除 %d 天后彻底清除):\n\n", len(tasks), retention)
+	for i, t := range tasks {
+		result += fmt.Sprintf("%d. %s (ID: %s)\n", i+1, t.Title, t.DisplayKey())
+		if t.DeletedAt.Valid {
+			result += fmt.Sprintf("   删除于: %s\n", t.DeletedAt.Time.Format("2006-01-02 15:04"))
+		}
+	}
+	return result, nil
+}
+
+// restoreTask 从回收站恢复任务
+func (e *Executor) restoreTask(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+	key, err := taskKeyArg(args["task_id"])
+	if err != nil {
+		return "", err
+	}
+	taskID, err := tm.ResolveTrashedTaskKey(workspaceID, key)
+	if err != nil {
+		return "", err
+	}
+
+	restored, err := tm.RestoreTask(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to restore task: %v", err)
+	}
+	return fmt.Sprintf("♻️ 任务已从回收站恢复！\n%s", task.FormatTaskForDisplayWithManager(restored, tm)), nil
+}
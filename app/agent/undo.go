@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/869413421/wechatbot/app/config"
+)
+
+// undoLast 撤销调用方在当前工作区最近一次的任务操作
+func (e *Executor) undoLast(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	force, _ := args["force"].(bool)
+	window := time.Duration(config.LoadConfig().Task.UndoWindowMinutes) * time.Minute
+	description, err := tm.UndoLast(workspaceID, window, force)
+	if err != nil {
+		return "", fmt.Errorf("failed to undo: %v", err)
+	}
+	return fmt.Sprintf("↩️ 已撤销上一次操作：%s", description), nil
+}
@@ -0,0 +1,310 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/869413421/wechatbot/app/notify"
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// caller 工具调用方上下文（由 LLM 层根据当前消息注入）
+type caller struct {
+	ChatID   string // 群或好友的UserName
+	ChatName string // 群名或好友昵称
+	IsGroup  bool
+	ID       string // 发送者UserName（群聊中为群成员）
+	Name     string // 发送者昵称
+}
+
+// callerFromArgs 从工具参数中读取调用方上下文
+func callerFromArgs(args map[string]interface{}) caller {
+	c := caller{}
+	c.ChatID, _ = args["chat_id"].(string)
+	c.ChatName, _ = args["chat_name"].(string)
+	c.IsGroup, _ = args["is_group"].(bool)
+	c.ID, _ = args["caller_id"].(string)
+	c.Name, _ = args["caller_name"].(string)
+	if c.ID == "" {
+		c.ID = c.ChatID
+	}
+	if c.Name == "" {
+		c.Name = c.ChatName
+	}
+	return c
+}
+
+// taskManagerFor 以调用者身份获取任务管理器，修改会记录到任务动态中
+func taskManagerFor(args map[string]interface{}) *task.TaskManager {
+	c := callerFromArgs(args)
+	return task.GetTaskManager().WithActor(task.Actor{ID: c.ID, Name: c.Name})
+}
+
+// workspaceFromArgs 获取当前会话的工作区，没有会话上下文时返回 nil（不限制工作区）
+func workspaceFromArgs(args map[string]interface{}) (*task.Workspace, error) {
+	c := callerFromArgs(args)
+	if c.ChatID == "" {
+		return nil, nil
+	}
+	return task.GetTaskManager().ResolveWorkspace(c.ChatID, c.ChatName, c.IsGroup)
+}
+
+// workspaceIDFromArgs 获取当前会话的工作区ID，0 表示不限制工作区
+func workspaceIDFromArgs(args map[string]interface{}) (uint, error) {
+	ws, err := workspaceFromArgs(args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if ws == nil {
+		return 0, nil
+	}
+	return ws.ID, nil
+}
+
+// resolveTaskID 解析任务编号参数（如 OPS-12 或 12）为任务ID，并检查任务在当前工作区是否可见，0 原样返回
+func resolveTaskID(args map[string]interface{}, key string) (uint, error) {
+	raw, ok := args[key]
+	if !ok || raw == nil {
+		return 0, fmt.Errorf("%s is required", key)
+	}
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	return resolveTaskKey(workspaceID, raw)
+}
+
+// taskKeyArg 将任务编号参数转换为字符串（模型可能传数字）
+func taskKeyArg(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	default:
+		return "", fmt.Errorf("invalid task id type: %T", v)
+	}
+}
+
+// resolveTaskKey 在工作区中解析单个任务编号
+func resolveTaskKey(workspaceID uint, raw interface{}) (uint, error) {
+	key, err := taskKeyArg(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	tm := task.GetTaskManager()
+	id, err := tm.ResolveTaskKey(workspaceID, key)
+	if err != nil {
+		return 0, err
+	}
+	if id != 0 && !tm.IsVisible(workspaceID, id) {
+		return 0, fmt.Errorf("task not found: %s", key)
+	}
+	return id, nil
+}
+
+// resolveTaskIDList 解析任务ID列表参数（无效的ID跳过，不可见的任务报错）
+func resolveTaskIDList(args map[string]interface{}, key string) ([]uint, error) {
+	list, ok := args[key].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, raw := range list {
+		id, err := resolveTaskKey(workspaceID, raw)
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			log.Printf("WARNING: Invalid %s entry %v, skipping\n", key, raw)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// findTargetWorkspace 根据名称查找共享目标工作区，"personal"/"我的"/"个人" 表示调用者的个人工作区
+func findTargetWorkspace(args map[string]interface{}) (*task.Workspace, error) {
+	tm := task.GetTaskManager()
+	target, _ := args["workspace"].(string)
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, fmt.Errorf("workspace is required")
+	}
+
+	switch target {
+	case "personal", "我的", "个人":
+		c := callerFromArgs(args)
+		if c.ID == "" {
+			return nil, fmt.Errorf("unknown caller")
+		}
+		return tm.ResolveWorkspace(c.ID, c.Name, false)
+	}
+	return tm.FindWorkspace(target)
+}
+
+// shareTask 将任务共享到另一个工作区
+func (e *Executor) shareTask(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	target, err := findTargetWorkspace(args)
+	if err != nil {
+		return "", err
+	}
+
+	c := callerFromArgs(args)
+	if err := checkShareTarget(target, c); err != nil {
+		return "", err
+	}
+
+	if err := tm.ShareTask(taskID, target.ID, c.ID); err != nil {
+		return "", fmt.Errorf("failed to share task: %v", err)
+	}
+	return fmt.Sprintf("🔗 任务 %s 已共享到「%s」", tm.TaskKey(taskID), workspaceDisplayName(target)), nil
+}
+
+// checkShareTarget 任务只能共享到调用者自己的个人工作区或调用者所在的群
+func checkShareTarget(target *task.Workspace, c caller) error {
+	if target.Kind == task.WorkspacePrivate {
+		if c.ID == "" || target.ChatID != c.ID {
+			return fmt.Errorf("只能共享到自己的个人工作区或自己所在的群")
+		}
+		return nil
+	}
+	member, err := notify.IsGroupMember(target.ChatID, c.ID)
+	if err != nil {
+		return fmt.Errorf("无法确认你是否在群「%s」中: %v", target.Name, err)
+	}
+	if !member {
+		return fmt.Errorf("你不在群「%s」中，不能把任务共享过去", target.Name)
+	}
+	return nil
+}
+
+// unshareTask 取消任务共享
+func (e *Executor) unshareTask(args map[string]interface{}) (string, error) {
+	tm := taskManagerFor(args)
+
+	taskID, err := resolveTaskID(args, "task_id")
+	if err != nil {
+		return "", err
+	}
+	target, err := findTargetWorkspace(args)
+	if err != nil {
+		return "", err
+	}
+	workspaceID, err := workspaceIDFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tm.UnshareTask(taskID, target.ID, workspaceID); err != nil {
+		return "", fmt.Errorf("failed to unshare task: %v", err)
+	}
+	return fmt.Sprintf("任务 %s 已取消共享到「%s」", tm.TaskKey(taskID), workspaceDisplayName(target)), nil
+}
+
+// listWorkspaces 列出可作为共享目标的工作区
+func (e *Executor) listWorkspaces(args map[string]interface{}) (string, error) {
+	tm := task.GetTaskManager()
+
+	current, err := workspaceFromArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+
+	result := ""
+	if current != nil {
+		result += fmt.Sprintf("📍 当前工作区: %s（任务编号前缀 %s）\n", workspaceDisplayName(current), current.Prefix)
+		if current.EscalationPolicy != "" {
+			result += fmt.Sprintf("⏫ 逾期升级: %s\n", task.FormatEscalationPolicy(current))
+		}
+	}
+	workspaces := tm.ListGroupWorkspaces()
+	if len(workspaces) == 0 {
+		return result + "暂无群工作区", nil
+	}
+	result += "👥 群工作区：\n"
+	for _, ws := range workspaces {
+		result += fmt.Sprintf("- %s（%s）\n", workspaceDisplayName(ws), ws.Prefix)
+	}
+	return result, nil
+}
+
+// workspaceDisplayName 工作区显示名称
+func workspaceDisplayName(ws *task.Workspace) string {
+	if ws.Kind == task.WorkspacePrivate {
+		return ws.Name + " 的个人工作区"
+	}
+	return ws.Name
+}
+
+// setWorkspacePrefix 修改当前工作区的任务编号前缀
+func (e *Executor) setWorkspacePrefix(args map[string]interface{}) (string, error) {
+	tm := task.GetTaskManager()
+
+	current, err := workspaceFromArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if current == nil {
+		return "", fmt.Errorf("unknown workspace")
+	}
+
+	prefix, _ := args["prefix"].(string)
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if err := tm.SetWorkspacePrefix(current.ID, prefix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ 任务编号前缀已改为 %s，任务编号形如 %s-1", prefix, prefix), nil
+}
+
+// setEscalationPolicy 设置当前工作区的逾期升级策略
+func (e *Executor) setEscalationPolicy(args map[string]interface{}) (string, error) {
+	tm := task.GetTaskManager()
+
+	current, err := workspaceFromArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if current == nil {
+		return "", fmt.Errorf("unknown workspace")
+	}
+
+	raw, _ := args["steps"].(string)
+	var steps []task.EscalationStep
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "off", "关闭", "无":
+	default:
+		steps, err = task.ParseEscalationPolicy(raw)
+		if err != nil {
+			return "", err
+		}
+	}
+	admins := stringListArg(args, "admins")
+	if len(admins) == 0 {
+		admins = current.EscalationAdminList()
+	}
+	if err := tm.SetEscalationPolicy(current.ID, steps, admins); err != nil {
+		return "", err
+	}
+
+	updated, _ := tm.GetWorkspace(current.ID)
+	return fmt.Sprintf("✅ 「%s」的逾期升级策略已更新：\n%s", workspaceDisplayName(updated), task.FormatEscalationPolicy(updated)), nil
+}
@@ -0,0 +1,437 @@
+package anniversary
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/869413421/wechatbot/app/datetime"
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// 纪念日状态
+const (
+	StatusActive    = "active"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// DefaultTitle 没有指定名称时的纪念日名称
+	DefaultTitle = "生日"
+	// DefaultLeadDays 默认提前一天提醒（方便准备礼物）
+	DefaultLeadDays = 1
+	// MaxLeadDays 最多提前多少天提醒
+	MaxLeadDays = 30
+	// MaxAnniversaries 每个人在同一个群或私聊中最多登记的纪念日数
+	MaxAnniversaries = 200
+	// remindHour 提醒在当天几点发送（登记人的时区）
+	remindHour = 9
+	// maxAttempts 一次提醒最多尝试发送的次数
+	maxAttempts = 5
+	// retryBase 发送失败后第一次重试的间隔，之后每次翻倍
+	retryBase = time.Minute
+	// maxSchedulerSleep 调度器最长的休眠时间
+	maxSchedulerSleep = time.Hour
+)
+
+var (
+	store         *gorm.DB
+	schedulerWake = make(chan struct{}, 1)
+	schedulerOnce sync.Once
+)
+
+var (
+	isoDatePattern   = regexp.MustCompile(`^(?:(\d{4})[-/.])?(\d{1,2})[-/.](\d{1,2})$`)
+	yearPattern      = regexp.MustCompile(`^(\d{4})年`)
+	solarDatePattern = regexp.MustCompile(`^([0-9一二三四五六七八九十]+)月([0-9一二三四五六七八九十]+)(?:日|号)?$`)
+)
+
+// Init 初始化纪念日的存储
+func Init(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Anniversary{}); err != nil {
+		return fmt.Errorf("failed to migrate anniversaries table: %v", err)
+	}
+	log.Printf("Anniversaries table migrated\n")
+	store = db
+	return nil
+}
+
+// ParseDate 解析纪念日日期，如 “3月8日”“1990-03-08”“农历八月十五”“1990年农历正月初一”
+// 日期带“农历”“阴历”前缀或 lunar 为 true 时按农历解析；年份可选
+func ParseDate(s string, lunar bool) (a Anniversary, err error) {
+	input := s
+	s = strings.Join(strings.Fields(s), "")
+	if m := yearPattern.FindStringSubmatch(s); m != nil {
+		a.Year, _ = datetime.ParseNumber(m[1])
+		s = s[len(m[0]):]
+	}
+	for _, prefix := range []string{"农历", "阴历", "旧历"} {
+		if strings.HasPrefix(s, prefix) {
+			lunar = true
+			s = strings.TrimPrefix(s, prefix)
+		}
+	}
+	a.Lunar = lunar
+
+	if m := isoDatePattern.FindStringSubmatch(s); m != nil {
+		if m[1] != "" {
+			a.Year, _ = datetime.ParseNumber(m[1])
+		}
+		a.Month, _ = datetime.ParseNumber(m[2])
+		a.Day, _ = datetime.ParseNumber(m[3])
+	} else if lunar {
+		month, day, leap, rest, err := datetime.ParseLunarMonthDay(s)
+		if err != nil {
+			return a, err
+		}
+		if rest != "" {
+			return a, fmt.Errorf("无法识别的农历日期 %q", input)
+		}
+		a.Month, a.Day, a.LeapMonth = month, day, leap
+	} else if m := solarDatePattern.FindStringSubmatch(s); m != nil {
+		a.Month, _ = datetime.ParseNumber(m[1])
+		a.Day, _ = datetime.ParseNumber(m[2])
+	} else {
+		return a, fmt.Errorf("无法识别的日期 %q（示例: 3月8日、1990-03-08、农历八月十五）", input)
+	}
+
+	if err := a.validateDate(); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// validateDate 检查月日是否有效（公历允许 2 月 29 日，非闰年按 2 月 28 日提醒）
+func (a *Anniversary) validateDate() error {
+	if a.Month < 1 || a.Month > 12 || a.Day < 1 {
+		return fmt.Errorf("invalid date %d-%d", a.Month, a.Day)
+	}
+	if a.Lunar {
+		if a.Day > 30 {
+			return fmt.Errorf("农历每月最多30天")
+		}
+		if a.LeapMonth && a.Year > 0 && datetime.LeapMonth(a.Year) != a.Month {
+			return fmt.Errorf("农历%d年没有闰%d月", a.Year, a.Month)
+		}
+		return nil
+	}
+	if t := time.Date(2000, time.Month(a.Month), a.Day, 0, 0, 0, 0, time.UTC); t.Day() != a.Day {
+		return fmt.Errorf("invalid date %d月%d日", a.Month, a.Day)
+	}
+	return nil
+}
+
+// location 登记人的时区
+func (a *Anniversary) location() *time.Location {
+	return notify.GetPreference(a.CreatorName).Location()
+}
+
+// nextOccurrence from 当天或之后的下一次纪念日（公历日期）
+func (a *Anniversary) nextOccurrence(from time.Time) (time.Time, error) {
+	if a.Lunar {
+		return datetime.NextLunarDate(a.Month, a.Day, a.LeapMonth, from)
+	}
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for year := start.Year(); ; year++ {
+		day := a.Day
+		if last := time.Date(year, time.Month(a.Month)+1, 0, 0, 0, 0, 0, from.Location()).Day(); day > last {
+			// 2 月 29 日出生的非闰年按 2 月 28 日
+			day = last
+		}
+		t := time.Date(year, time.Month(a.Month), day, 0, 0, 0, 0, from.Location())
+		if !t.Before(start) {
+			return t, nil
+		}
+	}
+}
+
+// schedule 安排 from 当天或之后的下一次纪念日的提醒：提前 LeadDays 天的上午发送
+// 提前的时间已经过了（如登记时离纪念日不到 LeadDays 天）时改为纪念日当天，当天也过了时顺延到下一次
+func (a *Anniversary) schedule(now, from time.Time) error {
+	from = from.In(a.location())
+	for i := 0; i < 3; i++ {
+		occurs, err := a.nextOccurrence(from)
+		if err != nil {
+			return err
+		}
+		for _, lead := range []int{a.LeadDays, 0} {
+			day := occurs.AddDate(0, 0, -lead)
+			at := time.Date(day.Year(), day.Month(), day.Day(), remindHour, 0, 0, 0, day.Location())
+			if at.After(now) {
+				a.OccursOn, a.RemindAt = occurs, at
+				return nil
+			}
+		}
+		from = occurs.AddDate(0, 0, 1)
+	}
+	return fmt.Errorf("failed to schedule anniversary %d", a.ID)
+}
+
+// Create 登记纪念日并安排第一次提醒
+func Create(a *Anniversary, now time.Time) error {
+	if store == nil {
+		return fmt.Errorf("anniversary store is not initialized")
+	}
+	a.Contact = strings.TrimPrefix(strings.TrimSpace(a.Contact), "@")
+	if a.Contact == "" {
+		return fmt.Errorf("contact is required")
+	}
+	a.Title = strings.TrimSpace(a.Title)
+	if a.Title == "" {
+		a.Title = DefaultTitle
+	}
+	if a.ChatID == "" && a.ChatName == "" {
+		return fmt.Errorf("target chat is required")
+	}
+	if a.LeadDays < 0 || a.LeadDays > MaxLeadDays {
+		return fmt.Errorf("lead days must be between 0 and %d", MaxLeadDays)
+	}
+	if err := a.validateDate(); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := store.Model(&Anniversary{}).
+		Where("chat_name = ? AND is_group = ? AND creator_name = ? AND status = ?", a.ChatName, a.IsGroup, a.CreatorName, StatusActive).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count anniversaries: %v", err)
+	}
+	if count >= MaxAnniversaries {
+		return fmt.Errorf("at most %d anniversaries are allowed, please remove some first", MaxAnniversaries)
+	}
+
+	if err := a.schedule(now, now); err != nil {
+		return err
+	}
+	a.Status = StatusActive
+	a.CreateTime = now
+	if err := store.Create(a).Error; err != nil {
+		return fmt.Errorf("failed to create anniversary: %v", err)
+	}
+	log.Printf("Created anniversary %d (%s的%s), next reminder at %s\n", a.ID, a.Contact, a.Title, a.RemindAt.Format("2006-01-02 15:04"))
+	wakeScheduler()
+	return nil
+}
+
+// List 列出群或私聊中某人登记的纪念日（按下一次日期排序），creatorName 为空表示所有人
+func List(chatName string, isGroup bool, creatorName string) ([]Anniversary, error) {
+	var list []Anniversary
+	if store == nil {
+		return list, fmt.Errorf("anniversary store is not initialized")
+	}
+	query := store.Where("chat_name = ? AND is_group = ? AND status = ?", chatName, isGroup, StatusActive)
+	if creatorName != "" {
+		query = query.Where("creator_name = ?", creatorName)
+	}
+	if err := query.Order("occurs_on ASC").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to list anniversaries: %v", err)
+	}
+	return list, nil
+}
+
+// Remove 删除群或私聊中登记的纪念日（不再提醒）
+func Remove(id uint, chatName string, isGroup bool) (*Anniversary, error) {
+	if store == nil {
+		return nil, fmt.Errorf("anniversary store is not initialized")
+	}
+	var a Anniversary
+	if err := store.Where("id = ? AND chat_name = ? AND is_group = ? AND status = ?", id, chatName, isGroup, StatusActive).First(&a).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("anniversary %d not found in this chat", id)
+		}
+		return nil, fmt.Errorf("failed to get anniversary: %v", err)
+	}
+	if err := store.Model(&Anniversary{}).Where("id = ?", id).Update("status", StatusCancelled).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove anniversary: %v", err)
+	}
+	a.Status = StatusCancelled
+	log.Printf("Removed anniversary %d\n", id)
+	wakeScheduler()
+	return &a, nil
+}
+
+// DateText 纪念日的日期，如 “3月8日”“农历八月十五”“1990年农历正月初一”
+func (a *Anniversary) DateText() string {
+	var text string
+	if a.Lunar {
+		text = "农历" + datetime.LunarDate{Month: a.Month, Day: a.Day, Leap: a.LeapMonth}.MonthDay()
+	} else {
+		text = fmt.Sprintf("%d月%d日", a.Month, a.Day)
+	}
+	if a.Year > 0 {
+		text = fmt.Sprintf("%d年", a.Year) + text
+	}
+	return text
+}
+
+// Describe 纪念日的描述，如 “妈妈的生日 农历八月十五（下次 2027-09-15，提前1天提醒）”
+func (a *Anniversary) Describe() string {
+	lead := "当天提醒"
+	if a.LeadDays > 0 {
+		lead = fmt.Sprintf("提前%d天提醒", a.LeadDays)
+	}
+	occurs := a.OccursOn.In(a.location())
+	return fmt.Sprintf("%s的%s %s（下次 %s%s，%s）", a.Contact, a.Title, a.DateText(), occurs.Format("2006-01-02"), a.countText(occurs), lead)
+}
+
+// countText 第几次纪念日，如 “，60岁”“，10周年”，不知道年份时为空
+func (a *Anniversary) countText(occurs time.Time) string {
+	if a.Year <= 0 {
+		return ""
+	}
+	year := occurs.Year()
+	if a.Lunar {
+		if lunar, err := datetime.SolarToLunar(occurs); err == nil {
+			year = lunar.Year
+		}
+	}
+	n := year - a.Year
+	if n <= 0 {
+		return ""
+	}
+	if strings.Contains(a.Title, "生日") || strings.Contains(a.Title, "寿") {
+		return fmt.Sprintf("，%d岁", n)
+	}
+	return fmt.Sprintf("，%d周年", n)
+}
+
+// notification 纪念日提醒：群里 @ 登记的人
+func (a *Anniversary) notification(now time.Time) notify.Notification {
+	icon := "🎉"
+	if strings.Contains(a.Title, "生日") {
+		icon = "🎂"
+	}
+	// 数据库读出的时间按服务器时区，先换回登记人的时区
+	occurs := a.OccursOn.In(a.location())
+	local := now.In(occurs.Location())
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	when := "今天"
+	if days := int((occurs.Sub(today) + 12*time.Hour) / (24 * time.Hour)); days == 1 {
+		when = "明天"
+	} else if days > 1 {
+		when = fmt.Sprintf("%d天后", days)
+	}
+
+	date := occurs.Format("1月2日")
+	if a.Lunar {
+		if lunar, err := datetime.SolarToLunar(occurs); err == nil {
+			date += "，农历" + lunar.MonthDay()
+		}
+	}
+	text := fmt.Sprintf("%s 纪念日提醒：%s（%s）是%s的%s%s", icon, when, date, a.Contact, a.Title, a.countText(occurs))
+	n := notify.Notification{Target: a.ChatID, TargetName: a.ChatName, IsGroup: a.IsGroup, Text: text}
+	if a.IsGroup && a.CreatorName != "" {
+		n.Users = []notify.User{{ID: a.CreatorID, Name: a.CreatorName}}
+	}
+	return n
+}
+
+// deliverDue 发送已到时间的纪念日提醒，之后安排下一次；发送失败按指数退避重试
+func deliverDue(now time.Time, send notify.Sender) {
+	var due []Anniversary
+	if err := store.Where("status = ? AND remind_at <= ? AND (next_attempt_time IS NULL OR next_attempt_time <= ?)", StatusActive, now, now).
+		Order("remind_at ASC").
+		Find(&due).Error; err != nil {
+		log.Printf("ERROR: Failed to load due anniversaries: %v\n", err)
+		return
+	}
+
+	for i := range due {
+		a := &due[i]
+		updates := map[string]interface{}{}
+		err := send(a.notification(now))
+		if err != nil && a.Attempts+1 < maxAttempts {
+			retry := now.Add(retryBase << uint(a.Attempts))
+			updates["attempts"] = a.Attempts + 1
+			updates["next_attempt_time"] = &retry
+			updates["last_error"] = err.Error()
+			log.Printf("Failed to send anniversary %d, retrying at %s: %v\n", a.ID, retry.Format("15:04:05"), err)
+		} else {
+			if err != nil {
+				updates["last_error"] = err.Error()
+				log.Printf("Giving up anniversary %d reminder for %s: %v\n", a.ID, a.OccursOn.Format("2006-01-02"), err)
+			} else {
+				updates["last_sent_time"] = &now
+				updates["last_error"] = ""
+				log.Printf("Sent anniversary %d reminder to %s\n", a.ID, a.ChatName)
+			}
+			updates["attempts"] = 0
+			updates["next_attempt_time"] = nil
+			// 安排下一次纪念日的提醒
+			if err := a.schedule(now, a.OccursOn.AddDate(0, 0, 1)); err != nil {
+				log.Printf("ERROR: Failed to schedule anniversary %d: %v\n", a.ID, err)
+				updates["status"] = StatusCancelled
+			} else {
+				updates["occurs_on"] = a.OccursOn
+				updates["remind_at"] = a.RemindAt
+			}
+		}
+		if err := store.Model(&Anniversary{}).Where("id = ?", a.ID).Updates(updates).Error; err != nil {
+			log.Printf("ERROR: Failed to update anniversary %d: %v\n", a.ID, err)
+		}
+	}
+}
+
+// nextTime 下一条需要处理的纪念日提醒时间（包括重试时间）
+func nextTime(now time.Time) (time.Time, bool) {
+	var next time.Time
+	var times []time.Time
+	if err := store.Model(&Anniversary{}).
+		Where("status = ? AND next_attempt_time IS NULL AND remind_at > ?", StatusActive, now).
+		Order("remind_at ASC").Limit(1).
+		Pluck("remind_at", &times).Error; err != nil {
+		log.Printf("ERROR: Failed to load next anniversary: %v\n", err)
+	} else if len(times) > 0 {
+		next = times[0]
+	}
+
+	times = nil
+	if err := store.Model(&Anniversary{}).
+		Where("status = ? AND next_attempt_time > ?", StatusActive, now).
+		Order("next_attempt_time ASC").Limit(1).
+		Pluck("next_attempt_time", &times).Error; err != nil {
+		log.Printf("ERROR: Failed to load next anniversary retry: %v\n", err)
+	} else if len(times) > 0 && (next.IsZero() || times[0].Before(next)) {
+		next = times[0]
+	}
+	return next, !next.IsZero()
+}
+
+// StartScheduler 启动纪念日提醒的调度器（只启动一次）
+func StartScheduler(send notify.Sender) {
+	schedulerOnce.Do(func() {
+		go runScheduler(send)
+	})
+}
+
+// wakeScheduler 纪念日修改后唤醒调度器
+func wakeScheduler() {
+	select {
+	case schedulerWake <- struct{}{}:
+	default:
+	}
+}
+
+// runScheduler 调度循环
+func runScheduler(send notify.Sender) {
+	log.Printf("Anniversary scheduler started\n")
+	for {
+		now := time.Now()
+		deliverDue(now, send)
+
+		wait := maxSchedulerSleep
+		if next, ok := nextTime(now); ok && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-schedulerWake:
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package anniversary
+
+import "time"
+
+// Anniversary 生日或纪念日（公历或农历），每年到时间后提醒登记的人
+type Anniversary struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatID          string     `gorm:"type:varchar(100);not null;default:''" json:"chat_id"`                   // 发送目标：群或好友的 UserName（重新登录后会变化）
+	ChatName        string     `gorm:"type:varchar(255);not null;index:idx_anniversary_chat" json:"chat_name"` // 群名或好友昵称（UserName 失效时按名称查找）
+	IsGroup         bool       `gorm:"not null;default:false;index:idx_anniversary_chat" json:"is_group"`
+	CreatorID       string     `gorm:"type:varchar(100);not null;default:''" json:"creator_id"`
+	CreatorName     string     `gorm:"type:varchar(100);not null;default:''" json:"creator_name"` // 登记的人（群里提醒时 @ 他）
+	Contact         string     `gorm:"type:varchar(100);not null" json:"contact"`                 // 谁的纪念日，如 妈妈、张三
+	Title           string     `gorm:"type:varchar(100);not null" json:"title"`                   // 如 生日、结婚纪念日
+	Lunar           bool       `gorm:"not null;default:false" json:"lunar"`                       // 是否按农历
+	Month           int        `gorm:"not null" json:"month"`
+	Day             int        `gorm:"not null" json:"day"`
+	LeapMonth       bool       `gorm:"not null;default:false" json:"leap_month"` // 农历闰月（当年没有这个闰月时按同名的月份）
+	Year            int        `gorm:"not null;default:0" json:"year"`           // 出生或开始的年份（农历时为农历年），用于计算岁数或周年，0 表示未知
+	LeadDays        int        `gorm:"not null;default:0" json:"lead_days"`      // 提前几天提醒，0 表示当天提醒
+	OccursOn        time.Time  `gorm:"type:datetime;not null" json:"occurs_on"`  // 下一次纪念日（公历）
+	RemindAt        time.Time  `gorm:"type:datetime;not null;index" json:"remind_at"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"` // active, cancelled
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`                             // 本次提醒已尝试发送的次数
+	NextAttemptTime *time.Time `gorm:"type:datetime;null" json:"next_attempt_time"`                    // 发送失败后下一次重试的时间
+	LastError       string     `gorm:"type:text" json:"last_error"`
+	LastSentTime    *time.Time `gorm:"type:datetime;null" json:"last_sent_time"`
+	CreateTime      time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (Anniversary) TableName() string {
+	return "anniversaries"
+}
@@ -10,6 +10,12 @@ const HelpText = `🤖 你好！我是一个通用的AI助手，可以和你聊
 其他命令：
 - "help" - 查看帮助
 - "get:session" - 查看对话记录
+- "/q 查询语句" - 直接查询任务，如 /q status:open assignee:me due<2026-11-01 label:release sort:due
+- "下一页" - 查看上一次任务列表或查询的下一页
+- "设置免打扰 22:00-08:00" - 修改通知偏好（免打扰、时区、私聊提醒、本群静音）
+- "10分钟后提醒我喝水" - 设置个人提醒（"我有哪些提醒"查看，"取消提醒 3"取消）
+- "妈妈的生日是农历八月十五" - 登记生日或纪念日，每年提前提醒（"我登记了哪些生日"查看）
+- 收到任务提醒后回复"稍后提醒 30分钟"、"明天再提醒"或"知道了"
 - "换个话题" - 重新开始对话
 
 直接和我聊天就行，需要任务管理时明确告诉我！`
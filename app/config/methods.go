@@ -51,6 +51,12 @@ func LoadConfig() *Configuration {
 		if config.ModelName == "" {
 			config.ModelName = "deepseek-chat"
 		}
+		if config.Task.TrashRetentionDays <= 0 {
+			config.Task.TrashRetentionDays = 30
+		}
+		if config.Task.UndoWindowMinutes <= 0 {
+			config.Task.UndoWindowMinutes = 30
+		}
 	})
 	return config
 }
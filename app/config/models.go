@@ -11,6 +11,28 @@ type Configuration struct {
 	MaxMsg   int  `json:"max_msg"`
 	// MySQL 数据库配置
 	MySQL MySQLConfig `json:"mysql"`
+	// 任务管理配置
+	Task TaskConfig `json:"task"`
+	// 通知配置
+	Notify NotifyConfig `json:"notify"`
+}
+
+// TaskConfig 任务管理配置
+type TaskConfig struct {
+	TrashRetentionDays int    `json:"trash_retention_days"` // 回收站中任务的保留天数，超过后彻底删除，默认30天
+	UndoWindowMinutes  int    `json:"undo_window_minutes"`  // 可以撤销多少分钟内的操作，默认30分钟
+	ReminderOffsets    string `json:"reminder_offsets"`     // 默认的任务提醒提前量，逗号分隔，如 "1d,1h,0"（0表示到期时），默认 "1d,0"
+	HolidayFile        string `json:"holiday_file"`         // 节假日和调休数据文件（可选），其中的年份替换内置数据，修改后自动重新加载
+	LegacyWorkspace    string `json:"legacy_workspace"`     // 旧版本遗留的、未归属任何工作区的任务归入哪个群或好友的工作区（群名或昵称，可选），该会话下次发消息时迁移
+}
+
+// NotifyConfig 通知的默认设置，用户可以通过通知偏好覆盖
+type NotifyConfig struct {
+	QuietHours   string `json:"quiet_hours"`   // 默认免打扰时段，如 "23:00-07:00"，为空表示不免打扰
+	Timezone     string `json:"timezone"`      // 默认时区，如 "Asia/Shanghai"，为空表示服务器本地时区
+	DigestTime   string `json:"digest_time"`   // 默认每日摘要发送时间，如 "08:30"，群周报也在这个时间发送
+	WeeklyDigest string `json:"weekly_digest"` // 群周报在星期几发送，如 "monday" 或 "周一"，默认周一，off 表示不发送
+	DigestPolish bool   `json:"digest_polish"` // 是否用 AI 润色摘要（失败时使用模板）
 }
 
 // MySQLConfig MySQL数据库配置
@@ -0,0 +1,266 @@
+package datetime
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DateLayout 日历中日期的格式
+const DateLayout = "2006-01-02"
+
+// holidayReloadInterval 检查节假日数据文件是否更新的间隔
+const holidayReloadInterval = time.Minute
+
+// bundledHolidays 内置的中国法定节假日和调休安排
+//
+//go:embed holidays_cn.json
+var bundledHolidays []byte
+
+// Festival 法定节假日：放假的日期区间和调休上班的日期
+type Festival struct {
+	Name     string   `json:"name"`
+	Start    string   `json:"start"`              // 放假第一天，如 2026-10-01
+	End      string   `json:"end"`                // 放假最后一天
+	Workdays []string `json:"workdays,omitempty"` // 调休上班的日期
+}
+
+// dayOverride 日历对某一天的特殊安排
+type dayOverride struct {
+	name string
+	off  bool // true 表示放假，false 表示调休上班
+}
+
+// Calendar 工作日历：周末休息，法定节假日放假，调休的周末上班，还可以加上自定义的休息日
+type Calendar struct {
+	days   map[string]dayOverride
+	years  map[int]bool      // 节假日数据覆盖的年份
+	closed map[string]string // 自定义的休息日（日期 → 名称）
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultCalendar *Calendar
+	holidayFile     string
+	holidayModTime  time.Time
+	holidayChecked  time.Time
+
+	// warnedYears 已经提示过缺少节假日数据的年份
+	warnedYears sync.Map
+)
+
+// ParseFestivals 解析并校验节假日数据（JSON 数组，格式同 holidays_cn.json）
+func ParseFestivals(data []byte) ([]Festival, error) {
+	var festivals []Festival
+	if err := json.Unmarshal(data, &festivals); err != nil {
+		return nil, fmt.Errorf("invalid holiday data: %v", err)
+	}
+	for _, f := range festivals {
+		start, err := time.Parse(DateLayout, f.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start %q of holiday %q", f.Start, f.Name)
+		}
+		end, err := time.Parse(DateLayout, f.End)
+		if err != nil || end.Before(start) {
+			return nil, fmt.Errorf("invalid end %q of holiday %q", f.End, f.Name)
+		}
+		for _, w := range f.Workdays {
+			if _, err := time.Parse(DateLayout, w); err != nil {
+				return nil, fmt.Errorf("invalid workday %q of holiday %q", w, f.Name)
+			}
+		}
+	}
+	return festivals, nil
+}
+
+// NewCalendar 由节假日数据创建日历，无效的日期会被忽略
+func NewCalendar(festivals []Festival) *Calendar {
+	c := &Calendar{days: make(map[string]dayOverride), years: make(map[int]bool)}
+	for _, f := range festivals {
+		start, err1 := time.Parse(DateLayout, f.Start)
+		end, err2 := time.Parse(DateLayout, f.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		c.years[start.Year()] = true
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			c.days[d.Format(DateLayout)] = dayOverride{name: f.Name, off: true}
+		}
+		for _, w := range f.Workdays {
+			c.days[w] = dayOverride{name: f.Name + "调休", off: false}
+		}
+	}
+	return c
+}
+
+// festivalYear 节假日所在的年份（按放假第一天）
+func festivalYear(f Festival) int {
+	start, err := time.Parse(DateLayout, f.Start)
+	if err != nil {
+		return 0
+	}
+	return start.Year()
+}
+
+// mergeFestivals 合并节假日数据：updates 中出现的年份整年替换 base 中的安排
+func mergeFestivals(base, updates []Festival) []Festival {
+	replaced := make(map[int]bool)
+	for _, f := range updates {
+		replaced[festivalYear(f)] = true
+	}
+	var merged []Festival
+	for _, f := range base {
+		if !replaced[festivalYear(f)] {
+			merged = append(merged, f)
+		}
+	}
+	return append(merged, updates...)
+}
+
+// bundledCalendar 只包含内置节假日数据的日历
+func bundledCalendar() *Calendar {
+	festivals, err := ParseFestivals(bundledHolidays)
+	if err != nil {
+		log.Printf("ERROR: Invalid bundled holiday data: %v\n", err)
+	}
+	return NewCalendar(festivals)
+}
+
+// LoadHolidays 从文件加载节假日数据，文件中出现的年份替换内置数据；之后文件修改时自动重新加载
+func LoadHolidays(path string) error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	holidayFile = path
+	holidayModTime = time.Time{}
+	return reloadHolidays(time.Now())
+}
+
+// reloadHolidays 节假日数据文件修改后重新加载（调用方持有 defaultMu）
+func reloadHolidays(now time.Time) error {
+	holidayChecked = now
+	info, err := os.Stat(holidayFile)
+	if err != nil {
+		return fmt.Errorf("failed to read holiday file: %v", err)
+	}
+	if defaultCalendar != nil && info.ModTime().Equal(holidayModTime) {
+		return nil
+	}
+	data, err := os.ReadFile(holidayFile)
+	if err != nil {
+		return fmt.Errorf("failed to read holiday file: %v", err)
+	}
+	festivals, err := ParseFestivals(data)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %v", holidayFile, err)
+	}
+	bundled, _ := ParseFestivals(bundledHolidays)
+	defaultCalendar = NewCalendar(mergeFestivals(bundled, festivals))
+	holidayModTime = info.ModTime()
+	log.Printf("Loaded %d holidays from %s\n", len(festivals), holidayFile)
+	return nil
+}
+
+// DefaultCalendar 默认的工作日历（内置数据加上 LoadHolidays 加载的文件）
+func DefaultCalendar() *Calendar {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	now := time.Now()
+	if holidayFile != "" && now.Sub(holidayChecked) >= holidayReloadInterval {
+		if err := reloadHolidays(now); err != nil {
+			log.Printf("WARNING: %v\n", err)
+		}
+	}
+	if defaultCalendar == nil {
+		defaultCalendar = bundledCalendar()
+	}
+	return defaultCalendar
+}
+
+// WithClosedDays 返回加上自定义休息日（日期 → 名称）的日历
+func (c *Calendar) WithClosedDays(days map[string]string) *Calendar {
+	return &Calendar{days: c.days, years: c.years, closed: days}
+}
+
+// IsWorkday 某天是否上班
+func (c *Calendar) IsWorkday(t time.Time) bool {
+	key := t.Format(DateLayout)
+	if _, ok := c.closed[key]; ok {
+		return false
+	}
+	if o, ok := c.days[key]; ok {
+		return !o.off
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// DayName 节假日、调休或自定义休息日的名称，普通的日子返回 false
+func (c *Calendar) DayName(t time.Time) (string, bool) {
+	key := t.Format(DateLayout)
+	if name, ok := c.closed[key]; ok {
+		return name, true
+	}
+	if o, ok := c.days[key]; ok {
+		return o.name, true
+	}
+	return "", false
+}
+
+// Years 节假日数据覆盖的年份（其他年份只按周末计算）
+func (c *Calendar) Years() []int {
+	years := make([]int, 0, len(c.years))
+	for y := range c.years {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	return years
+}
+
+// AddWorkdays t 之后的第 n 个工作日（保留 t 的时刻）；n 为 0 时为 t 当天或之后最近的工作日
+func (c *Calendar) AddWorkdays(t time.Time, n int) time.Time {
+	d := t
+	if n <= 0 {
+		for !c.IsWorkday(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+		c.checkCoverage(t, d)
+		return d
+	}
+	for n > 0 {
+		d = d.AddDate(0, 0, 1)
+		if c.IsWorkday(d) {
+			n--
+		}
+	}
+	c.checkCoverage(t, d)
+	return d
+}
+
+// WorkdaysBetween from 之后到 to 当天（按日期，不含 from 当天）的工作日数，to 不晚于 from 时为 0
+func (c *Calendar) WorkdaysBetween(from, to time.Time) int {
+	n := 0
+	last := startOfDay(to.In(from.Location()))
+	c.checkCoverage(from, last)
+	for d := startOfDay(from).AddDate(0, 0, 1); !d.After(last); d = d.AddDate(0, 0, 1) {
+		if c.IsWorkday(d) {
+			n++
+		}
+	}
+	return n
+}
+
+// checkCoverage from 到 to 跨越的年份没有节假日数据时打印警告（每个年份只提示一次）
+func (c *Calendar) checkCoverage(from, to time.Time) {
+	for year := from.Year(); year <= to.Year(); year++ {
+		if c.years[year] {
+			continue
+		}
+		if _, warned := warnedYears.LoadOrStore(year, true); !warned {
+			log.Printf("WARNING: No holiday data for %d, counting workdays by weekends only\n", year)
+		}
+	}
+}
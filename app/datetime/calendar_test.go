@@ -0,0 +1,167 @@
+package datetime
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarMakeupWorkday(t *testing.T) {
+	c := testCalendar()
+	tests := []struct {
+		day  time.Time
+		want bool
+	}{
+		{date(2026, 9, 30, 0, 0), true},  // 周三
+		{date(2026, 10, 1, 0, 0), false}, // 国庆节
+		{date(2026, 10, 7, 0, 0), false},
+		{date(2026, 10, 8, 0, 0), true},
+		{date(2026, 10, 10, 0, 0), true},  // 周六调休上班
+		{date(2026, 10, 11, 0, 0), false}, // 周日
+		{date(2026, 10, 17, 0, 0), false}, // 普通周六
+	}
+	for _, tt := range tests {
+		if got := c.IsWorkday(tt.day); got != tt.want {
+			t.Errorf("IsWorkday(%s) = %v, want %v", tt.day.Format(DateLayout), got, tt.want)
+		}
+	}
+
+	if name, ok := c.DayName(date(2026, 10, 10, 0, 0)); !ok || name != "国庆节调休" {
+		t.Errorf("DayName(2026-10-10) = %q, %v, want 国庆节调休", name, ok)
+	}
+	if name, ok := c.DayName(date(2026, 10, 12, 0, 0)); ok {
+		t.Errorf("DayName(2026-10-12) = %q, want none", name)
+	}
+}
+
+func TestCalendarClosedDays(t *testing.T) {
+	c := testCalendar().WithClosedDays(map[string]string{"2026-10-10": "年会"})
+	if c.IsWorkday(date(2026, 10, 10, 0, 0)) {
+		t.Error("IsWorkday(2026-10-10) with closed day = true, want false")
+	}
+	if name, _ := c.DayName(date(2026, 10, 10, 0, 0)); name != "年会" {
+		t.Errorf("DayName(2026-10-10) = %q, want 年会", name)
+	}
+	// 原日历不受影响
+	if !testCalendar().IsWorkday(date(2026, 10, 10, 0, 0)) {
+		t.Error("IsWorkday(2026-10-10) = false, want true")
+	}
+}
+
+func TestCalendarAddWorkdays(t *testing.T) {
+	c := testCalendar()
+	tests := []struct {
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{date(2026, 9, 30, 18, 0), 1, date(2026, 10, 8, 18, 0)}, // 跳过国庆假期，保留时刻
+		{date(2026, 9, 30, 0, 0), 3, date(2026, 10, 10, 0, 0)},  // 调休的周六算工作日
+		{date(2026, 10, 9, 0, 0), 1, date(2026, 10, 10, 0, 0)},
+		{date(2026, 10, 9, 0, 0), 2, date(2026, 10, 12, 0, 0)},
+		{date(2026, 10, 16, 0, 0), 1, date(2026, 10, 19, 0, 0)}, // 普通周末
+		{date(2026, 10, 3, 0, 0), 0, date(2026, 10, 8, 0, 0)},   // 当天或之后最近的工作日
+		{date(2026, 10, 15, 0, 0), 0, date(2026, 10, 15, 0, 0)},
+	}
+	for _, tt := range tests {
+		if got := c.AddWorkdays(tt.from, tt.n); !got.Equal(tt.want) {
+			t.Errorf("AddWorkdays(%s, %d) = %s, want %s", tt.from.Format(time.RFC3339), tt.n, got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestCalendarWorkdaysBetween(t *testing.T) {
+	c := testCalendar()
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{date(2026, 9, 30, 10, 0), date(2026, 10, 10, 9, 0), 3},
+		{date(2026, 10, 1, 0, 0), date(2026, 10, 7, 0, 0), 0},
+		{date(2026, 10, 15, 0, 0), date(2026, 10, 15, 23, 0), 0},
+		{date(2026, 10, 20, 0, 0), date(2026, 10, 15, 0, 0), 0},
+		{date(2026, 10, 15, 0, 0), date(2026, 10, 22, 0, 0), 5},
+	}
+	for _, tt := range tests {
+		if got := c.WorkdaysBetween(tt.from, tt.to); got != tt.want {
+			t.Errorf("WorkdaysBetween(%s, %s) = %d, want %d", tt.from.Format(DateLayout), tt.to.Format(DateLayout), got, tt.want)
+		}
+	}
+}
+
+func TestCalendarWarnsUncoveredYear(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	c := testCalendar()
+	c.AddWorkdays(date(2026, 10, 15, 0, 0), 3)
+	if buf.Len() != 0 {
+		t.Errorf("AddWorkdays within covered year logged %q", buf.String())
+	}
+
+	c.AddWorkdays(date(2098, 12, 30, 0, 0), 3)
+	c.WorkdaysBetween(date(2098, 12, 1, 0, 0), date(2099, 1, 10, 0, 0))
+	if got := strings.Count(buf.String(), "No holiday data for 2099"); got != 1 {
+		t.Errorf("warnings for 2099 = %d, want 1 (log: %q)", got, buf.String())
+	}
+	if got := strings.Count(buf.String(), "No holiday data for 2098"); got != 1 {
+		t.Errorf("warnings for 2098 = %d, want 1 (log: %q)", got, buf.String())
+	}
+}
+
+func TestMergeFestivals(t *testing.T) {
+	base := []Festival{
+		{Name: "国庆节", Start: "2025-10-01", End: "2025-10-08"},
+		{Name: "春节", Start: "2026-02-15", End: "2026-02-23"},
+		{Name: "国庆节", Start: "2026-10-01", End: "2026-10-08"},
+	}
+	updates := []Festival{
+		{Name: "国庆节", Start: "2026-10-01", End: "2026-10-07", Workdays: []string{"2026-10-10"}},
+		{Name: "元旦", Start: "2027-01-01", End: "2027-01-01"},
+	}
+
+	merged := mergeFestivals(base, updates)
+	var names []string
+	for _, f := range merged {
+		names = append(names, f.Start+" "+f.Name)
+	}
+	want := []string{"2025-10-01 国庆节", "2026-10-01 国庆节", "2027-01-01 元旦"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("mergeFestivals = %v, want %v", names, want)
+	}
+
+	// 2026 年整年替换：春节安排被去掉，国庆按新数据调休
+	c := NewCalendar(merged)
+	if !c.IsWorkday(date(2026, 2, 16, 0, 0)) {
+		t.Error("IsWorkday(2026-02-16) = false, want true after 2026 was replaced")
+	}
+	if !c.IsWorkday(date(2026, 10, 8, 0, 0)) || !c.IsWorkday(date(2026, 10, 10, 0, 0)) {
+		t.Error("2026-10-08 and 2026-10-10 should be workdays after update")
+	}
+	if c.IsWorkday(date(2025, 10, 8, 0, 0)) {
+		t.Error("IsWorkday(2025-10-08) = true, want false (bundled 2025 data kept)")
+	}
+	if got := c.Years(); len(got) != 3 || got[0] != 2025 || got[2] != 2027 {
+		t.Errorf("Years() = %v, want [2025 2026 2027]", got)
+	}
+}
+
+func TestParseFestivals(t *testing.T) {
+	if _, err := ParseFestivals(bundledHolidays); err != nil {
+		t.Fatalf("bundled holiday data: %v", err)
+	}
+	for _, data := range []string{
+		`{}`,
+		`[{"name": "国庆节", "start": "2026-10-01", "end": "2026-09-30"}]`,
+		`[{"name": "国庆节", "start": "10/01", "end": "2026-10-07"}]`,
+		`[{"name": "国庆节", "start": "2026-10-01", "end": "2026-10-07", "workdays": ["10-10"]}]`,
+	} {
+		if _, err := ParseFestivals([]byte(data)); err == nil {
+			t.Errorf("ParseFestivals(%s) = nil error, want error", data)
+		}
+	}
+}
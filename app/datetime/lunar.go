@@ -0,0 +1,271 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 支持的农历年份范围
+const (
+	MinLunarYear = 1900
+	MaxLunarYear = 2100
+)
+
+// lunarInfo 1900-2100 年的农历数据：
+// 第 0-3 位为闰月月份（0 表示无闰月），第 4-15 位为十二个月的大小（第 15 位为正月，1 表示 30 天），第 16 位为闰月的大小
+var lunarInfo = []int{
+	0x04bd8, 0x04ae0, 0x0a570, 0x054d5, 0x0d260, 0x0d950, 0x16554, 0x056a0, 0x09ad0, 0x055d2, // 1900-1909
+	0x04ae0, 0x0a5b6, 0x0a4d0, 0x0d250, 0x1d255, 0x0b540, 0x0d6a0, 0x0ada2, 0x095b0, 0x14977, // 1910-1919
+	0x04970, 0x0a4b0, 0x0b4b5, 0x06a50, 0x06d40, 0x1ab54, 0x02b60, 0x09570, 0x052f2, 0x04970, // 1920-1929
+	0x06566, 0x0d4a0, 0x0ea50, 0x16a95, 0x05ad0, 0x02b60, 0x186e3, 0x092e0, 0x1c8d7, 0x0c950, // 1930-1939
+	0x0d4a0, 0x1d8a6, 0x0b550, 0x056a0, 0x1a5b4, 0x025d0, 0x092d0, 0x0d2b2, 0x0a950, 0x0b557, // 1940-1949
+	0x06ca0, 0x0b550, 0x15355, 0x04da0, 0x0a5b0, 0x14573, 0x052b0, 0x0a9a8, 0x0e950, 0x06aa0, // 1950-1959
+	0x0aea6, 0x0ab50, 0x04b60, 0x0aae4, 0x0a570, 0x05260, 0x0f263, 0x0d950, 0x05b57, 0x056a0, // 1960-1969
+	0x096d0, 0x04dd5, 0x04ad0, 0x0a4d0, 0x0d4d4, 0x0d250, 0x0d558, 0x0b540, 0x0b6a0, 0x195a6, // 1970-1979
+	0x095b0, 0x049b0, 0x0a974, 0x0a4b0, 0x0b27a, 0x06a50, 0x06d40, 0x0af46, 0x0ab60, 0x09570, // 1980-1989
+	0x04af5, 0x04970, 0x064b0, 0x074a3, 0x0ea50, 0x06b58, 0x05ac0, 0x0ab60, 0x096d5, 0x092e0, // 1990-1999
+	0x0c960, 0x0d954, 0x0d4a0, 0x0da50, 0x07552, 0x056a0, 0x0abb7, 0x025d0, 0x092d0, 0x0cab5, // 2000-2009
+	0x0a950, 0x0b4a0, 0x0baa4, 0x0ad50, 0x055d9, 0x04ba0, 0x0a5b0, 0x15176, 0x052b0, 0x0a930, // 2010-2019
+	0x07954, 0x06aa0, 0x0ad50, 0x05b52, 0x04b60, 0x0a6e6, 0x0a4e0, 0x0d260, 0x0ea65, 0x0d530, // 2020-2029
+	0x05aa0, 0x076a3, 0x096d0, 0x04afb, 0x04ad0, 0x0a4d0, 0x1d0b6, 0x0d250, 0x0d520, 0x0dd45, // 2030-2039
+	0x0b5a0, 0x056d0, 0x055b2, 0x049b0, 0x0a577, 0x0a4b0, 0x0aa50, 0x1b255, 0x06d20, 0x0ada0, // 2040-2049
+	0x14b63, 0x09370, 0x049f8, 0x04970, 0x064b0, 0x168a6, 0x0ea50, 0x06b20, 0x1a6c4, 0x0aae0, // 2050-2059
+	0x092e0, 0x0d2e3, 0x0c960, 0x0d557, 0x0d4a0, 0x0da50, 0x05d55, 0x056a0, 0x0a6d0, 0x055d4, // 2060-2069
+	0x052d0, 0x0a9b8, 0x0a950, 0x0b4a0, 0x0b6a6, 0x0ad50, 0x055a0, 0x0aba4, 0x0a5b0, 0x052b0, // 2070-2079
+	0x0b273, 0x06930, 0x07337, 0x06aa0, 0x0ad50, 0x14b55, 0x04b60, 0x0a570, 0x054e4, 0x0d160, // 2080-2089
+	0x0e968, 0x0d520, 0x0daa0, 0x16aa6, 0x056d0, 0x04ae0, 0x0a9d4, 0x0a2d0, 0x0d150, 0x0f252, // 2090-2099
+	0x0d520, // 2100
+}
+
+// lunarEpoch 农历 1900 年正月初一对应的公历日期
+var lunarEpoch = time.Date(1900, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+var (
+	lunarMonthNames = []string{"正", "二", "三", "四", "五", "六", "七", "八", "九", "十", "冬", "腊"}
+	lunarDayTens    = []string{"初", "十", "廿", "三"}
+	lunarDigits     = []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九", "十"}
+)
+
+// LunarDate 农历日期
+type LunarDate struct {
+	Year  int
+	Month int  // 1-12
+	Day   int  // 1-30
+	Leap  bool // 是否闰月
+}
+
+// LeapMonth 农历某年的闰月月份，没有闰月时为 0
+func LeapMonth(year int) int {
+	if year < MinLunarYear || year > MaxLunarYear {
+		return 0
+	}
+	return lunarInfo[year-MinLunarYear] & 0xf
+}
+
+// LunarMonthDays 农历某年某月（或闰月）的天数，月份不存在时为 0
+func LunarMonthDays(year, month int, leap bool) int {
+	if year < MinLunarYear || year > MaxLunarYear || month < 1 || month > 12 {
+		return 0
+	}
+	info := lunarInfo[year-MinLunarYear]
+	if leap {
+		if LeapMonth(year) != month {
+			return 0
+		}
+		if info&0x10000 != 0 {
+			return 30
+		}
+		return 29
+	}
+	if info&(0x10000>>uint(month)) != 0 {
+		return 30
+	}
+	return 29
+}
+
+// lunarYearDays 农历某年的总天数
+func lunarYearDays(year int) int {
+	days := 0
+	for m := 1; m <= 12; m++ {
+		days += LunarMonthDays(year, m, false)
+	}
+	if leap := LeapMonth(year); leap > 0 {
+		days += LunarMonthDays(year, leap, true)
+	}
+	return days
+}
+
+// LunarToSolar 农历日期对应的公历日期（loc 时区的 00:00）
+func LunarToSolar(d LunarDate, loc *time.Location) (time.Time, error) {
+	if d.Year < MinLunarYear || d.Year > MaxLunarYear {
+		return time.Time{}, fmt.Errorf("lunar year %d is out of range %d-%d", d.Year, MinLunarYear, MaxLunarYear)
+	}
+	days := LunarMonthDays(d.Year, d.Month, d.Leap)
+	if days == 0 {
+		return time.Time{}, fmt.Errorf("农历%d年没有%s", d.Year, lunarMonthName(d.Month, d.Leap))
+	}
+	if d.Day < 1 || d.Day > days {
+		return time.Time{}, fmt.Errorf("农历%d年%s只有%d天", d.Year, lunarMonthName(d.Month, d.Leap), days)
+	}
+
+	offset := 0
+	for y := MinLunarYear; y < d.Year; y++ {
+		offset += lunarYearDays(y)
+	}
+	leap := LeapMonth(d.Year)
+	for m := 1; m < d.Month; m++ {
+		offset += LunarMonthDays(d.Year, m, false)
+		if m == leap {
+			offset += LunarMonthDays(d.Year, m, true)
+		}
+	}
+	if d.Leap {
+		// 闰月在同名的月份之后
+		offset += LunarMonthDays(d.Year, d.Month, false)
+	}
+	offset += d.Day - 1
+
+	t := lunarEpoch.AddDate(0, 0, offset)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// SolarToLunar 公历日期对应的农历日期
+func SolarToLunar(t time.Time) (LunarDate, error) {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := int(date.Sub(lunarEpoch).Hours() / 24)
+	if offset < 0 {
+		return LunarDate{}, fmt.Errorf("date %s is before lunar year %d", t.Format(DateLayout), MinLunarYear)
+	}
+
+	year := MinLunarYear
+	for ; year <= MaxLunarYear; year++ {
+		days := lunarYearDays(year)
+		if offset < days {
+			break
+		}
+		offset -= days
+	}
+	if year > MaxLunarYear {
+		return LunarDate{}, fmt.Errorf("date %s is after lunar year %d", t.Format(DateLayout), MaxLunarYear)
+	}
+
+	leap := LeapMonth(year)
+	for month := 1; month <= 12; month++ {
+		days := LunarMonthDays(year, month, false)
+		if offset < days {
+			return LunarDate{Year: year, Month: month, Day: offset + 1}, nil
+		}
+		offset -= days
+		if month == leap {
+			days = LunarMonthDays(year, month, true)
+			if offset < days {
+				return LunarDate{Year: year, Month: month, Day: offset + 1, Leap: true}, nil
+			}
+			offset -= days
+		}
+	}
+	return LunarDate{}, fmt.Errorf("failed to convert %s to lunar date", t.Format(DateLayout))
+}
+
+// NextLunarDate 农历每年的 month 月 day 日在 from 当天或之后的第一个公历日期
+// 当年该月只有 29 天时取月末；leap 为 true 但当年没有这个闰月时取同名的月份
+func NextLunarDate(month, day int, leap bool, from time.Time) (time.Time, error) {
+	start := startOfDay(from)
+	current, err := SolarToLunar(start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for year := current.Year; year <= current.Year+1; year++ {
+		d := LunarDate{Year: year, Month: month, Day: day, Leap: leap && LeapMonth(year) == month}
+		if days := LunarMonthDays(year, month, d.Leap); d.Day > days {
+			d.Day = days
+		}
+		t, err := LunarToSolar(d, from.Location())
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !t.Before(start) {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no lunar date %s after %s", LunarDate{Month: month, Day: day, Leap: leap}.MonthDay(), from.Format(DateLayout))
+}
+
+// lunarMonthName 农历月份名称，如 “正月”“闰四月”“腊月”
+func lunarMonthName(month int, leap bool) string {
+	if month < 1 || month > 12 {
+		return fmt.Sprintf("%d月", month)
+	}
+	name := lunarMonthNames[month-1] + "月"
+	if leap {
+		name = "闰" + name
+	}
+	return name
+}
+
+// lunarDayName 农历日期名称，如 “初一”“十五”“廿三”“三十”
+func lunarDayName(day int) string {
+	switch day {
+	case 10:
+		return "初十"
+	case 20:
+		return "二十"
+	case 30:
+		return "三十"
+	}
+	if day < 1 || day > 30 {
+		return fmt.Sprintf("%d日", day)
+	}
+	return lunarDayTens[day/10] + lunarDigits[day%10]
+}
+
+// MonthDay 农历月日，如 “八月十五”“闰四月初一”
+func (d LunarDate) MonthDay() string {
+	return lunarMonthName(d.Month, d.Leap) + lunarDayName(d.Day)
+}
+
+// String 农历日期，如 “农历2026年八月十五”
+func (d LunarDate) String() string {
+	return fmt.Sprintf("农历%d年%s", d.Year, d.MonthDay())
+}
+
+// lunarMonthDayPattern 农历月日，如 “八月十五”“正月初一”“闰四月初八”“腊月廿三”“12月3”
+var lunarMonthDayPattern = regexp.MustCompile(`^(闰)?(正|冬|腊|` + numPattern + `)月(初|廿|卅)?(` + numPattern + `)(?:日|号)?`)
+
+// ParseLunarMonthDay 解析农历月日（可以带“农历”“阴历”前缀），返回月、日、是否闰月和剩余内容
+func ParseLunarMonthDay(s string) (month, day int, leap bool, rest string, err error) {
+	input := s
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"农历", "阴历", "旧历"} {
+		s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+	}
+	m := lunarMonthDayPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false, "", fmt.Errorf("无法识别的农历日期 %q（示例: 八月十五、正月初一、腊月廿三）", input)
+	}
+
+	switch m[2] {
+	case "正":
+		month = 1
+	case "冬":
+		month = 11
+	case "腊":
+		month = 12
+	default:
+		month, _ = ParseNumber(m[2])
+	}
+	n, ok := ParseNumber(m[4])
+	switch m[3] {
+	case "初":
+	case "廿":
+		n += 20
+	case "卅":
+		n += 30
+	}
+	if !ok || month < 1 || month > 12 || n < 1 || n > 30 {
+		return 0, 0, false, "", fmt.Errorf("无效的农历日期 %q", input)
+	}
+	return month, n, m[1] != "", strings.TrimSpace(s[len(m[0]):]), nil
+}
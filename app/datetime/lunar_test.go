@@ -0,0 +1,151 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSolarToLunar(t *testing.T) {
+	tests := []struct {
+		solar time.Time
+		want  LunarDate
+	}{
+		{date(1900, 1, 31, 0, 0), LunarDate{Year: 1900, Month: 1, Day: 1}},
+		{date(2000, 2, 5, 0, 0), LunarDate{Year: 2000, Month: 1, Day: 1}},
+		{date(2020, 5, 23, 0, 0), LunarDate{Year: 2020, Month: 4, Day: 1, Leap: true}},
+		{date(2023, 3, 22, 0, 0), LunarDate{Year: 2023, Month: 2, Day: 1, Leap: true}},
+		{date(2024, 2, 9, 0, 0), LunarDate{Year: 2023, Month: 12, Day: 30}},
+		{date(2024, 9, 17, 0, 0), LunarDate{Year: 2024, Month: 8, Day: 15}},
+		{date(2025, 7, 25, 0, 0), LunarDate{Year: 2025, Month: 6, Day: 1, Leap: true}},
+		{date(2025, 10, 6, 0, 0), LunarDate{Year: 2025, Month: 8, Day: 15}},
+		{date(2026, 2, 16, 0, 0), LunarDate{Year: 2025, Month: 12, Day: 29}}, // 2026 年除夕没有年三十
+		{date(2026, 2, 17, 23, 30), LunarDate{Year: 2026, Month: 1, Day: 1}},
+		{date(2026, 9, 25, 0, 0), LunarDate{Year: 2026, Month: 8, Day: 15}},
+	}
+	for _, tt := range tests {
+		got, err := SolarToLunar(tt.solar)
+		if err != nil {
+			t.Errorf("SolarToLunar(%s) error: %v", tt.solar.Format(DateLayout), err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SolarToLunar(%s) = %s, want %s", tt.solar.Format(DateLayout), got, tt.want)
+		}
+	}
+
+	if _, err := SolarToLunar(date(1900, 1, 30, 0, 0)); err == nil {
+		t.Error("SolarToLunar(1900-01-30) = nil error, want out of range")
+	}
+}
+
+func TestLunarToSolar(t *testing.T) {
+	tests := []struct {
+		lunar LunarDate
+		want  time.Time
+	}{
+		{LunarDate{Year: 2026, Month: 1, Day: 1}, date(2026, 2, 17, 0, 0)},
+		{LunarDate{Year: 2026, Month: 8, Day: 15}, date(2026, 9, 25, 0, 0)},
+		{LunarDate{Year: 2025, Month: 6, Day: 1}, date(2025, 6, 25, 0, 0)},
+		{LunarDate{Year: 2025, Month: 6, Day: 1, Leap: true}, date(2025, 7, 25, 0, 0)},
+		{LunarDate{Year: 2025, Month: 12, Day: 29}, date(2026, 2, 16, 0, 0)},
+		{LunarDate{Year: 2027, Month: 1, Day: 1}, date(2027, 2, 6, 0, 0)},
+	}
+	for _, tt := range tests {
+		got, err := LunarToSolar(tt.lunar, cst)
+		if err != nil {
+			t.Errorf("LunarToSolar(%s) error: %v", tt.lunar, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("LunarToSolar(%s) = %s, want %s", tt.lunar, got.Format(DateLayout), tt.want.Format(DateLayout))
+		}
+	}
+
+	for _, d := range []LunarDate{
+		{Year: 2026, Month: 6, Day: 1, Leap: true}, // 2026 年没有闰月
+		{Year: 2025, Month: 12, Day: 30},           // 腊月只有 29 天
+		{Year: 2026, Month: 13, Day: 1},
+		{Year: 1899, Month: 1, Day: 1},
+		{Year: 2101, Month: 1, Day: 1},
+	} {
+		if got, err := LunarToSolar(d, cst); err == nil {
+			t.Errorf("LunarToSolar(%+v) = %s, want error", d, got.Format(DateLayout))
+		}
+	}
+}
+
+func TestLunarRoundTrip(t *testing.T) {
+	for d := date(1990, 1, 1, 0, 0); d.Year() < 2040; d = d.AddDate(0, 0, 1) {
+		lunar, err := SolarToLunar(d)
+		if err != nil {
+			t.Fatalf("SolarToLunar(%s) error: %v", d.Format(DateLayout), err)
+		}
+		back, err := LunarToSolar(lunar, cst)
+		if err != nil {
+			t.Fatalf("LunarToSolar(%s) error: %v", lunar, err)
+		}
+		if !back.Equal(d) {
+			t.Fatalf("%s → %s → %s", d.Format(DateLayout), lunar, back.Format(DateLayout))
+		}
+	}
+}
+
+func TestNextLunarDate(t *testing.T) {
+	tests := []struct {
+		month, day int
+		leap       bool
+		from       time.Time
+		want       time.Time
+	}{
+		{8, 15, false, date(2026, 9, 1, 10, 0), date(2026, 9, 25, 0, 0)},
+		{8, 15, false, date(2026, 9, 25, 18, 0), date(2026, 9, 25, 0, 0)}, // 当天也算
+		{8, 15, false, date(2026, 10, 18, 0, 0), date(2027, 9, 15, 0, 0)},
+		{1, 1, false, date(2026, 1, 1, 0, 0), date(2026, 2, 17, 0, 0)},
+		{12, 30, false, date(2026, 1, 1, 0, 0), date(2026, 2, 16, 0, 0)}, // 腊月只有 29 天时取月末
+		{6, 1, true, date(2025, 7, 1, 0, 0), date(2025, 7, 25, 0, 0)},
+	}
+	for _, tt := range tests {
+		got, err := NextLunarDate(tt.month, tt.day, tt.leap, tt.from)
+		if err != nil {
+			t.Errorf("NextLunarDate(%d, %d, %v, %s) error: %v", tt.month, tt.day, tt.leap, tt.from.Format(DateLayout), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("NextLunarDate(%d, %d, %v, %s) = %s, want %s", tt.month, tt.day, tt.leap, tt.from.Format(DateLayout), got.Format(DateLayout), tt.want.Format(DateLayout))
+		}
+	}
+
+	// 当年没有闰六月时取六月
+	got, err := NextLunarDate(6, 1, true, date(2025, 8, 1, 0, 0))
+	if err != nil {
+		t.Fatalf("NextLunarDate(闰六月初一) error: %v", err)
+	}
+	if lunar, _ := SolarToLunar(got); lunar != (LunarDate{Year: 2026, Month: 6, Day: 1}) {
+		t.Errorf("NextLunarDate(闰六月初一, 2025-08-01) = %s (%s), want 农历2026年六月初一", got.Format(DateLayout), lunar)
+	}
+}
+
+func TestParseLunarMonthDay(t *testing.T) {
+	tests := []struct {
+		input      string
+		month, day int
+		leap       bool
+		rest       string
+	}{
+		{"农历八月十五", 8, 15, false, ""},
+		{"正月初一", 1, 1, false, ""},
+		{"阴历腊月廿三 上午9点", 12, 23, false, "上午9点"},
+		{"闰四月初八", 4, 8, true, ""},
+		{"农历12月3", 12, 3, false, ""},
+	}
+	for _, tt := range tests {
+		month, day, leap, rest, err := ParseLunarMonthDay(tt.input)
+		if err != nil {
+			t.Errorf("ParseLunarMonthDay(%q) error: %v", tt.input, err)
+			continue
+		}
+		if month != tt.month || day != tt.day || leap != tt.leap || rest != tt.rest {
+			t.Errorf("ParseLunarMonthDay(%q) = %d, %d, %v, %q, want %d, %d, %v, %q", tt.input, month, day, leap, rest, tt.month, tt.day, tt.leap, tt.rest)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package datetime
+
+import (
+	"strconv"
+	"strings"
+)
+
+// numPattern 数字（阿拉伯数字或中文数字）的正则片段
+const numPattern = `[0-9零〇一二两三四五六七八九十百千]+`
+
+// chineseDigits 中文数字对应的值
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// chineseUnits 中文数字单位
+var chineseUnits = map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+// ParseNumber 解析阿拉伯数字或中文数字，如 "12"、"十二"、"三十一"、"一百零五"、"二〇二六"
+func ParseNumber(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, n >= 0
+	}
+
+	// 没有单位时逐位读，如 二〇二六、零五
+	if !strings.ContainsAny(s, "十百千") {
+		n := 0
+		for _, r := range s {
+			d, ok := chineseDigits[r]
+			if !ok {
+				return 0, false
+			}
+			n = n*10 + d
+		}
+		return n, true
+	}
+
+	total, digit, lastUnit := 0, -1, 10000
+	for _, r := range s {
+		if d, ok := chineseDigits[r]; ok {
+			digit = d
+			continue
+		}
+		unit, ok := chineseUnits[r]
+		if !ok || unit >= lastUnit {
+			return 0, false
+		}
+		if digit < 0 {
+			// “十五”省略了前面的“一”
+			digit = 1
+		}
+		total += digit * unit
+		digit, lastUnit = -1, unit
+	}
+	if digit > 0 {
+		total += digit
+	}
+	return total, true
+}
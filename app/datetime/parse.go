@@ -0,0 +1,674 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Parser 中文自然语言日期时间解析器
+// 支持 “下周一”“本周五下午”“月底”“3天后”“两小时后”“一刻钟后”“中午”“12月3号”“明年3月”“三点半”“农历八月十五” 和标准日期格式
+type Parser struct {
+	Location *time.Location   // 没有指定时区的时间按这个时区解释，为 nil 时使用 time.Local
+	Now      func() time.Time // 当前时间，为 nil 时使用 time.Now（测试时可注入固定时间）
+	Calendar *Calendar        // 计算“3个工作日内”所用的工作日历，为 nil 时使用 DefaultCalendar
+}
+
+// NewParser 创建按 loc 时区解析的解析器
+func NewParser(loc *time.Location) *Parser {
+	return &Parser{Location: loc}
+}
+
+// Result 解析结果
+type Result struct {
+	Time     time.Time // 解析出的时间；没有时刻时为当天 00:00
+	HasDate  bool      // 是否指定了日期（否则为今天或最近的一次）
+	HasClock bool      // 是否指定了时刻或时段（如“三点”“下午”）
+}
+
+// At 没有指定时刻时使用给定的时刻，如截止时间默认当天 23:59:59
+func (r Result) At(hour, minute, sec int) time.Time {
+	if r.HasClock {
+		return r.Time
+	}
+	t := r.Time
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, sec, 0, t.Location())
+}
+
+// 标准日期格式，按顺序尝试
+var layouts = []struct {
+	layout   string
+	hasClock bool
+}{
+	{"2006-1-2 15:04:05", true},
+	{"2006-1-2 15:04", true},
+	{"2006-1-2T15:04:05", true},
+	{"2006-1-2T15:04", true},
+	{"2006-1-2", false},
+	{"2006/1/2 15:04:05", true},
+	{"2006/1/2 15:04", true},
+	{"2006/1/2", false},
+}
+
+// 时段及没有具体时刻时的默认时刻（分钟）
+var periodDefaults = map[string]int{
+	"凌晨": 5 * 60, "清晨": 8 * 60, "早上": 8 * 60, "早晨": 8 * 60, "一早": 8 * 60, "上午": 9 * 60,
+	"中午": 12 * 60, "午后": 15 * 60, "下午": 15 * 60, "傍晚": 18 * 60, "黄昏": 18 * 60,
+	"晚上": 20 * 60, "晚间": 20 * 60, "夜里": 20 * 60, "夜晚": 20 * 60,
+	"深夜": 23 * 60, "半夜": 23 * 60, "午夜": 24 * 60,
+}
+
+// 带时段的日期词，如“今晚”“明早”
+var dayWords = map[string]struct {
+	days   int
+	period string
+}{
+	"今天": {0, ""}, "今日": {0, ""}, "今儿": {0, ""},
+	"明天": {1, ""}, "明日": {1, ""}, "明儿": {1, ""},
+	"后天": {2, ""}, "大后天": {3, ""},
+	"昨天": {-1, ""}, "前天": {-2, ""},
+	"今早": {0, "早上"}, "今晚": {0, "晚上"}, "今夜": {0, "晚上"},
+	"明早": {1, "早上"}, "明晚": {1, "晚上"},
+}
+
+// 周、月、年的相对偏移
+var (
+	weekOffsets  = map[string]int{"": 0, "上上": -2, "上": -1, "本": 0, "这": 0, "下": 1, "下下": 2}
+	monthOffsets = map[string]int{"上个月": -1, "上月": -1, "本月": 0, "这个月": 0, "这月": 0, "下个月": 1, "下月": 1, "下下个月": 2, "下下月": 2}
+	yearOffsets  = map[string]int{"今年": 0, "明年": 1, "去年": -1, "后年": 2}
+	weekdayIndex = map[string]int{
+		"一": 0, "二": 1, "三": 2, "四": 3, "五": 4, "六": 5, "日": 6, "天": 6,
+		"1": 0, "2": 1, "3": 2, "4": 3, "5": 4, "6": 5, "7": 6,
+	}
+)
+
+var (
+	zoneOffsetPattern = regexp.MustCompile(`(?i)(?:^|\s)(utc|gmt)\s*(?:([+-])(\d{1,2})(?::?(\d{2}))?)?$`)
+	zoneNamePattern   = regexp.MustCompile(`(?:^|\s)([A-Za-z]+/[A-Za-z_]+(?:/[A-Za-z_]+)?)$`)
+
+	workdayPattern    = regexp.MustCompile(`^(?:(` + numPattern + `)个?|下一?个)工作日(?:后|以后|之后|内|之内|以内)?`)
+	relativePattern   = regexp.MustCompile(`^(` + numPattern + `|半)个?(半)?个?(秒钟|秒|分钟|分|刻钟|小时|钟头|天|日|周|星期|礼拜|月|年)(?:后|以后|之后|内|之内|以内)`)
+	pastPattern       = regexp.MustCompile(`^(?:` + numPattern + `|半)(个)?半?个?(秒钟|秒|分钟|分|刻钟|小时|钟头|天|周|星期|礼拜|月|年|工作日)$`)
+	dayWordPattern    = regexp.MustCompile(`^(大后天|今天|今日|今儿|明天|明日|明儿|后天|昨天|前天|今早|今晚|今夜|明早|明晚)`)
+	weekdayPattern    = regexp.MustCompile(`^(上上|上|本|这|下下|下)?个?(?:周|星期|礼拜)([一二三四五六日天1-7])`)
+	weekendPattern    = regexp.MustCompile(`^(上|本|这|下)?个?周末`)
+	isoDatePattern    = regexp.MustCompile(`^(\d{4})[-/](\d{1,2})[-/](\d{1,2})(?:t|\s|$)`)
+	monthDayPattern   = regexp.MustCompile(`^(?:(今年|明年|去年|后年)|(` + numPattern + `)年)?(` + numPattern + `)月(?:(` + numPattern + `)(?:号|日)?|(底|末|初|中))?`)
+	yearEdgePattern   = regexp.MustCompile(`^(今年|明年|去年|后年)?(年底|年末|年初)`)
+	monthEdgePattern  = regexp.MustCompile(`^(上个月|上月|本月|这个月|这月|下下个月|下下月|下个月|下月)(底|末|初|中|(` + numPattern + `)(?:号|日))`)
+	thisMonthPattern  = regexp.MustCompile(`^月(底|末|初|中)`)
+	dayOfMonthPattern = regexp.MustCompile(`^(` + numPattern + `)(?:号|日)`)
+	periodPattern     = regexp.MustCompile(`^(凌晨|清晨|早上|早晨|一早|上午|中午|午后|下午|傍晚|黄昏|晚上|晚间|夜里|夜晚|深夜|半夜|午夜)`)
+	colonClockPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?`)
+	clockPattern      = regexp.MustCompile(`^(` + numPattern + `)(?:点|时)钟?(?:(半|一刻|三刻|整)|(` + numPattern + `)分?(?:(` + numPattern + `)秒)?)?`)
+	trailingWords     = []string{"完成", "之前", "以前", "前", "左右", "截止", "为止", "整"}
+	beforeWords       = map[string]bool{"之前": true, "以前": true, "前": true}
+)
+
+// parseState 解析过程中的中间状态
+type parseState struct {
+	now      time.Time // 当前时间（已转换到解析时区）
+	date     time.Time // 日期（当天 00:00）
+	hasDate  bool
+	period   string
+	hour     int
+	minute   int
+	second   int
+	hasClock bool
+}
+
+// now 当前时间
+func (p *Parser) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// location 解析时区
+func (p *Parser) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return time.Local
+}
+
+// calendar 工作日历
+func (p *Parser) calendar() *Calendar {
+	if p.Calendar != nil {
+		return p.Calendar
+	}
+	return DefaultCalendar()
+}
+
+// Parse 解析日期时间，如 “下周一上午10点”“12月3号”“两小时后”“2026-12-03 15:00”
+// 可以带时区，如 “明天9点 UTC”“2026-12-03 15:00 Asia/Tokyo”“北京时间下午3点”
+func (p *Parser) Parse(s string) (Result, error) {
+	input := strings.TrimSpace(s)
+	if input == "" {
+		return Result{}, fmt.Errorf("时间不能为空")
+	}
+
+	rest, loc, err := p.extractZone(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if t, err := time.Parse(time.RFC3339, rest); err == nil {
+		return Result{Time: t, HasDate: true, HasClock: true}, nil
+	}
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l.layout, rest, loc); err == nil {
+			return Result{Time: t, HasDate: true, HasClock: l.hasClock}, nil
+		}
+	}
+
+	text, ok := trimTrailingWords(normalize(rest))
+	if !ok {
+		return Result{}, fmt.Errorf("不支持过去的相对时间 %q，请说明具体的截止时间（如 周五前、18点前）", input)
+	}
+	now := p.now().In(loc)
+	res, ok := parseNatural(text, now, p.calendar())
+	if !ok {
+		return Result{}, fmt.Errorf("无法识别的时间 %q（示例: 明天下午3点、下周一、月底、3天后、12月3号 15:30、2026-12-03 15:00）", input)
+	}
+	return res, nil
+}
+
+// ParseDeadline 解析截止时间，只有日期时取当天 23:59:59
+func (p *Parser) ParseDeadline(s string) (time.Time, error) {
+	res, err := p.Parse(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return res.At(23, 59, 59), nil
+}
+
+// extractZone 去掉首尾的时区说明，返回其余部分和解析时区
+func (p *Parser) extractZone(s string) (string, *time.Location, error) {
+	for _, prefix := range []string{"北京时间", "中国时间"} {
+		if strings.HasPrefix(s, prefix) || strings.HasSuffix(s, prefix) {
+			rest := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, prefix), prefix))
+			return rest, time.FixedZone("CST", 8*3600), nil
+		}
+	}
+	if m := zoneOffsetPattern.FindStringSubmatchIndex(s); m != nil {
+		name := strings.ToUpper(s[m[2]:m[3]])
+		offset := 0
+		if m[4] >= 0 {
+			hours, _ := ParseNumber(s[m[6]:m[7]])
+			minutes := 0
+			if m[8] >= 0 {
+				minutes, _ = ParseNumber(s[m[8]:m[9]])
+			}
+			if hours > 14 || minutes > 59 {
+				return "", nil, fmt.Errorf("无效的时区 %q", strings.TrimSpace(s[m[0]:]))
+			}
+			offset = hours*3600 + minutes*60
+			if s[m[4]:m[5]] == "-" {
+				offset = -offset
+			}
+			name = strings.TrimSpace(s[m[2]:])
+		}
+		return strings.TrimSpace(s[:m[0]]), time.FixedZone(name, offset), nil
+	}
+	if m := zoneNamePattern.FindStringSubmatchIndex(s); m != nil {
+		loc, err := time.LoadLocation(s[m[2]:m[3]])
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的时区 %q，请使用 Asia/Shanghai 这样的时区名", s[m[2]:m[3]])
+		}
+		return strings.TrimSpace(s[:m[0]]), loc, nil
+	}
+	return s, p.location(), nil
+}
+
+// normalize 统一全角字符、大小写和空白
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '０' && r <= '９':
+			return r - '０' + '0'
+		case r == '：':
+			return ':'
+		case r == '　':
+			return ' '
+		}
+		return r
+	}, strings.ToLower(s))
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.ReplaceAll(s, "的", "")
+	return strings.TrimLeft(s, "在于")
+}
+
+// trimTrailingWords 去掉结尾的“完成”“之前”等词
+// “前”只能跟在具体时间后面（周五前、18点前）；“3天前”这样过去的相对时间返回 false
+func trimTrailingWords(s string) (string, bool) {
+	for _, w := range trailingWords {
+		if !strings.HasSuffix(s, w) || s == "前天" {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimSuffix(s, w))
+		if beforeWords[w] {
+			// “3月前”指三月之前，“3个月前”才是过去的时间
+			if m := pastPattern.FindStringSubmatch(rest); m != nil && (m[2] != "月" || m[1] != "") {
+				return "", false
+			}
+		}
+		s = rest
+	}
+	return s, true
+}
+
+// parseNatural 依次解析日期、时段和时刻，全部内容都要被识别
+func parseNatural(s string, now time.Time, cal *Calendar) (Result, bool) {
+	switch s {
+	case "现在", "马上", "立刻", "now":
+		return Result{Time: now, HasDate: true, HasClock: true}, true
+	}
+
+	st := &parseState{now: now, date: startOfDay(now)}
+
+	// 工作日（3个工作日内、下个工作日）或相对时长（两小时后、3天后、一个半月后）
+	if m := workdayPattern.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "" {
+			var ok bool
+			if n, ok = ParseNumber(m[1]); !ok {
+				return Result{}, false
+			}
+		}
+		st.date, st.hasDate = cal.AddWorkdays(st.date, n), true
+		s = strings.TrimSpace(s[len(m[0]):])
+	} else if m := relativePattern.FindStringSubmatch(s); m != nil {
+		done, ok := st.applyRelative(m)
+		if !ok {
+			return Result{}, false
+		}
+		s = strings.TrimSpace(s[len(m[0]):])
+		if done {
+			return Result{Time: st.date, HasDate: true, HasClock: true}, s == ""
+		}
+	} else if rest, ok := st.parseDate(s); ok {
+		s = rest
+	} else {
+		return Result{}, false
+	}
+
+	if m := periodPattern.FindStringSubmatch(s); m != nil {
+		st.period = m[1]
+		s = strings.TrimSpace(s[len(m[0]):])
+	}
+	if rest, ok := st.parseClock(s); ok {
+		s = rest
+	} else {
+		return Result{}, false
+	}
+	if s != "" {
+		return Result{}, false
+	}
+	return st.result()
+}
+
+// applyRelative 处理相对时长；精确到时分秒的时长直接得出时间（返回 done）
+func (st *parseState) applyRelative(m []string) (done bool, ok bool) {
+	n, half := 0, m[2] != ""
+	if m[1] == "半" {
+		if half {
+			return false, false
+		}
+		half = true
+	} else if n, ok = ParseNumber(m[1]); !ok {
+		return false, false
+	}
+
+	var unit time.Duration
+	switch m[3] {
+	case "秒", "秒钟":
+		unit = time.Second
+	case "分", "分钟":
+		unit = time.Minute
+	case "刻钟":
+		unit = 15 * time.Minute
+	case "小时", "钟头":
+		unit = time.Hour
+	case "天", "日":
+		if half {
+			unit = 24 * time.Hour
+			break
+		}
+		st.date, st.hasDate = st.date.AddDate(0, 0, n), true
+		return false, true
+	case "周", "星期", "礼拜":
+		days := 7 * n
+		if half {
+			days += 3
+		}
+		st.date, st.hasDate = st.date.AddDate(0, 0, days), true
+		return false, true
+	case "月":
+		st.date, st.hasDate = addMonths(st.date, n), true
+		if half {
+			st.date = st.date.AddDate(0, 0, 15)
+		}
+		return false, true
+	case "年":
+		st.date, st.hasDate = addMonths(st.date, 12*n), true
+		if half {
+			st.date = addMonths(st.date, 6)
+		}
+		return false, true
+	}
+
+	d := time.Duration(n) * unit
+	if half {
+		d += unit / 2
+	}
+	st.date = st.now.Add(d)
+	return true, true
+}
+
+// parseDate 解析日期部分（可以没有），返回剩余内容
+func (st *parseState) parseDate(s string) (string, bool) {
+	today := startOfDay(st.now)
+	consume := func(m string) string {
+		st.hasDate = true
+		return strings.TrimSpace(s[len(m):])
+	}
+
+	if m := dayWordPattern.FindStringSubmatch(s); m != nil {
+		w := dayWords[m[1]]
+		st.date, st.period = today.AddDate(0, 0, w.days), w.period
+		return consume(m[0]), true
+	}
+
+	if m := weekdayPattern.FindStringSubmatch(s); m != nil {
+		st.date = weekday(today, m[1], weekdayIndex[m[2]])
+		return consume(m[0]), true
+	}
+	if m := weekendPattern.FindStringSubmatch(s); m != nil {
+		st.date = weekday(today, m[1], 5)
+		if weekOffsets[m[1]] == 0 && mondayIndex(today) == 6 {
+			// 周日说“周末”“这周末”指今天
+			st.date = today
+		}
+		return consume(m[0]), true
+	}
+
+	if m := isoDatePattern.FindStringSubmatch(s); m != nil {
+		year, _ := ParseNumber(m[1])
+		month, _ := ParseNumber(m[2])
+		day, _ := ParseNumber(m[3])
+		date, ok := makeDate(year, month, day, today.Location())
+		if !ok {
+			return "", false
+		}
+		st.date = date
+		return consume(m[0]), true
+	}
+
+	// 农历：农历八月十五、阴历正月初一（今天或之后最近的一次）
+	if strings.HasPrefix(s, "农历") || strings.HasPrefix(s, "阴历") {
+		month, day, leap, rest, err := ParseLunarMonthDay(s)
+		if err != nil {
+			return "", false
+		}
+		date, err := NextLunarDate(month, day, leap, today)
+		if err != nil {
+			return "", false
+		}
+		st.date, st.hasDate = date, true
+		return rest, true
+	}
+
+	if m := monthDayPattern.FindStringSubmatch(s); m != nil {
+		year, explicitYear := today.Year(), true
+		switch {
+		case m[1] != "":
+			year += yearOffsets[m[1]]
+		case m[2] != "":
+			y, ok := ParseNumber(m[2])
+			if !ok {
+				return "", false
+			}
+			if y < 100 {
+				y += 2000
+			}
+			year = y
+		default:
+			explicitYear = false
+		}
+		month, ok := ParseNumber(m[3])
+		if !ok || month < 1 || month > 12 {
+			return "", false
+		}
+		edge, wholeMonth := m[5], m[4] == "" && m[5] == ""
+		if wholeMonth {
+			// 只说了月份（明年3月、十一月），指当月 1 日
+			edge = "初"
+		}
+		date, ok := monthDay(year, time.Month(month), m[4], edge, today.Location())
+		if !ok {
+			return "", false
+		}
+		if !explicitYear && wholeMonth && date.Year() == today.Year() && date.Month() == today.Month() {
+			// 只说了本月，指今天
+			date = today
+		} else if !explicitYear && date.Before(today) {
+			// 没说年份且今年的已经过了，指明年
+			if date, ok = monthDay(year+1, time.Month(month), m[4], edge, today.Location()); !ok {
+				return "", false
+			}
+		}
+		st.date = date
+		return consume(m[0]), true
+	}
+
+	if m := yearEdgePattern.FindStringSubmatch(s); m != nil {
+		year := today.Year() + yearOffsets[m[1]]
+		if m[2] == "年初" {
+			st.date = time.Date(year, time.January, 1, 0, 0, 0, 0, today.Location())
+		} else {
+			st.date = time.Date(year, time.December, 31, 0, 0, 0, 0, today.Location())
+		}
+		return consume(m[0]), true
+	}
+
+	if m := monthEdgePattern.FindStringSubmatch(s); m != nil {
+		first := addMonths(time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()), monthOffsets[m[1]])
+		edge := m[2]
+		if m[3] != "" {
+			edge = ""
+		}
+		date, ok := monthDay(first.Year(), first.Month(), m[3], edge, today.Location())
+		if !ok {
+			return "", false
+		}
+		st.date = date
+		return consume(m[0]), true
+	}
+	if m := thisMonthPattern.FindStringSubmatch(s); m != nil {
+		date, _ := monthDay(today.Year(), today.Month(), "", m[1], today.Location())
+		st.date = date
+		return consume(m[0]), true
+	}
+
+	if m := dayOfMonthPattern.FindStringSubmatch(s); m != nil {
+		day, ok := ParseNumber(m[1])
+		if !ok {
+			return "", false
+		}
+		date, ok := makeDate(today.Year(), int(today.Month()), day, today.Location())
+		if !ok || date.Before(today) {
+			// 本月的已经过了（或本月没有这一天），指下个月
+			next := addMonths(time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()), 1)
+			if date, ok = makeDate(next.Year(), int(next.Month()), day, today.Location()); !ok {
+				return "", false
+			}
+		}
+		st.date = date
+		return consume(m[0]), true
+	}
+
+	// 没有日期，只有时段或时刻
+	return s, true
+}
+
+// parseClock 解析时刻（可以没有），返回剩余内容
+func (st *parseState) parseClock(s string) (string, bool) {
+	if m := colonClockPattern.FindStringSubmatch(s); m != nil {
+		st.hour, _ = ParseNumber(m[1])
+		st.minute, _ = ParseNumber(m[2])
+		if m[3] != "" {
+			st.second, _ = ParseNumber(m[3])
+		}
+		st.hasClock = true
+		return strings.TrimSpace(s[len(m[0]):]), true
+	}
+	if m := clockPattern.FindStringSubmatch(s); m != nil {
+		var ok bool
+		if st.hour, ok = ParseNumber(m[1]); !ok {
+			return "", false
+		}
+		switch m[2] {
+		case "半":
+			st.minute = 30
+		case "一刻":
+			st.minute = 15
+		case "三刻":
+			st.minute = 45
+		}
+		if m[3] != "" {
+			if st.minute, ok = ParseNumber(m[3]); !ok {
+				return "", false
+			}
+		}
+		if m[4] != "" {
+			if st.second, ok = ParseNumber(m[4]); !ok {
+				return "", false
+			}
+		}
+		st.hasClock = true
+		return strings.TrimSpace(s[len(m[0]):]), true
+	}
+	return s, true
+}
+
+// result 根据时段调整小时并得出最终时间
+func (st *parseState) result() (Result, bool) {
+	res := Result{HasDate: st.hasDate, HasClock: st.hasClock || st.period != ""}
+	if !res.HasClock {
+		if !st.hasDate {
+			return Result{}, false
+		}
+		res.Time = st.date
+		return res, true
+	}
+
+	minutes := 0
+	if st.hasClock {
+		if st.hour > 24 || st.minute > 59 || st.second > 59 {
+			return Result{}, false
+		}
+		minutes = adjustHour(st.hour, st.period)*60 + st.minute
+	} else {
+		minutes = periodDefaults[st.period]
+	}
+
+	at := func(date time.Time, minutes int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, minutes, st.second, 0, date.Location())
+	}
+	res.Time = at(st.date, minutes)
+	if !st.hasDate && res.Time.Before(st.now) {
+		// 只说了时刻：没有时段的“三点”先看今天下午，否则指明天
+		if st.hasClock && st.period == "" && st.hour < 12 && at(st.date, minutes+12*60).After(st.now) {
+			res.Time = at(st.date, minutes+12*60)
+		} else {
+			res.Time = at(st.date.AddDate(0, 0, 1), minutes)
+		}
+	}
+	return res, true
+}
+
+// adjustHour 按时段把 12 小时制的钟点换算成 24 小时制（可能超过 24，表示次日凌晨）
+func adjustHour(hour int, period string) int {
+	switch period {
+	case "凌晨":
+		if hour == 12 {
+			return 0
+		}
+	case "中午":
+		if hour <= 3 {
+			return hour + 12
+		}
+	case "午后", "下午":
+		if hour < 12 {
+			return hour + 12
+		}
+	case "傍晚", "黄昏", "晚上", "晚间", "夜里", "夜晚", "深夜", "半夜", "午夜":
+		switch {
+		case hour <= 4:
+			return hour + 24
+		case hour <= 12:
+			return hour + 12
+		}
+	}
+	return hour
+}
+
+// weekday 相对 today 的某周的星期几（index 从周一的 0 开始）
+// 没有前缀时为今天或之后最近的一天；“本周”“这周”的这一天已经过了时指下周
+func weekday(today time.Time, prefix string, index int) time.Time {
+	current := mondayIndex(today)
+	if prefix == "" || (weekOffsets[prefix] == 0 && index < current) {
+		return today.AddDate(0, 0, (index-current+7)%7)
+	}
+	return today.AddDate(0, 0, index-current+7*weekOffsets[prefix])
+}
+
+// mondayIndex 星期几，周一为 0，周日为 6
+func mondayIndex(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// monthDay 某月的某天，或月底、月初、月中
+func monthDay(year int, month time.Month, day, edge string, loc *time.Location) (time.Time, bool) {
+	switch edge {
+	case "底", "末":
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, loc), true
+	case "初":
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc), true
+	case "中":
+		return time.Date(year, month, 15, 0, 0, 0, 0, loc), true
+	}
+	d, ok := ParseNumber(day)
+	if !ok {
+		return time.Time{}, false
+	}
+	return makeDate(year, int(month), d, loc)
+}
+
+// makeDate 构造日期，日期不存在（如 2 月 30 日）时返回 false
+func makeDate(year, month, day int, loc *time.Location) (time.Time, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	return t, t.Day() == day
+}
+
+// addMonths 加若干个月，目标月没有这一天时取月底（如 1 月 31 日加一个月为 2 月底）
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// startOfDay 当天 00:00
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
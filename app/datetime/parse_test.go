@@ -0,0 +1,226 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+var cst = time.FixedZone("CST", 8*3600)
+
+// testCalendar 2026 年国庆节放假 10 月 1 日至 7 日，10 月 10 日（周六）调休上班
+func testCalendar() *Calendar {
+	return NewCalendar([]Festival{
+		{Name: "国庆节", Start: "2026-10-01", End: "2026-10-07", Workdays: []string{"2026-10-10"}},
+	})
+}
+
+// testParser 当前时间固定为 2026-10-15（周四）10:00
+func testParser() *Parser {
+	now := time.Date(2026, 10, 15, 10, 0, 0, 0, cst)
+	return &Parser{Location: cst, Now: func() time.Time { return now }, Calendar: testCalendar()}
+}
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, cst)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     time.Time
+		hasDate  bool
+		hasClock bool
+	}{
+		// 相对时间
+		{"现在", date(2026, 10, 15, 10, 0), true, true},
+		{"3天后", date(2026, 10, 18, 0, 0), true, false},
+		{"三天之内", date(2026, 10, 18, 0, 0), true, false},
+		{"两小时后", date(2026, 10, 15, 12, 0), true, true},
+		{"半小时后", date(2026, 10, 15, 10, 30), true, true},
+		{"一刻钟后", date(2026, 10, 15, 10, 15), true, true},
+		{"三刻钟后", date(2026, 10, 15, 10, 45), true, true},
+		{"十分钟后", date(2026, 10, 15, 10, 10), true, true},
+		{"2周后", date(2026, 10, 29, 0, 0), true, false},
+		{"一个半月后", date(2026, 11, 30, 0, 0), true, false},
+		{"3天后下午2点", date(2026, 10, 18, 14, 0), true, true},
+
+		// 星期
+		{"下周一", date(2026, 10, 19, 0, 0), true, false},
+		{"本周五下午", date(2026, 10, 16, 15, 0), true, true},
+		{"这周三", date(2026, 10, 21, 0, 0), true, false}, // 本周三已经过了，指下周三
+		{"本周四", date(2026, 10, 15, 0, 0), true, false},
+		{"周三", date(2026, 10, 21, 0, 0), true, false},
+		{"星期天", date(2026, 10, 18, 0, 0), true, false},
+		{"上周五", date(2026, 10, 9, 0, 0), true, false},
+		{"下下周二", date(2026, 10, 27, 0, 0), true, false},
+		{"周末", date(2026, 10, 17, 0, 0), true, false},
+		{"下周末", date(2026, 10, 24, 0, 0), true, false},
+
+		// 日期
+		{"今天", date(2026, 10, 15, 0, 0), true, false},
+		{"大后天", date(2026, 10, 18, 0, 0), true, false},
+		{"月底", date(2026, 10, 31, 0, 0), true, false},
+		{"下个月15号", date(2026, 11, 15, 0, 0), true, false},
+		{"下月初", date(2026, 11, 1, 0, 0), true, false},
+		{"12月3号", date(2026, 12, 3, 0, 0), true, false},
+		{"3月5日", date(2027, 3, 5, 0, 0), true, false}, // 今年的已经过了
+		{"明年3月", date(2027, 3, 1, 0, 0), true, false},
+		{"十一月", date(2026, 11, 1, 0, 0), true, false},
+		{"十月", date(2026, 10, 15, 0, 0), true, false},
+		{"二〇二七年一月", date(2027, 1, 1, 0, 0), true, false},
+		{"27年5月底", date(2027, 5, 31, 0, 0), true, false},
+		{"年底", date(2026, 12, 31, 0, 0), true, false},
+		{"20号", date(2026, 10, 20, 0, 0), true, false},
+		{"3号", date(2026, 11, 3, 0, 0), true, false},
+		{"2026-12-03", date(2026, 12, 3, 0, 0), true, false},
+		{"2026/12/3 15:04", date(2026, 12, 3, 15, 4), true, true},
+
+		// 中文数字和时刻
+		{"三点半", date(2026, 10, 15, 15, 30), false, true}, // 今天凌晨已过，指下午
+		{"明天十点一刻", date(2026, 10, 16, 10, 15), true, true},
+		{"后天上午九点二十", date(2026, 10, 17, 9, 20), true, true},
+		{"明天下午三点半", date(2026, 10, 16, 15, 30), true, true},
+		{"12月3号 15:30", date(2026, 12, 3, 15, 30), true, true},
+		{"十二月三十一日晚上十一点", date(2026, 12, 31, 23, 0), true, true},
+
+		// 时段
+		{"中午", date(2026, 10, 15, 12, 0), false, true},
+		{"傍晚", date(2026, 10, 15, 18, 0), false, true},
+		{"今晚", date(2026, 10, 15, 20, 0), true, true},
+		{"明晚8点", date(2026, 10, 16, 20, 0), true, true},
+		{"凌晨2点", date(2026, 10, 16, 2, 0), false, true},
+		{"明天晚上12点", date(2026, 10, 17, 0, 0), true, true},
+
+		// 结尾的“前”“完成”等
+		{"周五前", date(2026, 10, 16, 0, 0), true, false},
+		{"18点前", date(2026, 10, 15, 18, 0), false, true},
+		{"明天中午之前完成", date(2026, 10, 16, 12, 0), true, true},
+		{"3日前", date(2026, 11, 3, 0, 0), true, false}, // 3 号之前
+		{"3月前", date(2027, 3, 1, 0, 0), true, false},  // 三月之前
+		{"前天", date(2026, 10, 13, 0, 0), true, false},
+
+		// 工作日
+		{"3个工作日内", date(2026, 10, 20, 0, 0), true, false},
+		{"下个工作日", date(2026, 10, 16, 0, 0), true, false},
+		{"两个工作日后下午5点", date(2026, 10, 19, 17, 0), true, true},
+	}
+
+	p := testParser()
+	for _, tt := range tests {
+		res, err := p.Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !res.Time.Equal(tt.want) || res.HasDate != tt.hasDate || res.HasClock != tt.hasClock {
+			t.Errorf("Parse(%q) = %s (date %v, clock %v), want %s (date %v, clock %v)",
+				tt.input, res.Time.Format(time.RFC3339), res.HasDate, res.HasClock,
+				tt.want.Format(time.RFC3339), tt.hasDate, tt.hasClock)
+		}
+	}
+}
+
+func TestParseTimezone(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"明天9点 UTC", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"2026-12-03 15:00 UTC+9", time.Date(2026, 12, 3, 6, 0, 0, 0, time.UTC)},
+		{"2026-12-03 15:00 GMT-5:30", time.Date(2026, 12, 3, 20, 30, 0, 0, time.UTC)},
+		{"北京时间下午3点", date(2026, 10, 15, 15, 0)},
+		{"2026-12-03T15:00:00Z", time.Date(2026, 12, 3, 15, 0, 0, 0, time.UTC)},
+	}
+
+	// 解析时区为 UTC 时，北京时间仍按东八区解释
+	now := time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)
+	p := &Parser{Location: time.UTC, Now: func() time.Time { return now }, Calendar: testCalendar()}
+	for _, tt := range tests {
+		res, err := p.Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !res.Time.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, res.Time.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseWorkdaysOverHoliday(t *testing.T) {
+	// 2026-09-30（周三），国庆放假到 10 月 7 日，10 月 10 日调休上班
+	now := time.Date(2026, 9, 30, 10, 0, 0, 0, cst)
+	p := &Parser{Location: cst, Now: func() time.Time { return now }, Calendar: testCalendar()}
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"下个工作日", date(2026, 10, 8, 0, 0)},
+		{"3个工作日内", date(2026, 10, 10, 0, 0)},
+		{"四个工作日后", date(2026, 10, 12, 0, 0)},
+	}
+	for _, tt := range tests {
+		res, err := p.Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !res.Time.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, res.Time.Format(DateLayout), tt.want.Format(DateLayout))
+		}
+	}
+
+	// 自定义休息日
+	p.Calendar = testCalendar().WithClosedDays(map[string]string{"2026-10-08": "团建"})
+	if res, err := p.Parse("下个工作日"); err != nil || !res.Time.Equal(date(2026, 10, 9, 0, 0)) {
+		t.Errorf("Parse(下个工作日) with closed day = %s, %v, want 2026-10-09", res.Time.Format(DateLayout), err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	p := testParser()
+	for _, input := range []string{
+		"",
+		"随便什么时候",
+		"3天前",
+		"两小时之前",
+		"3个月前",
+		"一周以前完成",
+		"2月30号",
+		"13月",
+		"25点",
+		"明天9点 UTC+15",
+		"明天9点 Mars/Olympus",
+	} {
+		if res, err := p.Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", input, res.Time.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"12", 12},
+		{"十", 10},
+		{"十二", 12},
+		{"三十一", 31},
+		{"两", 2},
+		{"一百零五", 105},
+		{"二〇二六", 2026},
+		{"零五", 5},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseNumber(tt.input); !ok || got != tt.want {
+			t.Errorf("ParseNumber(%q) = %d, %v, want %d", tt.input, got, ok, tt.want)
+		}
+	}
+	for _, input := range []string{"", "十十", "abc", "三x"} {
+		if got, ok := ParseNumber(input); ok {
+			t.Errorf("ParseNumber(%q) = %d, want failure", input, got)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type DeepSeekProvider struct {
 	apiKey    string
 	modelName string
 	baseURL   string
+	chat      ChatContext // 当前请求的会话上下文
 }
 
 // NewDeepSeekProvider 创建 DeepSeek 提供者
@@ -48,6 +49,12 @@ func (p *DeepSeekProvider) Chat(messages []Message) (string, error) {
 	return p.ChatWithUserID(messages, userID)
 }
 
+// ChatWithContext 发送聊天请求（带会话上下文，工具调用按会话所在的工作区执行）
+func (p *DeepSeekProvider) ChatWithContext(messages []Message, chat ChatContext) (string, error) {
+	p.chat = chat
+	return p.ChatWithUserID(messages, chat.ChatID)
+}
+
 // ChatWithUserID 发送聊天请求（支持 Agent 功能，带用户ID）
 func (p *DeepSeekProvider) ChatWithUserID(messages []Message, userID string) (string, error) {
 	// 从sessionId中提取用户ID（格式：NickName-UserName，使用UserName部分）
@@ -188,13 +195,8 @@ func (p *DeepSeekProvider) ChatWithUserID(messages []Message, userID string) (st
 				
 				log.Printf("Tool arguments parsed: %v\n", args)
 
-				// 如果是create_task命令，自动注入creator_id
-				if toolCall.Function.Name == "create_task" {
-					if _, exists := args["creator_id"]; !exists || args["creator_id"] == "" {
-						args["creator_id"] = userID
-						log.Printf("Auto-injected creator_id: %s\n", userID)
-					}
-				}
+				// 注入调用方上下文（creator_id、chat_id）
+				p.injectCallerArgs(toolCall.Function.Name, args, userID)
 
 				// 执行命令
 				log.Printf("Executing tool: %s\n", toolCall.Function.Name)
@@ -473,6 +475,22 @@ func (p *DeepSeekProvider) extractUserIDFromMessages(messages []Message) string
 	return ""
 }
 
+// injectCallerArgs 向工具参数注入调用方上下文
+// create_task 缺少 creator_id 时使用当前用户ID；会话相关参数总是覆盖，不信任模型给出的值
+func (p *DeepSeekProvider) injectCallerArgs(toolName string, args map[string]interface{}, userID string) {
+	if toolName == "create_task" {
+		if _, exists := args["creator_id"]; !exists || args["creator_id"] == "" {
+			args["creator_id"] = userID
+			log.Printf("Auto-injected creator_id: %s\n", userID)
+		}
+	}
+	for key, value := range p.chat.CallerArgs() {
+		args[key] = value
+	}
+	// 当前用户ID即私聊好友或群的UserName，可直接作为发送目标
+	args["chat_id"] = userID
+}
+
 // findMarker 查找标记，支持带空格的变体（如 <|tool_calls_begin|> 或 <|tool_calls_begin | >）
 func findMarker(content, baseMarker string) int {
 	// 先尝试精确匹配
@@ -718,13 +736,11 @@ func (p *DeepSeekProvider) executeRedactedToolCalls(messages []Message, toolCall
 
 		log.Printf("Executing redacted tool call %d/%d: %s\n", i+1, len(toolCalls), toolName)
 
-		// 如果是create_task命令，自动注入creator_id
-		if toolName == "create_task" {
-			if _, exists := args["creator_id"]; !exists || args["creator_id"] == "" {
-				args["creator_id"] = userID
-				log.Printf("Auto-injected creator_id: %s\n", userID)
-			}
+		// 注入调用方上下文（creator_id、chat_id）
+		if args == nil {
+			args = make(map[string]interface{})
 		}
+		p.injectCallerArgs(toolName, args, userID)
 
 		// 执行命令
 		result, err := executor.ExecuteCommand(toolName, args)
@@ -6,10 +6,32 @@ type Message struct {
 	Content string `json:"content"`
 }
 
+// ChatContext 当前消息所在的会话
+type ChatContext struct {
+	ChatID     string // 群或好友的UserName
+	ChatName   string // 群名或好友昵称
+	IsGroup    bool
+	SenderID   string // 发送者UserName（群聊中为发言的群成员）
+	SenderName string // 发送者昵称
+}
+
+// CallerArgs 会话上下文对应的工具参数（工具据此确定工作区和操作人）
+func (c ChatContext) CallerArgs() map[string]interface{} {
+	return map[string]interface{}{
+		"chat_id":     c.ChatID,
+		"chat_name":   c.ChatName,
+		"is_group":    c.IsGroup,
+		"caller_id":   c.SenderID,
+		"caller_name": c.SenderName,
+	}
+}
+
 // Provider AI 提供者接口
 type Provider interface {
 	// Chat 发送聊天请求
 	Chat(messages []Message) (string, error)
+	// ChatWithContext 发送聊天请求，并将会话上下文提供给工具调用
+	ChatWithContext(messages []Message, chat ChatContext) (string, error)
 	// GetModelName 获取模型名称
 	GetModelName() string
 	// GetBaseURL 获取 API 端点
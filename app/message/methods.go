@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/llm"
 	"github.com/869413421/wechatbot/app/session"
 	"github.com/eatmoreapple/openwechat"
 )
@@ -117,7 +118,12 @@ func (g *UserMessageHandler) ReplyText(msg *openwechat.Message) error {
 		reply = config.HelpText
 	} else {
 		// 移除角色修改功能，直接处理消息
-		reply, err = session.Completions(g.getSessionId(sender), requestText, "")
+		reply, err = session.CompletionsWithContext(g.getSessionId(sender), requestText, llm.ChatContext{
+			ChatID:     sender.UserName,
+			ChatName:   sender.NickName,
+			SenderID:   sender.UserName,
+			SenderName: sender.NickName,
+		})
 	}
 	if err != nil {
 		log.Printf("gtp request error: %v \n", err)
@@ -190,11 +196,24 @@ func (g *GroupMessageHandler) ReplyText(msg *openwechat.Message) error {
 	requestText := strings.TrimSpace(strings.ReplaceAll(msg.Content, replaceText, ""))
 	var reply string
 
+	// 获取@我的用户
+	groupSender, err := msg.SenderInGroup()
+	if err != nil {
+		log.Printf("get sender in group error :%v \n", err)
+		return err
+	}
+
 	if requestText == "help" {
 		reply = config.HelpText
 	} else {
 		// 移除角色修改功能，直接处理消息
-		reply, err = session.Completions(g.getSessionId(group), requestText, "")
+		reply, err = session.CompletionsWithContext(g.getSessionId(group), requestText, llm.ChatContext{
+			ChatID:     group.UserName,
+			ChatName:   group.NickName,
+			IsGroup:    true,
+			SenderID:   groupSender.UserName,
+			SenderName: groupSender.NickName,
+		})
 	}
 	if err != nil {
 		log.Printf("gtp request error: %v \n", err)
@@ -206,13 +225,6 @@ func (g *GroupMessageHandler) ReplyText(msg *openwechat.Message) error {
 		reply = "抱歉，我暂时无法处理这个请求，请稍后再试。"
 	}
 
-	// 获取@我的用户
-	groupSender, err := msg.SenderInGroup()
-	if err != nil {
-		log.Printf("get sender in group error :%v \n", err)
-		return err
-	}
-
 	// 去除markdown语法并回复@我的用户
 	reply = removeMarkdown(reply)
 	reply = strings.TrimSpace(reply)
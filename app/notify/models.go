@@ -0,0 +1,54 @@
+package notify
+
+import "time"
+
+// Preference 用户的通知偏好（按微信昵称识别，UserName 重新登录后会变化）
+type Preference struct {
+	UserName   string    `gorm:"primaryKey;type:varchar(100)" json:"user_name"`
+	QuietStart string    `gorm:"type:varchar(5);not null;default:''" json:"quiet_start"` // 免打扰开始时间（HH:MM），为空表示使用默认
+	QuietEnd   string    `gorm:"type:varchar(5);not null;default:''" json:"quiet_end"`   // 免打扰结束时间（HH:MM），可以跨过午夜
+	QuietOff   bool      `gorm:"not null;default:false" json:"quiet_off"`                // 关闭免打扰（包括默认的免打扰时段）
+	DigestTime string    `gorm:"type:varchar(5);not null;default:''" json:"digest_time"` // 每日摘要的发送时间（HH:MM），为空表示使用默认
+	DigestOff  bool      `gorm:"not null;default:false" json:"digest_off"`               // 不接收每日摘要
+	Timezone   string    `gorm:"type:varchar(64);not null;default:''" json:"timezone"`   // 时区（如 Asia/Shanghai），为空表示使用默认
+	Channel    string    `gorm:"type:varchar(20);not null;default:''" json:"channel"`    // 群工作区的通知渠道：group（在群里 @）或 private（私聊），为空表示 group
+	UpdateTime time.Time `gorm:"type:datetime;not null" json:"update_time"`
+}
+
+// TableName 指定表名
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
+
+// WorkspaceMute 用户在某个工作区静音（不再收到该工作区发给他的通知）
+type WorkspaceMute struct {
+	UserName    string    `gorm:"primaryKey;type:varchar(100)" json:"user_name"`
+	WorkspaceID uint      `gorm:"primaryKey" json:"workspace_id"`
+	CreateTime  time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (WorkspaceMute) TableName() string {
+	return "notification_mutes"
+}
+
+// DeferredNotification 因免打扰延后发送的通知
+type DeferredNotification struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SendAt      time.Time  `gorm:"type:datetime;not null;index" json:"send_at"` // 允许发送的时间
+	WorkspaceID uint       `gorm:"not null;default:0" json:"workspace_id"`
+	Target      string     `gorm:"type:varchar(100);not null;default:''" json:"target"`      // 发送时的 UserName
+	TargetName  string     `gorm:"type:varchar(255);not null;default:''" json:"target_name"` // 群名或好友昵称（UserName 失效时按名称查找）
+	IsGroup     bool       `gorm:"not null;default:false" json:"is_group"`
+	Text        string     `gorm:"type:text" json:"text"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, sent, failed
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	SentTime    *time.Time `gorm:"type:datetime;null" json:"sent_time"`
+	CreateTime  time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (DeferredNotification) TableName() string {
+	return "notification_deferred"
+}
@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/eatmoreapple/openwechat"
+)
+
+var (
+	bot   *openwechat.Bot
+	botMu sync.RWMutex
+)
+
+// SetBot 设置用于主动发送消息的已登录机器人
+func SetBot(b *openwechat.Bot) {
+	botMu.Lock()
+	defer botMu.Unlock()
+	bot = b
+}
+
+// SendText 向好友或群（按UserName）发送文本消息
+func SendText(userName, text string) error {
+	user, err := findContact(userName)
+	if err != nil {
+		return err
+	}
+	if group, ok := user.AsGroup(); ok {
+		_, err = group.SendText(text)
+		return err
+	}
+	if friend, ok := user.AsFriend(); ok {
+		_, err = friend.SendText(text)
+		return err
+	}
+	return fmt.Errorf("contact %s is neither a friend nor a group", userName)
+}
+
+// SendImage 向好友或群（按UserName）发送图片消息
+func SendImage(userName string, image io.Reader) error {
+	user, err := findContact(userName)
+	if err != nil {
+		return err
+	}
+	if group, ok := user.AsGroup(); ok {
+		_, err = group.SendImage(image)
+		return err
+	}
+	if friend, ok := user.AsFriend(); ok {
+		_, err = friend.SendImage(image)
+		return err
+	}
+	return fmt.Errorf("contact %s is neither a friend nor a group", userName)
+}
+
+// sendTextTo 发送文本消息：优先按 UserName 查找，找不到时（重新登录后 UserName 会变化）按群名或昵称查找
+func sendTextTo(userName, nickName string, isGroup bool, text string) error {
+	if userName != "" {
+		err := SendText(userName, text)
+		if err == nil || nickName == "" {
+			return err
+		}
+		log.Printf("Failed to send to %s, looking up %s by name: %v\n", userName, nickName, err)
+	}
+
+	user, err := findContactByName(nickName, isGroup)
+	if err != nil {
+		return err
+	}
+	if group, ok := user.AsGroup(); ok {
+		_, err = group.SendText(text)
+		return err
+	}
+	if friend, ok := user.AsFriend(); ok {
+		_, err = friend.SendText(text)
+		return err
+	}
+	return fmt.Errorf("contact %s is neither a friend nor a group", nickName)
+}
+
+// findContactByName 按群名或好友昵称查找联系人
+func findContactByName(nickName string, isGroup bool) (*openwechat.User, error) {
+	if nickName == "" {
+		return nil, fmt.Errorf("contact name is required")
+	}
+	botMu.RLock()
+	b := bot
+	botMu.RUnlock()
+	if b == nil || !b.Alive() {
+		return nil, fmt.Errorf("bot is not logged in")
+	}
+
+	self, err := b.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	members, err := self.Members()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range members.SearchByNickName(0, nickName) {
+		if user.IsGroup() == isGroup && (isGroup || user.IsFriend()) {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("contact %s not found", nickName)
+}
+
+// findContact 在当前登录用户的联系人中查找好友或群
+func findContact(userName string) (*openwechat.User, error) {
+	botMu.RLock()
+	b := bot
+	botMu.RUnlock()
+
+	if b == nil || !b.Alive() {
+		return nil, fmt.Errorf("bot is not logged in")
+	}
+	if userName == "" {
+		return nil, fmt.Errorf("contact user name is required")
+	}
+
+	self, err := b.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	members, err := self.Members()
+	if err != nil {
+		return nil, err
+	}
+	user, ok := members.GetByUserName(userName)
+	if !ok {
+		// 联系人列表可能过期，刷新后再找一次
+		log.Printf("Contact %s not found, refreshing members\n", userName)
+		if members, err = self.Members(true); err != nil {
+			return nil, err
+		}
+		if user, ok = members.GetByUserName(userName); !ok {
+			return nil, fmt.Errorf("contact %s not found", userName)
+		}
+	}
+	return user, nil
+}
+
+// currentMembers 当前登录用户的联系人（好友和群），refresh 为 true 时重新获取
+func currentMembers(refresh bool) (openwechat.Members, error) {
+	botMu.RLock()
+	b := bot
+	botMu.RUnlock()
+	if b == nil || !b.Alive() {
+		return nil, fmt.Errorf("bot is not logged in")
+	}
+	self, err := b.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	return self.Members(refresh)
+}
+
+// CanRebind 判断按名称找到的旧会话能否绑定到新的 UserName：旧的 UserName 已经失效（重新登录后会变化），
+// 并且当前只有一个这个名称的群（或好友）。旧会话仍然有效或有重名时说明是另一个会话，返回 false
+func CanRebind(oldUserName, nickName string, isGroup bool) (bool, error) {
+	members, err := currentMembers(true)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := members.GetByUserName(oldUserName); ok {
+		return false, nil
+	}
+	n := 0
+	for _, user := range members.SearchByNickName(0, nickName) {
+		if user.IsGroup() == isGroup && (isGroup || user.IsFriend()) {
+			n++
+		}
+	}
+	return n == 1, nil
+}
+
+// IsGroupMember 判断用户是否在群里（都按 UserName 查找）
+func IsGroupMember(groupUserName, userName string) (bool, error) {
+	if userName == "" {
+		return false, fmt.Errorf("user name is required")
+	}
+	user, err := findContact(groupUserName)
+	if err != nil {
+		return false, err
+	}
+	group, ok := user.AsGroup()
+	if !ok {
+		return false, fmt.Errorf("contact %s is not a group", groupUserName)
+	}
+	members, err := group.Members()
+	if err != nil {
+		return false, err
+	}
+	_, ok = members.GetByUserName(userName)
+	return ok, nil
+}
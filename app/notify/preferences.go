@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	_ "time/tzdata" // 容器中可能没有时区数据
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/869413421/wechatbot/app/config"
+)
+
+// 通知渠道
+const (
+	ChannelGroup   = "group"
+	ChannelPrivate = "private"
+)
+
+// DefaultDigestTime 未设置时每日摘要的发送时间
+const DefaultDigestTime = "08:30"
+
+var store *gorm.DB
+
+// Init 初始化通知服务的存储（偏好、静音和延后发送的通知）
+func Init(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Preference{}, &WorkspaceMute{}, &DeferredNotification{}); err != nil {
+		return fmt.Errorf("failed to migrate notification tables: %v", err)
+	}
+	log.Printf("Notification tables migrated\n")
+	store = db
+	return nil
+}
+
+// parseClock 解析 HH:MM，返回从零点开始的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ParseQuietHours 解析免打扰时段，如 "22:00-08:00"
+func ParseQuietHours(s string) (start, end string, err error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '~' || r == '～' || r == '到' || r == '至' })
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid quiet hours %q, use HH:MM-HH:MM", s)
+	}
+	startMin, err := parseClock(parts[0])
+	if err != nil {
+		return "", "", err
+	}
+	endMin, err := parseClock(parts[1])
+	if err != nil {
+		return "", "", err
+	}
+	if startMin == endMin {
+		return "", "", fmt.Errorf("quiet hours must not start and end at the same time")
+	}
+	return formatClock(startMin), formatClock(endMin), nil
+}
+
+// ParseClock 解析并规范化 HH:MM
+func ParseClock(s string) (string, error) {
+	minutes, err := parseClock(s)
+	if err != nil {
+		return "", err
+	}
+	return formatClock(minutes), nil
+}
+
+// formatClock 将分钟数格式化为 HH:MM
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// GetPreference 获取用户的通知偏好，未设置时返回空偏好（使用默认值）
+func GetPreference(userName string) Preference {
+	pref := Preference{UserName: userName}
+	if store == nil || userName == "" {
+		return pref
+	}
+	if err := store.First(&pref, "user_name = ?", userName).Error; err != nil && err != gorm.ErrRecordNotFound {
+		log.Printf("ERROR: Failed to get notification preference of %s: %v\n", userName, err)
+	}
+	return pref
+}
+
+// SavePreference 保存用户的通知偏好
+func SavePreference(pref Preference) error {
+	if store == nil {
+		return fmt.Errorf("notification store is not initialized")
+	}
+	if pref.UserName == "" {
+		return fmt.Errorf("user name is required")
+	}
+	pref.UpdateTime = time.Now()
+	if err := store.Save(&pref).Error; err != nil {
+		return fmt.Errorf("failed to save notification preference: %v", err)
+	}
+	wakeDispatcher()
+	return nil
+}
+
+// ListPreferences 列出所有设置过通知偏好的用户
+func ListPreferences() []Preference {
+	var prefs []Preference
+	if store == nil {
+		return prefs
+	}
+	if err := store.Order("user_name ASC").Find(&prefs).Error; err != nil {
+		log.Printf("ERROR: Failed to list notification preferences: %v\n", err)
+	}
+	return prefs
+}
+
+// SetMuted 设置用户在工作区是否静音
+func SetMuted(userName string, workspaceID uint, muted bool) error {
+	if store == nil {
+		return fmt.Errorf("notification store is not initialized")
+	}
+	if muted {
+		mute := WorkspaceMute{UserName: userName, WorkspaceID: workspaceID, CreateTime: time.Now()}
+		if err := store.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+			return fmt.Errorf("failed to mute workspace: %v", err)
+		}
+		return nil
+	}
+	if err := store.Where("user_name = ? AND workspace_id = ?", userName, workspaceID).Delete(&WorkspaceMute{}).Error; err != nil {
+		return fmt.Errorf("failed to unmute workspace: %v", err)
+	}
+	return nil
+}
+
+// IsMuted 用户是否在工作区静音
+func IsMuted(userName string, workspaceID uint) bool {
+	if store == nil || userName == "" || workspaceID == 0 {
+		return false
+	}
+	var count int64
+	if err := store.Model(&WorkspaceMute{}).Where("user_name = ? AND workspace_id = ?", userName, workspaceID).Count(&count).Error; err != nil {
+		log.Printf("ERROR: Failed to check mute of %s: %v\n", userName, err)
+		return false
+	}
+	return count > 0
+}
+
+// MutedWorkspaces 用户静音的工作区ID
+func MutedWorkspaces(userName string) []uint {
+	var ids []uint
+	if store == nil {
+		return ids
+	}
+	if err := store.Model(&WorkspaceMute{}).Where("user_name = ?", userName).Order("workspace_id ASC").Pluck("workspace_id", &ids).Error; err != nil {
+		log.Printf("ERROR: Failed to list mutes of %s: %v\n", userName, err)
+	}
+	return ids
+}
+
+// Location 用户的时区，未设置或无效时使用默认时区
+func (p Preference) Location() *time.Location {
+	name := p.Timezone
+	if name == "" {
+		name = config.LoadConfig().Notify.Timezone
+	}
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("WARNING: Invalid timezone %q: %v\n", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// QuietHours 生效的免打扰时段（HH:MM），没有时返回 false
+func (p Preference) QuietHours() (start, end string, ok bool) {
+	if p.QuietOff {
+		return "", "", false
+	}
+	if p.QuietStart != "" && p.QuietEnd != "" {
+		return p.QuietStart, p.QuietEnd, true
+	}
+	if raw := config.LoadConfig().Notify.QuietHours; raw != "" {
+		start, end, err := ParseQuietHours(raw)
+		if err == nil {
+			return start, end, true
+		}
+		log.Printf("WARNING: Invalid notify.quiet_hours %q: %v\n", raw, err)
+	}
+	return "", "", false
+}
+
+// QuietUntil 如果 now 处于免打扰时段，返回时段结束的时间
+func (p Preference) QuietUntil(now time.Time) (time.Time, bool) {
+	start, end, ok := p.QuietHours()
+	if !ok {
+		return time.Time{}, false
+	}
+	startMin, _ := parseClock(start)
+	endMin, _ := parseClock(end)
+
+	local := now.In(p.Location())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	current := local.Hour()*60 + local.Minute()
+
+	if startMin < endMin {
+		// 同一天内，如 13:00-14:00
+		if current >= startMin && current < endMin {
+			return day.Add(time.Duration(endMin) * time.Minute), true
+		}
+		return time.Time{}, false
+	}
+	// 跨过午夜，如 22:00-08:00
+	if current >= startMin {
+		return day.AddDate(0, 0, 1).Add(time.Duration(endMin) * time.Minute), true
+	}
+	if current < endMin {
+		return day.Add(time.Duration(endMin) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// EffectiveDigestTime 生效的每日摘要发送时间（HH:MM）
+func (p Preference) EffectiveDigestTime() string {
+	if p.DigestTime != "" {
+		return p.DigestTime
+	}
+	if raw := config.LoadConfig().Notify.DigestTime; raw != "" {
+		if clock, err := ParseClock(raw); err == nil {
+			return clock
+		}
+	}
+	return DefaultDigestTime
+}
+
+// EffectiveChannel 生效的通知渠道
+func (p Preference) EffectiveChannel() string {
+	if p.Channel == ChannelPrivate {
+		return ChannelPrivate
+	}
+	return ChannelGroup
+}
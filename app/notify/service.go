@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 延后发送的通知状态
+const (
+	DeferredPending = "pending"
+	DeferredSent    = "sent"
+	DeferredFailed  = "failed"
+)
+
+const (
+	// maxDeferredAttempts 延后的通知最多尝试发送的次数
+	maxDeferredAttempts = 5
+	// deferredRetryBase 延后的通知发送失败后第一次重试的间隔，之后每次翻倍
+	deferredRetryBase = 5 * time.Minute
+	// maxDispatcherSleep 分发器最长的休眠时间
+	maxDispatcherSleep = time.Hour
+)
+
+// User 通知对象
+type User struct {
+	ID   string // UserName（可能为空或已失效）
+	Name string // 微信昵称，用于查找偏好和私聊发送
+}
+
+// Notification 一条待发送的通知，所有主动发出的消息都通过 Notify 发送
+type Notification struct {
+	WorkspaceID uint   // 来源工作区，用于按工作区静音，0 表示没有
+	Target      string // 默认发送目标：群或好友的 UserName
+	TargetName  string // 群名或好友昵称（UserName 失效时按名称查找）
+	IsGroup     bool
+	Users       []User // 通知对象：私聊时为好友本人，群聊时为负责人等（在群消息中 @ 他们）
+	Text        string
+	// IgnoreQuietHours 不延后到免打扰时段结束（用户自己设定了准确时间的提醒），静音和渠道设置仍然生效
+	IgnoreQuietHours bool
+}
+
+// preference 通知对象的偏好，IgnoreQuietHours 时不使用免打扰时段
+func (n Notification) preference(userName string) Preference {
+	pref := GetPreference(userName)
+	if n.IgnoreQuietHours {
+		pref.QuietOff = true
+	}
+	return pref
+}
+
+// Sender 发送通知的函数类型（便于替换）
+type Sender func(n Notification) error
+
+var (
+	dispatcherWake = make(chan struct{}, 1)
+	dispatcherOnce sync.Once
+)
+
+// sendMessage 实际发送文本消息（可在测试中替换）
+var sendMessage = sendTextTo
+
+// Notify 按接收人的偏好发送通知：
+// 跳过在该工作区静音的用户；群通知中选择私聊渠道的用户改为私聊；
+// 处于免打扰时段的部分延后到时段结束后发送；群通知没有指定用户时使用默认的免打扰时段
+// 只有默认发送目标（群或好友）发送失败时返回错误，此时其余部分都还没有发出，调用方可以整条重试；
+// 改为私聊的用户发送失败时转入延后队列单独重试，不影响已经发出的群消息
+func Notify(n Notification) error {
+	now := time.Now()
+
+	if !n.IsGroup {
+		user := User{ID: n.Target, Name: n.TargetName}
+		if len(n.Users) > 0 {
+			user = n.Users[0]
+		}
+		if IsMuted(user.Name, n.WorkspaceID) {
+			log.Printf("Notification to %s skipped: workspace %d is muted\n", user.Name, n.WorkspaceID)
+			return nil
+		}
+		return deliver(n.WorkspaceID, n.Target, n.TargetName, false, n.Text, n.preference(user.Name), now)
+	}
+
+	if len(n.Users) == 0 {
+		return deliver(n.WorkspaceID, n.Target, n.TargetName, true, n.Text, Preference{QuietOff: n.IgnoreQuietHours}, now)
+	}
+
+	// 群通知：按用户的渠道和免打扰时段分组
+	groupNow := make([]string, 0)
+	groupLater := make(map[time.Time][]string)
+	private := make([]Preference, 0)
+	for _, u := range n.Users {
+		if IsMuted(u.Name, n.WorkspaceID) {
+			continue
+		}
+		pref := n.preference(u.Name)
+		if pref.EffectiveChannel() == ChannelPrivate {
+			private = append(private, pref)
+			continue
+		}
+		if until, quiet := pref.QuietUntil(now); quiet {
+			groupLater[until] = append(groupLater[until], u.Name)
+		} else {
+			groupNow = append(groupNow, u.Name)
+		}
+	}
+
+	// 先发群消息，失败时直接返回，整条通知留给调用方重试
+	if len(groupNow) > 0 {
+		if err := sendMessage(n.Target, n.TargetName, true, withMentions(n.Text, groupNow)); err != nil {
+			return err
+		}
+	}
+	for until, names := range groupLater {
+		if err := deferNotification(n.WorkspaceID, n.Target, n.TargetName, true, withMentions(n.Text, names), until, now); err != nil {
+			log.Printf("ERROR: Failed to defer group notification to %s: %v\n", n.TargetName, err)
+		}
+	}
+	for _, pref := range private {
+		if err := deliver(n.WorkspaceID, "", pref.UserName, false, n.Text, pref, now); err != nil {
+			log.Printf("Failed to send notification to %s privately, retrying later: %v\n", pref.UserName, err)
+			retryLater(n.WorkspaceID, "", pref.UserName, false, n.Text, err, now)
+		}
+	}
+	return nil
+}
+
+// deliver 立即发送，处于免打扰时段时延后
+func deliver(workspaceID uint, target, targetName string, isGroup bool, text string, pref Preference, now time.Time) error {
+	if until, quiet := pref.QuietUntil(now); quiet {
+		return deferNotification(workspaceID, target, targetName, isGroup, text, until, now)
+	}
+	return sendMessage(target, targetName, isGroup, text)
+}
+
+// withMentions 在群消息末尾 @ 通知对象
+func withMentions(text string, names []string) string {
+	sort.Strings(names)
+	mentions := make([]string, len(names))
+	for i, name := range names {
+		mentions[i] = "@" + name
+	}
+	return text + "\n" + strings.Join(mentions, " ")
+}
+
+// deferNotification 保存延后发送的通知
+func deferNotification(workspaceID uint, target, targetName string, isGroup bool, text string, sendAt, now time.Time) error {
+	if store == nil {
+		return fmt.Errorf("notification store is not initialized")
+	}
+	d := DeferredNotification{
+		SendAt:      sendAt,
+		WorkspaceID: workspaceID,
+		Target:      target,
+		TargetName:  targetName,
+		IsGroup:     isGroup,
+		Text:        text,
+		Status:      DeferredPending,
+		CreateTime:  now,
+	}
+	if err := store.Create(&d).Error; err != nil {
+		return fmt.Errorf("failed to defer notification: %v", err)
+	}
+	log.Printf("Deferred notification %d to %s until %s (quiet hours)\n", d.ID, targetName, sendAt.Format("2006-01-02 15:04"))
+	wakeDispatcher()
+	return nil
+}
+
+// retryLater 将发送失败的通知转入延后队列，按延后通知的退避间隔重试
+func retryLater(workspaceID uint, target, targetName string, isGroup bool, text string, sendErr error, now time.Time) {
+	if store == nil {
+		log.Printf("ERROR: Notification to %s dropped: notification store is not initialized\n", targetName)
+		return
+	}
+	d := DeferredNotification{
+		SendAt:      now.Add(deferredRetryBase),
+		WorkspaceID: workspaceID,
+		Target:      target,
+		TargetName:  targetName,
+		IsGroup:     isGroup,
+		Text:        text,
+		Status:      DeferredFailed,
+		Attempts:    1,
+		LastError:   sendErr.Error(),
+		CreateTime:  now,
+	}
+	if err := store.Create(&d).Error; err != nil {
+		log.Printf("ERROR: Failed to queue notification to %s for retry: %v\n", targetName, err)
+		return
+	}
+	wakeDispatcher()
+}
+
+// StartDispatcher 启动延后通知的分发器（只启动一次）
+func StartDispatcher() {
+	dispatcherOnce.Do(func() {
+		go runDispatcher()
+	})
+}
+
+// wakeDispatcher 有新的延后通知时唤醒分发器
+func wakeDispatcher() {
+	select {
+	case dispatcherWake <- struct{}{}:
+	default:
+	}
+}
+
+// runDispatcher 休眠到下一条延后通知的发送时间，发送所有已到时间的通知
+func runDispatcher() {
+	for {
+		now := time.Now()
+		dispatchDeferred(now)
+
+		wait := maxDispatcherSleep
+		var next []time.Time
+		if err := store.Model(&DeferredNotification{}).
+			Where("status IN ? AND attempts < ? AND send_at > ?", []string{DeferredPending, DeferredFailed}, maxDeferredAttempts, now).
+			Order("send_at ASC").
+			Limit(1).
+			Pluck("send_at", &next).Error; err != nil {
+			log.Printf("ERROR: Failed to load next deferred notification: %v\n", err)
+		} else if len(next) > 0 && next[0].Sub(now) < wait {
+			wait = next[0].Sub(now)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-dispatcherWake:
+		}
+	}
+}
+
+// dispatchDeferred 发送已到时间的延后通知，失败时按指数退避重试
+func dispatchDeferred(now time.Time) {
+	var due []DeferredNotification
+	if err := store.Where("status IN ? AND attempts < ? AND send_at <= ?", []string{DeferredPending, DeferredFailed}, maxDeferredAttempts, now).
+		Order("send_at ASC").
+		Find(&due).Error; err != nil {
+		log.Printf("ERROR: Failed to load deferred notifications: %v\n", err)
+		return
+	}
+
+	for _, d := range due {
+		updates := map[string]interface{}{"attempts": d.Attempts + 1}
+		if err := sendMessage(d.Target, d.TargetName, d.IsGroup, d.Text); err != nil {
+			log.Printf("Failed to send deferred notification %d: %v\n", d.ID, err)
+			updates["status"] = DeferredFailed
+			updates["last_error"] = err.Error()
+			updates["send_at"] = now.Add(deferredRetryBase << uint(d.Attempts))
+		} else {
+			updates["status"] = DeferredSent
+			updates["sent_time"] = &now
+			updates["last_error"] = ""
+		}
+		if err := store.Model(&DeferredNotification{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+			log.Printf("ERROR: Failed to update deferred notification %d: %v\n", d.ID, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDBSeq 每个测试使用独立的内存数据库
+var testDBSeq int64
+
+func TestMain(m *testing.M) {
+	// 配置文件在仓库根目录
+	if err := os.Chdir("../.."); err != nil {
+		fmt.Fprintf(os.Stderr, "chdir: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// sentMessage 测试中记录的一条发出的消息
+type sentMessage struct {
+	target  string
+	isGroup bool
+	text    string
+}
+
+// newTestStore 使用内存 SQLite 数据库初始化通知存储，并用 fail 决定每条消息是否发送失败
+func newTestStore(t *testing.T, fail func(m sentMessage) bool) *[]sentMessage {
+	t.Helper()
+	dsn := fmt.Sprintf("file:notify_test_%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	previousStore, previousSend := store, sendMessage
+	t.Cleanup(func() { store, sendMessage = previousStore, previousSend })
+	if err := Init(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []sentMessage
+	sendMessage = func(userName, nickName string, isGroup bool, text string) error {
+		m := sentMessage{target: nickName, isGroup: isGroup, text: text}
+		if fail != nil && fail(m) {
+			return fmt.Errorf("send to %s failed", nickName)
+		}
+		sent = append(sent, m)
+		return nil
+	}
+	return &sent
+}
+
+// mustPreference 保存测试用的通知偏好（关闭免打扰，避免依赖配置文件中的默认时段）
+func mustPreference(t *testing.T, name, channel string) {
+	t.Helper()
+	if err := SavePreference(Preference{UserName: name, Channel: channel, QuietOff: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotifyRetriesFailedPrivateRecipientOnly(t *testing.T) {
+	sent := newTestStore(t, func(m sentMessage) bool { return m.target == "Bob" })
+	mustPreference(t, "Alice", ChannelGroup)
+	mustPreference(t, "Bob", ChannelPrivate)
+
+	err := Notify(Notification{
+		WorkspaceID: 1,
+		Target:      "@@ops",
+		TargetName:  "运维群",
+		IsGroup:     true,
+		Users:       []User{{Name: "Alice"}, {Name: "Bob"}},
+		Text:        "任务即将到期",
+	})
+	if err != nil {
+		t.Fatalf("Notify returned %v, want nil when only a private recipient fails", err)
+	}
+	if len(*sent) != 1 || (*sent)[0].target != "运维群" || !strings.Contains((*sent)[0].text, "@Alice") {
+		t.Fatalf("sent = %+v, want one group message mentioning Alice", *sent)
+	}
+
+	var retries []DeferredNotification
+	if err := store.Find(&retries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(retries) != 1 || retries[0].TargetName != "Bob" || retries[0].Status != DeferredFailed || retries[0].Attempts != 1 {
+		t.Fatalf("deferred = %+v, want one failed private retry for Bob", retries)
+	}
+}
+
+func TestNotifyGroupFailureSendsNothingElse(t *testing.T) {
+	sent := newTestStore(t, func(m sentMessage) bool { return m.isGroup })
+	mustPreference(t, "Alice", ChannelGroup)
+	mustPreference(t, "Bob", ChannelPrivate)
+
+	err := Notify(Notification{
+		WorkspaceID: 1,
+		Target:      "@@ops",
+		TargetName:  "运维群",
+		IsGroup:     true,
+		Users:       []User{{Name: "Alice"}, {Name: "Bob"}},
+		Text:        "任务即将到期",
+	})
+	if err == nil {
+		t.Fatal("Notify returned nil, want the group send error")
+	}
+	// 调用方会整条重试，此时不能已经私聊过 Bob
+	if len(*sent) != 0 {
+		t.Errorf("sent = %+v, want nothing before the group message succeeds", *sent)
+	}
+}
+
+func TestNotifySkipsMutedUsers(t *testing.T) {
+	sent := newTestStore(t, nil)
+	mustPreference(t, "Alice", ChannelGroup)
+	mustPreference(t, "Bob", ChannelPrivate)
+	if err := SetMuted("Bob", 1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Notify(Notification{WorkspaceID: 1, Target: "@@ops", TargetName: "运维群", IsGroup: true,
+		Users: []User{{Name: "Alice"}, {Name: "Bob"}}, Text: "提醒"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 || !(*sent)[0].isGroup {
+		t.Errorf("sent = %+v, want only the group message", *sent)
+	}
+}
+
+func TestNotifyIgnoreQuietHours(t *testing.T) {
+	sent := newTestStore(t, nil)
+	// 当前时间处于免打扰时段
+	now := time.Now().UTC()
+	start, end := now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04")
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := SavePreference(Preference{UserName: name, QuietStart: start, QuietEnd: end, Timezone: "UTC"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := Notification{WorkspaceID: 1, Target: "@alice", TargetName: "Alice", Users: []User{{Name: "Alice"}}, Text: "喝水"}
+	if err := Notify(n); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("sent = %+v, want the notification deferred during quiet hours", *sent)
+	}
+
+	n.IgnoreQuietHours = true
+	if err := Notify(n); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent = %+v, want the notification sent immediately", *sent)
+	}
+
+	// 静音仍然生效
+	if err := SetMuted("Bob", 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Notify(Notification{WorkspaceID: 1, Target: "@bob", TargetName: "Bob", Text: "喝水", IgnoreQuietHours: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 {
+		t.Errorf("sent = %+v, want nothing sent to a muted user", *sent)
+	}
+}
@@ -0,0 +1,29 @@
+package reminder
+
+import "time"
+
+// Reminder 个人提醒（如“10分钟后提醒我喝水”），与任务无关，到时间后发到设置提醒的群或私聊
+type Reminder struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	WorkspaceID     uint       `gorm:"not null;default:0;index" json:"workspace_id"`                        // 所在会话的工作区（稳定的标识，群改名或重新登录后不变），0 表示尚未认领的旧提醒
+	ChatID          string     `gorm:"type:varchar(100);not null;default:''" json:"chat_id"`                // 发送目标：群或好友的 UserName（重新登录后会变化）
+	ChatName        string     `gorm:"type:varchar(255);not null;index:idx_reminder_chat" json:"chat_name"` // 群名或好友昵称（UserName 失效时按名称查找）
+	IsGroup         bool       `gorm:"not null;default:false;index:idx_reminder_chat" json:"is_group"`
+	CreatorID       string     `gorm:"type:varchar(100);not null;default:''" json:"creator_id"`
+	CreatorName     string     `gorm:"type:varchar(100);not null;default:''" json:"creator_name"` // 设置提醒的人（群里提醒时 @ 他）
+	Text            string     `gorm:"type:text" json:"text"`
+	RemindAt        time.Time  `gorm:"type:datetime;not null;index" json:"remind_at"`                     // 下一次提醒的时间
+	Recurrence      string     `gorm:"type:varchar(255);not null;default:''" json:"recurrence,omitempty"` // 重复规则（RRULE子集），为空表示只提醒一次
+	Status          string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`    // active, done, cancelled, failed
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`                                // 本次提醒已尝试发送的次数
+	NextAttemptTime *time.Time `gorm:"type:datetime;null" json:"next_attempt_time"`                       // 发送失败后下一次重试的时间
+	LastError       string     `gorm:"type:text" json:"last_error"`
+	FiredCount      int        `gorm:"not null;default:0" json:"fired_count"` // 已提醒的次数
+	LastFiredTime   *time.Time `gorm:"type:datetime;null" json:"last_fired_time"`
+	CreateTime      time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (Reminder) TableName() string {
+	return "personal_reminders"
+}
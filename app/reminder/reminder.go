@@ -0,0 +1,321 @@
+package reminder
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/869413421/wechatbot/app/notify"
+	"github.com/869413421/wechatbot/app/task"
+)
+
+// 提醒状态
+const (
+	StatusActive    = "active"
+	StatusDone      = "done"
+	StatusCancelled = "cancelled"
+	StatusFailed    = "failed"
+)
+
+const (
+	// MaxActiveReminders 每个人在同一个群或私聊中最多的未完成提醒数
+	MaxActiveReminders = 50
+	// maxAttempts 一次提醒最多尝试发送的次数
+	maxAttempts = 5
+	// retryBase 发送失败后第一次重试的间隔，之后每次翻倍
+	retryBase = time.Minute
+	// maxSchedulerSleep 调度器最长的休眠时间
+	maxSchedulerSleep = time.Hour
+	// lateThreshold 晚于预定时间多久发送时注明原定时间
+	lateThreshold = time.Minute
+)
+
+var (
+	store         *gorm.DB
+	schedulerWake = make(chan struct{}, 1)
+	schedulerOnce sync.Once
+)
+
+// Init 初始化提醒的存储
+func Init(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Reminder{}); err != nil {
+		return fmt.Errorf("failed to migrate personal_reminders table: %v", err)
+	}
+	log.Printf("Personal reminders table migrated\n")
+	store = db
+	return nil
+}
+
+// Create 创建提醒，recurrence 为空表示只提醒一次
+func Create(r *Reminder, now time.Time) error {
+	if store == nil {
+		return fmt.Errorf("reminder store is not initialized")
+	}
+	r.Text = strings.TrimSpace(r.Text)
+	if r.Text == "" {
+		return fmt.Errorf("reminder text is required")
+	}
+	if r.WorkspaceID == 0 {
+		return fmt.Errorf("workspace is required")
+	}
+	if r.ChatID == "" && r.ChatName == "" {
+		return fmt.Errorf("target chat is required")
+	}
+	if !r.RemindAt.After(now) {
+		return fmt.Errorf("remind time %s is in the past", r.RemindAt.Format("2006-01-02 15:04"))
+	}
+	if r.Recurrence != "" {
+		rec, err := task.ParseRecurrence(r.Recurrence)
+		if err != nil {
+			return err
+		}
+		r.Recurrence = rec.String()
+	}
+
+	var count int64
+	if err := store.Model(&Reminder{}).
+		Where("workspace_id = ? AND creator_name = ? AND status = ?", r.WorkspaceID, r.CreatorName, StatusActive).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count reminders: %v", err)
+	}
+	if count >= MaxActiveReminders {
+		return fmt.Errorf("at most %d active reminders are allowed, please cancel some first", MaxActiveReminders)
+	}
+
+	r.Status = StatusActive
+	r.CreateTime = now
+	if err := store.Create(r).Error; err != nil {
+		return fmt.Errorf("failed to create reminder: %v", err)
+	}
+	log.Printf("Created reminder %d for %s at %s\n", r.ID, r.ChatName, r.RemindAt.Format("2006-01-02 15:04"))
+	wakeScheduler()
+	return nil
+}
+
+// AdoptLegacy 将按群名或昵称保存的旧提醒归入会话的工作区
+func AdoptLegacy(workspaceID uint, chatName string, isGroup bool) error {
+	if store == nil {
+		return fmt.Errorf("reminder store is not initialized")
+	}
+	if workspaceID == 0 || chatName == "" {
+		return nil
+	}
+	result := store.Model(&Reminder{}).
+		Where("workspace_id = 0 AND chat_name = ? AND is_group = ?", chatName, isGroup).
+		Update("workspace_id", workspaceID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to adopt legacy reminders: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Moved %d legacy reminder(s) of %s into workspace %d\n", result.RowsAffected, chatName, workspaceID)
+	}
+	return nil
+}
+
+// List 列出工作区中某人设置的未完成提醒（按提醒时间排序），creatorName 为空表示所有人
+func List(workspaceID uint, creatorName string) ([]Reminder, error) {
+	var reminders []Reminder
+	if store == nil {
+		return reminders, fmt.Errorf("reminder store is not initialized")
+	}
+	query := store.Where("workspace_id = ? AND status = ?", workspaceID, StatusActive)
+	if creatorName != "" {
+		query = query.Where("creator_name = ?", creatorName)
+	}
+	if err := query.Order("remind_at ASC").Find(&reminders).Error; err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %v", err)
+	}
+	return reminders, nil
+}
+
+// Cancel 取消工作区中的提醒，只有设置提醒的人可以取消
+func Cancel(id, workspaceID uint, creatorName string) (*Reminder, error) {
+	if store == nil {
+		return nil, fmt.Errorf("reminder store is not initialized")
+	}
+	var r Reminder
+	if err := store.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("reminder %d not found in this chat", id)
+		}
+		return nil, fmt.Errorf("failed to get reminder: %v", err)
+	}
+	if r.CreatorName != creatorName {
+		return nil, fmt.Errorf("reminder %d was set by %s, only they can cancel it", id, r.CreatorName)
+	}
+	if r.Status != StatusActive {
+		return nil, fmt.Errorf("reminder %d is already %s", id, r.Status)
+	}
+	if err := store.Model(&Reminder{}).Where("id = ?", id).Update("status", StatusCancelled).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel reminder: %v", err)
+	}
+	r.Status = StatusCancelled
+	log.Printf("Cancelled reminder %d\n", id)
+	wakeScheduler()
+	return &r, nil
+}
+
+// Describe 提醒的时间描述，如 “2026-10-18 15:30（每天）”
+func (r *Reminder) Describe() string {
+	desc := r.RemindAt.Format("2006-01-02 15:04")
+	if r.Recurrence != "" {
+		if rec, err := task.ParseRecurrence(r.Recurrence); err == nil {
+			desc += "（" + rec.Describe() + "）"
+		}
+	}
+	return desc
+}
+
+// nextOccurrence 重复提醒在 now 之后的下一次时间（错过的时间不补发），没有下一次时返回 false
+func (r *Reminder) nextOccurrence(now time.Time) (time.Time, bool) {
+	if r.Recurrence == "" {
+		return time.Time{}, false
+	}
+	rec, err := task.ParseRecurrence(r.Recurrence)
+	if err != nil {
+		log.Printf("WARNING: Invalid recurrence %q of reminder %d: %v\n", r.Recurrence, r.ID, err)
+		return time.Time{}, false
+	}
+	if rec.Count > 0 && r.FiredCount+1 >= rec.Count {
+		return time.Time{}, false
+	}
+	next := r.RemindAt
+	for {
+		var ok bool
+		if next, ok = rec.Next(next); !ok {
+			return time.Time{}, false
+		}
+		if next.After(now) {
+			return next, true
+		}
+	}
+}
+
+// notification 提醒对应的通知：群里 @ 设置提醒的人
+func (r *Reminder) notification(now time.Time) notify.Notification {
+	text := "⏰ 提醒：" + r.Text
+	if now.Sub(r.RemindAt) > lateThreshold {
+		text += fmt.Sprintf("（原定 %s）", r.RemindAt.Format("01-02 15:04"))
+	}
+	// 用户指定了准确的提醒时间，不因免打扰延后
+	n := notify.Notification{WorkspaceID: r.WorkspaceID, Target: r.ChatID, TargetName: r.ChatName, IsGroup: r.IsGroup, Text: text, IgnoreQuietHours: true}
+	// 工作区记录了会话当前的 UserName 和名称（重新登录或改名后会更新）
+	if r.WorkspaceID != 0 {
+		if ws, exists := task.GetTaskManager().GetWorkspace(r.WorkspaceID); exists {
+			n.Target, n.TargetName = ws.ChatID, ws.Name
+		}
+	}
+	if r.IsGroup && r.CreatorName != "" {
+		n.Users = []notify.User{{ID: r.CreatorID, Name: r.CreatorName}}
+	}
+	return n
+}
+
+// deliverDue 发送已到时间的提醒；重复提醒发送后安排下一次，发送失败按指数退避重试
+func deliverDue(now time.Time, send notify.Sender) {
+	var due []Reminder
+	if err := store.Where("status = ? AND remind_at <= ? AND (next_attempt_time IS NULL OR next_attempt_time <= ?)", StatusActive, now, now).
+		Order("remind_at ASC").
+		Find(&due).Error; err != nil {
+		log.Printf("ERROR: Failed to load due reminders: %v\n", err)
+		return
+	}
+
+	for i := range due {
+		r := &due[i]
+		updates := map[string]interface{}{}
+		err := send(r.notification(now))
+		if err != nil && r.Attempts+1 < maxAttempts {
+			retry := now.Add(retryBase << uint(r.Attempts))
+			updates["attempts"] = r.Attempts + 1
+			updates["next_attempt_time"] = &retry
+			updates["last_error"] = err.Error()
+			log.Printf("Failed to send reminder %d, retrying at %s: %v\n", r.ID, retry.Format("15:04:05"), err)
+		} else {
+			if err != nil {
+				updates["last_error"] = err.Error()
+				log.Printf("Giving up reminder %d at %s: %v\n", r.ID, r.RemindAt.Format("2006-01-02 15:04"), err)
+			} else {
+				updates["fired_count"] = r.FiredCount + 1
+				updates["last_fired_time"] = &now
+				updates["last_error"] = ""
+				log.Printf("Sent reminder %d to %s\n", r.ID, r.ChatName)
+			}
+			updates["attempts"] = 0
+			updates["next_attempt_time"] = nil
+			if next, ok := r.nextOccurrence(now); ok {
+				updates["remind_at"] = next
+			} else if err != nil && r.Recurrence == "" {
+				updates["status"] = StatusFailed
+			} else {
+				updates["status"] = StatusDone
+			}
+		}
+		if err := store.Model(&Reminder{}).Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+			log.Printf("ERROR: Failed to update reminder %d: %v\n", r.ID, err)
+		}
+	}
+}
+
+// nextTime 下一条需要处理的提醒时间（包括重试时间）
+func nextTime(now time.Time) (time.Time, bool) {
+	var next time.Time
+	var times []time.Time
+	if err := store.Model(&Reminder{}).
+		Where("status = ? AND next_attempt_time IS NULL AND remind_at > ?", StatusActive, now).
+		Order("remind_at ASC").Limit(1).
+		Pluck("remind_at", &times).Error; err != nil {
+		log.Printf("ERROR: Failed to load next reminder: %v\n", err)
+	} else if len(times) > 0 {
+		next = times[0]
+	}
+
+	times = nil
+	if err := store.Model(&Reminder{}).
+		Where("status = ? AND next_attempt_time > ?", StatusActive, now).
+		Order("next_attempt_time ASC").Limit(1).
+		Pluck("next_attempt_time", &times).Error; err != nil {
+		log.Printf("ERROR: Failed to load next reminder retry: %v\n", err)
+	} else if len(times) > 0 && (next.IsZero() || times[0].Before(next)) {
+		next = times[0]
+	}
+	return next, !next.IsZero()
+}
+
+// StartScheduler 启动提醒调度器（只启动一次）：休眠到下一条提醒的时间准时发送，提醒创建或取消时重新计算
+func StartScheduler(send notify.Sender) {
+	schedulerOnce.Do(func() {
+		go runScheduler(send)
+	})
+}
+
+// wakeScheduler 提醒修改后唤醒调度器
+func wakeScheduler() {
+	select {
+	case schedulerWake <- struct{}{}:
+	default:
+	}
+}
+
+// runScheduler 调度循环
+func runScheduler(send notify.Sender) {
+	log.Printf("Personal reminder scheduler started\n")
+	for {
+		now := time.Now()
+		deliverDue(now, send)
+
+		wait := maxSchedulerSleep
+		if next, ok := nextTime(now); ok && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-schedulerWake:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDBSeq 每个测试使用独立的内存数据库
+var testDBSeq int64
+
+// newTestStore 使用内存 SQLite 数据库初始化提醒存储
+func newTestStore(t *testing.T) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:reminder_test_%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	previous := store
+	t.Cleanup(func() { store = previous })
+	if err := Init(conn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustCreate 创建测试用的提醒
+func mustCreate(t *testing.T, workspaceID uint, chatName, creator, text string, now time.Time) *Reminder {
+	t.Helper()
+	r := &Reminder{
+		WorkspaceID: workspaceID,
+		ChatID:      "@@" + chatName,
+		ChatName:    chatName,
+		IsGroup:     true,
+		CreatorName: creator,
+		Text:        text,
+		RemindAt:    now.Add(10 * time.Minute),
+	}
+	if err := Create(r, now); err != nil {
+		t.Fatalf("Create(%s): %v", text, err)
+	}
+	return r
+}
+
+func TestCancelOnlyByCreator(t *testing.T) {
+	newTestStore(t)
+	now := time.Now()
+	r := mustCreate(t, 1, "运维群", "Alice", "喝水", now)
+
+	if _, err := Cancel(r.ID, 1, "Bob"); err == nil || !strings.Contains(err.Error(), "only they can cancel") {
+		t.Errorf("Cancel by another member error = %v, want a creator check", err)
+	}
+	if _, err := Cancel(r.ID, 2, "Alice"); err == nil {
+		t.Error("Cancel from another workspace succeeded")
+	}
+	cancelled, err := Cancel(r.ID, 1, "Alice")
+	if err != nil {
+		t.Fatalf("Cancel by creator: %v", err)
+	}
+	if cancelled.Status != StatusCancelled {
+		t.Errorf("status = %s, want cancelled", cancelled.Status)
+	}
+}
+
+func TestRemindersKeyedByWorkspace(t *testing.T) {
+	newTestStore(t)
+	now := time.Now()
+	mustCreate(t, 1, "运维群", "Alice", "喝水", now)
+	// 另一个同名的群是不同的工作区
+	mustCreate(t, 2, "运维群", "Alice", "开会", now)
+
+	reminders, err := List(1, "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].Text != "喝水" {
+		t.Errorf("List(1) = %v, want only the reminder of workspace 1", reminders)
+	}
+
+	if err := Create(&Reminder{ChatName: "运维群", Text: "没有工作区", RemindAt: now.Add(time.Minute)}, now); err == nil {
+		t.Error("Create without a workspace succeeded")
+	}
+}
+
+func TestAdoptLegacyReminders(t *testing.T) {
+	newTestStore(t)
+	now := time.Now()
+	legacy := Reminder{ChatName: "运维群", IsGroup: true, CreatorName: "Alice", Text: "旧提醒", RemindAt: now.Add(time.Hour), Status: StatusActive, CreateTime: now}
+	private := Reminder{ChatName: "运维群", IsGroup: false, CreatorName: "Alice", Text: "同名好友的提醒", RemindAt: now.Add(time.Hour), Status: StatusActive, CreateTime: now}
+	if err := store.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(&private).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AdoptLegacy(7, "运维群", true); err != nil {
+		t.Fatal(err)
+	}
+	reminders, err := List(7, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].ID != legacy.ID {
+		t.Errorf("List(7) after adoption = %v, want only the group reminder %d", reminders, legacy.ID)
+	}
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// Node 图中的节点
+type Node struct {
+	ID    uint
+	Key   string     // 图片中显示的短标签（仅支持数字、字母、#、-）
+	Title string     // 完整标题（用于 DOT / Mermaid 和文字图例）
+	Fill  color.RGBA // 节点填充色
+}
+
+// Edge 有向边，From 为前置任务，To 为依赖它的任务
+type Edge struct {
+	From uint
+	To   uint
+}
+
+// LegendItem 图例项
+type LegendItem struct {
+	Label string
+	Fill  color.RGBA
+}
+
+// Graph 待渲染的有向无环图
+type Graph struct {
+	Nodes  []Node
+	Edges  []Edge
+	Legend []LegendItem
+}
+
+// ToDOT 导出 Graphviz DOT 文本
+func ToDOT(g *Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"sans-serif\"];\n")
+	for _, n := range sortedNodes(g) {
+		fmt.Fprintf(&b, "  t%d [label=\"%s\\n%s\", fillcolor=\"%s\"];\n", n.ID, escapeDOT(n.Key), escapeDOT(n.Title), hexColor(n.Fill))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  t%d -> t%d;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToMermaid 导出 Mermaid flowchart 文本
+func ToMermaid(g *Graph) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range sortedNodes(g) {
+		fmt.Fprintf(&b, "  t%d[\"%s %s\"]\n", n.ID, escapeMermaid(n.Key), escapeMermaid(n.Title))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  t%d --> t%d\n", e.From, e.To)
+	}
+	for _, n := range sortedNodes(g) {
+		fmt.Fprintf(&b, "  style t%d fill:%s\n", n.ID, hexColor(n.Fill))
+	}
+	return b.String()
+}
+
+func sortedNodes(g *Graph) []Node {
+	nodes := append([]Node(nil), g.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func escapeDOT(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	return strings.ReplaceAll(s, "\"", "\\\"")
+}
+
+func escapeMermaid(s string) string {
+	return strings.ReplaceAll(s, "\"", "#quot;")
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+)
+
+// layoutSweeps 重心排序的迭代次数
+const layoutSweeps = 4
+
+// position 节点在分层布局中的位置
+type position struct {
+	layer int // 所在列（从左到右）
+	order int // 列内序号（从上到下）
+}
+
+// layout 分层布局：按最长路径分层，再用重心法减少边交叉
+func layout(g *Graph) (map[uint]position, [][]uint, error) {
+	preds := make(map[uint][]uint)
+	succs := make(map[uint][]uint)
+	inDegree := make(map[uint]int, len(g.Nodes))
+	for _, n := range g.Nodes {
+		inDegree[n.ID] = 0
+	}
+	for _, e := range g.Edges {
+		if _, ok := inDegree[e.From]; !ok {
+			continue
+		}
+		if _, ok := inDegree[e.To]; !ok {
+			continue
+		}
+		preds[e.To] = append(preds[e.To], e.From)
+		succs[e.From] = append(succs[e.From], e.To)
+		inDegree[e.To]++
+	}
+
+	// 拓扑排序并计算层号（最长路径）
+	var queue []uint
+	for id, d := range inDegree {
+		if d == 0 {
+			queue = append(queue, id)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i] < queue[j] })
+
+	layerOf := make(map[uint]int, len(g.Nodes))
+	visited := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, next := range succs[id] {
+			if layerOf[id]+1 > layerOf[next] {
+				layerOf[next] = layerOf[id] + 1
+			}
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if visited != len(g.Nodes) {
+		return nil, nil, fmt.Errorf("graph contains a cycle")
+	}
+
+	maxLayer := 0
+	for _, l := range layerOf {
+		if l > maxLayer {
+			maxLayer = l
+		}
+	}
+	layers := make([][]uint, maxLayer+1)
+	for _, n := range sortedNodes(g) {
+		l := layerOf[n.ID]
+		layers[l] = append(layers[l], n.ID)
+	}
+
+	pos := make(map[uint]position, len(g.Nodes))
+	assign := func() {
+		for l, ids := range layers {
+			for i, id := range ids {
+				pos[id] = position{layer: l, order: i}
+			}
+		}
+	}
+	assign()
+
+	// 交替向下/向上按相邻层的重心重新排序
+	barycenter := func(id uint, neighbours []uint, fallback int) float64 {
+		if len(neighbours) == 0 {
+			return float64(fallback)
+		}
+		sum := 0
+		for _, n := range neighbours {
+			sum += pos[n].order
+		}
+		return float64(sum) / float64(len(neighbours))
+	}
+	for sweep := 0; sweep < layoutSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for l := 1; l < len(layers); l++ {
+				sortLayer(layers[l], func(id uint) float64 { return barycenter(id, preds[id], pos[id].order) })
+				assign()
+			}
+		} else {
+			for l := len(layers) - 2; l >= 0; l-- {
+				sortLayer(layers[l], func(id uint) float64 { return barycenter(id, succs[id], pos[id].order) })
+				assign()
+			}
+		}
+	}
+
+	return pos, layers, nil
+}
+
+func sortLayer(ids []uint, key func(uint) float64) {
+	keys := make(map[uint]float64, len(ids))
+	for _, id := range ids {
+		keys[id] = key(id)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return keys[ids[i]] < keys[ids[j]] })
+}
@@ -0,0 +1,245 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// 布局和绘制参数（像素）
+const (
+	maxRenderNodes = 200
+	fontScale      = 2
+	nodePadding    = 10
+	nodeHeight     = glyphHeight*fontScale + 2*nodePadding
+	layerGap       = 70
+	rowGap         = 18
+	canvasMargin   = 24
+	legendSwatch   = 14
+	arrowLength    = 10
+	arrowWidth     = 5
+)
+
+var (
+	backgroundColor = color.RGBA{255, 255, 255, 255}
+	borderColor     = color.RGBA{60, 60, 60, 255}
+	edgeColor       = color.RGBA{120, 120, 120, 255}
+	textColor       = color.RGBA{20, 20, 20, 255}
+)
+
+// RenderPNG 将图渲染为 PNG 图片（纯 Go 实现的分层布局和光栅化）
+func RenderPNG(g *Graph) ([]byte, error) {
+	if len(g.Nodes) == 0 {
+		return nil, fmt.Errorf("graph has no nodes")
+	}
+	if len(g.Nodes) > maxRenderNodes {
+		return nil, fmt.Errorf("graph has %d nodes, at most %d can be rendered", len(g.Nodes), maxRenderNodes)
+	}
+
+	pos, layers, err := layout(g)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	// 每列宽度取决于该列最长的标签
+	layerWidth := make([]int, len(layers))
+	maxRows := 0
+	for l, ids := range layers {
+		for _, id := range ids {
+			w := textWidth(nodes[id].Key, fontScale) + 2*nodePadding
+			if w > layerWidth[l] {
+				layerWidth[l] = w
+			}
+		}
+		if len(ids) > maxRows {
+			maxRows = len(ids)
+		}
+	}
+	layerX := make([]int, len(layers))
+	x := canvasMargin
+	for l := range layers {
+		layerX[l] = x
+		x += layerWidth[l] + layerGap
+	}
+
+	graphWidth := x - layerGap + canvasMargin
+	graphHeight := canvasMargin*2 + maxRows*nodeHeight + (maxRows-1)*rowGap
+	legendHeight := 0
+	legendWidth := canvasMargin
+	for _, item := range g.Legend {
+		legendWidth += legendSwatch + 6 + textWidth(item.Label, 1) + 16
+	}
+	if len(g.Legend) > 0 {
+		legendHeight = legendSwatch + canvasMargin
+	}
+
+	width := graphWidth
+	if legendWidth > width {
+		width = legendWidth
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, graphHeight+legendHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: backgroundColor}, image.Point{}, draw.Src)
+
+	// 计算节点矩形，列内垂直居中
+	rects := make(map[uint]image.Rectangle, len(g.Nodes))
+	for l, ids := range layers {
+		columnHeight := len(ids)*nodeHeight + (len(ids)-1)*rowGap
+		top := (graphHeight - columnHeight) / 2
+		for _, id := range ids {
+			y := top + pos[id].order*(nodeHeight+rowGap)
+			rects[id] = image.Rect(layerX[l], y, layerX[l]+layerWidth[l], y+nodeHeight)
+		}
+	}
+
+	// 先画边，再画节点，避免边覆盖节点
+	for _, e := range g.Edges {
+		from, ok1 := rects[e.From]
+		to, ok2 := rects[e.To]
+		if !ok1 || !ok2 {
+			continue
+		}
+		x0, y0 := from.Max.X, (from.Min.Y+from.Max.Y)/2
+		x1, y1 := to.Min.X, (to.Min.Y+to.Max.Y)/2
+		drawLine(img, x0, y0, x1, y1, 2, edgeColor)
+		drawArrowHead(img, x0, y0, x1, y1, edgeColor)
+	}
+
+	for id, r := range rects {
+		n := nodes[id]
+		draw.Draw(img, r, &image.Uniform{C: n.Fill}, image.Point{}, draw.Src)
+		drawRectBorder(img, r, borderColor)
+		tx := r.Min.X + (r.Dx()-textWidth(n.Key, fontScale))/2
+		drawText(img, tx, r.Min.Y+nodePadding, n.Key, fontScale, textColor)
+	}
+
+	// 图例
+	lx, ly := canvasMargin, graphHeight
+	for _, item := range g.Legend {
+		swatch := image.Rect(lx, ly, lx+legendSwatch, ly+legendSwatch)
+		draw.Draw(img, swatch, &image.Uniform{C: item.Fill}, image.Point{}, draw.Src)
+		drawRectBorder(img, swatch, borderColor)
+		lx += legendSwatch + 6
+		drawText(img, lx, ly+(legendSwatch-glyphHeight)/2, item.Label, 1, textColor)
+		lx += textWidth(item.Label, 1) + 16
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawText 用点阵字体绘制文本
+func drawText(img *image.RGBA, x, y int, text string, scale int, c color.RGBA) {
+	for _, r := range text {
+		glyph := glyphFor(r)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if glyph[row][col] != '#' {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(img, px, &image.Uniform{C: c}, image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+// drawRectBorder 绘制1像素矩形边框
+func drawRectBorder(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		img.SetRGBA(x, r.Min.Y, c)
+		img.SetRGBA(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		img.SetRGBA(r.Min.X, y, c)
+		img.SetRGBA(r.Max.X-1, y, c)
+	}
+}
+
+// drawLine 使用 Bresenham 算法绘制有宽度的直线
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	errAcc := dx + dy
+	half := thickness / 2
+	for {
+		for ox := -half; ox < thickness-half; ox++ {
+			for oy := -half; oy < thickness-half; oy++ {
+				img.SetRGBA(x0+ox, y0+oy, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * errAcc
+		if e2 >= dy {
+			errAcc += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			errAcc += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawArrowHead 在线段终点绘制实心三角形箭头
+func drawArrowHead(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	angle := math.Atan2(float64(y1-y0), float64(x1-x0))
+	tipX, tipY := float64(x1), float64(y1)
+	baseX := tipX - arrowLength*math.Cos(angle)
+	baseY := tipY - arrowLength*math.Sin(angle)
+	leftX := baseX + arrowWidth*math.Sin(angle)
+	leftY := baseY - arrowWidth*math.Cos(angle)
+	rightX := baseX - arrowWidth*math.Sin(angle)
+	rightY := baseY + arrowWidth*math.Cos(angle)
+
+	minX := int(math.Floor(math.Min(tipX, math.Min(leftX, rightX))))
+	maxX := int(math.Ceil(math.Max(tipX, math.Max(leftX, rightX))))
+	minY := int(math.Floor(math.Min(tipY, math.Min(leftY, rightY))))
+	maxY := int(math.Ceil(math.Max(tipY, math.Max(leftY, rightY))))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			d1 := cross(px, py, tipX, tipY, leftX, leftY)
+			d2 := cross(px, py, leftX, leftY, rightX, rightY)
+			d3 := cross(px, py, rightX, rightY, tipX, tipY)
+			hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+			hasPos := d1 > 0 || d2 > 0 || d3 > 0
+			if !(hasNeg && hasPos) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func cross(px, py, ax, ay, bx, by float64) float64 {
+	return (px-bx)*(ay-by) - (ax-bx)*(py-by)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
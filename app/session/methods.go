@@ -5,6 +5,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/869413421/wechatbot/app/agent"
 	"github.com/869413421/wechatbot/app/config"
 	"github.com/869413421/wechatbot/app/llm"
 )
@@ -16,6 +17,11 @@ var sessionMap = make(map[string][]Message)
 
 // Completions 会话完成处理（支持多种 AI 模型）
 func Completions(sessionId, msg string, change_str string) (string, error) {
+	return CompletionsWithContext(sessionId, msg, llm.ChatContext{})
+}
+
+// CompletionsWithContext 会话完成处理，工具调用使用消息所在的会话上下文（工作区、发送者）
+func CompletionsWithContext(sessionId, msg string, chat llm.ChatContext) (string, error) {
 	// 移除角色修改功能，不再支持 change_str 参数
 	if msg == "换个话题" || msg == "换个话题吧" || msg == "清空" || msg == "清空对话" {
 		clearSession(sessionId)
@@ -23,6 +29,18 @@ func Completions(sessionId, msg string, change_str string) (string, error) {
 	if msg == "get:session" {
 		return getSessionMsg(sessionId), nil
 	}
+	if query, ok := directQuery(msg); ok {
+		return runDirectQuery(query, chat), nil
+	}
+	if msg == "下一页" || msg == "/next" {
+		return runNextPage(chat), nil
+	}
+	// 对最近一条任务提醒的回复（稍后提醒、知道了）
+	if chat.ChatID != "" {
+		if reply, ok := agent.HandleReminderReply(chat.CallerArgs(), msg); ok {
+			return reply, nil
+		}
+	}
 
 	addSession(sessionId, Message{Role: "user", Content: msg})
 
@@ -33,7 +51,13 @@ func Completions(sessionId, msg string, change_str string) (string, error) {
 	messages := getSession(sessionId)
 
 	// 调用 AI 提供者
-	reply, err := provider.Chat(messages)
+	var reply string
+	var err error
+	if chat.ChatID != "" {
+		reply, err = provider.ChatWithContext(messages, chat)
+	} else {
+		reply, err = provider.Chat(messages)
+	}
 	if err != nil {
 		log.Printf("AI request error: %v \n", err)
 		// 即使出错，也返回友好的错误提示
@@ -57,6 +81,39 @@ func Completions(sessionId, msg string, change_str string) (string, error) {
 	return reply, nil
 }
 
+// directQueryPrefixes 直接执行任务查询（不经过AI）的命令前缀
+var directQueryPrefixes = []string{"/query ", "/q ", "查询:", "查询："}
+
+// directQuery 判断消息是否为直接查询命令，返回查询语句
+func directQuery(msg string) (string, bool) {
+	for _, prefix := range directQueryPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(msg, prefix)), true
+		}
+	}
+	return "", false
+}
+
+// runDirectQuery 直接执行任务查询，出错时返回带用法说明的提示
+func runDirectQuery(query string, chat llm.ChatContext) string {
+	args := chat.CallerArgs()
+	args["query"] = query
+	result, err := agent.NewExecutor().ExecuteCommand("query_tasks", args)
+	if err != nil {
+		return fmt.Sprintf("查询失败: %v\n\n用法示例: /q status:open assignee:me due<2026-11-01 label:release sort:due", err)
+	}
+	return result
+}
+
+// runNextPage 直接显示上一次任务列表或查询的下一页
+func runNextPage(chat llm.ChatContext) string {
+	result, err := agent.NewExecutor().ExecuteCommand("next_page", chat.CallerArgs())
+	if err != nil {
+		return fmt.Sprintf("翻页失败: %v", err)
+	}
+	return result
+}
+
 func addSession(sessionId string, msg Message) {
 	session := getSession(sessionId)
 	session = append(session, msg)
@@ -87,8 +144,22 @@ func getSession(sessionId string) []Message {
 
 任务管理工具使用说明（仅在用户明确要求时使用）：
 - 创建任务：使用 create_task 工具，creator_id 使用: %s
-- 如果用户提到时间（如"今天13点"、"明天12点"、"后天下午4点"），需要将自然语言转换为标准格式 "YYYY-MM-DD HH:MM:SS" 再传递给 due_time 参数
-- 时间转换示例："今天13点" → 当前日期 + " 13:00:00"，"明天12点" → 明天日期 + " 12:00:00"
+- 如果用户提到时间（如"明天12点"、"本周五下午"、"下周一"、"月底"、"3天后"、"12月3号"），可以把用户的说法原样传给 due_time 参数（会按用户的时区解析），也可以转换为标准格式 "YYYY-MM-DD HH:MM:SS"
+- 只说日期时截止到当天 23:59:59；工具返回时间无效的错误时，换成标准格式重试
+- 周期性任务（如"每周一提交周报"）：在 create_task 中填写 recurrence（如 每周一、workdays、FREQ=MONTHLY;BYMONTHDAY=-1）；完成一次后会自动生成下一次。修改或取消重复任务时，用 scope 区分"只改这一次"(this) 和"整个系列"(series)
+- 每个群和每个私聊各有独立的任务工作区，任务默认只在创建它的群或私聊中可见；用户要求把任务给别的群或自己看时，使用 share_task 共享
+- 任务编号形如 OPS-12（每个群和私聊独立编号），调用工具时 task_id 直接使用任务列表中显示的编号
+- 用户给出 status:pending label:release 这样的查询语句时，使用 query_tasks 原样传入
+- 用户要求任务提前多久提醒（如"提前一小时提醒我"）时，在 create_task 或 update_task 中填写 reminders（如 1h）
+- 用户要求设置免打扰、摘要时间、时区、私聊提醒或静音本群时，使用 set_notification_preferences（不想收每日摘要时 digest_time 设为 off）；查看设置用 get_notification_preferences
+- 用户提到工作日（如"三个工作日内完成"）时，可以直接把"三个工作日内"传给 due_time；公司放假、团建等休息日用 set_non_working_day 设置，周末和法定节假日不需要设置
+- 用户只是要求到时间提醒一件小事（如"10分钟后提醒我喝水"、"每天8点提醒我吃药"）时，使用 set_reminder 设置个人提醒，不要创建任务；查看和取消用 list_reminders、cancel_reminder
+- 用户要求记住生日或纪念日（如"妈妈的生日是农历八月十五"）时，使用 add_anniversary 登记，每年自动提醒；查看和删除用 list_anniversaries、remove_anniversary
+- 用户要求逾期后继续催办或升级到管理员（如"逾期一天再提醒，三天后@群主"）时，使用 set_escalation_policy
+- 用户要求一次处理多个任务时使用 bulk_update_tasks：先预览并告诉用户会影响多少个任务，用户确认后再执行
+- 工具返回"任务已被他人修改"时，把最新内容告诉用户并询问是否仍要修改，不要自动重试
+- 任务列表是分页显示的，用户说"下一页"、"更多"时使用 next_page
+- 用户说"撤销刚才的操作"、"撤回"、"刚才弄错了"时，使用 undo_last 撤销该用户最近一次的任务修改
 - 列出任务：使用 list_tasks 工具。如果用户说"我的任务"、"查看我的任务"，传入 creator_id 为当前用户ID；如果用户说"所有任务"、"查看所有任务"、"团队任务"等，不传 creator_id 或传空字符串（查看所有任务，团队协作模式）
 - 其他工具按需使用：get_task（查看任务详情）、update_task（更新任务）、update_task_dependencies（更新依赖）等
 
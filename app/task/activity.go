@@ -0,0 +1,236 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 任务动态类型
+const (
+	ActivityCreated    = "created"
+	ActivityStatus     = "status"
+	ActivityField      = "field"
+	ActivityDependency = "dependency"
+	ActivityParent     = "parent"
+	ActivityShare      = "share"
+	ActivityChecklist  = "checklist"
+	ActivityComment    = "comment"
+	ActivityDeleted    = "deleted"
+	ActivityRestored   = "restored"
+	ActivityUndo       = "undo"
+)
+
+// Actor 执行修改的用户
+type Actor struct {
+	ID   string // 用户UserName
+	Name string // 用户昵称
+}
+
+// statusNames 状态的中文名称
+var statusNames = map[string]string{
+	StatusPending:    "待处理",
+	StatusInProgress: "进行中",
+	StatusCompleted:  "已完成",
+	StatusCancelled:  "已取消",
+	StatusBlocked:    "被阻塞",
+}
+
+// fieldNames 字段的中文名称
+var fieldNames = map[string]string{
+	"title":         "标题",
+	"content":       "内容",
+	"due_time":      "截止时间",
+	"recurrence":    "重复规则",
+	"estimate":      "预计耗时",
+	"auto_complete": "自动完成",
+	"labels":        "标签",
+	"assignees":     "负责人",
+	"priority":      "优先级",
+	"reminders":     "提醒",
+}
+
+// WithActor 返回以 actor 身份执行修改的任务管理器，修改会记录到任务动态中
+func (tm *TaskManager) WithActor(actor Actor) *TaskManager {
+	scoped := *tm
+	scoped.actor = actor
+	return &scoped
+}
+
+// logActivity 追加一条任务动态（记录失败只打印日志，不影响修改本身）
+func (tm *TaskManager) logActivity(t *Task, kind, field, oldValue, newValue string) {
+	activity := TaskActivity{
+		TaskID:      t.ID,
+		WorkspaceID: t.WorkspaceID,
+		TaskTitle:   t.Title,
+		Kind:        kind,
+		Field:       field,
+		OldValue:    oldValue,
+		NewValue:    newValue,
+		ActorID:     tm.actor.ID,
+		ActorName:   tm.actor.Name,
+		CreateTime:  time.Now(),
+	}
+	if err := tm.db.Create(&activity).Error; err != nil {
+		log.Printf("ERROR: Failed to record %s activity for task %d: %v\n", kind, t.ID, err)
+	}
+	// 任务有新动态（评论、标签等）也算作修改，用于按修改时间排序
+	if err := tm.db.Unscoped().Model(&Task{}).Where("id = ?", t.ID).UpdateColumn("update_time", activity.CreateTime).Error; err != nil {
+		log.Printf("ERROR: Failed to touch task %d: %v\n", t.ID, err)
+	}
+	// 截止时间、状态或提醒设置可能变化，让提醒调度器重新计算
+	wakeReminderScheduler()
+}
+
+// AddComment 添加任务评论
+func (tm *TaskManager) AddComment(taskID uint, content string) error {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return fmt.Errorf("comment content is required")
+	}
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+	tm.logActivity(t, ActivityComment, "", "", content)
+	return nil
+}
+
+// GetTaskActivity 获取任务最近的动态（按时间倒序）
+func (tm *TaskManager) GetTaskActivity(taskID uint, limit int) []TaskActivity {
+	var activities []TaskActivity
+	if err := tm.db.Where("task_id = ?", taskID).Order("id DESC").Limit(limit).Find(&activities).Error; err != nil {
+		log.Printf("ERROR: Failed to get activity of task %d: %v\n", taskID, err)
+		return []TaskActivity{}
+	}
+	return activities
+}
+
+// ActivitySince 获取工作区内自 since 起的所有动态（按时间正序），workspaceID 为 0 表示所有工作区
+func (tm *TaskManager) ActivitySince(workspaceID uint, since time.Time) []TaskActivity {
+	var activities []TaskActivity
+	query := tm.db.Where("create_time >= ?", since)
+	if workspaceID != 0 {
+		query = query.Where("(workspace_id = ? OR task_id IN (SELECT task_id FROM task_shares WHERE workspace_id = ?))", workspaceID, workspaceID)
+	}
+	if err := query.Order("id ASC").Find(&activities).Error; err != nil {
+		log.Printf("ERROR: Failed to get activity since %s: %v\n", since.Format("2006-01-02 15:04:05"), err)
+		return []TaskActivity{}
+	}
+	return activities
+}
+
+// FormatActivity 格式化单条动态（不含时间和任务）
+func FormatActivity(a TaskActivity) string {
+	actor := a.ActorName
+	if actor == "" {
+		actor = "系统"
+	}
+
+	switch a.Kind {
+	case ActivityCreated:
+		return fmt.Sprintf("%s 创建了任务", actor)
+	case ActivityStatus:
+		return fmt.Sprintf("%s 将状态从「%s」改为「%s」", actor, statusName(a.OldValue), statusName(a.NewValue))
+	case ActivityField:
+		name := fieldNames[a.Field]
+		if name == "" {
+			name = a.Field
+		}
+		return fmt.Sprintf("%s 将%s从「%s」改为「%s」", actor, name, emptyAsNone(a.OldValue), emptyAsNone(a.NewValue))
+	case ActivityDependency:
+		return fmt.Sprintf("%s 将依赖从「%s」改为「%s」", actor, emptyAsNone(a.OldValue), emptyAsNone(a.NewValue))
+	case ActivityParent:
+		return fmt.Sprintf("%s 将父任务从「%s」改为「%s」", actor, emptyAsNone(a.OldValue), emptyAsNone(a.NewValue))
+	case ActivityShare:
+		if a.NewValue != "" {
+			return fmt.Sprintf("%s 将任务共享到「%s」", actor, a.NewValue)
+		}
+		return fmt.Sprintf("%s 取消了到「%s」的共享", actor, a.OldValue)
+	case ActivityChecklist:
+		return fmt.Sprintf("%s %s", actor, a.NewValue)
+	case ActivityComment:
+		return fmt.Sprintf("%s 评论: %s", actor, a.NewValue)
+	case ActivityDeleted:
+		return fmt.Sprintf("%s 将任务移入回收站", actor)
+	case ActivityRestored:
+		return fmt.Sprintf("%s 从回收站恢复了任务", actor)
+	case ActivityUndo:
+		return fmt.Sprintf("%s 撤销了%s", actor, a.NewValue)
+	default:
+		return fmt.Sprintf("%s %s", actor, a.Kind)
+	}
+}
+
+// FormatTimeline 格式化任务的动态时间线
+func FormatTimeline(activities []TaskActivity) string {
+	if len(activities) == 0 {
+		return "暂无动态\n"
+	}
+	result := ""
+	for _, a := range activities {
+		result += fmt.Sprintf("  %s %s\n", a.CreateTime.Format("01-02 15:04"), FormatActivity(a))
+	}
+	return result
+}
+
+// FormatActivityDigest 按任务分组格式化一段时间内的动态（用于"今天有什么变化"）
+func FormatActivityDigest(tm *TaskManager, activities []TaskActivity) string {
+	if len(activities) == 0 {
+		return "📭 这段时间没有任务变化"
+	}
+
+	var order []uint
+	byTask := make(map[uint][]TaskActivity)
+	for _, a := range activities {
+		if _, ok := byTask[a.TaskID]; !ok {
+			order = append(order, a.TaskID)
+		}
+		byTask[a.TaskID] = append(byTask[a.TaskID], a)
+	}
+
+	result := fmt.Sprintf("📰 共 %d 个任务有变化：\n", len(order))
+	for _, taskID := range order {
+		items := byTask[taskID]
+		title := items[len(items)-1].TaskTitle
+		result += fmt.Sprintf("\n📌 %s (ID: %s)\n", title, tm.TaskKey(taskID))
+		for _, a := range items {
+			result += fmt.Sprintf("  %s %s\n", a.CreateTime.Format("15:04"), FormatActivity(a))
+		}
+	}
+	return result
+}
+
+// statusName 状态的中文名称
+func statusName(status string) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+	return status
+}
+
+// emptyAsNone 空值显示为"无"
+func emptyAsNone(value string) string {
+	if value == "" {
+		return "无"
+	}
+	return value
+}
+
+// formatDueTime 格式化截止时间用于动态记录
+func formatDueTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// formatTaskKeys 格式化任务ID列表为显示编号
+func (tm *TaskManager) formatTaskKeys(ids []uint) string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = tm.TaskKey(id)
+	}
+	return strings.Join(keys, ", ")
+}
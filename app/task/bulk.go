@@ -0,0 +1,302 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 批量操作类型
+const (
+	BulkComplete = "complete"
+	BulkCancel   = "cancel"
+	BulkReassign = "reassign"
+	BulkRelabel  = "relabel"
+	BulkShiftDue = "shift_due"
+)
+
+// MaxBulkTasks 一次批量操作最多处理的任务数
+const MaxBulkTasks = 200
+
+// bulkActionNames 批量操作的中文名称
+var bulkActionNames = map[string]string{
+	BulkComplete: "完成",
+	BulkCancel:   "取消",
+	BulkReassign: "重新分配负责人",
+	BulkRelabel:  "修改标签",
+	BulkShiftDue: "平移截止时间",
+}
+
+// BulkRequest 批量操作请求，任务由 Filter 或 IDs 指定（二选一）
+type BulkRequest struct {
+	Action       string
+	Filter       *TaskFilter
+	IDs          []uint
+	Assignees    []Actor  // reassign：新的负责人（替换原有负责人，为空表示清空）
+	AddLabels    []string // relabel：要添加的标签
+	RemoveLabels []string // relabel：要去掉的标签
+	ShiftDays    int      // shift_due：截止时间平移的天数（负数表示提前）
+	Force        bool     // complete：忽略未完成的前置依赖
+}
+
+// BulkItemResult 单个任务的处理结果
+type BulkItemResult struct {
+	TaskID uint
+	Key    string
+	Title  string
+	Error  string // 为空表示成功
+}
+
+// BulkReport 批量操作报告
+type BulkReport struct {
+	Action    string
+	Items     []BulkItemResult
+	Succeeded int
+	Failed    int
+	Created   []uint // 提交后生成的下一次重复任务
+}
+
+// pendingEffects 事务（批量操作、撤销）中推迟到提交之后的副作用
+// 生成下一次重复任务和通知下游任务都在事务外进行，回滚的修改不会留下新任务或发出通知
+type pendingEffects struct {
+	spawn   []*Task // 完成或取消的重复任务
+	unblock []*Task // 完成或取消的任务
+}
+
+// validate 检查批量操作参数
+func (req *BulkRequest) validate() error {
+	if _, ok := bulkActionNames[req.Action]; !ok {
+		return fmt.Errorf("invalid bulk action %q, use complete, cancel, reassign, relabel or shift_due", req.Action)
+	}
+	if req.Filter == nil && len(req.IDs) == 0 {
+		return fmt.Errorf("a filter or task ids is required")
+	}
+	if req.Filter != nil && len(req.IDs) > 0 {
+		return fmt.Errorf("use either a filter or task ids, not both")
+	}
+	switch req.Action {
+	case BulkRelabel:
+		if len(req.AddLabels) == 0 && len(req.RemoveLabels) == 0 {
+			return fmt.Errorf("labels to add or remove are required")
+		}
+	case BulkShiftDue:
+		if req.ShiftDays == 0 {
+			return fmt.Errorf("shift days must not be zero")
+		}
+	}
+	return nil
+}
+
+// PreviewBulk 列出批量操作将要处理的任务（不做修改）
+func (tm *TaskManager) PreviewBulk(req BulkRequest) ([]*Task, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	var tasks []*Task
+	if req.Filter != nil {
+		filter := *req.Filter
+		filter.Limit = MaxBulkTasks + 1
+		if filter.SortBy == "" {
+			filter.SortBy = SortKey
+		}
+		found, err := tm.QueryTasks(filter)
+		if err != nil {
+			return nil, err
+		}
+		tasks = found
+	} else {
+		if err := tm.db.Preload("Dependencies").Where("id IN ?", req.IDs).Order("id ASC").Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("failed to load tasks: %v", err)
+		}
+		tm.annotate(tasks)
+	}
+
+	if len(tasks) > MaxBulkTasks {
+		return nil, fmt.Errorf("more than %d tasks match, please narrow the filter", MaxBulkTasks)
+	}
+	return tasks, nil
+}
+
+// RunBulk 在一个事务中执行批量操作
+// 单个任务失败只回滚该任务的修改并记录在报告中，其余任务照常提交；
+// 生成下一次重复任务和下游通知在提交之后进行
+func (tm *TaskManager) RunBulk(req BulkRequest) (*BulkReport, error) {
+	tasks, err := tm.PreviewBulk(req)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{Action: req.Action}
+	pending := &pendingEffects{}
+	err = tm.db.Transaction(func(tx *gorm.DB) error {
+		scoped := *tm
+		scoped.db = tx
+		scoped.pending = pending
+
+		for i, t := range tasks {
+			item := BulkItemResult{TaskID: t.ID, Key: t.DisplayKey(), Title: t.Title}
+
+			savepoint := fmt.Sprintf("bulk_item_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return fmt.Errorf("failed to create savepoint: %v", err)
+			}
+			spawned, unblocked := len(pending.spawn), len(pending.unblock)
+			if err := scoped.applyBulkAction(t, req); err != nil {
+				if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+					return fmt.Errorf("failed to roll back task %d: %v", t.ID, rbErr)
+				}
+				// 回滚的修改不产生副作用
+				pending.spawn, pending.unblock = pending.spawn[:spawned], pending.unblock[:unblocked]
+				item.Error = err.Error()
+				report.Failed++
+			} else {
+				report.Succeeded++
+			}
+			report.Items = append(report.Items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Created = tm.runPendingEffects(pending)
+
+	log.Printf("Bulk %s finished: %d succeeded, %d failed\n", req.Action, report.Succeeded, report.Failed)
+	return report, nil
+}
+
+// runPendingEffects 事务提交后执行推迟的副作用，返回生成的下一次重复任务
+func (tm *TaskManager) runPendingEffects(pending *pendingEffects) []uint {
+	var created []uint
+	for _, t := range pending.spawn {
+		next, err := tm.spawnNextOccurrence(t)
+		if err != nil {
+			log.Printf("ERROR: Failed to spawn next occurrence for task %d: %v\n", t.ID, err)
+			continue
+		}
+		if next != nil {
+			created = append(created, next.ID)
+		}
+	}
+	for _, t := range pending.unblock {
+		tm.notifyUnblocked(t)
+	}
+
+	// 事务中的修改提交后才对调度器可见
+	wakeReminderScheduler()
+	return created
+}
+
+// BulkUndoable 批量操作能否撤销（撤销快照不包含标签和负责人，重新分配和修改标签无法撤销）
+func BulkUndoable(action string) bool {
+	return action == BulkComplete || action == BulkCancel || action == BulkShiftDue
+}
+
+// BulkUndoIDs 批量操作撤销时需要恢复的任务：任务本身及其父任务（子任务结束可能自动完成父任务）
+func BulkUndoIDs(tasks []*Task) []uint {
+	seen := make(map[uint]bool, len(tasks))
+	ids := make([]uint, 0, len(tasks))
+	add := func(id uint) {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, t := range tasks {
+		add(t.ID)
+	}
+	for _, t := range tasks {
+		add(t.ParentID)
+	}
+	return ids
+}
+
+// applyBulkAction 对单个任务执行批量操作
+func (tm *TaskManager) applyBulkAction(t *Task, req BulkRequest) error {
+	switch req.Action {
+	case BulkComplete:
+		return tm.TransitionTaskStatus(t.ID, StatusCompleted, req.Force)
+	case BulkCancel:
+		return tm.TransitionTaskStatus(t.ID, StatusCancelled, false)
+	case BulkReassign:
+		return tm.UpdateTaskAssignees(t.ID, req.Assignees, nil, true)
+	case BulkRelabel:
+		return tm.UpdateTaskLabels(t.ID, req.AddLabels, req.RemoveLabels, false)
+	case BulkShiftDue:
+		if t.DueTime == nil {
+			return fmt.Errorf("task has no due time")
+		}
+		due := t.DueTime.AddDate(0, 0, req.ShiftDays)
+		return tm.UpdateTask(t.ID, TaskUpdate{DueTime: &due, ExpectedVersion: t.Version})
+	}
+	return fmt.Errorf("invalid bulk action %q", req.Action)
+}
+
+// DescribeBulkAction 批量操作的中文描述
+func DescribeBulkAction(req BulkRequest) string {
+	name := bulkActionNames[req.Action]
+	switch req.Action {
+	case BulkReassign:
+		if len(req.Assignees) == 0 {
+			return "清空负责人"
+		}
+		names := make([]string, len(req.Assignees))
+		for i, a := range req.Assignees {
+			names[i] = a.Name
+		}
+		return fmt.Sprintf("%s为 %s", name, strings.Join(names, "、"))
+	case BulkRelabel:
+		var parts []string
+		if len(req.AddLabels) > 0 {
+			parts = append(parts, "添加 "+formatLabels(req.AddLabels))
+		}
+		if len(req.RemoveLabels) > 0 {
+			parts = append(parts, "去掉 "+formatLabels(req.RemoveLabels))
+		}
+		return fmt.Sprintf("%s（%s）", name, strings.Join(parts, "，"))
+	case BulkShiftDue:
+		if req.ShiftDays > 0 {
+			return fmt.Sprintf("截止时间推迟 %d 天", req.ShiftDays)
+		}
+		return fmt.Sprintf("截止时间提前 %d 天", -req.ShiftDays)
+	}
+	return name
+}
+
+// FormatBulkPreview 格式化批量操作预览
+func FormatBulkPreview(req BulkRequest, tasks []*Task) string {
+	if len(tasks) == 0 {
+		return "📋 没有符合条件的任务，不需要执行批量操作"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 将对 %d 个任务执行「%s」:\n", len(tasks), DescribeBulkAction(req))
+	const shown = 20
+	for i, t := range tasks {
+		if i == shown {
+			fmt.Fprintf(&b, "… 以及另外 %d 个任务\n", len(tasks)-shown)
+			break
+		}
+		fmt.Fprintf(&b, "%d. %s %s (ID: %s)\n", i+1, statusEmoji(t.Status), t.Title, t.DisplayKey())
+	}
+	b.WriteString("\n确认后才会执行。")
+	return b.String()
+}
+
+// FormatBulkReport 格式化批量操作报告
+func FormatBulkReport(req BulkRequest, report *BulkReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ 批量「%s」完成：成功 %d 个，失败 %d 个\n", DescribeBulkAction(req), report.Succeeded, report.Failed)
+	for _, item := range report.Items {
+		if item.Error == "" {
+			fmt.Fprintf(&b, "✔ %s %s\n", item.Key, item.Title)
+		} else {
+			fmt.Fprintf(&b, "✘ %s %s：%s\n", item.Key, item.Title, item.Error)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunBulkDefersSideEffects(t *testing.T) {
+	tm := newTestManager(t).WithActor(Actor{ID: "wxid_alice", Name: "Alice"})
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+
+	due := time.Now().Add(2 * time.Hour)
+	daily := mustTask(t, tm, ws.ID, "每日站会", &due)
+	if err := tm.UpdateTaskRecurrence(daily.ID, "daily"); err != nil {
+		t.Fatal(err)
+	}
+	blocker := mustTask(t, tm, ws.ID, "设计", nil)
+	dependent := mustTask(t, tm, ws.ID, "开发", nil, blocker.ID)
+	// 前置依赖未完成，批量完成时这一项失败并回滚
+	later := mustTask(t, tm, ws.ID, "上线", nil, dependent.ID)
+
+	var unblocked []uint
+	OnTasksUnblocked(func(b *Task, tasks []*Task) {
+		unblocked = append(unblocked, b.ID)
+	})
+	t.Cleanup(func() { OnTasksUnblocked(nil) })
+
+	ids := []uint{daily.ID, blocker.ID, later.ID}
+	tasks, err := tm.PreviewBulk(BulkRequest{Action: BulkComplete, IDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	undo := tm.BeginUndo(ws.ID, UndoBulk, BulkUndoIDs(tasks)...)
+	report, err := tm.RunBulk(BulkRequest{Action: BulkComplete, IDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	undo.Created(report.Created...).Commit()
+
+	if report.Succeeded != 2 || report.Failed != 1 {
+		t.Fatalf("report = %d succeeded, %d failed, want 2 and 1", report.Succeeded, report.Failed)
+	}
+	if len(report.Created) != 1 {
+		t.Fatalf("created = %v, want the next occurrence of task %d", report.Created, daily.ID)
+	}
+	if next, ok := tm.GetTask(report.Created[0]); !ok || next.SeriesID != daily.ID || next.Status != StatusPending {
+		t.Errorf("next occurrence = %+v, want a pending task in series %d", next, daily.ID)
+	}
+	if len(unblocked) != 1 || unblocked[0] != blocker.ID {
+		t.Errorf("unblocked notifications = %v, want [%d]", unblocked, blocker.ID)
+	}
+	if got, _ := tm.GetTask(later.ID); got.Status != StatusPending {
+		t.Errorf("failed task status = %s, want pending", got.Status)
+	}
+
+	// 一次撤销恢复整批任务并删除生成的重复任务
+	if _, err := tm.UndoLast(ws.ID, time.Hour, false); err != nil {
+		t.Fatalf("UndoLast: %v", err)
+	}
+	for _, id := range []uint{daily.ID, blocker.ID} {
+		if got, _ := tm.GetTask(id); got.Status != StatusPending {
+			t.Errorf("task %d status after undo = %s, want pending", id, got.Status)
+		}
+	}
+	if _, ok := tm.GetTask(report.Created[0]); ok {
+		t.Errorf("next occurrence %d still exists after undo", report.Created[0])
+	}
+}
+
+func TestBulkUndoIDs(t *testing.T) {
+	tasks := []*Task{{ID: 3, ParentID: 1}, {ID: 4, ParentID: 1}, {ID: 1}, {ID: 5, ParentID: 2}}
+	got := BulkUndoIDs(tasks)
+	want := []uint{3, 4, 1, 5, 2}
+	if len(got) != len(want) {
+		t.Fatalf("BulkUndoIDs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("BulkUndoIDs = %v, want %v", got, want)
+		}
+	}
+}
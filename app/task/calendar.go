@@ -0,0 +1,120 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/869413421/wechatbot/app/datetime"
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// WorkCalendar 工作区的工作日历：默认日历（周末、法定节假日和调休）加上工作区自定义的休息日
+func (tm *TaskManager) WorkCalendar(workspaceID uint) *datetime.Calendar {
+	cal := datetime.DefaultCalendar()
+	if workspaceID == 0 {
+		return cal
+	}
+	var days []NonWorkingDay
+	if err := tm.db.Where("workspace_id = ?", workspaceID).Find(&days).Error; err != nil {
+		log.Printf("ERROR: Failed to load non-working days of workspace %d: %v\n", workspaceID, err)
+		return cal
+	}
+	if len(days) == 0 {
+		return cal
+	}
+	closed := make(map[string]string, len(days))
+	for _, d := range days {
+		closed[d.Date] = d.Name
+	}
+	return cal.WithClosedDays(closed)
+}
+
+// AddNonWorkingDay 为工作区添加自定义休息日，已存在时更新名称
+func (tm *TaskManager) AddNonWorkingDay(workspaceID uint, date time.Time, name string) (*NonWorkingDay, error) {
+	if _, exists := tm.GetWorkspace(workspaceID); !exists {
+		return nil, fmt.Errorf("workspace %d not found", workspaceID)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "休息日"
+	}
+
+	day := NonWorkingDay{WorkspaceID: workspaceID, Date: date.Format(datetime.DateLayout)}
+	err := tm.db.Where("workspace_id = ? AND date = ?", workspaceID, day.Date).First(&day).Error
+	switch {
+	case err == nil:
+		if err := tm.db.Model(&NonWorkingDay{}).Where("id = ?", day.ID).Update("name", name).Error; err != nil {
+			return nil, fmt.Errorf("failed to update non-working day: %v", err)
+		}
+		day.Name = name
+	case err == gorm.ErrRecordNotFound:
+		day.Name = name
+		day.CreatedBy = tm.actor.Name
+		day.CreateTime = time.Now()
+		if err := tm.db.Create(&day).Error; err != nil {
+			return nil, fmt.Errorf("failed to add non-working day: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to get non-working day: %v", err)
+	}
+
+	log.Printf("Workspace %d non-working day %s (%s) saved\n", workspaceID, day.Date, day.Name)
+	return &day, nil
+}
+
+// RemoveNonWorkingDay 删除工作区的自定义休息日
+func (tm *TaskManager) RemoveNonWorkingDay(workspaceID uint, date time.Time) error {
+	key := date.Format(datetime.DateLayout)
+	result := tm.db.Where("workspace_id = ? AND date = ?", workspaceID, key).Delete(&NonWorkingDay{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove non-working day: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s is not a custom non-working day of this workspace", key)
+	}
+	log.Printf("Workspace %d non-working day %s removed\n", workspaceID, key)
+	return nil
+}
+
+// ListNonWorkingDays 工作区从 from 当天起的自定义休息日（按日期排序）
+func (tm *TaskManager) ListNonWorkingDays(workspaceID uint, from time.Time) ([]NonWorkingDay, error) {
+	var days []NonWorkingDay
+	if err := tm.db.Where("workspace_id = ? AND date >= ?", workspaceID, from.Format(datetime.DateLayout)).
+		Order("date ASC").
+		Find(&days).Error; err != nil {
+		return nil, fmt.Errorf("failed to list non-working days: %v", err)
+	}
+	return days, nil
+}
+
+// GetUpcomingTasksInWorkdays 获取工作区内 workdays 个工作日内（到第 workdays 个工作日结束）到期的任务
+// workdays 为 0 表示到今天（今天不上班时为下一个工作日）结束
+func (tm *TaskManager) GetUpcomingTasksInWorkdays(workspaceID uint, workdays int) []*Task {
+	loc := notify.Preference{}.Location()
+	now := time.Now().In(loc)
+	last := tm.WorkCalendar(workspaceID).AddWorkdays(now, workdays)
+	end := startOfDay(last, loc).AddDate(0, 0, 1)
+	return tm.tasksDueBetween(workspaceScope(tm.db.Preload("Dependencies"), workspaceID), now, end)
+}
+
+// GetOverdueTasksByWorkdays 获取工作区内逾期至少 workdays 个工作日的任务（按任务所在工作区的日历计算）
+func (tm *TaskManager) GetOverdueTasksByWorkdays(workspaceID uint, workdays int) []*Task {
+	now := time.Now().In(notify.Preference{}.Location())
+	calendars := make(map[uint]*datetime.Calendar)
+	var result []*Task
+	for _, t := range tm.GetOverdueTasks(workspaceID) {
+		cal, ok := calendars[t.WorkspaceID]
+		if !ok {
+			cal = tm.WorkCalendar(t.WorkspaceID)
+			calendars[t.WorkspaceID] = cal
+		}
+		if cal.WorkdaysBetween(t.DueTime.In(now.Location()), now) >= workdays {
+			result = append(result, t)
+		}
+	}
+	return result
+}
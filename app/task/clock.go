@@ -0,0 +1,22 @@
+package task
+
+import "time"
+
+// Clock 时钟接口，提醒调度器通过它获取当前时间和等待，便于替换为可控的时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock 使用系统时间的时钟
+type SystemClock struct{}
+
+// Now 当前时间
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After 等待 d 后返回
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
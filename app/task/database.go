@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -110,17 +111,86 @@ func InitDatabase() error {
 // autoMigrate 自动迁移数据库表结构
 func autoMigrate() error {
 	// 迁移Task模型
+	hadUpdateTime := db.Migrator().HasTable(&Task{}) && db.Migrator().HasColumn(&Task{}, "update_time")
 	if err := db.AutoMigrate(&Task{}); err != nil {
 		return fmt.Errorf("failed to migrate tasks table: %v", err)
 	}
 	log.Printf("Tasks table migrated\n")
 
+	// 新增修改时间列时，已有任务的修改时间取创建时间
+	if !hadUpdateTime {
+		if err := db.Exec("UPDATE tasks SET update_time = create_time").Error; err != nil {
+			return fmt.Errorf("failed to backfill task update time: %v", err)
+		}
+	}
+
 	// 迁移TaskDependency模型
 	if err := db.AutoMigrate(&TaskDependency{}); err != nil {
 		return fmt.Errorf("failed to migrate task_dependencies table: %v", err)
 	}
 	log.Printf("Task dependencies table migrated\n")
 
+	// 迁移ChecklistItem模型
+	if err := db.AutoMigrate(&ChecklistItem{}); err != nil {
+		return fmt.Errorf("failed to migrate task_checklist_items table: %v", err)
+	}
+	log.Printf("Task checklist items table migrated\n")
+
+	// 迁移TaskLabel和TaskAssignee模型
+	if err := db.AutoMigrate(&TaskLabel{}, &TaskAssignee{}); err != nil {
+		return fmt.Errorf("failed to migrate label and assignee tables: %v", err)
+	}
+	log.Printf("Task label and assignee tables migrated\n")
+
+	// 迁移Workspace和TaskShare模型
+	if err := db.AutoMigrate(&Workspace{}, &TaskShare{}); err != nil {
+		return fmt.Errorf("failed to migrate workspace tables: %v", err)
+	}
+	log.Printf("Workspace tables migrated\n")
+
+	// 迁移TaskActivity模型
+	if err := db.AutoMigrate(&TaskActivity{}); err != nil {
+		return fmt.Errorf("failed to migrate task_activities table: %v", err)
+	}
+	log.Printf("Task activities table migrated\n")
+
+	// 迁移UndoEntry模型
+	if err := db.AutoMigrate(&UndoEntry{}); err != nil {
+		return fmt.Errorf("failed to migrate task_undo_entries table: %v", err)
+	}
+	log.Printf("Task undo entries table migrated\n")
+
+	// 迁移ReminderDelivery、ReminderSnooze和ReminderAck模型
+	if err := db.AutoMigrate(&ReminderDelivery{}, &ReminderSnooze{}, &ReminderAck{}); err != nil {
+		return fmt.Errorf("failed to migrate reminder tables: %v", err)
+	}
+	log.Printf("Task reminder tables migrated\n")
+
+	// 迁移DigestRun模型
+	if err := db.AutoMigrate(&DigestRun{}); err != nil {
+		return fmt.Errorf("failed to migrate task_digest_runs table: %v", err)
+	}
+	log.Printf("Task digest runs table migrated\n")
+
+	// 迁移NonWorkingDay模型
+	if err := db.AutoMigrate(&NonWorkingDay{}); err != nil {
+		return fmt.Errorf("failed to migrate task_non_working_days table: %v", err)
+	}
+	log.Printf("Task non-working days table migrated\n")
+
+	// 旧的24小时提醒阈值改为按提前量命名
+	if err := db.Model(&ReminderDelivery{}).Where("threshold = ?", "upcoming").Update("threshold", reminderThreshold(24*time.Hour)).Error; err != nil {
+		return fmt.Errorf("failed to rename reminder thresholds: %v", err)
+	}
+
+	// 全文索引（失败时搜索退回到 LIKE 匹配）
+	ensureFullTextIndex(db)
+
+	// 补齐工作区前缀和任务编号
+	if err := backfillTaskKeys(db); err != nil {
+		return fmt.Errorf("failed to backfill task keys: %v", err)
+	}
+
 	return nil
 }
 
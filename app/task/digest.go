@@ -0,0 +1,589 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// 摘要类型
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSkipped 没有内容、不需要发送的摘要（其余状态同 Delivery* 常量）
+const DigestSkipped = "skipped"
+
+const (
+	// digestGracePeriod 错过发送时间（如服务重启）后多久以内仍补发，超过后放弃当期摘要
+	digestGracePeriod = 3 * time.Hour
+	// maxDigestSleep 摘要调度器最长的休眠时间（修改摘要时间不会唤醒调度器，定期重新检查）
+	maxDigestSleep = 15 * time.Minute
+	// maxDigestAttempts 一期摘要最多尝试发送的次数
+	maxDigestAttempts = 3
+	// digestListLimit 摘要中每一类最多列出的任务数
+	digestListLimit = 10
+	// weeklyTopContributors 周报中列出完成任务最多的人数
+	weeklyTopContributors = 3
+)
+
+// DigestPolisher 润色摘要文本（通常调用 AI），失败或改动了任务编号时使用模板原文
+type DigestPolisher func(text string) (string, error)
+
+// DailyDigest 用户的每日摘要
+type DailyDigest struct {
+	UserName  string
+	Date      time.Time // 接收方时区的当天零点
+	Now       time.Time // 生成时间
+	DueToday  []*Task   // 今天晚些时候到期
+	Overdue   []*Task   // 已逾期
+	Blocked   []*Task   // 被阻塞（不含已在上面列出的任务）
+	Completed []*Task   // 昨天完成
+}
+
+// Empty 摘要是否没有内容
+func (d *DailyDigest) Empty() bool {
+	return len(d.DueToday) == 0 && len(d.Overdue) == 0 && len(d.Blocked) == 0 && len(d.Completed) == 0
+}
+
+// Contributor 周报中的贡献者
+type Contributor struct {
+	Name      string
+	Completed int
+}
+
+// WeeklyDigest 群工作区的周报
+type WeeklyDigest struct {
+	Workspace    *Workspace
+	Start        time.Time // 统计区间 [Start, End)
+	End          time.Time
+	Created      int
+	Completed    int
+	Overdue      []*Task
+	Contributors []Contributor
+}
+
+// Empty 周报是否没有内容
+func (d *WeeklyDigest) Empty() bool {
+	return d.Created == 0 && d.Completed == 0 && len(d.Overdue) == 0
+}
+
+// digestRecipient 每日摘要的接收人
+type digestRecipient struct {
+	UserName    string
+	WorkspaceID uint // 私聊工作区，0 表示没有（只统计分配给他的任务）
+	Pref        notify.Preference
+	Notice      notify.Notification
+}
+
+// userTaskScope 用户的任务范围：私聊工作区中的任务和分配给他的任务
+func userTaskScope(db *gorm.DB, workspaceID uint, userName string) *gorm.DB {
+	if workspaceID == 0 {
+		return db.Where("tasks.id IN (SELECT task_id FROM task_assignees WHERE user_name = ?)", userName)
+	}
+	return db.Where("(tasks.workspace_id = ? OR tasks.id IN (SELECT task_id FROM task_assignees WHERE user_name = ?))", workspaceID, userName)
+}
+
+// startOfDay loc 时区中 t 所在日期的零点
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// clockOn 日期 day（零点）的 HH:MM 时刻
+func clockOn(day time.Time, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		t, _ = time.Parse("15:04", notify.DefaultDigestTime)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+// BuildDailyDigest 生成用户的每日摘要：今天到期、已逾期、被阻塞的任务和昨天完成的任务
+func (tm *TaskManager) BuildDailyDigest(userName string, workspaceID uint, now time.Time, loc *time.Location) (*DailyDigest, error) {
+	scope := func() *gorm.DB {
+		return userTaskScope(tm.db.Preload("Dependencies"), workspaceID, userName)
+	}
+	today := startOfDay(now, loc)
+	digest := &DailyDigest{
+		UserName: userName,
+		Date:     today,
+		Now:      now,
+		DueToday: tm.tasksDueBetween(scope(), now, today.AddDate(0, 0, 1)),
+		Overdue:  tm.overdueTasks(scope(), now),
+	}
+
+	listed := make(map[uint]bool)
+	for _, t := range append(append([]*Task{}, digest.DueToday...), digest.Overdue...) {
+		listed[t.ID] = true
+	}
+	var blocked []*Task
+	if err := blockedCondition(scope()).Order("tasks.create_time ASC").Find(&blocked).Error; err != nil {
+		return nil, fmt.Errorf("failed to load blocked tasks: %v", err)
+	}
+	for _, t := range blocked {
+		if !listed[t.ID] {
+			digest.Blocked = append(digest.Blocked, t)
+		}
+	}
+	tm.annotate(digest.Blocked)
+
+	if err := scope().
+		Where("tasks.status = ? AND tasks.completed_time >= ? AND tasks.completed_time < ?", StatusCompleted, today.AddDate(0, 0, -1), today).
+		Order("tasks.completed_time ASC").
+		Find(&digest.Completed).Error; err != nil {
+		return nil, fmt.Errorf("failed to load completed tasks: %v", err)
+	}
+	tm.fillKeys(digest.Completed)
+	return digest, nil
+}
+
+// BuildWeeklyDigest 生成群工作区 [start, end) 的周报：完成和新建的任务数、当前逾期的任务和完成任务最多的人
+func (tm *TaskManager) BuildWeeklyDigest(ws *Workspace, start, end time.Time) (*WeeklyDigest, error) {
+	digest := &WeeklyDigest{
+		Workspace: ws,
+		Start:     start,
+		End:       end,
+		Overdue:   tm.GetOverdueTasks(ws.ID),
+	}
+
+	var completed, created int64
+	if err := workspaceScope(tm.db.Model(&Task{}), ws.ID).
+		Where("tasks.status = ? AND tasks.completed_time >= ? AND tasks.completed_time < ?", StatusCompleted, start, end).
+		Count(&completed).Error; err != nil {
+		return nil, fmt.Errorf("failed to count completed tasks: %v", err)
+	}
+	if err := workspaceScope(tm.db.Model(&Task{}), ws.ID).
+		Where("tasks.create_time >= ? AND tasks.create_time < ?", start, end).
+		Count(&created).Error; err != nil {
+		return nil, fmt.Errorf("failed to count created tasks: %v", err)
+	}
+	digest.Completed, digest.Created = int(completed), int(created)
+
+	if err := tm.db.Model(&TaskActivity{}).
+		Select("actor_name AS name, COUNT(*) AS completed").
+		Where("workspace_id = ? AND kind = ? AND new_value = ? AND actor_name <> '' AND create_time >= ? AND create_time < ?",
+			ws.ID, ActivityStatus, StatusCompleted, start, end).
+		Group("actor_name").
+		Order("completed DESC, name ASC").
+		Limit(weeklyTopContributors).
+		Scan(&digest.Contributors).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contributors: %v", err)
+	}
+	return digest, nil
+}
+
+// FormatDailyDigest 格式化每日摘要（时间按接收方时区显示）
+func FormatDailyDigest(d *DailyDigest) string {
+	loc := d.Date.Location()
+	weekdays := []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "☀️ %s 的今日任务摘要（%d月%d日 %s）\n", d.UserName, d.Date.Month(), d.Date.Day(), weekdays[d.Date.Weekday()])
+	writeSection := func(title string, tasks []*Task, markBlocked bool, line func(t *Task) string) {
+		if len(tasks) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s（%d）：\n", title, len(tasks))
+		for i, t := range tasks {
+			if i == digestListLimit {
+				fmt.Fprintf(&b, "… 以及另外 %d 个\n", len(tasks)-digestListLimit)
+				break
+			}
+			fmt.Fprintf(&b, "- %s (ID: %s)%s", t.Title, t.DisplayKey(), line(t))
+			if markBlocked && t.Blocked {
+				b.WriteString(" ⛔被阻塞")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	writeSection("📅 今天到期", d.DueToday, true, func(t *Task) string {
+		return " " + t.DueTime.In(loc).Format("15:04")
+	})
+	writeSection("⚠️ 已逾期", d.Overdue, true, func(t *Task) string {
+		return fmt.Sprintf(" 截止: %s（逾期%s）", t.DueTime.In(loc).Format("01-02 15:04"), formatRemaining(d.Now.Sub(*t.DueTime)))
+	})
+	writeSection("⛔ 被阻塞", d.Blocked, false, func(t *Task) string { return "" })
+	writeSection("✅ 昨天完成", d.Completed, false, func(t *Task) string { return "" })
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatWeeklyDigest 格式化群周报
+func FormatWeeklyDigest(d *WeeklyDigest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 %s 任务周报（%s ~ %s）\n", d.Workspace.Name, d.Start.Format("01-02"), d.End.AddDate(0, 0, -1).Format("01-02"))
+	fmt.Fprintf(&b, "本周完成 %d 个任务，新建 %d 个\n", d.Completed, d.Created)
+
+	if len(d.Contributors) > 0 {
+		parts := make([]string, len(d.Contributors))
+		for i, c := range d.Contributors {
+			parts[i] = fmt.Sprintf("%s %d 个", c.Name, c.Completed)
+		}
+		fmt.Fprintf(&b, "🏆 完成最多: %s\n", strings.Join(parts, "、"))
+	}
+
+	if len(d.Overdue) == 0 {
+		b.WriteString("👍 当前没有逾期任务")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n⚠️ 当前逾期 %d 个：\n", len(d.Overdue))
+	for i, t := range d.Overdue {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "… 以及另外 %d 个\n", len(d.Overdue)-digestListLimit)
+			break
+		}
+		fmt.Fprintf(&b, "- %s (ID: %s) 截止: %s\n", t.Title, t.DisplayKey(), t.DueTime.In(d.Start.Location()).Format("01-02 15:04"))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// polishDigest 润色摘要，润色失败或丢失了任务编号时返回原文
+func polishDigest(text string, tasks []*Task, polish DigestPolisher) string {
+	if polish == nil {
+		return text
+	}
+	polished, err := polish(text)
+	if err != nil {
+		log.Printf("WARNING: Failed to polish digest, using template: %v\n", err)
+		return text
+	}
+	polished = strings.TrimSpace(polished)
+	if polished == "" {
+		return text
+	}
+	for _, t := range tasks {
+		if !strings.Contains(polished, t.DisplayKey()) {
+			log.Printf("WARNING: Polished digest dropped task %s, using template\n", t.DisplayKey())
+			return text
+		}
+	}
+	return polished
+}
+
+// weeklyDigestDay 群周报在星期几发送，关闭时返回 false
+func weeklyDigestDay() (time.Weekday, bool) {
+	raw := strings.ToLower(strings.TrimSpace(config.LoadConfig().Notify.WeeklyDigest))
+	days := map[string]time.Weekday{
+		"": time.Monday, "monday": time.Monday, "周一": time.Monday, "星期一": time.Monday,
+		"tuesday": time.Tuesday, "周二": time.Tuesday, "星期二": time.Tuesday,
+		"wednesday": time.Wednesday, "周三": time.Wednesday, "星期三": time.Wednesday,
+		"thursday": time.Thursday, "周四": time.Thursday, "星期四": time.Thursday,
+		"friday": time.Friday, "周五": time.Friday, "星期五": time.Friday,
+		"saturday": time.Saturday, "周六": time.Saturday, "星期六": time.Saturday,
+		"sunday": time.Sunday, "周日": time.Sunday, "星期日": time.Sunday, "周天": time.Sunday,
+	}
+	day, ok := days[raw]
+	if !ok && raw != "off" {
+		log.Printf("WARNING: Invalid notify.weekly_digest %q, weekly digest disabled\n", raw)
+	}
+	return day, ok
+}
+
+// dailyDigestRecipients 每日摘要的接收人：私聊过机器人的好友和设置过通知偏好的用户（关闭了每日摘要的除外）
+func (tm *TaskManager) dailyDigestRecipients() ([]digestRecipient, error) {
+	var private []*Workspace
+	if err := tm.db.Where("kind = ?", WorkspacePrivate).Order("id ASC").Find(&private).Error; err != nil {
+		return nil, fmt.Errorf("failed to list private workspaces: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var recipients []digestRecipient
+	for _, ws := range private {
+		if ws.Name == "" || seen[ws.Name] {
+			continue
+		}
+		seen[ws.Name] = true
+		recipients = append(recipients, digestRecipient{
+			UserName:    ws.Name,
+			WorkspaceID: ws.ID,
+			Pref:        notify.GetPreference(ws.Name),
+			Notice: notify.Notification{
+				Target:     ws.ChatID,
+				TargetName: ws.Name,
+				Users:      []notify.User{{ID: ws.ChatID, Name: ws.Name}},
+			},
+		})
+	}
+	for _, pref := range notify.ListPreferences() {
+		if seen[pref.UserName] {
+			continue
+		}
+		seen[pref.UserName] = true
+		recipients = append(recipients, digestRecipient{
+			UserName: pref.UserName,
+			Pref:     pref,
+			Notice: notify.Notification{
+				TargetName: pref.UserName,
+				Users:      []notify.User{{Name: pref.UserName}},
+			},
+		})
+	}
+
+	result := recipients[:0]
+	for _, r := range recipients {
+		if !r.Pref.DigestOff {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// claimDigest 获取或创建一期摘要的发送记录，已发送、已跳过、已放弃重试或未到重试时间时返回 nil
+func (tm *TaskManager) claimDigest(kind, recipient, period string, now time.Time) (*DigestRun, error) {
+	run := DigestRun{
+		Kind:       kind,
+		Recipient:  recipient,
+		Period:     period,
+		Status:     DeliveryPending,
+		CreateTime: now,
+	}
+	if err := tm.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create digest run: %v", err)
+	}
+	if err := tm.db.Where("kind = ? AND recipient = ? AND period = ?", kind, recipient, period).First(&run).Error; err != nil {
+		return nil, fmt.Errorf("failed to load digest run: %v", err)
+	}
+
+	if run.Status == DeliverySent || run.Status == DigestSkipped || run.Attempts >= maxDigestAttempts {
+		return nil, nil
+	}
+	if run.NextAttemptTime != nil && run.NextAttemptTime.After(now) {
+		return nil, nil
+	}
+	return &run, nil
+}
+
+// finishDigest 记录摘要的发送结果，失败时安排下一次重试
+func (tm *TaskManager) finishDigest(run *DigestRun, status string, sendErr error, now time.Time) {
+	updates := map[string]interface{}{"status": status, "attempts": run.Attempts + 1}
+	if sendErr == nil {
+		updates["sent_time"] = &now
+		updates["next_attempt_time"] = nil
+		updates["last_error"] = ""
+	} else {
+		retry := now.Add(reminderRetryBase << uint(run.Attempts))
+		updates["status"] = DeliveryFailed
+		updates["next_attempt_time"] = &retry
+		updates["last_error"] = sendErr.Error()
+	}
+	if err := tm.db.Model(&DigestRun{}).Where("id = ?", run.ID).Updates(updates).Error; err != nil {
+		log.Printf("ERROR: Failed to record digest run %d: %v\n", run.ID, err)
+	}
+}
+
+// sendDigest 领取并发送一期摘要，build 返回摘要文本和其中列出的任务（文本为空表示没有内容）
+func (tm *TaskManager) sendDigest(kind, recipient, period string, notice notify.Notification, now time.Time,
+	build func() (string, []*Task, error), send ReminderSender, polish DigestPolisher) error {
+	run, err := tm.claimDigest(kind, recipient, period, now)
+	if err != nil || run == nil {
+		return err
+	}
+
+	text, tasks, err := build()
+	if err != nil {
+		tm.finishDigest(run, DeliveryFailed, err, now)
+		return err
+	}
+	if text == "" {
+		tm.finishDigest(run, DigestSkipped, nil, now)
+		return nil
+	}
+
+	notice.Text = polishDigest(text, tasks, polish)
+	sendErr := send(notice)
+	tm.finishDigest(run, DeliverySent, sendErr, now)
+	if sendErr != nil {
+		return fmt.Errorf("failed to send %s digest to %s: %v", kind, recipient, sendErr)
+	}
+	log.Printf("Sent %s digest to %s for %s\n", kind, recipient, period)
+	return nil
+}
+
+// DeliverDueDigests 发送到了发送时间的每日摘要和群周报，单个接收方失败不影响其他接收方
+func (tm *TaskManager) DeliverDueDigests(now time.Time, send ReminderSender, polish DigestPolisher) error {
+	recipients, err := tm.dailyDigestRecipients()
+	if err != nil {
+		return err
+	}
+	for _, r := range recipients {
+		r := r
+		loc := r.Pref.Location()
+		today := startOfDay(now, loc)
+		at := clockOn(today, r.Pref.EffectiveDigestTime())
+		if now.Before(at) || !now.Before(at.Add(digestGracePeriod)) {
+			continue
+		}
+		build := func() (string, []*Task, error) {
+			digest, err := tm.BuildDailyDigest(r.UserName, r.WorkspaceID, now, loc)
+			if err != nil || digest.Empty() {
+				return "", nil, err
+			}
+			var tasks []*Task
+			for _, list := range [][]*Task{digest.DueToday, digest.Overdue, digest.Blocked, digest.Completed} {
+				if len(list) > digestListLimit {
+					list = list[:digestListLimit]
+				}
+				tasks = append(tasks, list...)
+			}
+			return FormatDailyDigest(digest), tasks, nil
+		}
+		if err := tm.sendDigest(DigestDaily, "user:"+r.UserName, today.Format("2006-01-02"), r.Notice, now, build, send, polish); err != nil {
+			log.Printf("ERROR: %v\n", err)
+		}
+	}
+
+	weekday, ok := weeklyDigestDay()
+	if !ok {
+		return nil
+	}
+	defaults := notify.Preference{}
+	today := startOfDay(now, defaults.Location())
+	at := clockOn(today, defaults.EffectiveDigestTime())
+	if today.Weekday() != weekday || now.Before(at) || !now.Before(at.Add(digestGracePeriod)) {
+		return nil
+	}
+	for _, ws := range tm.ListGroupWorkspaces() {
+		ws := ws
+		build := func() (string, []*Task, error) {
+			digest, err := tm.BuildWeeklyDigest(ws, today.AddDate(0, 0, -7), today)
+			if err != nil || digest.Empty() {
+				return "", nil, err
+			}
+			tasks := digest.Overdue
+			if len(tasks) > digestListLimit {
+				tasks = tasks[:digestListLimit]
+			}
+			return FormatWeeklyDigest(digest), tasks, nil
+		}
+		notice := notify.Notification{WorkspaceID: ws.ID, Target: ws.ChatID, TargetName: ws.Name, IsGroup: true}
+		if err := tm.sendDigest(DigestWeekly, fmt.Sprintf("workspace:%d", ws.ID), today.Format("2006-01-02"), notice, now, build, send, polish); err != nil {
+			log.Printf("ERROR: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// NextDigestTime 计算下一次需要发送摘要（或重试）的时间
+func (tm *TaskManager) NextDigestTime(now time.Time) (time.Time, bool, error) {
+	var next time.Time
+	consider := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	nextAt := func(loc *time.Location, clock string) time.Time {
+		at := clockOn(startOfDay(now, loc), clock)
+		if !at.After(now) {
+			at = clockOn(startOfDay(now, loc).AddDate(0, 0, 1), clock)
+		}
+		return at
+	}
+
+	recipients, err := tm.dailyDigestRecipients()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for _, r := range recipients {
+		consider(nextAt(r.Pref.Location(), r.Pref.EffectiveDigestTime()))
+	}
+	if weekday, ok := weeklyDigestDay(); ok {
+		defaults := notify.Preference{}
+		at := nextAt(defaults.Location(), defaults.EffectiveDigestTime())
+		for at.Weekday() != weekday {
+			at = clockOn(startOfDay(at, at.Location()).AddDate(0, 0, 1), defaults.EffectiveDigestTime())
+		}
+		consider(at)
+	}
+
+	var retries []time.Time
+	if err := tm.db.Model(&DigestRun{}).
+		Where("status = ? AND attempts < ? AND next_attempt_time > ?", DeliveryFailed, maxDigestAttempts, now).
+		Order("next_attempt_time ASC").
+		Limit(1).
+		Pluck("next_attempt_time", &retries).Error; err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to load digest retries: %v", err)
+	}
+	if len(retries) > 0 {
+		consider(retries[0])
+	}
+	return next, !next.IsZero(), nil
+}
+
+// DigestScheduler 摘要调度器：休眠到下一次摘要的发送时间发送
+type DigestScheduler struct {
+	tm     *TaskManager
+	send   ReminderSender
+	polish DigestPolisher
+	clock  Clock
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewDigestScheduler 创建摘要调度器，polish 为 nil 时直接使用模板
+func NewDigestScheduler(tm *TaskManager, send ReminderSender, polish DigestPolisher, clock Clock) *DigestScheduler {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &DigestScheduler{
+		tm:     tm,
+		send:   send,
+		polish: polish,
+		clock:  clock,
+		stop:   make(chan struct{}),
+	}
+}
+
+// StartDigestService 启动每日摘要和群周报服务
+func StartDigestService(send ReminderSender, polish DigestPolisher) *DigestScheduler {
+	s := NewDigestScheduler(GetTaskManager(), send, polish, SystemClock{})
+	go s.Run()
+	return s
+}
+
+// Stop 停止调度器
+func (s *DigestScheduler) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// Run 调度循环，直到 Stop 被调用
+func (s *DigestScheduler) Run() {
+	log.Printf("Digest scheduler started\n")
+	for {
+		select {
+		case <-s.clock.After(s.RunOnce()):
+		case <-s.stop:
+			log.Printf("Digest scheduler stopped\n")
+			return
+		}
+	}
+}
+
+// RunOnce 发送当前到期的摘要，返回距离下一次检查的等待时间
+func (s *DigestScheduler) RunOnce() time.Duration {
+	if err := s.tm.DeliverDueDigests(s.clock.Now(), s.send, s.polish); err != nil {
+		log.Printf("ERROR: Failed to deliver digests: %v\n", err)
+		return schedulerErrorSleep
+	}
+
+	now := s.clock.Now()
+	next, ok, err := s.tm.NextDigestTime(now)
+	if err != nil {
+		log.Printf("ERROR: Failed to compute next digest time: %v\n", err)
+		return schedulerErrorSleep
+	}
+	wait := maxDigestSleep
+	if ok && next.Sub(now) < wait {
+		wait = next.Sub(now)
+	}
+	return wait
+}
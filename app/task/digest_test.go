@@ -0,0 +1,195 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTaskTimes 直接修改任务的截止和完成时间（绕过不能设置过去时间等校验）
+func setTaskTimes(t *testing.T, tm *TaskManager, id uint, due, completed *time.Time) {
+	t.Helper()
+	updates := map[string]interface{}{"due_time": due}
+	if completed != nil {
+		updates["status"] = StatusCompleted
+		updates["completed_time"] = completed
+	}
+	if err := tm.db.Model(&Task{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildDailyDigest(t *testing.T) {
+	tm := newTestManager(t)
+	alice := mustWorkspace(t, tm, "@alice", "alice", false)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	now := startOfDay(time.Now(), time.Local).Add(9 * time.Hour)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	dueToday := mustTask(t, tm, alice.ID, "今天到期", nil)
+	setTaskTimes(t, tm, dueToday.ID, at(3*time.Hour), nil)
+	overdue := mustTask(t, tm, alice.ID, "已逾期", nil)
+	setTaskTimes(t, tm, overdue.ID, at(-26*time.Hour), nil)
+	tomorrow := mustTask(t, tm, alice.ID, "明天到期", nil)
+	setTaskTimes(t, tm, tomorrow.ID, at(20*time.Hour), nil)
+	blocker := mustTask(t, tm, ops.ID, "评审", nil)
+	blocked := mustTask(t, tm, alice.ID, "发布", nil, blocker.ID)
+	// 被阻塞又已逾期的任务只在已逾期中列出
+	blockedOverdue := mustTask(t, tm, alice.ID, "上线", nil, blocker.ID)
+	setTaskTimes(t, tm, blockedOverdue.ID, at(-time.Hour), nil)
+	doneYesterday := mustTask(t, tm, alice.ID, "昨天完成", nil)
+	setTaskTimes(t, tm, doneYesterday.ID, nil, at(-12*time.Hour))
+	doneEarlier := mustTask(t, tm, alice.ID, "前天完成", nil)
+	setTaskTimes(t, tm, doneEarlier.ID, nil, at(-40*time.Hour))
+
+	// 群里分配给 alice 的任务也在范围内，没分配给她的不在
+	assigned := mustTask(t, tm, ops.ID, "群里分配给 alice", nil)
+	setTaskTimes(t, tm, assigned.ID, at(time.Hour), nil)
+	if err := tm.UpdateTaskAssignees(assigned.ID, []Actor{{ID: "@alice", Name: "alice"}}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	other := mustTask(t, tm, ops.ID, "群里的其他任务", nil)
+	setTaskTimes(t, tm, other.ID, at(time.Hour), nil)
+
+	digest, err := tm.BuildDailyDigest("alice", alice.ID, now, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := []struct {
+		name  string
+		tasks []*Task
+		want  []string
+	}{
+		{"DueToday", digest.DueToday, []string{"群里分配给 alice", "今天到期"}},
+		{"Overdue", digest.Overdue, []string{"已逾期", "上线"}},
+		{"Blocked", digest.Blocked, []string{"发布"}},
+		{"Completed", digest.Completed, []string{"昨天完成"}},
+	}
+	for _, s := range sections {
+		var got []string
+		for _, task := range s.tasks {
+			got = append(got, task.Title)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(s.want) {
+			t.Errorf("%s = %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	text := FormatDailyDigest(digest)
+	for _, want := range []string{"📅 今天到期（2）", "⚠️ 已逾期（2）", "⛔ 被阻塞（1）", "✅ 昨天完成（1）", "发布 (ID: " + tm.TaskKey(blocked.ID) + ")\n", "上线 (ID: " + tm.TaskKey(blockedOverdue.ID) + ") 截止"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("digest text does not contain %q:\n%s", want, text)
+		}
+	}
+
+	empty, err := tm.BuildDailyDigest("bob", 0, now, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.Empty() {
+		t.Errorf("digest of a user without tasks is not empty: %+v", empty)
+	}
+}
+
+func TestBuildWeeklyDigest(t *testing.T) {
+	tm := newTestManager(t)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	end := startOfDay(time.Now(), time.Local).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -7)
+
+	for i, actor := range []string{"alice", "bob", "alice", "carol"} {
+		task := mustTask(t, tm, ops.ID, fmt.Sprintf("任务%d", i+1), nil)
+		if err := tm.WithActor(Actor{ID: "@" + actor, Name: actor}).TransitionTaskStatus(task.ID, StatusCompleted, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overdue := mustTask(t, tm, ops.ID, "逾期任务", nil)
+	past := time.Now().Add(-time.Hour)
+	setTaskTimes(t, tm, overdue.ID, &past, nil)
+	old := mustTask(t, tm, ops.ID, "上周创建", nil)
+	if err := tm.db.Model(&Task{}).Where("id = ?", old.ID).Update("create_time", start.Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := tm.BuildWeeklyDigest(ops, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.Completed != 4 || digest.Created != 5 || len(digest.Overdue) != 1 {
+		t.Errorf("completed %d, created %d, overdue %d, want 4, 5, 1", digest.Completed, digest.Created, len(digest.Overdue))
+	}
+	if got := fmt.Sprint(digest.Contributors); got != "[{alice 2} {bob 1} {carol 1}]" {
+		t.Errorf("contributors = %s, want alice 2, bob 1, carol 1", got)
+	}
+	if text := FormatWeeklyDigest(digest); !strings.Contains(text, "本周完成 4 个任务，新建 5 个") || !strings.Contains(text, "alice 2 个") {
+		t.Errorf("weekly digest text:\n%s", text)
+	}
+}
+
+func TestPolishDigest(t *testing.T) {
+	tasks := []*Task{{Key: "OPS-1"}, {Key: "OPS-2"}}
+	text := "模板 OPS-1 OPS-2"
+	tests := []struct {
+		name   string
+		polish DigestPolisher
+		want   string
+	}{
+		{"不润色", nil, text},
+		{"润色失败", func(string) (string, error) { return "", fmt.Errorf("timeout") }, text},
+		{"返回空白", func(string) (string, error) { return "  ", nil }, text},
+		{"丢了任务编号", func(string) (string, error) { return "润色 OPS-1", nil }, text},
+		{"润色成功", func(string) (string, error) { return " 润色 OPS-1 和 OPS-2\n", nil }, "润色 OPS-1 和 OPS-2"},
+	}
+	for _, tt := range tests {
+		if got := polishDigest(text, tasks, tt.polish); got != tt.want {
+			t.Errorf("%s: polishDigest = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClaimDigest(t *testing.T) {
+	tm := newTestManager(t)
+	now := time.Now().Truncate(time.Second)
+
+	run, err := tm.claimDigest(DigestDaily, "user:alice", "2026-10-14", now)
+	if err != nil || run == nil {
+		t.Fatalf("first claim = %v, %v, want a run", run, err)
+	}
+	tm.finishDigest(run, DeliverySent, fmt.Errorf("bot is not logged in"), now)
+
+	steps := []struct {
+		after time.Duration
+		claim bool
+	}{
+		{0, false},                     // 重试时间之前
+		{reminderRetryBase, true},      // 第一次重试
+		{reminderRetryBase * 2, false}, // 第二次重试的时间还没到
+		{reminderRetryBase * 3, true},
+	}
+	for _, step := range steps {
+		run, err := tm.claimDigest(DigestDaily, "user:alice", "2026-10-14", now.Add(step.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (run != nil) != step.claim {
+			t.Fatalf("claim at +%s = %v, want claimed %v", step.after, run, step.claim)
+		}
+		if run != nil {
+			tm.finishDigest(run, DeliverySent, fmt.Errorf("bot is not logged in"), now.Add(step.after))
+		}
+	}
+	// 已经尝试了 maxDigestAttempts 次，放弃这一期
+	if run, _ := tm.claimDigest(DigestDaily, "user:alice", "2026-10-14", now.Add(time.Hour)); run != nil {
+		t.Errorf("claimed after %d failed attempts", maxDigestAttempts)
+	}
+
+	run, _ = tm.claimDigest(DigestDaily, "user:alice", "2026-10-15", now)
+	tm.finishDigest(run, DeliverySent, nil, now)
+	if again, _ := tm.claimDigest(DigestDaily, "user:alice", "2026-10-15", now.Add(time.Hour)); again != nil {
+		t.Error("claimed a digest that was already sent")
+	}
+}
@@ -0,0 +1,366 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// 逾期升级的通知对象
+const (
+	EscalateAssignee = "assignee" // 再次提醒负责人（群工作区在群里 @ 负责人，私聊工作区发给好友）
+	EscalateAdmin    = "admin"    // 在群里 @ 群管理员
+)
+
+// MaxEscalationSteps 升级策略最多的步骤数
+const MaxEscalationSteps = 5
+
+// EscalationStep 逾期升级的一个步骤：逾期 After 后通知 Target
+type EscalationStep struct {
+	After  time.Duration
+	Target string
+}
+
+// ParseEscalationPolicy 解析逾期升级策略，逗号分隔，如 "1d,3d:admin"（默认通知负责人，也支持 3天:管理员）
+// 返回按逾期时长从小到大排列的步骤；到期时的提醒总会发送，不需要写在策略中
+func ParseEscalationPolicy(s string) ([]EscalationStep, error) {
+	seen := make(map[time.Duration]bool)
+	var steps []EscalationStep
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' || r == '、' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		step := EscalationStep{Target: EscalateAssignee}
+		if i := strings.IndexAny(part, ":："); i >= 0 {
+			_, size := utf8.DecodeRuneInString(part[i:])
+			target, err := parseEscalationTarget(part[i+size:])
+			if err != nil {
+				return nil, err
+			}
+			step.Target = target
+			part = part[:i]
+		}
+		after, err := parseReminderOffset(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(part), "逾期"), "后"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid escalation step %q, use forms like 1d or 3d:admin", part)
+		}
+		if after <= 0 {
+			return nil, fmt.Errorf("escalation steps must be after the due time (the due-time reminder is always sent)")
+		}
+		if after > MaxReminderOffset {
+			return nil, fmt.Errorf("escalation step %s is too late, at most %s after the due time", formatDurationShort(after), formatDurationShort(MaxReminderOffset))
+		}
+		if seen[after] {
+			return nil, fmt.Errorf("duplicate escalation step %s", formatDurationShort(after))
+		}
+		seen[after] = true
+		step.After = after
+		steps = append(steps, step)
+	}
+	if len(steps) > MaxEscalationSteps {
+		return nil, fmt.Errorf("at most %d escalation steps are allowed", MaxEscalationSteps)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].After < steps[j].After })
+	return steps, nil
+}
+
+// parseEscalationTarget 解析升级步骤的通知对象
+func parseEscalationTarget(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", EscalateAssignee, "负责人":
+		return EscalateAssignee, nil
+	case EscalateAdmin, "管理员", "群管理员", "群主":
+		return EscalateAdmin, nil
+	}
+	return "", fmt.Errorf("invalid escalation target %q, use assignee or admin", s)
+}
+
+// encodeEscalationPolicy 将升级策略编码为存储格式（逾期分钟数，通知管理员时加 :admin）
+func encodeEscalationPolicy(steps []EscalationStep) string {
+	parts := make([]string, len(steps))
+	for i, step := range steps {
+		parts[i] = strconv.Itoa(int(step.After / time.Minute))
+		if step.Target == EscalateAdmin {
+			parts[i] += ":" + EscalateAdmin
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// EscalationSteps 工作区的逾期升级步骤，没有设置或格式错误时返回 nil
+func (ws *Workspace) EscalationSteps() []EscalationStep {
+	if ws.EscalationPolicy == "" {
+		return nil
+	}
+	var steps []EscalationStep
+	for _, part := range strings.Split(ws.EscalationPolicy, ",") {
+		minutes, target := part, EscalateAssignee
+		if i := strings.Index(part, ":"); i >= 0 {
+			minutes, target = part[:i], part[i+1:]
+		}
+		n, err := strconv.Atoi(minutes)
+		if err != nil || n <= 0 {
+			log.Printf("WARNING: Invalid escalation policy %q of workspace %d\n", ws.EscalationPolicy, ws.ID)
+			return nil
+		}
+		steps = append(steps, EscalationStep{After: time.Duration(n) * time.Minute, Target: target})
+	}
+	return steps
+}
+
+// EscalationAdminList 升级到管理员时 @ 的群成员
+func (ws *Workspace) EscalationAdminList() []string {
+	if ws.EscalationAdmins == "" {
+		return nil
+	}
+	return strings.Split(ws.EscalationAdmins, ",")
+}
+
+// FormatEscalationPolicy 格式化工作区的逾期升级策略
+func FormatEscalationPolicy(ws *Workspace) string {
+	steps := ws.EscalationSteps()
+	if len(steps) == 0 {
+		return "未设置（只在到期时提醒一次）"
+	}
+	parts := []string{"到期时提醒负责人"}
+	for _, step := range steps {
+		if step.Target == EscalateAdmin {
+			parts = append(parts, fmt.Sprintf("逾期%s @管理员（%s）", formatDurationShort(step.After), strings.Join(ws.EscalationAdminList(), "、")))
+		} else {
+			parts = append(parts, fmt.Sprintf("逾期%s再次提醒负责人", formatDurationShort(step.After)))
+		}
+	}
+	return strings.Join(parts, " → ")
+}
+
+// SetEscalationPolicy 设置工作区的逾期升级策略，steps 为空表示关闭升级
+// 通知管理员的步骤只能用于群工作区，并且需要指定管理员
+func (tm *TaskManager) SetEscalationPolicy(workspaceID uint, steps []EscalationStep, admins []string) error {
+	ws, exists := tm.GetWorkspace(workspaceID)
+	if !exists {
+		return fmt.Errorf("workspace %d not found", workspaceID)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range admins {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, step := range steps {
+		if step.Target != EscalateAdmin {
+			continue
+		}
+		if ws.Kind != WorkspaceGroup {
+			return fmt.Errorf("escalating to admins is only available in group workspaces")
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("admins to mention are required for admin escalation steps")
+		}
+	}
+
+	updates := map[string]interface{}{
+		"escalation_policy": encodeEscalationPolicy(steps),
+		"escalation_admins": strings.Join(names, ","),
+	}
+	if err := tm.db.Model(&Workspace{}).Where("id = ?", workspaceID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to set escalation policy: %v", err)
+	}
+
+	log.Printf("Workspace %d escalation policy set to %q\n", workspaceID, updates["escalation_policy"])
+	wakeReminderScheduler()
+	return nil
+}
+
+// escalationThreshold 升级步骤对应的提醒阈值名称（按逾期时长命名，修改策略后相同时长的步骤不会重复发送）
+func escalationThreshold(step EscalationStep) string {
+	return fmt.Sprintf("escalate_%dm", int(step.After/time.Minute))
+}
+
+// dueEscalation 任务在 now 时已到时间的最近一个升级步骤
+func dueEscalation(t *Task, steps []EscalationStep, now time.Time) (EscalationStep, bool) {
+	var due EscalationStep
+	ok := false
+	for _, step := range steps {
+		if !t.DueTime.Add(step.After).After(now) {
+			due, ok = step, true
+		}
+	}
+	return due, ok
+}
+
+// escalationWorkspaces 设置了逾期升级策略的工作区
+func (tm *TaskManager) escalationWorkspaces() ([]*Workspace, error) {
+	var workspaces []*Workspace
+	if err := tm.db.Where("escalation_policy <> ''").Order("id ASC").Find(&workspaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to load escalation policies: %v", err)
+	}
+	return workspaces, nil
+}
+
+// escalationCandidates 工作区中已到第一个升级步骤、最后一个步骤尚未完成的未完成任务
+// 策略中只有提醒负责人的步骤时，已确认（“知道了”）的任务不再升级
+func (tm *TaskManager) escalationCandidates(ws *Workspace, steps []EscalationStep, now time.Time) ([]*Task, error) {
+	last := steps[len(steps)-1]
+	var tasks []*Task
+	query := tm.db.
+		Where("workspace_id = ? AND status NOT IN ? AND due_time IS NOT NULL AND due_time <= ?",
+			ws.ID, []string{StatusCompleted, StatusCancelled}, now.Add(-steps[0].After)).
+		Where("NOT EXISTS (SELECT 1 FROM task_reminder_deliveries d WHERE d.task_id = tasks.id AND d.threshold = ? AND d.due_time = tasks.due_time AND (d.status = ? OR d.attempts >= ?))",
+			escalationThreshold(last), DeliverySent, MaxReminderAttempts)
+	if !hasAdminStep(steps) {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM task_reminder_acks a WHERE a.task_id = tasks.id AND a.due_time = tasks.due_time)")
+	}
+	if err := query.Order("due_time ASC").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load escalation candidates: %v", err)
+	}
+	tm.fillKeys(tasks)
+	return tasks, nil
+}
+
+// hasAdminStep 升级策略中是否有通知管理员的步骤
+func hasAdminStep(steps []EscalationStep) bool {
+	for _, step := range steps {
+		if step.Target == EscalateAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverEscalations 按工作区的升级策略发送逾期升级通知
+// 每个任务在同一截止时间内每个步骤只发送一次；任务完成后不再升级，截止时间修改后从头开始；错过的多个步骤只补发最近的一个
+// 再次提醒负责人的步骤和普通提醒一样：确认后不再发送，稍后提醒时间之前的步骤跳过；通知管理员的步骤不受影响
+func (tm *TaskManager) deliverEscalations(now time.Time, send ReminderSender) error {
+	workspaces, err := tm.escalationWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	for _, ws := range workspaces {
+		steps := ws.EscalationSteps()
+		if len(steps) == 0 {
+			continue
+		}
+		tasks, err := tm.escalationCandidates(ws, steps, now)
+		if err != nil {
+			return err
+		}
+		acked, err := tm.acknowledgedTasks(tasks)
+		if err != nil {
+			return err
+		}
+		snoozedUntil, err := tm.snoozeHorizons(tasks)
+		if err != nil {
+			return err
+		}
+
+		// 同一工作区、同一通知对象的升级合并成一条消息
+		byTarget := make(map[string][]pendingReminder)
+		notices := make(map[string]notify.Notification)
+		for _, t := range tasks {
+			step, ok := dueEscalation(t, steps, now)
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("workspace:%d", ws.ID)
+			if step.Target == EscalateAssignee {
+				if acked[t.ID] {
+					continue
+				}
+				if until, snoozed := snoozedUntil[snoozeKey(t.ID, *t.DueTime, key)]; snoozed && !t.DueTime.Add(step.After).After(until) {
+					continue
+				}
+			}
+			notice := tm.NotificationFor(t)
+			if step.Target == EscalateAdmin {
+				key = fmt.Sprintf("admins:%d", ws.ID)
+				notice.Users = nil
+				for _, name := range ws.EscalationAdminList() {
+					notice.Users = append(notice.Users, notify.User{Name: name})
+				}
+			}
+			d, err := tm.claimDelivery(t, escalationThreshold(step), key, now)
+			if err != nil {
+				return err
+			}
+			if d == nil {
+				continue
+			}
+			if existing, seen := notices[key]; seen {
+				notice.Users = mergeUsers(existing.Users, notice.Users)
+			}
+			notices[key] = notice
+			byTarget[key] = append(byTarget[key], pendingReminder{Delivery: d, Task: t})
+		}
+
+		for key, items := range byTarget {
+			notice := notices[key]
+			notice.Text = tm.formatEscalation(items, strings.HasPrefix(key, "admins:"), now)
+			err := send(notice)
+			tm.finishDeliveries(items, err, now)
+			if err != nil {
+				log.Printf("Failed to send %d escalation(s) to %s: %v\n", len(items), key, err)
+			} else {
+				log.Printf("Sent %d escalation(s) to %s\n", len(items), key)
+			}
+		}
+	}
+	return nil
+}
+
+// formatEscalation 格式化逾期升级消息
+func (tm *TaskManager) formatEscalation(items []pendingReminder, toAdmins bool, now time.Time) string {
+	var b strings.Builder
+	if toAdmins {
+		b.WriteString("🚨 以下任务逾期仍未完成，请关注：\n")
+	} else {
+		b.WriteString("⏫ 以下任务已逾期，请尽快处理：\n")
+	}
+	for _, p := range items {
+		tm.writeReminderLine(&b, p.Task, fmt.Sprintf("（已逾期%s）", formatRemaining(now.Sub(*p.Task.DueTime))))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// nextEscalationTime now 之后最早的逾期升级时间
+func (tm *TaskManager) nextEscalationTime(now time.Time) (time.Time, bool, error) {
+	workspaces, err := tm.escalationWorkspaces()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var next time.Time
+	for _, ws := range workspaces {
+		steps := ws.EscalationSteps()
+		if len(steps) == 0 {
+			continue
+		}
+		var tasks []*Task
+		if err := tm.db.Select("id", "due_time").
+			Where("workspace_id = ? AND status NOT IN ? AND due_time IS NOT NULL AND due_time > ?",
+				ws.ID, []string{StatusCompleted, StatusCancelled}, now.Add(-steps[len(steps)-1].After)).
+			Find(&tasks).Error; err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to load escalation times: %v", err)
+		}
+		for _, t := range tasks {
+			for _, step := range steps {
+				fire := t.DueTime.Add(step.After)
+				if fire.After(now) && (next.IsZero() || fire.Before(next)) {
+					next = fire
+				}
+			}
+		}
+	}
+	return next, !next.IsZero(), nil
+}
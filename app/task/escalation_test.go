@@ -0,0 +1,121 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscalationRespectsAckAndSnooze(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply func(tm *TaskManager, batch *ReminderBatch, now time.Time) error
+		want  []string // 逾期 1 小时和 2 小时时发出的消息
+	}{
+		{"没有回复", nil, []string{"workspace", "admins"}},
+		{"知道了", func(tm *TaskManager, batch *ReminderBatch, now time.Time) error {
+			return tm.AcknowledgeReminder(batch, now)
+		}, []string{"admins"}},
+		{"稍后提醒", func(tm *TaskManager, batch *ReminderBatch, now time.Time) error {
+			return tm.SnoozeReminder(batch, now.Add(90*time.Minute), now)
+		}, []string{"snooze", "admins"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTestManager(t)
+			ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+			steps := []EscalationStep{{After: time.Hour, Target: EscalateAssignee}, {After: 2 * time.Hour, Target: EscalateAdmin}}
+			if err := tm.SetEscalationPolicy(ws.ID, steps, []string{"老板"}); err != nil {
+				t.Fatal(err)
+			}
+			start := schedulerStart()
+			mustDueTask(t, tm, ws.ID, "发布", start)
+
+			sender := newRecordingSender()
+			if err := tm.DeliverDueReminders(start, sender.send); err != nil {
+				t.Fatal(err)
+			}
+			if sender.count() != 1 {
+				t.Fatalf("sent %d reminders at the due time, want 1", sender.count())
+			}
+			if tt.reply != nil {
+				batch, err := tm.LastUnrepliedReminder(ws.ID, "", start)
+				if err != nil || batch == nil {
+					t.Fatalf("LastUnrepliedReminder = %v, %v", batch, err)
+				}
+				if err := tt.reply(tm, batch, start); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, after := range []time.Duration{time.Hour, 90 * time.Minute, 2 * time.Hour} {
+				if err := tm.DeliverDueReminders(start.Add(after), sender.send); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			for _, n := range sender.sent[1:] {
+				switch {
+				case len(n.Users) == 1 && n.Users[0].Name == "老板":
+					got = append(got, "admins")
+				case strings.Contains(n.Text, "已逾期，请尽快处理"):
+					got = append(got, "workspace")
+				default:
+					got = append(got, "snooze")
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("messages after the due time = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("messages after the due time = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParseEscalationPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string // 编码后的存储格式
+		wantErr string // 错误信息中应包含的内容
+	}{
+		{"1d,3d:admin", "1440,4320:admin", ""},
+		{"3天:管理员，1天", "1440,4320:admin", ""},
+		{"逾期2小时后：负责人、逾期1天后:群主", "120,1440:admin", ""},
+		{"30m; 1h:assignee", "30,60", ""},
+		{"", "", ""},
+		{"1d:boss", "", "invalid escalation target"},
+		{"soon", "", "use forms like 1d or 3d:admin"},
+		{"0", "", "must be after the due time"},
+		{"31d", "", "too late"},
+		{"1d,24h", "", "duplicate escalation step"},
+		{"1h,2h,3h,4h,5h,6h", "", "at most 5 escalation steps"},
+	}
+	for _, tt := range tests {
+		steps, err := ParseEscalationPolicy(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseEscalationPolicy(%q) error = %v, want it to mention %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEscalationPolicy(%q) error: %v", tt.input, err)
+			continue
+		}
+		got := encodeEscalationPolicy(steps)
+		if got != tt.want {
+			t.Errorf("ParseEscalationPolicy(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+		// 存储格式能还原出同样的步骤
+		ws := &Workspace{EscalationPolicy: got}
+		if decoded := encodeEscalationPolicy(ws.EscalationSteps()); decoded != got {
+			t.Errorf("EscalationSteps(%s) round trip = %s", got, decoded)
+		}
+	}
+}
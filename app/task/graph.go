@@ -0,0 +1,533 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// taskGraph 加载到内存中的任务依赖图
+type taskGraph struct {
+	nodes      map[uint]*Task
+	deps       map[uint][]uint // 任务 -> 前置依赖
+	dependents map[uint][]uint // 任务 -> 依赖它的任务
+	visible    map[uint]bool   // 在当前工作区可见的任务，nil 表示不限制
+}
+
+// 依赖链上其他工作区中不可见任务的占位编号和标题
+const (
+	hiddenTaskKey   = "-"
+	hiddenTaskTitle = "（其他工作区的任务）"
+)
+
+// ScheduledTask 依赖图分析中的任务排期结果
+type ScheduledTask struct {
+	Task   *Task
+	Start  time.Time // 最早开始时间
+	Finish time.Time // 预计完成时间
+	AtRisk bool      // 预计完成时间晚于截止时间
+}
+
+// loadGraph 加载 seeds 所在的依赖连通分量：从 seeds 出发沿依赖关系向上下游逐层扩展（每层一次查询），只加载涉及的任务
+func (tm *TaskManager) loadGraph(seeds ...uint) (*taskGraph, error) {
+	reached := make(map[uint]bool, len(seeds))
+	var frontier []uint
+	for _, id := range seeds {
+		if id != 0 && !reached[id] {
+			reached[id] = true
+			frontier = append(frontier, id)
+		}
+	}
+
+	var edges []TaskDependency
+	seen := make(map[TaskDependency]bool)
+	for len(frontier) > 0 {
+		var layer []TaskDependency
+		if err := tm.db.Where("task_id IN ? OR dependency_id IN ?", frontier, frontier).Find(&layer).Error; err != nil {
+			return nil, fmt.Errorf("failed to load task dependencies: %v", err)
+		}
+		var next []uint
+		for _, e := range layer {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			edges = append(edges, e)
+			for _, id := range []uint{e.TaskID, e.DependencyID} {
+				if !reached[id] {
+					reached[id] = true
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	var tasks []*Task
+	if len(reached) > 0 {
+		ids := make([]uint, 0, len(reached))
+		for id := range reached {
+			ids = append(ids, id)
+		}
+		if err := tm.db.Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("failed to load tasks: %v", err)
+		}
+	}
+	return tm.buildGraph(tasks, edges), nil
+}
+
+// loadWorkspaceGraph 加载工作区内可见的任务（含共享进来的任务）及其直接前置依赖，workspaceID 为 0 时加载所有任务
+// 其他工作区的前置依赖只用于判断任务是否被阻塞，不在 visible 中
+func (tm *TaskManager) loadWorkspaceGraph(workspaceID uint) (*taskGraph, error) {
+	var tasks []*Task
+	if err := workspaceScope(tm.db, workspaceID).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %v", err)
+	}
+	if workspaceID == 0 {
+		var edges []TaskDependency
+		if err := tm.db.Find(&edges).Error; err != nil {
+			return nil, fmt.Errorf("failed to load task dependencies: %v", err)
+		}
+		return tm.buildGraph(tasks, edges), nil
+	}
+
+	visible := make(map[uint]bool, len(tasks))
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		visible[t.ID] = true
+		ids[i] = t.ID
+	}
+	var edges []TaskDependency
+	if len(ids) > 0 {
+		if err := tm.db.Where("task_id IN ?", ids).Find(&edges).Error; err != nil {
+			return nil, fmt.Errorf("failed to load task dependencies: %v", err)
+		}
+	}
+
+	var outside []uint
+	for _, e := range edges {
+		if !visible[e.DependencyID] {
+			outside = append(outside, e.DependencyID)
+		}
+	}
+	if len(outside) > 0 {
+		var extra []*Task
+		if err := tm.db.Where("id IN ?", outside).Find(&extra).Error; err != nil {
+			return nil, fmt.Errorf("failed to load dependency tasks: %v", err)
+		}
+		tasks = append(tasks, extra...)
+	}
+
+	g := tm.buildGraph(tasks, edges)
+	g.visible = visible
+	return g, nil
+}
+
+// buildGraph 由任务和依赖关系构建依赖图，并标记被阻塞的任务
+func (tm *TaskManager) buildGraph(tasks []*Task, edges []TaskDependency) *taskGraph {
+	g := &taskGraph{
+		nodes:      make(map[uint]*Task, len(tasks)),
+		deps:       make(map[uint][]uint),
+		dependents: make(map[uint][]uint),
+	}
+	for _, t := range tasks {
+		g.nodes[t.ID] = t
+	}
+	for _, e := range edges {
+		// 忽略指向不存在任务的依赖
+		if g.nodes[e.TaskID] == nil || g.nodes[e.DependencyID] == nil {
+			continue
+		}
+		g.deps[e.TaskID] = append(g.deps[e.TaskID], e.DependencyID)
+		g.dependents[e.DependencyID] = append(g.dependents[e.DependencyID], e.TaskID)
+		g.nodes[e.TaskID].Dependencies = append(g.nodes[e.TaskID].Dependencies, e)
+	}
+
+	tm.fillKeys(tasks)
+	for id, t := range g.nodes {
+		if !IsOpenStatus(t.Status) {
+			continue
+		}
+		for _, depID := range g.deps[id] {
+			if IsOpenStatus(g.nodes[depID].Status) {
+				t.Blocked = true
+				break
+			}
+		}
+	}
+	return g
+}
+
+// scopeTo 标记依赖图中在工作区内可见的任务（属于该工作区或被共享进来），workspaceID 为 0 表示不限制
+func (tm *TaskManager) scopeTo(g *taskGraph, workspaceID uint) error {
+	if workspaceID == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	visible, err := tm.visibleTaskIDs(workspaceID, ids)
+	if err != nil {
+		return err
+	}
+	g.visible = visible
+	return nil
+}
+
+// inScope 任务是否在当前工作区可见
+func (g *taskGraph) inScope(id uint) bool {
+	return g.visible == nil || g.visible[id]
+}
+
+// display 用于显示的任务：当前工作区不可见的任务只保留状态，标题、编号、负责人和截止时间都用占位内容代替
+func (g *taskGraph) display(t *Task) *Task {
+	if g.inScope(t.ID) {
+		return t
+	}
+	return &Task{
+		ID:         t.ID,
+		Key:        hiddenTaskKey,
+		Title:      hiddenTaskTitle,
+		Status:     t.Status,
+		Blocked:    t.Blocked,
+		CreateTime: t.CreateTime,
+	}
+}
+
+// findCycle 检查为 taskID 设置 dependencies 后是否会形成环（taskID 为 0 表示新任务）
+func (g *taskGraph) findCycle(taskID uint, dependencies []uint) error {
+	for _, depID := range dependencies {
+		if g.nodes[depID] == nil {
+			return fmt.Errorf("dependency task %d not found", depID)
+		}
+	}
+	if taskID == 0 {
+		return nil
+	}
+
+	// 从新的依赖出发向上游遍历，如果能回到 taskID 则形成环
+	visited := make(map[uint]bool)
+	stack := append([]uint(nil), dependencies...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == taskID {
+			return fmt.Errorf("circular dependency detected")
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, g.deps[id]...)
+	}
+	return nil
+}
+
+// ancestors 返回 target 及其所有上游任务
+func (g *taskGraph) ancestors(target uint) map[uint]bool {
+	result := map[uint]bool{target: true}
+	stack := []uint{target}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, depID := range g.deps[id] {
+			if !result[depID] {
+				result[depID] = true
+				stack = append(stack, depID)
+			}
+		}
+	}
+	return result
+}
+
+// descendants 返回 source 的所有下游任务（不含自身）
+func (g *taskGraph) descendants(source uint) map[uint]bool {
+	result := make(map[uint]bool)
+	stack := []uint{source}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range g.dependents[id] {
+			if !result[next] {
+				result[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return result
+}
+
+// topoSort 对给定节点集合做拓扑排序（Kahn算法），同一层按截止时间、ID排序
+func (g *taskGraph) topoSort(subset map[uint]bool) ([]*Task, error) {
+	inDegree := make(map[uint]int, len(subset))
+	for id := range subset {
+		inDegree[id] = 0
+	}
+	for id := range subset {
+		for _, depID := range g.deps[id] {
+			if subset[depID] {
+				inDegree[id]++
+			}
+		}
+	}
+
+	var ready []uint
+	for id, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]*Task, 0, len(subset))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return g.less(ready[i], ready[j]) })
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, g.nodes[id])
+
+		for _, next := range g.dependents[id] {
+			if !subset[next] {
+				continue
+			}
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if len(order) != len(subset) {
+		return order, fmt.Errorf("dependency graph contains a cycle")
+	}
+	return order, nil
+}
+
+// less 排序规则：有截止时间的在前，截止时间早的在前，其次按ID
+func (g *taskGraph) less(a, b uint) bool {
+	ta, tb := g.nodes[a], g.nodes[b]
+	switch {
+	case ta.DueTime != nil && tb.DueTime == nil:
+		return true
+	case ta.DueTime == nil && tb.DueTime != nil:
+		return false
+	case ta.DueTime != nil && tb.DueTime != nil && !ta.DueTime.Equal(*tb.DueTime):
+		return ta.DueTime.Before(*tb.DueTime)
+	}
+	return a < b
+}
+
+// schedule 按拓扑顺序正向推算每个任务的最早开始和预计完成时间
+// 已结束的任务完成时间取实际完成时间；override 可覆盖某些任务的完成时间（用于模拟延期）
+func (g *taskGraph) schedule(order []*Task, now time.Time, override map[uint]time.Time) map[uint]*ScheduledTask {
+	result := make(map[uint]*ScheduledTask, len(order))
+	for _, t := range order {
+		st := &ScheduledTask{Task: t, Start: now}
+
+		if !IsOpenStatus(t.Status) {
+			st.Start = t.CreateTime
+			st.Finish = now
+			if t.CompletedTime != nil {
+				st.Finish = *t.CompletedTime
+			}
+		} else {
+			for _, depID := range g.deps[t.ID] {
+				if dep, ok := result[depID]; ok && dep.Finish.After(st.Start) {
+					st.Start = dep.Finish
+				}
+			}
+			st.Finish = st.Start.Add(t.Estimate())
+		}
+
+		if finish, ok := override[t.ID]; ok {
+			st.Finish = finish
+		}
+		st.AtRisk = IsOpenStatus(t.Status) && t.DueTime != nil && st.Finish.After(*t.DueTime)
+		result[t.ID] = st
+	}
+	return result
+}
+
+// ReadyTasks 获取工作区内可以立即开始的任务：待处理且所有前置依赖均已结束
+func (tm *TaskManager) ReadyTasks(workspaceID uint) ([]*Task, error) {
+	g, err := tm.loadWorkspaceGraph(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for id, t := range g.nodes {
+		if !g.inScope(id) {
+			continue
+		}
+		if t.Status == StatusPending && !t.Blocked {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return g.less(ids[i], ids[j]) })
+
+	tasks := make([]*Task, len(ids))
+	for i, id := range ids {
+		tasks[i] = g.nodes[id]
+	}
+	return tasks, nil
+}
+
+// ExecutionOrder 获取工作区内未结束任务的拓扑执行顺序；targetID 不为 0 时只包含完成该任务所需的上游任务
+// 上游任务可能来自其他工作区（通过共享任务建立的依赖），此时只显示占位内容
+func (tm *TaskManager) ExecutionOrder(workspaceID uint, targetID uint) ([]*Task, error) {
+	subset := make(map[uint]bool)
+	if targetID != 0 {
+		g, err := tm.loadGraph(targetID)
+		if err != nil {
+			return nil, err
+		}
+		if g.nodes[targetID] == nil {
+			return nil, fmt.Errorf("task %d not found", targetID)
+		}
+		if err := tm.scopeTo(g, workspaceID); err != nil {
+			return nil, err
+		}
+		for id := range g.ancestors(targetID) {
+			if IsOpenStatus(g.nodes[id].Status) {
+				subset[id] = true
+			}
+		}
+		order, err := g.topoSort(subset)
+		for i, t := range order {
+			order[i] = g.display(t)
+		}
+		return order, err
+	}
+
+	g, err := tm.loadWorkspaceGraph(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for id, t := range g.nodes {
+		if IsOpenStatus(t.Status) && g.inScope(id) {
+			subset[id] = true
+		}
+	}
+	return g.topoSort(subset)
+}
+
+// CriticalPath 计算到达目标任务的关键路径（决定目标最早完成时间的那条依赖链）
+// 路径上在工作区 workspaceID 中不可见的任务只显示占位内容
+func (tm *TaskManager) CriticalPath(workspaceID, targetID uint) ([]*ScheduledTask, error) {
+	g, err := tm.loadGraph(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if g.nodes[targetID] == nil {
+		return nil, fmt.Errorf("task %d not found", targetID)
+	}
+	if err := tm.scopeTo(g, workspaceID); err != nil {
+		return nil, err
+	}
+
+	order, err := g.topoSort(g.ancestors(targetID))
+	if err != nil {
+		return nil, err
+	}
+	schedule := g.schedule(order, time.Now(), nil)
+
+	// 从目标向上回溯，每一步选择完成时间最晚的前置任务
+	path := []*ScheduledTask{schedule[targetID]}
+	current := targetID
+	for {
+		var critical *ScheduledTask
+		for _, depID := range g.deps[current] {
+			dep := schedule[depID]
+			if dep == nil || !IsOpenStatus(dep.Task.Status) {
+				continue
+			}
+			if critical == nil || dep.Finish.After(critical.Finish) {
+				critical = dep
+			}
+		}
+		if critical == nil {
+			break
+		}
+		path = append([]*ScheduledTask{critical}, path...)
+		current = critical.Task.ID
+	}
+
+	for _, st := range path {
+		st.Task = g.display(st.Task)
+	}
+	log.Printf("Critical path to task %d has %d task(s)\n", targetID, len(path))
+	return path, nil
+}
+
+// DownstreamAtRisk 模拟任务延期 slip 后，找出预计无法按截止时间完成的下游任务
+// slip 为 0 且任务已逾期时，按从现在开始计算；在工作区 workspaceID 中不可见的下游任务只显示占位内容
+func (tm *TaskManager) DownstreamAtRisk(workspaceID, taskID uint, slip time.Duration) ([]*ScheduledTask, error) {
+	g, err := tm.loadGraph(taskID)
+	if err != nil {
+		return nil, err
+	}
+	source := g.nodes[taskID]
+	if source == nil {
+		return nil, fmt.Errorf("task %d not found", taskID)
+	}
+	if err := tm.scopeTo(g, workspaceID); err != nil {
+		return nil, err
+	}
+
+	downstream := g.descendants(taskID)
+	subset := make(map[uint]bool)
+	for id := range downstream {
+		for ancestor := range g.ancestors(id) {
+			subset[ancestor] = true
+		}
+	}
+	subset[taskID] = true
+
+	order, err := g.topoSort(subset)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	finish := now.Add(source.Estimate())
+	if source.DueTime != nil && source.DueTime.After(now) {
+		finish = *source.DueTime
+	}
+	finish = finish.Add(slip)
+
+	schedule := g.schedule(order, now, map[uint]time.Time{taskID: finish})
+
+	var atRisk []*ScheduledTask
+	for _, t := range order {
+		st := schedule[t.ID]
+		if downstream[t.ID] && st.AtRisk {
+			st.Task = g.display(st.Task)
+			atRisk = append(atRisk, st)
+		}
+	}
+	return atRisk, nil
+}
+
+// FormatScheduleForDisplay 格式化排期结果用于微信显示
+func FormatScheduleForDisplay(items []*ScheduledTask) string {
+	result := ""
+	for i, st := range items {
+		emoji := statusEmoji(st.Task.Status)
+		if st.AtRisk {
+			emoji = "⚠️"
+		}
+		result += fmt.Sprintf("%d. %s %s (ID: %s)\n", i+1, emoji, st.Task.Title, st.Task.DisplayKey())
+		if IsOpenStatus(st.Task.Status) {
+			result += fmt.Sprintf("   预计: %s → %s", st.Start.Format("01-02 15:04"), st.Finish.Format("01-02 15:04"))
+		} else {
+			result += fmt.Sprintf("   已结束: %s", st.Finish.Format("01-02 15:04"))
+		}
+		if st.Task.DueTime != nil {
+			result += fmt.Sprintf(" | 截止: %s", st.Task.DueTime.Format("01-02 15:04"))
+		}
+		result += "\n"
+	}
+	return result
+}
@@ -0,0 +1,191 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+// mustWorkspace 创建测试用的工作区
+func mustWorkspace(t *testing.T, tm *TaskManager, chatID, name string, isGroup bool) *Workspace {
+	t.Helper()
+	ws, err := tm.ResolveWorkspace(chatID, name, isGroup)
+	if err != nil {
+		t.Fatalf("ResolveWorkspace(%s): %v", name, err)
+	}
+	return ws
+}
+
+// mustTask 创建测试用的任务
+func mustTask(t *testing.T, tm *TaskManager, workspaceID uint, title string, due *time.Time, deps ...uint) *Task {
+	t.Helper()
+	task, err := tm.CreateTask(workspaceID, title, title, "creator", due, deps)
+	if err != nil {
+		t.Fatalf("CreateTask(%s): %v", title, err)
+	}
+	return task
+}
+
+func TestLoadGraphComponent(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+
+	a := mustTask(t, tm, ws.ID, "设计", nil)
+	b := mustTask(t, tm, ws.ID, "开发", nil, a.ID)
+	c := mustTask(t, tm, ws.ID, "测试", nil, b.ID)
+	d := mustTask(t, tm, ws.ID, "文档", nil, a.ID)
+	other := mustTask(t, tm, ws.ID, "无关任务", nil)
+
+	g, err := tm.loadGraph(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint{a.ID, b.ID, c.ID, d.ID} {
+		if g.nodes[id] == nil {
+			t.Errorf("task %d missing from component of %d", id, c.ID)
+		}
+	}
+	if g.nodes[other.ID] != nil {
+		t.Errorf("unrelated task %d loaded into component of %d", other.ID, c.ID)
+	}
+	if !g.nodes[b.ID].Blocked || !g.nodes[c.ID].Blocked || g.nodes[a.ID].Blocked {
+		t.Errorf("blocked flags = a:%v b:%v c:%v, want false true true", g.nodes[a.ID].Blocked, g.nodes[b.ID].Blocked, g.nodes[c.ID].Blocked)
+	}
+
+	order, err := tm.ExecutionOrder(ws.ID, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0].ID != a.ID || order[1].ID != b.ID || order[2].ID != c.ID {
+		t.Errorf("ExecutionOrder(%d) = %v, want [%d %d %d]", c.ID, taskIDs(order), a.ID, b.ID, c.ID)
+	}
+
+	if err := tm.checkDependencies([]uint{c.ID}, a.ID); err == nil {
+		t.Errorf("checkDependencies: want circular dependency error")
+	}
+	if err := tm.checkDependencies([]uint{other.ID}, a.ID); err != nil {
+		t.Errorf("checkDependencies: %v", err)
+	}
+	if err := tm.checkDependencies([]uint{9999}, a.ID); err == nil {
+		t.Errorf("checkDependencies: want error for missing dependency")
+	}
+}
+
+func TestLoadWorkspaceGraph(t *testing.T) {
+	tm := newTestManager(t)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	dev := mustWorkspace(t, tm, "@@dev", "开发群", true)
+
+	upstream := mustTask(t, tm, dev.ID, "接口开发", nil)
+	mustTask(t, tm, dev.ID, "开发群的其他任务", nil)
+	waiting := mustTask(t, tm, ops.ID, "上线", nil, upstream.ID)
+	ready := mustTask(t, tm, ops.ID, "巡检", nil)
+
+	g, err := tm.loadWorkspaceGraph(ops.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.nodes) != 3 {
+		t.Errorf("loaded %d tasks, want the 2 workspace tasks and 1 upstream dependency", len(g.nodes))
+	}
+	if g.inScope(upstream.ID) || !g.inScope(waiting.ID) {
+		t.Errorf("inScope(upstream) = %v, inScope(waiting) = %v", g.inScope(upstream.ID), g.inScope(waiting.ID))
+	}
+	if !g.nodes[waiting.ID].Blocked {
+		t.Errorf("task blocked by another workspace's task should be blocked")
+	}
+
+	tasks, err := tm.ReadyTasks(ops.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != ready.ID {
+		t.Errorf("ReadyTasks = %v, want [%d]", taskIDs(tasks), ready.ID)
+	}
+}
+
+// taskIDs 任务ID列表（用于测试输出）
+func taskIDs(tasks []*Task) []uint {
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestGraphHidesOtherWorkspaces(t *testing.T) {
+	tm := newTestManager(t)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	dev := mustWorkspace(t, tm, "@@dev", "开发群", true)
+
+	due := time.Now().Add(time.Hour)
+	secret := mustTask(t, tm, dev.ID, "开发群的机密任务", &due)
+	shared := mustTask(t, tm, dev.ID, "共享的接口任务", nil, secret.ID)
+	if err := tm.ShareTask(shared.ID, ops.ID, "someone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.SetTaskEstimate(shared.ID, 4*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	launch := mustTask(t, tm, ops.ID, "上线", &due, shared.ID)
+
+	path, err := tm.CriticalPath(ops.ID, launch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 {
+		t.Fatalf("critical path has %d tasks, want 3", len(path))
+	}
+	assertHidden(t, "CriticalPath", path[0].Task, secret)
+	if path[1].Task.Title != shared.Title || path[2].Task.Title != launch.Title {
+		t.Errorf("visible tasks on the critical path should keep their titles, got %q and %q", path[1].Task.Title, path[2].Task.Title)
+	}
+
+	atRisk, err := tm.DownstreamAtRisk(dev.ID, secret.ID, 48*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range atRisk {
+		if st.Task.ID == launch.ID {
+			assertHidden(t, "DownstreamAtRisk", st.Task, launch)
+		}
+	}
+	if len(atRisk) == 0 {
+		t.Errorf("DownstreamAtRisk found no tasks at risk")
+	}
+
+	_, tasks, err := tm.DependencyGraphView(ops.ID, launch.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range tasks {
+		if task.ID == secret.ID {
+			assertHidden(t, "DependencyGraphView", task, secret)
+		}
+	}
+
+	order, err := tm.ExecutionOrder(ops.ID, launch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHidden(t, "ExecutionOrder", order[0], secret)
+
+	// 没有会话上下文时不限制
+	path, err = tm.CriticalPath(0, launch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[0].Task.Title != secret.Title {
+		t.Errorf("CriticalPath without workspace hid %q", path[0].Task.Title)
+	}
+}
+
+// assertHidden 检查其他工作区的任务只显示占位内容
+func assertHidden(t *testing.T, name string, got, original *Task) {
+	t.Helper()
+	if got.ID != original.ID {
+		t.Fatalf("%s: got task %d, want %d", name, got.ID, original.ID)
+	}
+	if got.Title != hiddenTaskTitle || got.DisplayKey() != hiddenTaskKey || got.DueTime != nil {
+		t.Errorf("%s: task from another workspace shown as %s %q (due %v)", name, got.DisplayKey(), got.Title, got.DueTime)
+	}
+}
@@ -0,0 +1,102 @@
+package task
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/869413421/wechatbot/app/render"
+)
+
+// statusColors 依赖图中各状态的节点颜色
+var statusColors = map[string]color.RGBA{
+	StatusPending:    {255, 224, 130, 255}, // 黄
+	StatusInProgress: {129, 190, 247, 255}, // 蓝
+	StatusCompleted:  {152, 219, 152, 255}, // 绿
+	StatusCancelled:  {200, 200, 200, 255}, // 灰
+	StatusBlocked:    {244, 143, 143, 255}, // 红
+}
+
+// graphLegend 依赖图图例（图片字体只支持英文）
+var graphLegend = []render.LegendItem{
+	{Label: "PENDING", Fill: statusColors[StatusPending]},
+	{Label: "IN PROGRESS", Fill: statusColors[StatusInProgress]},
+	{Label: "BLOCKED", Fill: statusColors[StatusBlocked]},
+	{Label: "DONE", Fill: statusColors[StatusCompleted]},
+	{Label: "CANCELLED", Fill: statusColors[StatusCancelled]},
+}
+
+// DependencyGraphView 构建依赖图视图
+// taskID 不为 0 时只包含该任务的上下游（其他工作区中不可见的任务只显示占位内容），否则包含工作区内的所有任务
+// includeClosed 为 false 时忽略已完成/已取消的任务（指定的任务本身除外）
+// 返回的任务列表按ID排序，用于生成文字图例
+func (tm *TaskManager) DependencyGraphView(workspaceID uint, taskID uint, includeClosed bool) (*render.Graph, []*Task, error) {
+	var g *taskGraph
+	var err error
+	subset := make(map[uint]bool)
+	if taskID != 0 {
+		if g, err = tm.loadGraph(taskID); err != nil {
+			return nil, nil, err
+		}
+		if g.nodes[taskID] == nil {
+			return nil, nil, fmt.Errorf("task %d not found", taskID)
+		}
+		if err := tm.scopeTo(g, workspaceID); err != nil {
+			return nil, nil, err
+		}
+		for id := range g.ancestors(taskID) {
+			subset[id] = true
+		}
+		for id := range g.descendants(taskID) {
+			subset[id] = true
+		}
+	} else {
+		if g, err = tm.loadWorkspaceGraph(workspaceID); err != nil {
+			return nil, nil, err
+		}
+		for id := range g.nodes {
+			if g.inScope(id) {
+				subset[id] = true
+			}
+		}
+	}
+	for id := range subset {
+		if !includeClosed && id != taskID && !IsOpenStatus(g.nodes[id].Status) {
+			delete(subset, id)
+		}
+	}
+	if len(subset) == 0 {
+		return nil, nil, fmt.Errorf("no tasks to draw")
+	}
+
+	view := &render.Graph{Legend: graphLegend}
+	tasks := make([]*Task, 0, len(subset))
+	for id := range subset {
+		t := g.display(g.nodes[id])
+		tasks = append(tasks, t)
+
+		fill := statusColors[t.Status]
+		if t.Blocked {
+			fill = statusColors[StatusBlocked]
+		}
+		view.Nodes = append(view.Nodes, render.Node{
+			ID:    t.ID,
+			Key:   t.DisplayKey(),
+			Title: t.Title,
+			Fill:  fill,
+		})
+		for _, depID := range g.deps[id] {
+			if subset[depID] {
+				view.Edges = append(view.Edges, render.Edge{From: depID, To: id})
+			}
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	sort.Slice(view.Edges, func(i, j int) bool {
+		if view.Edges[i].From != view.Edges[j].From {
+			return view.Edges[i].From < view.Edges[j].From
+		}
+		return view.Edges[i].To < view.Edges[j].To
+	})
+	return view, tasks, nil
+}
@@ -0,0 +1,254 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxPrefixLength 任务编号前缀的最大长度
+const maxPrefixLength = 8
+
+var (
+	// taskKeyPattern 显示编号格式：前缀-编号（如 OPS-12）
+	taskKeyPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)-(\d+)$`)
+	// prefixPattern 合法的编号前缀
+	prefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*$`)
+)
+
+// defaultPrefix 根据工作区名称生成默认前缀：取名称中的英文字母，没有时群用 T、私聊用 P
+func defaultPrefix(name, kind string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+			if b.Len() >= 4 {
+				break
+			}
+		}
+	}
+	if b.Len() > 0 {
+		return b.String()
+	}
+	if kind == WorkspacePrivate {
+		return "P"
+	}
+	return "T"
+}
+
+// assignPrefix 为工作区分配默认前缀，与其他工作区重复时追加工作区ID
+func assignPrefix(tx *gorm.DB, ws *Workspace) error {
+	prefix := defaultPrefix(ws.Name, ws.Kind)
+	var count int64
+	if err := tx.Model(&Workspace{}).Where("prefix = ? AND id <> ?", prefix, ws.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check workspace prefix: %v", err)
+	}
+	if count > 0 {
+		prefix = fmt.Sprintf("%s%d", prefix, ws.ID)
+	}
+	if err := tx.Model(ws).Update("prefix", prefix).Error; err != nil {
+		return fmt.Errorf("failed to set workspace prefix: %v", err)
+	}
+	ws.Prefix = prefix
+	return nil
+}
+
+// nextTaskNumber 在事务中分配工作区的下一个任务编号（UPDATE 会锁住工作区行，保证并发下不重复）
+func nextTaskNumber(tx *gorm.DB, workspaceID uint) (uint, error) {
+	result := tx.Model(&Workspace{}).Where("id = ?", workspaceID).Update("task_seq", gorm.Expr("task_seq + 1"))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to allocate task number: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("workspace %d not found", workspaceID)
+	}
+	var seq uint
+	if err := tx.Model(&Workspace{}).Where("id = ?", workspaceID).Pluck("task_seq", &seq).Error; err != nil {
+		return 0, fmt.Errorf("failed to allocate task number: %v", err)
+	}
+	return seq, nil
+}
+
+// numberUnnumberedTasks 按创建顺序为工作区中还没有编号的任务分配编号
+func numberUnnumberedTasks(tx *gorm.DB, workspaceID uint) error {
+	var ids []uint
+	if err := tx.Model(&Task{}).Where("workspace_id = ? AND number = 0", workspaceID).Order("id ASC").Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to load unnumbered tasks: %v", err)
+	}
+	for _, id := range ids {
+		number, err := nextTaskNumber(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&Task{}).Where("id = ?", id).Update("number", number).Error; err != nil {
+			return fmt.Errorf("failed to number task %d: %v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Numbered %d task(s) in workspace %d\n", len(ids), workspaceID)
+	}
+	return nil
+}
+
+// backfillTaskKeys 为缺少前缀的工作区和缺少编号的任务补齐显示编号
+func backfillTaskKeys(db *gorm.DB) error {
+	var workspaces []*Workspace
+	if err := db.Where("prefix = ''").Find(&workspaces).Error; err != nil {
+		return fmt.Errorf("failed to load workspaces: %v", err)
+	}
+	for _, ws := range workspaces {
+		if err := assignPrefix(db, ws); err != nil {
+			return err
+		}
+	}
+
+	var workspaceIDs []uint
+	if err := db.Model(&Task{}).Where("workspace_id <> 0 AND number = 0").Distinct().Pluck("workspace_id", &workspaceIDs).Error; err != nil {
+		return fmt.Errorf("failed to load unnumbered tasks: %v", err)
+	}
+	for _, id := range workspaceIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return numberUnnumberedTasks(tx, id)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillKeys 填充任务的显示编号
+func (tm *TaskManager) fillKeys(tasks []*Task) {
+	workspaceIDs := make([]uint, 0)
+	seen := make(map[uint]bool)
+	for _, t := range tasks {
+		if t.WorkspaceID != 0 && t.Number != 0 && !seen[t.WorkspaceID] {
+			seen[t.WorkspaceID] = true
+			workspaceIDs = append(workspaceIDs, t.WorkspaceID)
+		}
+	}
+	if len(workspaceIDs) == 0 {
+		return
+	}
+
+	var workspaces []Workspace
+	if err := tm.db.Select("id", "prefix").Where("id IN ?", workspaceIDs).Find(&workspaces).Error; err != nil {
+		log.Printf("ERROR: Failed to load workspace prefixes: %v\n", err)
+		return
+	}
+	prefixes := make(map[uint]string, len(workspaces))
+	for _, ws := range workspaces {
+		prefixes[ws.ID] = ws.Prefix
+	}
+	for _, t := range tasks {
+		if prefix := prefixes[t.WorkspaceID]; prefix != "" && t.Number != 0 {
+			t.Key = fmt.Sprintf("%s-%d", prefix, t.Number)
+		}
+	}
+}
+
+// annotate 填充从数据库加载的任务的派生字段（阻塞状态、显示编号）
+func (tm *TaskManager) annotate(tasks []*Task) {
+	tm.MarkBlocked(tasks)
+	tm.fillKeys(tasks)
+}
+
+// TaskKey 获取任务的显示编号
+func (tm *TaskManager) TaskKey(id uint) string {
+	var t Task
+	if err := tm.db.Select("id", "workspace_id", "number").First(&t, "id = ?", id).Error; err != nil {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	tm.fillKeys([]*Task{&t})
+	return t.DisplayKey()
+}
+
+// taskKeyOrID 在 tm 可能为 nil 的格式化函数中获取显示编号
+func (tm *TaskManager) taskKeyOrID(id uint) string {
+	if tm == nil {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	return tm.TaskKey(id)
+}
+
+// ResolveTaskKey 将用户输入的任务编号解析为任务ID
+// 支持 OPS-12、#12 和 12：带前缀时在前缀对应的工作区中查找，否则在当前工作区中按编号查找
+// workspaceID 为 0（没有会话上下文）时，纯数字按全局ID处理
+func (tm *TaskManager) ResolveTaskKey(workspaceID uint, key string) (uint, error) {
+	return tm.resolveTaskKey(tm.db, workspaceID, key)
+}
+
+// resolveTaskKey 在 db 的查询范围内解析任务编号（回收站中的任务需要传入 Unscoped 的 db）
+func (tm *TaskManager) resolveTaskKey(db *gorm.DB, workspaceID uint, key string) (uint, error) {
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(key, "#")
+	key = strings.TrimPrefix(key, "任务")
+	if key == "" {
+		return 0, fmt.Errorf("task key is empty")
+	}
+
+	var targetWorkspace uint
+	var numberStr string
+	if m := taskKeyPattern.FindStringSubmatch(key); m != nil {
+		var ws Workspace
+		if err := tm.db.Where("prefix = ?", strings.ToUpper(m[1])).First(&ws).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return 0, fmt.Errorf("task %s not found", key)
+			}
+			return 0, fmt.Errorf("failed to resolve task key: %v", err)
+		}
+		targetWorkspace, numberStr = ws.ID, m[2]
+	} else {
+		number, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid task key: %s", key)
+		}
+		if workspaceID == 0 || number == 0 {
+			return uint(number), nil
+		}
+		targetWorkspace, numberStr = workspaceID, key
+	}
+
+	var ids []uint
+	if err := db.Model(&Task{}).Where("workspace_id = ? AND number = ?", targetWorkspace, numberStr).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to resolve task key: %v", err)
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("task %s not found", key)
+	}
+	return ids[0], nil
+}
+
+// SetWorkspacePrefix 修改工作区的任务编号前缀（已有任务的编号不变，显示编号随前缀变化）
+func (tm *TaskManager) SetWorkspacePrefix(workspaceID uint, prefix string) error {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if len(prefix) > maxPrefixLength || !prefixPattern.MatchString(prefix) {
+		return fmt.Errorf("prefix must be 1-%d letters or digits starting with a letter", maxPrefixLength)
+	}
+
+	var count int64
+	if err := tm.db.Model(&Workspace{}).Where("prefix = ? AND id <> ?", prefix, workspaceID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check workspace prefix: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("prefix %s is already used by another workspace", prefix)
+	}
+
+	result := tm.db.Model(&Workspace{}).Where("id = ?", workspaceID).Update("prefix", prefix)
+	if result.Error != nil {
+		return fmt.Errorf("failed to set workspace prefix: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// 前缀未变化时 RowsAffected 也为 0，需要区分工作区不存在的情况
+		if _, exists := tm.GetWorkspace(workspaceID); !exists {
+			return fmt.Errorf("workspace %d not found", workspaceID)
+		}
+	}
+
+	log.Printf("Workspace %d prefix set to %s\n", workspaceID, prefix)
+	return nil
+}
@@ -0,0 +1,237 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxLabelLength 标签的最大长度（字符）
+const maxLabelLength = 50
+
+// normalizeLabels 整理标签：去掉 # 前缀和空白，忽略大小写去重
+func normalizeLabels(labels []string) ([]string, error) {
+	seen := make(map[string]bool, len(labels))
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(label), "#＃"))
+		if label == "" {
+			continue
+		}
+		if strings.ContainsAny(label, " \t,，") {
+			return nil, fmt.Errorf("label %q must not contain spaces or commas", label)
+		}
+		if len([]rune(label)) > maxLabelLength {
+			return nil, fmt.Errorf("label %q is too long (max %d characters)", label, maxLabelLength)
+		}
+		key := strings.ToLower(label)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, label)
+	}
+	return result, nil
+}
+
+// GetTaskLabels 获取任务的标签（按字母排序）
+func (tm *TaskManager) GetTaskLabels(taskID uint) []string {
+	var labels []string
+	if err := tm.db.Model(&TaskLabel{}).Where("task_id = ?", taskID).Order("label ASC").Pluck("label", &labels).Error; err != nil {
+		log.Printf("ERROR: Failed to get labels of task %d: %v\n", taskID, err)
+		return []string{}
+	}
+	return labels
+}
+
+// UpdateTaskLabels 修改任务标签：先移除 remove 中的标签，再添加 add 中的标签
+// replace 为 true 时用 add 替换全部标签
+func (tm *TaskManager) UpdateTaskLabels(taskID uint, add, remove []string, replace bool) error {
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+	add, err := normalizeLabels(add)
+	if err != nil {
+		return err
+	}
+	remove, err = normalizeLabels(remove)
+	if err != nil {
+		return err
+	}
+
+	// 标签有变化时以乐观锁增加任务版本号
+	var before, after []string
+	err = tm.db.Transaction(func(tx *gorm.DB) error {
+		scoped := *tm
+		scoped.db = tx
+		before = scoped.GetTaskLabels(taskID)
+		if err := updateTaskLabels(tx, taskID, add, remove, replace); err != nil {
+			return err
+		}
+		after = scoped.GetTaskLabels(taskID)
+		if strings.Join(before, ",") == strings.Join(after, ",") {
+			return nil
+		}
+		return updateVersioned(tx, t, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if strings.Join(before, ",") != strings.Join(after, ",") {
+		log.Printf("Updated labels of task %d: %v -> %v\n", taskID, before, after)
+		tm.logActivity(t, ActivityField, "labels", formatLabels(before), formatLabels(after))
+	}
+	return nil
+}
+
+// updateTaskLabels 在事务中修改任务标签
+func updateTaskLabels(tx *gorm.DB, taskID uint, add, remove []string, replace bool) error {
+	if replace {
+		if err := tx.Where("task_id = ?", taskID).Delete(&TaskLabel{}).Error; err != nil {
+			return fmt.Errorf("failed to clear labels: %v", err)
+		}
+	} else if len(remove) > 0 {
+		if err := tx.Where("task_id = ? AND label IN ?", taskID, remove).Delete(&TaskLabel{}).Error; err != nil {
+			return fmt.Errorf("failed to remove labels: %v", err)
+		}
+	}
+	for _, label := range add {
+		var count int64
+		if err := tx.Model(&TaskLabel{}).Where("task_id = ? AND label = ?", taskID, label).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check label: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if err := tx.Create(&TaskLabel{TaskID: taskID, Label: label}).Error; err != nil {
+			return fmt.Errorf("failed to add label %s: %v", label, err)
+		}
+	}
+	return nil
+}
+
+// ListLabels 统计工作区内使用的标签及其未结束任务数
+func (tm *TaskManager) ListLabels(workspaceID uint) (map[string]int, error) {
+	var rows []struct {
+		Label string
+		Count int
+	}
+	query := tm.db.Model(&TaskLabel{}).
+		Select("task_labels.label, COUNT(*) AS count").
+		Joins("JOIN tasks ON tasks.id = task_labels.task_id AND tasks.deleted_at IS NULL").
+		Where("tasks.status IN ?", openStatuses)
+	if err := workspaceScope(query, workspaceID).Group("task_labels.label").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list labels: %v", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Label] = row.Count
+	}
+	return counts, nil
+}
+
+// formatLabels 格式化标签列表，如 #release #bug
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+	return "#" + strings.Join(sorted, " #")
+}
+
+// GetTaskAssignees 获取任务的负责人
+func (tm *TaskManager) GetTaskAssignees(taskID uint) []TaskAssignee {
+	var assignees []TaskAssignee
+	if err := tm.db.Where("task_id = ?", taskID).Order("user_name ASC").Find(&assignees).Error; err != nil {
+		log.Printf("ERROR: Failed to get assignees of task %d: %v\n", taskID, err)
+		return []TaskAssignee{}
+	}
+	return assignees
+}
+
+// UpdateTaskAssignees 修改任务负责人：先移除 remove 中的负责人（按昵称），再添加 add 中的负责人
+// replace 为 true 时用 add 替换全部负责人
+func (tm *TaskManager) UpdateTaskAssignees(taskID uint, add []Actor, remove []string, replace bool) error {
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+
+	// 负责人有变化时以乐观锁增加任务版本号
+	var before, after []TaskAssignee
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		scoped := *tm
+		scoped.db = tx
+		before = scoped.GetTaskAssignees(taskID)
+		if err := updateTaskAssignees(tx, taskID, add, remove, replace); err != nil {
+			return err
+		}
+		after = scoped.GetTaskAssignees(taskID)
+		if formatAssignees(before) == formatAssignees(after) {
+			return nil
+		}
+		return updateVersioned(tx, t, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if formatAssignees(before) != formatAssignees(after) {
+		log.Printf("Updated assignees of task %d\n", taskID)
+		tm.logActivity(t, ActivityField, "assignees", formatAssignees(before), formatAssignees(after))
+	}
+	return nil
+}
+
+// updateTaskAssignees 在事务中修改任务负责人
+func updateTaskAssignees(tx *gorm.DB, taskID uint, add []Actor, remove []string, replace bool) error {
+	if replace {
+		if err := tx.Where("task_id = ?", taskID).Delete(&TaskAssignee{}).Error; err != nil {
+			return fmt.Errorf("failed to clear assignees: %v", err)
+		}
+	} else if len(remove) > 0 {
+		if err := tx.Where("task_id = ? AND user_name IN ?", taskID, remove).Delete(&TaskAssignee{}).Error; err != nil {
+			return fmt.Errorf("failed to remove assignees: %v", err)
+		}
+	}
+	for _, a := range add {
+		name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(a.Name), "@"))
+		if name == "" {
+			continue
+		}
+		var existing TaskAssignee
+		err := tx.Where("task_id = ? AND user_name = ?", taskID, name).First(&existing).Error
+		if err == nil {
+			// 已存在时更新 UserID（对方重新登录后 UserName 会变化）
+			if a.ID != "" && a.ID != existing.UserID {
+				if err := tx.Model(&existing).Update("user_id", a.ID).Error; err != nil {
+					return fmt.Errorf("failed to update assignee %s: %v", name, err)
+				}
+			}
+			continue
+		}
+		if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to check assignee: %v", err)
+		}
+		if err := tx.Create(&TaskAssignee{TaskID: taskID, UserName: name, UserID: a.ID}).Error; err != nil {
+			return fmt.Errorf("failed to add assignee %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// formatAssignees 格式化负责人列表
+func formatAssignees(assignees []TaskAssignee) string {
+	names := make([]string, len(assignees))
+	for i, a := range assignees {
+		names[i] = a.UserName
+	}
+	sort.Strings(names)
+	return strings.Join(names, "、")
+}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDBSeq 每个测试使用独立的内存数据库
+var testDBSeq int64
+
+func TestMain(m *testing.M) {
+	// 配置文件在仓库根目录
+	if err := os.Chdir("../.."); err != nil {
+		fmt.Fprintf(os.Stderr, "chdir: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestManager 创建使用内存 SQLite 数据库的任务管理器
+func newTestManager(t *testing.T) *TaskManager {
+	t.Helper()
+	dsn := fmt.Sprintf("file:task_test_%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	previous := db
+	db = conn
+	t.Cleanup(func() { db = previous })
+	if err := autoMigrate(); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return &TaskManager{db: conn}
+}
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,6 +10,8 @@ import (
 // Task 任务模型
 type Task struct {
 	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`                  // 任务ID（自增主键）
+	WorkspaceID   uint      `gorm:"not null;default:0;index" json:"workspace_id"`        // 所属工作区ID（0表示尚未归属任何工作区的旧任务）
+	Number        uint      `gorm:"not null;default:0;index" json:"number"`              // 工作区内的任务编号（与前缀组成显示编号，如 OPS-12）
 	Title         string    `gorm:"type:varchar(255);not null" json:"title"`            // 任务标题（由AI推测）
 	Content       string    `gorm:"type:text;not null" json:"content"`                   // 任务具体内容（用户输入）
 	CreatorID     string    `gorm:"type:varchar(100);not null;index" json:"creator_id"` // 创建任务的用户ID
@@ -16,9 +19,34 @@ type Task struct {
 	DueTime       *time.Time `gorm:"type:datetime;null;index" json:"due_time"`          // 预计结束时间（可选）
 	Status        string    `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 任务状态: pending, in_progress, completed, cancelled
 	CompletedTime *time.Time `gorm:"type:datetime;null" json:"completed_time"`         // 完成时间（可选）
+	UpdateTime    time.Time `gorm:"type:datetime;not null;default:CURRENT_TIMESTAMP;autoUpdateTime;index" json:"update_time"` // 最近修改时间（任务有新动态时也会更新）
+	Priority      int       `gorm:"not null;default:0;index" json:"priority,omitempty"` // 优先级，见 Priority* 常量，0表示未设置
+	Version       uint      `gorm:"not null;default:1" json:"version"`                  // 版本号（乐观锁），每次修改任务字段或状态时加一
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`              // 移入回收站的时间（软删除，查询时自动排除）
+
+	// 重复任务
+	RecurrenceRule string `gorm:"type:varchar(255);not null;default:''" json:"recurrence_rule,omitempty"` // 重复规则（RRULE子集），为空表示不重复
+	SeriesID       uint   `gorm:"not null;default:0;index" json:"series_id,omitempty"`                   // 所属重复系列ID（系列第一个任务的ID），0表示不属于任何系列
+
+	// 提醒
+	ReminderOffsets string `gorm:"type:varchar(100);not null;default:''" json:"reminder_offsets,omitempty"` // 提醒提前量（分钟，逗号分隔，如 1440,60,0），为空使用默认值
+
+	// 工作量估计（用于关键路径等依赖图分析）
+	EstimateMinutes int `gorm:"not null;default:0" json:"estimate_minutes,omitempty"` // 预计耗时（分钟），0表示未估计
+
+	// 子任务
+	ParentID     uint `gorm:"not null;default:0;index" json:"parent_id,omitempty"`    // 父任务ID，0表示顶层任务
+	AutoComplete bool `gorm:"not null;default:false" json:"auto_complete,omitempty"` // 所有子任务完成后是否自动完成本任务
+	
+	// 派生字段（不存储）
+	Blocked bool   `gorm:"-" json:"blocked,omitempty"` // 是否被未完成的前置依赖阻塞
+	Key     string `gorm:"-" json:"key,omitempty"`     // 显示编号（如 OPS-12），由工作区前缀和编号组成
 	
 	// 关联关系
-	Dependencies []TaskDependency `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"-"` // GORM关联，不序列化到JSON
+	Dependencies   []TaskDependency `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"-"` // GORM关联，不序列化到JSON
+	ChecklistItems []ChecklistItem  `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"-"` // 检查项
+	Labels         []TaskLabel      `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"-"` // 标签
+	Assignees      []TaskAssignee   `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"-"` // 负责人
 }
 
 // TableName 指定表名
@@ -37,9 +65,216 @@ func (TaskDependency) TableName() string {
 	return "task_dependencies"
 }
 
+// ChecklistItem 任务内的检查项（轻量级，不是独立任务）
+type ChecklistItem struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID      uint       `gorm:"not null;index" json:"task_id"`           // 所属任务ID
+	Position    int        `gorm:"not null;default:0" json:"position"`      // 在任务中的序号（从1开始）
+	Content     string     `gorm:"type:varchar(500);not null" json:"content"` // 检查项内容
+	Checked     bool       `gorm:"not null;default:false" json:"checked"`   // 是否已勾选
+	CheckedTime *time.Time `gorm:"type:datetime;null" json:"checked_time"`  // 勾选时间
+	CreateTime  time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (ChecklistItem) TableName() string {
+	return "task_checklist_items"
+}
+
+// TaskLabel 任务标签
+type TaskLabel struct {
+	TaskID uint   `gorm:"primaryKey" json:"task_id"`
+	Label  string `gorm:"primaryKey;type:varchar(50);index" json:"label"`
+}
+
+// TableName 指定表名
+func (TaskLabel) TableName() string {
+	return "task_labels"
+}
+
+// TaskAssignee 任务负责人
+type TaskAssignee struct {
+	TaskID   uint   `gorm:"primaryKey" json:"task_id"`
+	UserName string `gorm:"primaryKey;type:varchar(100);index" json:"user_name"` // 昵称（群成员的UserName重新登录后会变化，按昵称识别）
+	UserID   string `gorm:"type:varchar(100);not null;default:'';index" json:"user_id"` // 微信UserName（已知时记录，用于发送提醒）
+}
+
+// TableName 指定表名
+func (TaskAssignee) TableName() string {
+	return "task_assignees"
+}
+
+// Workspace 工作区：每个微信群对应一个群工作区，每个私聊对应一个个人工作区
+type Workspace struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind             string    `gorm:"type:varchar(20);not null;index:idx_workspace_kind_name" json:"kind"`      // group 或 private
+	ChatID           string    `gorm:"type:varchar(100);not null;index" json:"chat_id"`                          // 群或好友的UserName（重新登录后会变化）
+	Name             string    `gorm:"type:varchar(255);not null;index:idx_workspace_kind_name" json:"name"`     // 群名或好友昵称
+	Prefix           string    `gorm:"type:varchar(16);not null;default:'';index" json:"prefix"`                 // 任务编号前缀（如 OPS）
+	TaskSeq          uint      `gorm:"not null;default:0" json:"task_seq"`                                       // 已分配的最大任务编号
+	EscalationPolicy string    `gorm:"type:varchar(255);not null;default:''" json:"escalation_policy,omitempty"` // 逾期升级策略（逾期分钟数:对象，逗号分隔，如 1440,4320:admin），为空表示不升级
+	EscalationAdmins string    `gorm:"type:varchar(500);not null;default:''" json:"escalation_admins,omitempty"` // 升级到管理员时 @ 的群成员昵称（逗号分隔）
+	CreateTime       time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+// 工作区类型
+const (
+	WorkspaceGroup   = "group"
+	WorkspacePrivate = "private"
+)
+
+// TaskShare 任务跨工作区共享
+type TaskShare struct {
+	TaskID      uint      `gorm:"primaryKey" json:"task_id"`
+	WorkspaceID uint      `gorm:"primaryKey;index" json:"workspace_id"` // 共享到的工作区
+	SharedBy    string    `gorm:"type:varchar(100);not null" json:"shared_by"`
+	CreateTime  time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (TaskShare) TableName() string {
+	return "task_shares"
+}
+
+// TaskActivity 任务动态（只追加不修改）：状态变化、字段修改、依赖修改、评论等
+type TaskActivity struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID      uint      `gorm:"not null;index" json:"task_id"`
+	WorkspaceID uint      `gorm:"not null;default:0;index" json:"workspace_id"`
+	TaskTitle   string    `gorm:"type:varchar(255);not null;default:''" json:"task_title"` // 记录时的任务标题（任务删除后仍可显示）
+	Kind        string    `gorm:"type:varchar(20);not null" json:"kind"`                   // 动态类型，见 Activity* 常量
+	Field       string    `gorm:"type:varchar(50);not null;default:''" json:"field"`       // 修改的字段（字段修改时）
+	OldValue    string    `gorm:"type:text" json:"old_value"`
+	NewValue    string    `gorm:"type:text" json:"new_value"` // 新值，评论时为评论内容
+	ActorID     string    `gorm:"type:varchar(100);not null;default:''" json:"actor_id"`
+	ActorName   string    `gorm:"type:varchar(100);not null;default:''" json:"actor_name"`
+	CreateTime  time.Time `gorm:"type:datetime;not null;index" json:"create_time"`
+}
+
+// TableName 指定表名
+func (TaskActivity) TableName() string {
+	return "task_activities"
+}
+
+// UndoEntry 可撤销的操作记录：保存操作前的任务快照，撤销时据此恢复
+type UndoEntry struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID     string `gorm:"type:varchar(100);not null;index" json:"actor_id"` // 执行操作的用户
+	WorkspaceID uint   `gorm:"not null;default:0;index" json:"workspace_id"`
+	Op          string `gorm:"type:varchar(20);not null" json:"op"` // 操作类型，见 Undo* 常量
+	Payload     string `gorm:"type:mediumtext" json:"payload"`      // 操作前的任务快照（JSON）
+
+	// 动态ID区间：操作前后以及撤销前后最新的动态ID，用于检测操作之后是否有人修改过任务
+	StartActivityID     uint `gorm:"not null;default:0" json:"start_activity_id"`
+	LastActivityID      uint `gorm:"not null;default:0" json:"last_activity_id"`
+	UndoStartActivityID uint `gorm:"not null;default:0" json:"undo_start_activity_id"`
+	UndoEndActivityID   uint `gorm:"not null;default:0" json:"undo_end_activity_id"`
+
+	Undone     bool      `gorm:"not null;default:false;index" json:"undone"`
+	CreateTime time.Time `gorm:"type:datetime;not null;index" json:"create_time"`
+}
+
+// TableName 指定表名
+func (UndoEntry) TableName() string {
+	return "task_undo_entries"
+}
+
+// ReminderDelivery 任务提醒的发送记录：同一任务、同一截止时间、同一提醒阈值对每个接收方只成功发送一次
+type ReminderDelivery struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID          uint       `gorm:"not null;uniqueIndex:idx_reminder_once" json:"task_id"`
+	Threshold       string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_reminder_once" json:"threshold"`  // 提醒阈值，见 Reminder* 常量
+	DueTime         time.Time  `gorm:"type:datetime;not null;uniqueIndex:idx_reminder_once" json:"due_time"`      // 提醒针对的截止时间（截止时间修改后重新提醒）
+	Recipient       string     `gorm:"type:varchar(150);not null;uniqueIndex:idx_reminder_once" json:"recipient"` // 接收方（workspace:ID 或 assignee:昵称，重新登录后 UserName 会变化，不直接记录）
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`           // pending, sent, failed
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`                                        // 已尝试发送的次数
+	LastError       string     `gorm:"type:text" json:"last_error"`
+	SentTime        *time.Time `gorm:"type:datetime;null" json:"sent_time"`
+	NextAttemptTime *time.Time `gorm:"type:datetime;null;index" json:"next_attempt_time"` // 发送失败后下一次重试的时间
+	Replied         bool       `gorm:"not null;default:false" json:"replied"`             // 用户是否已回复（稍后提醒或知道了）
+	CreateTime      time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (ReminderDelivery) TableName() string {
+	return "task_reminder_deliveries"
+}
+
+// ReminderSnooze 稍后提醒：到 RemindAt 时向同一接收方再提醒一次，此前不发送该任务的其他提醒
+type ReminderSnooze struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID     uint      `gorm:"not null;index" json:"task_id"`
+	DueTime    time.Time `gorm:"type:datetime;not null" json:"due_time"`                          // 推迟时任务的截止时间（截止时间修改后失效）
+	Recipient  string    `gorm:"type:varchar(150);not null" json:"recipient"`                     // 接收方，同 ReminderDelivery.Recipient
+	RemindAt   time.Time `gorm:"type:datetime;not null;index" json:"remind_at"`                   // 再次提醒的时间
+	Status     string    `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, fired, cancelled
+	ActorID    string    `gorm:"type:varchar(100);not null;default:''" json:"actor_id"`
+	CreateTime time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (ReminderSnooze) TableName() string {
+	return "task_reminder_snoozes"
+}
+
+// ReminderAck 已确认（“知道了”）的提醒：同一截止时间内不再提醒该任务
+type ReminderAck struct {
+	TaskID     uint      `gorm:"primaryKey" json:"task_id"`
+	DueTime    time.Time `gorm:"primaryKey;type:datetime" json:"due_time"`
+	ActorID    string    `gorm:"type:varchar(100);not null;default:''" json:"actor_id"`
+	ActorName  string    `gorm:"type:varchar(100);not null;default:''" json:"actor_name"`
+	CreateTime time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (ReminderAck) TableName() string {
+	return "task_reminder_acks"
+}
+
+// DigestRun 摘要的发送记录：同一种摘要对每个接收方每期只发送一次
+type DigestRun struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind            string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_digest_once" json:"kind"`       // 摘要类型，见 Digest* 常量
+	Recipient       string     `gorm:"type:varchar(150);not null;uniqueIndex:idx_digest_once" json:"recipient"` // 接收方（user:昵称 或 workspace:ID）
+	Period          string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_digest_once" json:"period"`     // 摘要所属的日期（接收方时区的 YYYY-MM-DD）
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`               // pending, sent, failed, skipped（没有内容）
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`
+	LastError       string     `gorm:"type:text" json:"last_error"`
+	SentTime        *time.Time `gorm:"type:datetime;null" json:"sent_time"`
+	NextAttemptTime *time.Time `gorm:"type:datetime;null;index" json:"next_attempt_time"` // 发送失败后下一次重试的时间
+	CreateTime      time.Time  `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (DigestRun) TableName() string {
+	return "task_digest_runs"
+}
+
+// NonWorkingDay 工作区自定义的休息日（如公司年会、团建），计算工作日时跳过
+type NonWorkingDay struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	WorkspaceID uint      `gorm:"not null;uniqueIndex:idx_non_working_day" json:"workspace_id"`
+	Date        string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_non_working_day" json:"date"` // YYYY-MM-DD
+	Name        string    `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	CreatedBy   string    `gorm:"type:varchar(100);not null;default:''" json:"created_by"`
+	CreateTime  time.Time `gorm:"type:datetime;not null" json:"create_time"`
+}
+
+// TableName 指定表名
+func (NonWorkingDay) TableName() string {
+	return "task_non_working_days"
+}
+
 // TaskManager 任务管理器
 type TaskManager struct {
-	db *gorm.DB
+	db      *gorm.DB
+	actor   Actor           // 执行修改的用户，用于记录任务动态
+	pending *pendingEffects // 事务中推迟到提交后执行的副作用，为 nil 时立即执行
 }
 
 // TaskStatus 任务状态常量
@@ -50,6 +285,33 @@ const (
 	StatusCancelled   = "cancelled"
 )
 
+// 任务优先级
+const (
+	PriorityNone   = 0
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+	PriorityUrgent = 4
+)
+
+// IsRecurring 是否为重复任务
+func (t *Task) IsRecurring() bool {
+	return t.RecurrenceRule != ""
+}
+
+// Estimate 预计耗时
+func (t *Task) Estimate() time.Duration {
+	return time.Duration(t.EstimateMinutes) * time.Minute
+}
+
+// DisplayKey 任务的显示编号，没有工作区编号的旧任务使用全局ID
+func (t *Task) DisplayKey() string {
+	if t.Key != "" {
+		return t.Key
+	}
+	return strconv.FormatUint(uint64(t.ID), 10)
+}
+
 // GetDependencyIDs 获取依赖任务ID列表（用于JSON序列化）
 func (t *Task) GetDependencyIDs() []uint {
 	ids := make([]uint, len(t.Dependencies))
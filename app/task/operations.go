@@ -4,14 +4,29 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// CreateTask 创建任务
-func (tm *TaskManager) CreateTask(title, content, creatorID string, dueTime *time.Time, dependencies []uint) (*Task, error) {
-	log.Printf("CreateTask called: title=%s, content_length=%d, creatorID=%s, dependencies=%v\n", title, len(content), creatorID, dependencies)
+// CreateTask 在工作区中创建任务
+func (tm *TaskManager) CreateTask(workspaceID uint, title, content, creatorID string, dueTime *time.Time, dependencies []uint) (*Task, error) {
+	task, err := tm.newTask(workspaceID, title, content, creatorID, dueTime, dependencies)
+	if err != nil {
+		return nil, err
+	}
+	if err := tm.saveNewTask(task, dependencies); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created task successfully: %s (ID: %d)\n", task.Title, task.ID)
+	return task, nil
+}
+
+// newTask 校验参数并构造尚未保存的任务
+func (tm *TaskManager) newTask(workspaceID uint, title, content, creatorID string, dueTime *time.Time, dependencies []uint) (*Task, error) {
+	log.Printf("CreateTask called: workspace=%d, title=%s, content_length=%d, creatorID=%s, dependencies=%v\n", workspaceID, title, len(content), creatorID, dependencies)
 
 	// 验证必需参数
 	if content == "" {
@@ -46,14 +61,57 @@ func (tm *TaskManager) CreateTask(title, content, creatorID string, dueTime *tim
 		Title:         title,
 		Content:       content,
 		CreatorID:     creatorID,
+		WorkspaceID:   workspaceID,
 		CreateTime:    time.Now(),
 		DueTime:       dueTime,
 		Status:        StatusPending,
 		Dependencies:  make([]TaskDependency, 0),
 	}
+	return task, nil
+}
+
+// CreateRecurringTask 创建重复任务（作为系列的第一次发生）
+// 未指定截止时间时，使用规则从今天起的第一次发生日期
+func (tm *TaskManager) CreateRecurringTask(workspaceID uint, title, content, creatorID string, dueTime *time.Time, dependencies []uint, rule string) (*Task, error) {
+	recurrence, err := ParseRecurrence(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	if dueTime == nil {
+		first, ok := recurrence.First(time.Now())
+		if !ok {
+			return nil, fmt.Errorf("recurrence rule %s has no occurrence", rule)
+		}
+		dueTime = &first
+	}
+
+	task, err := tm.newTask(workspaceID, title, content, creatorID, dueTime, dependencies)
+	if err != nil {
+		return nil, err
+	}
+	// 重复规则和系列ID与任务一起保存，提醒和通知看到的始终是完整的重复任务
+	task.RecurrenceRule = recurrence.String()
+	if err := tm.saveNewTask(task, dependencies); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created recurring task %d: %s\n", task.ID, task.RecurrenceRule)
+	return task, nil
+}
 
-	// 使用事务保存任务和依赖关系
+// saveNewTask 在事务中保存新任务及其依赖关系
+func (tm *TaskManager) saveNewTask(task *Task, dependencies []uint) error {
 	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		// 分配工作区内的任务编号
+		if task.WorkspaceID != 0 {
+			number, err := nextTaskNumber(tx, task.WorkspaceID)
+			if err != nil {
+				return err
+			}
+			task.Number = number
+		}
+
 		// 保存任务
 		if err := tx.Create(task).Error; err != nil {
 			log.Printf("ERROR: Failed to create task in database: %v\n", err)
@@ -61,6 +119,14 @@ func (tm *TaskManager) CreateTask(title, content, creatorID string, dueTime *tim
 		}
 		log.Printf("Task created with ID: %d\n", task.ID)
 
+		// 新的重复系列以第一次发生的任务ID作为系列ID
+		if task.RecurrenceRule != "" && task.SeriesID == 0 {
+			if err := tx.Model(task).Update("series_id", task.ID).Error; err != nil {
+				return fmt.Errorf("failed to set task series: %v", err)
+			}
+			task.SeriesID = task.ID
+		}
+
 		// 保存依赖关系
 		if len(dependencies) > 0 {
 			deps := make([]TaskDependency, len(dependencies))
@@ -83,14 +149,14 @@ func (tm *TaskManager) CreateTask(title, content, creatorID string, dueTime *tim
 
 	if err != nil {
 		log.Printf("ERROR: Transaction failed: %v\n", err)
-		return nil, err
+		return err
 	}
 
-	log.Printf("Created task successfully: %s (ID: %d)\n", title, task.ID)
-	return task, nil
+	tm.logActivity(task, ActivityCreated, "", "", "")
+	return nil
 }
 
-// checkDependencies 检查依赖关系，防止循环依赖
+// checkDependencies 检查依赖关系，防止循环依赖（一次性加载依赖图，在内存中检查）
 func (tm *TaskManager) checkDependencies(dependencies []uint, currentTaskID uint) error {
 	log.Printf("checkDependencies called: dependencies=%v, currentTaskID=%d\n", dependencies, currentTaskID)
 
@@ -99,54 +165,14 @@ func (tm *TaskManager) checkDependencies(dependencies []uint, currentTaskID uint
 		return nil
 	}
 
-	visited := make(map[uint]bool)
-
-	var checkCycle func(taskID uint) error
-	checkCycle = func(taskID uint) error {
-		log.Printf("Checking dependency: %d\n", taskID)
-		if taskID == currentTaskID && currentTaskID != 0 {
-			return fmt.Errorf("circular dependency detected")
-		}
-
-		if visited[taskID] {
-			log.Printf("Dependency %d already visited, skipping\n", taskID)
-			return nil // 已经检查过，避免重复
-		}
-
-		visited[taskID] = true
-
-		// 检查任务是否存在
-		var task Task
-		if err := tm.db.First(&task, "id = ?", taskID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				log.Printf("ERROR: Dependency task %d not found\n", taskID)
-				return fmt.Errorf("dependency task %d not found", taskID)
-			}
-			return fmt.Errorf("failed to check dependency task %d: %v", taskID, err)
-		}
-
-		// 加载依赖关系
-		var deps []TaskDependency
-		if err := tm.db.Where("task_id = ?", taskID).Find(&deps).Error; err != nil {
-			return fmt.Errorf("failed to load dependencies for task %d: %v", taskID, err)
-		}
-
-		log.Printf("Dependency task %d found, checking its dependencies...\n", taskID)
-		// 递归检查依赖任务的依赖
-		for _, dep := range deps {
-			if err := checkCycle(dep.DependencyID); err != nil {
-				return err
-			}
-		}
-
-		return nil
+	g, err := tm.loadGraph(append([]uint{currentTaskID}, dependencies...)...)
+	if err != nil {
+		return err
 	}
 
-	for _, depID := range dependencies {
-		if err := checkCycle(depID); err != nil {
-			log.Printf("Dependency check failed for %d: %v\n", depID, err)
-			return err
-		}
+	if err := g.findCycle(currentTaskID, dependencies); err != nil {
+		log.Printf("ERROR: Dependency check failed: %v\n", err)
+		return err
 	}
 
 	log.Printf("All dependencies checked successfully\n")
@@ -156,13 +182,16 @@ func (tm *TaskManager) checkDependencies(dependencies []uint, currentTaskID uint
 // GetTask 获取任务
 func (tm *TaskManager) GetTask(id uint) (*Task, bool) {
 	var task Task
-	if err := tm.db.Preload("Dependencies").First(&task, "id = ?", id).Error; err != nil {
+	if err := tm.db.Preload("Dependencies").
+		Preload("ChecklistItems", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		First(&task, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, false
 		}
 		log.Printf("ERROR: Failed to get task: %v\n", err)
 		return nil, false
 	}
+	tm.annotate([]*Task{&task})
 	return &task, true
 }
 
@@ -193,8 +222,17 @@ func (tm *TaskManager) UpdateTaskDependencies(taskID uint, dependencies []uint)
 		}
 	}
 
-	// 使用事务更新依赖关系
+	var oldDependencies []uint
+	if err := tm.db.Model(&TaskDependency{}).Where("task_id = ?", taskID).Order("dependency_id ASC").Pluck("dependency_id", &oldDependencies).Error; err != nil {
+		return fmt.Errorf("failed to load dependencies: %v", err)
+	}
+
+	// 使用事务更新依赖关系，并以乐观锁增加任务版本号
 	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, &task, nil); err != nil {
+			return err
+		}
+
 		// 删除旧的依赖关系
 		if err := tx.Where("task_id = ?", taskID).Delete(&TaskDependency{}).Error; err != nil {
 			log.Printf("ERROR: Failed to delete old dependencies: %v\n", err)
@@ -219,17 +257,23 @@ func (tm *TaskManager) UpdateTaskDependencies(taskID uint, dependencies []uint)
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	tm.logActivity(&task, ActivityDependency, "", tm.formatTaskKeys(oldDependencies), tm.formatTaskKeys(dependencies))
+	return nil
 }
 
-// ListTasks 列出任务（支持按状态和创建人筛选）
-func (tm *TaskManager) ListTasks(status string, creatorID string) []*Task {
+// ListTasks 列出工作区内可见的任务（支持按状态和创建人筛选），workspaceID 为 0 表示所有工作区
+func (tm *TaskManager) ListTasks(workspaceID uint, status string, creatorID string) []*Task {
 	var tasks []*Task
-	query := tm.db.Preload("Dependencies")
+	query := workspaceScope(tm.db.Preload("Dependencies"), workspaceID)
 	
-	if status != "" {
-		query = query.Where("status = ?", status)
+	if status == StatusBlocked {
+		query = blockedCondition(query)
+	} else if status != "" {
+		query = query.Where("tasks.status = ?", status)
 	}
 	
 	if creatorID != "" {
@@ -241,46 +285,13 @@ func (tm *TaskManager) ListTasks(status string, creatorID string) []*Task {
 		return []*Task{}
 	}
 
+	tm.annotate(tasks)
 	return tasks
 }
 
-// UpdateTaskStatus 更新任务状态
+// UpdateTaskStatus 更新任务状态（遵循状态转换表，存在未完成依赖时拒绝完成）
 func (tm *TaskManager) UpdateTaskStatus(id uint, status string) error {
-	// 验证状态
-	validStatuses := map[string]bool{
-		StatusPending:    true,
-		StatusInProgress: true,
-		StatusCompleted:  true,
-		StatusCancelled:  true,
-	}
-	if !validStatuses[status] {
-		return fmt.Errorf("invalid status: %s", status)
-	}
-
-	// 检查任务是否存在
-	var task Task
-	if err := tm.db.First(&task, "id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("task %d not found", id)
-		}
-		return fmt.Errorf("failed to get task: %v", err)
-	}
-
-	// 更新状态
-	updates := map[string]interface{}{
-		"status": status,
-	}
-	if status == StatusCompleted {
-		now := time.Now()
-		updates["completed_time"] = &now
-	}
-
-	if err := tm.db.Model(&task).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update task status: %v", err)
-	}
-
-	log.Printf("Updated task %d status to %s\n", id, status)
-	return nil
+	return tm.TransitionTaskStatus(id, status, false)
 }
 
 // UpdateTaskStatusByString 通过字符串ID更新任务状态（用于兼容）
@@ -292,8 +303,36 @@ func (tm *TaskManager) UpdateTaskStatusByString(idStr, status string) error {
 	return tm.UpdateTaskStatus(uint(id), status)
 }
 
-// UpdateTask 更新任务的多个字段（标题、内容、截止时间等）
-func (tm *TaskManager) UpdateTask(id uint, title *string, content *string, dueTime *time.Time) error {
+// TaskUpdate 任务字段修改：指针为 nil 的字段不修改，Clear 中列出的字段清空
+type TaskUpdate struct {
+	Title           *string
+	Content         *string
+	DueTime         *time.Time
+	Estimate        *time.Duration
+	Priority        *int
+	ReminderOffsets *[]time.Duration // 提醒提前量，空切片表示恢复默认
+	Clear           []string         // 要清空的字段，见 Clearable* 常量
+	ExpectedVersion uint     // 调用方看到的版本号，任务版本不一致时返回 ErrConflict；0 表示以读取时的版本为准
+}
+
+// 可以清空的字段
+const (
+	ClearableDueTime = "due_time"
+	ClearableContent = "content"
+)
+
+// UpdateTask 更新任务的标题、内容、截止时间、预计耗时、优先级和提醒提前量，所有字段在一次写入中完成
+// （乐观锁：任务在此期间被他人修改时返回 ErrConflict）
+func (tm *TaskManager) UpdateTask(id uint, update TaskUpdate) error {
+	if update.Estimate != nil && *update.Estimate < 0 {
+		return fmt.Errorf("estimate must not be negative")
+	}
+	if update.Priority != nil {
+		if _, ok := priorityNames[*update.Priority]; !ok {
+			return fmt.Errorf("invalid priority: %d", *update.Priority)
+		}
+	}
+
 	// 检查任务是否存在
 	var task Task
 	if err := tm.db.First(&task, "id = ?", id).Error; err != nil {
@@ -302,20 +341,56 @@ func (tm *TaskManager) UpdateTask(id uint, title *string, content *string, dueTi
 		}
 		return fmt.Errorf("failed to get task: %v", err)
 	}
+	if update.ExpectedVersion != 0 && update.ExpectedVersion != task.Version {
+		return conflictError(id)
+	}
 
 	// 构建更新字段
+	next := task
 	updates := make(map[string]interface{})
-	if title != nil {
-		updates["title"] = *title
-	}
-	if content != nil {
-		updates["content"] = *content
-	}
-	if dueTime != nil {
-		updates["due_time"] = *dueTime
-	} else if dueTime == nil && len(updates) > 0 {
-		// 如果明确传入 nil，表示要清空截止时间
-		// 这里不处理，因为 nil 指针无法区分"不更新"和"清空"
+	if update.Title != nil {
+		updates["title"] = *update.Title
+		next.Title = *update.Title
+	}
+	if update.Content != nil {
+		updates["content"] = *update.Content
+		next.Content = *update.Content
+	}
+	if update.DueTime != nil {
+		updates["due_time"] = *update.DueTime
+		next.DueTime = update.DueTime
+	}
+	if update.Estimate != nil {
+		minutes := int(*update.Estimate / time.Minute)
+		updates["estimate_minutes"] = minutes
+		next.EstimateMinutes = minutes
+	}
+	if update.Priority != nil {
+		updates["priority"] = *update.Priority
+		next.Priority = *update.Priority
+	}
+	if update.ReminderOffsets != nil {
+		encoded := encodeReminderOffsets(*update.ReminderOffsets)
+		updates["reminder_offsets"] = encoded
+		next.ReminderOffsets = encoded
+	}
+	for _, field := range update.Clear {
+		switch field {
+		case ClearableDueTime:
+			if update.DueTime != nil {
+				return fmt.Errorf("cannot set and clear due_time at the same time")
+			}
+			updates["due_time"] = nil
+			next.DueTime = nil
+		case ClearableContent:
+			if update.Content != nil {
+				return fmt.Errorf("cannot set and clear content at the same time")
+			}
+			updates["content"] = ""
+			next.Content = ""
+		default:
+			return fmt.Errorf("field %s cannot be cleared", field)
+		}
 	}
 
 	if len(updates) == 0 {
@@ -323,19 +398,89 @@ func (tm *TaskManager) UpdateTask(id uint, title *string, content *string, dueTi
 	}
 
 	// 更新任务
-	if err := tm.db.Model(&task).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update task: %v", err)
+	if err := updateVersioned(tm.db, &task, updates); err != nil {
+		return err
 	}
 
 	log.Printf("Updated task %d: %v\n", id, updates)
+	tm.logFieldChanges(&task, &next)
 	return nil
 }
 
-// DeleteTask 删除任务
+// logFieldChanges 记录任务标题、内容、截止时间、预计耗时、优先级和提醒的修改（old 为修改前的任务，next 为修改后的任务）
+func (tm *TaskManager) logFieldChanges(old, next *Task) {
+	if next.Title != old.Title {
+		tm.logActivity(old, ActivityField, "title", old.Title, next.Title)
+	}
+	if next.Content != old.Content {
+		tm.logActivity(old, ActivityField, "content", old.Content, next.Content)
+	}
+	if formatDueTime(old.DueTime) != formatDueTime(next.DueTime) {
+		tm.logActivity(old, ActivityField, "due_time", formatDueTime(old.DueTime), formatDueTime(next.DueTime))
+	}
+	if next.EstimateMinutes != old.EstimateMinutes {
+		tm.logActivity(old, ActivityField, "estimate", formatEstimate(old.Estimate()), formatEstimate(next.Estimate()))
+	}
+	if next.Priority != old.Priority {
+		tm.logActivity(old, ActivityField, "priority", PriorityName(old.Priority), PriorityName(next.Priority))
+	}
+	if next.ReminderOffsets != old.ReminderOffsets {
+		tm.logActivity(old, ActivityField, "reminders", formatStoredOffsets(old.ReminderOffsets), formatStoredOffsets(next.ReminderOffsets))
+	}
+}
+
+// SetTaskEstimate 设置任务的预计耗时
+func (tm *TaskManager) SetTaskEstimate(id uint, estimate time.Duration) error {
+	return tm.UpdateTask(id, TaskUpdate{Estimate: &estimate})
+}
+
+// priorityNames 优先级的中文名称
+var priorityNames = map[int]string{
+	PriorityNone:   "未设置",
+	PriorityLow:    "低",
+	PriorityMedium: "中",
+	PriorityHigh:   "高",
+	PriorityUrgent: "紧急",
+}
+
+// PriorityName 优先级的中文名称
+func PriorityName(priority int) string {
+	if name, ok := priorityNames[priority]; ok {
+		return name
+	}
+	return strconv.Itoa(priority)
+}
+
+// ParsePriority 解析优先级：low/medium/high/urgent、低/中/高/紧急、P0-P3（P0 最紧急）或 0-4
+func ParsePriority(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none", "无", "未设置":
+		return PriorityNone, nil
+	case "low", "低", "p3", "1":
+		return PriorityLow, nil
+	case "medium", "normal", "中", "普通", "p2", "2":
+		return PriorityMedium, nil
+	case "high", "高", "重要", "p1", "3":
+		return PriorityHigh, nil
+	case "urgent", "紧急", "最高", "p0", "4":
+		return PriorityUrgent, nil
+	}
+	return 0, fmt.Errorf("invalid priority %q, use low, medium, high or urgent", s)
+}
+
+// SetTaskPriority 设置任务优先级
+func (tm *TaskManager) SetTaskPriority(id uint, priority int) error {
+	return tm.UpdateTask(id, TaskUpdate{Priority: &priority})
+}
+
+// DeleteTask 删除任务（移入回收站，可通过 RestoreTask 恢复）
 func (tm *TaskManager) DeleteTask(id uint) error {
-	// 检查是否有其他任务依赖此任务
+	// 检查是否有其他任务依赖此任务（回收站中的任务不算）
 	var count int64
-	if err := tm.db.Model(&TaskDependency{}).Where("dependency_id = ?", id).Count(&count).Error; err != nil {
+	if err := tm.db.Model(&TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.task_id").
+		Where("task_dependencies.dependency_id = ? AND tasks.deleted_at IS NULL", id).
+		Count(&count).Error; err != nil {
 		return fmt.Errorf("failed to check task dependencies: %v", err)
 	}
 
@@ -343,6 +488,15 @@ func (tm *TaskManager) DeleteTask(id uint) error {
 		return fmt.Errorf("cannot delete task %d: %d task(s) depend on it", id, count)
 	}
 
+	// 检查是否有子任务
+	var subtaskCount int64
+	if err := tm.db.Model(&Task{}).Where("parent_id = ?", id).Count(&subtaskCount).Error; err != nil {
+		return fmt.Errorf("failed to check subtasks: %v", err)
+	}
+	if subtaskCount > 0 {
+		return fmt.Errorf("cannot delete task %d: it has %d subtask(s)", id, subtaskCount)
+	}
+
 	// 检查任务是否存在
 	var task Task
 	if err := tm.db.First(&task, "id = ?", id).Error; err != nil {
@@ -352,12 +506,13 @@ func (tm *TaskManager) DeleteTask(id uint) error {
 		return fmt.Errorf("failed to get task: %v", err)
 	}
 
-	// 删除任务（依赖关系会通过外键级联删除）
+	// 移入回收站（软删除），超过保留期后由清理服务彻底删除
 	if err := tm.db.Delete(&task).Error; err != nil {
 		return fmt.Errorf("failed to delete task: %v", err)
 	}
 
-	log.Printf("Deleted task %d\n", id)
+	log.Printf("Moved task %d to trash\n", id)
+	tm.logActivity(&task, ActivityDeleted, "", "", "")
 	return nil
 }
 
@@ -370,15 +525,17 @@ func (tm *TaskManager) DeleteTaskByString(idStr string) error {
 	return tm.DeleteTask(uint(id))
 }
 
-// GetTaskCount 获取任务数量
-func (tm *TaskManager) GetTaskCount(status string) int {
-	log.Printf("GetTaskCount called with status: '%s'\n", status)
+// GetTaskCount 获取工作区内可见的任务数量
+func (tm *TaskManager) GetTaskCount(workspaceID uint, status string) int {
+	log.Printf("GetTaskCount called with workspace: %d, status: '%s'\n", workspaceID, status)
 
 	var count int64
-	query := tm.db.Model(&Task{})
+	query := workspaceScope(tm.db.Model(&Task{}), workspaceID)
 	
-	if status != "" {
-		query = query.Where("status = ?", status)
+	if status == StatusBlocked {
+		query = blockedCondition(query)
+	} else if status != "" {
+		query = query.Where("tasks.status = ?", status)
 	}
 	
 	if err := query.Count(&count).Error; err != nil {
@@ -390,19 +547,23 @@ func (tm *TaskManager) GetTaskCount(status string) int {
 	return int(count)
 }
 
-// GetOverdueTasks 获取过期任务
-func (tm *TaskManager) GetOverdueTasks() []*Task {
-	now := time.Now()
+// GetOverdueTasks 获取工作区内的过期任务，workspaceID 为 0 表示所有工作区
+func (tm *TaskManager) GetOverdueTasks(workspaceID uint) []*Task {
+	return tm.overdueTasks(workspaceScope(tm.db.Preload("Dependencies"), workspaceID), time.Now())
+}
+
+// overdueTasks 查询范围内在 now 之前到期但未完成的任务（按截止时间排序）
+func (tm *TaskManager) overdueTasks(query *gorm.DB, now time.Time) []*Task {
 	var tasks []*Task
-	
-	if err := tm.db.Preload("Dependencies").
-		Where("status NOT IN ? AND due_time IS NOT NULL AND due_time < ?", []string{StatusCompleted, StatusCancelled}, now).
-		Order("due_time ASC").
+	if err := query.
+		Where("tasks.status NOT IN ? AND tasks.due_time IS NOT NULL AND tasks.due_time < ?", []string{StatusCompleted, StatusCancelled}, now).
+		Order("tasks.due_time ASC").
 		Find(&tasks).Error; err != nil {
 		log.Printf("ERROR: Failed to get overdue tasks: %v\n", err)
 		return []*Task{}
 	}
 
+	tm.annotate(tasks)
 	return tasks
 }
 
@@ -424,6 +585,9 @@ func FormatTaskForDisplayWithManager(task *Task, tm *TaskManager) string {
 	if status == "" {
 		status = task.Status
 	}
+	if task.Blocked {
+		status += "（被阻塞，等待前置任务完成）"
+	}
 
 	result := fmt.Sprintf("📋 任务: %s\n", task.Title)
 	result += fmt.Sprintf("状态: %s\n", status)
@@ -440,6 +604,29 @@ func FormatTaskForDisplayWithManager(task *Task, tm *TaskManager) string {
 		result += fmt.Sprintf("内容: %s\n", task.Content)
 	}
 
+	if task.Priority != PriorityNone {
+		result += fmt.Sprintf("优先级: %s\n", PriorityName(task.Priority))
+	}
+
+	if task.ReminderOffsets != "" {
+		result += fmt.Sprintf("提醒: %s\n", formatStoredOffsets(task.ReminderOffsets))
+	}
+
+	if task.EstimateMinutes > 0 {
+		result += fmt.Sprintf("预计耗时: %s\n", formatEstimate(task.Estimate()))
+	}
+
+	if task.IsRecurring() {
+		if recurrence, err := ParseRecurrence(task.RecurrenceRule); err == nil {
+			result += fmt.Sprintf("重复: %s\n", recurrence.Describe())
+		} else {
+			result += fmt.Sprintf("重复: %s\n", task.RecurrenceRule)
+		}
+	}
+	if task.SeriesID != 0 && task.SeriesID != task.ID {
+		result += fmt.Sprintf("所属系列: 任务%s\n", tm.taskKeyOrID(task.SeriesID))
+	}
+
 	dependencyIDs := task.GetDependencyIDs()
 	if len(dependencyIDs) > 0 {
 		result += fmt.Sprintf("依赖任务: ")
@@ -451,7 +638,7 @@ func FormatTaskForDisplayWithManager(task *Task, tm *TaskManager) string {
 			if tm != nil {
 				depTask, exists := tm.GetTask(depID)
 				if exists {
-					result += fmt.Sprintf("任务%d(%s)", depID, depTask.Title)
+					result += fmt.Sprintf("任务%s(%s %s)", depTask.DisplayKey(), statusEmoji(depTask.Status), depTask.Title)
 				} else {
 					result += fmt.Sprintf("任务%d", depID)
 				}
@@ -466,7 +653,55 @@ func FormatTaskForDisplayWithManager(task *Task, tm *TaskManager) string {
 		result += fmt.Sprintf("完成时间: %s\n", task.CompletedTime.Format("2006-01-02 15:04:05"))
 	}
 
-	result += fmt.Sprintf("ID: %d", task.ID)
+	// 父任务、子任务树和检查项需要查询数据库，只在提供了TaskManager时显示
+	if tm != nil {
+		if task.ParentID != 0 {
+			if parent, exists := tm.GetTask(task.ParentID); exists {
+				result += fmt.Sprintf("父任务: 任务%s(%s)\n", parent.DisplayKey(), parent.Title)
+			} else {
+				result += fmt.Sprintf("父任务: 任务%d\n", task.ParentID)
+			}
+		}
+
+		if done, total := tm.SubtaskProgress(task.ID); total > 0 {
+			result += fmt.Sprintf("子任务进度: %d/%d", done, total)
+			if task.AutoComplete {
+				result += "（全部完成后自动完成）"
+			}
+			result += "\n"
+			result += formatSubtaskTree(tm, task.ID, 0, map[uint]bool{task.ID: true})
+		}
+
+		if assignees := tm.GetTaskAssignees(task.ID); len(assignees) > 0 {
+			result += fmt.Sprintf("负责人: %s\n", formatAssignees(assignees))
+		}
+		if labels := tm.GetTaskLabels(task.ID); len(labels) > 0 {
+			result += fmt.Sprintf("标签: %s\n", formatLabels(labels))
+		}
+
+		if shares := tm.GetTaskShares(task.ID); len(shares) > 0 {
+			names := make([]string, len(shares))
+			for i, ws := range shares {
+				names[i] = ws.Name
+			}
+			result += fmt.Sprintf("共享到: %s\n", strings.Join(names, "、"))
+		}
+	}
+
+	if len(task.ChecklistItems) > 0 {
+		done, total := ChecklistProgress(task.ChecklistItems)
+		result += fmt.Sprintf("检查项 (%d/%d):\n", done, total)
+		for _, item := range task.ChecklistItems {
+			mark := "☐"
+			if item.Checked {
+				mark = "☑"
+			}
+			result += fmt.Sprintf("  %d. %s %s\n", item.Position, mark, item.Content)
+		}
+	}
+
+	result += fmt.Sprintf("版本: %d\n", task.Version)
+	result += fmt.Sprintf("ID: %s", task.DisplayKey())
 
 	return result
 }
@@ -478,21 +713,29 @@ func FormatTaskListForDisplay(tasks []*Task) string {
 	}
 
 	result := fmt.Sprintf("📋 任务列表 (共 %d 个):\n\n", len(tasks))
+	result += formatTaskItems(tasks, 0)
+
+	return result
+}
 
+// formatTaskItems 格式化任务列表的条目，序号从 offset+1 开始
+func formatTaskItems(tasks []*Task, offset int) string {
+	result := ""
 	for i, task := range tasks {
-		statusEmoji := map[string]string{
-			StatusPending:    "⏳",
-			StatusInProgress: "🔄",
-			StatusCompleted:  "✅",
-			StatusCancelled:  "❌",
+		emoji := statusEmoji(task.Status)
+		if task.Blocked {
+			emoji = "⛔"
 		}
 
-		emoji := statusEmoji[task.Status]
-		if emoji == "" {
-			emoji = "📝"
+		repeat := ""
+		if task.IsRecurring() {
+			repeat = " 🔁"
+		}
+		if task.Priority != PriorityNone {
+			repeat += fmt.Sprintf(" [%s]", PriorityName(task.Priority))
 		}
 
-		result += fmt.Sprintf("%d. %s %s (ID: %d)\n", i+1, emoji, task.Title, task.ID)
+		result += fmt.Sprintf("%d. %s %s%s (ID: %s)\n", offset+i+1, emoji, task.Title, repeat, task.DisplayKey())
 		result += fmt.Sprintf("   创建人ID: %s", task.CreatorID)
 		
 		if task.DueTime != nil {
@@ -506,8 +749,38 @@ func FormatTaskListForDisplay(tasks []*Task) string {
 			result += fmt.Sprintf("   依赖: %d个任务\n", len(dependencyIDs))
 		}
 
+		if task.ParentID != 0 {
+			result += fmt.Sprintf("   父任务: 任务%s\n", GetTaskManager().TaskKey(task.ParentID))
+		}
+
 		result += "\n"
 	}
 
 	return result
 }
+
+// statusEmoji 返回任务状态对应的表情
+func statusEmoji(status string) string {
+	emoji := map[string]string{
+		StatusPending:    "⏳",
+		StatusInProgress: "🔄",
+		StatusCompleted:  "✅",
+		StatusCancelled:  "❌",
+	}[status]
+	if emoji == "" {
+		emoji = "📝"
+	}
+	return emoji
+}
+
+// formatEstimate 格式化预计耗时
+func formatEstimate(d time.Duration) string {
+	hours := d.Hours()
+	if hours >= 24 && int(hours)%24 == 0 {
+		return fmt.Sprintf("%d天", int(hours)/24)
+	}
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%d小时", int(hours))
+	}
+	return fmt.Sprintf("%d分钟", int(d/time.Minute))
+}
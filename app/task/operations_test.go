@@ -0,0 +1,123 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateTaskAppliesAllFieldsInOneVersion(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	task := mustTask(t, tm, ws.ID, "写周报", nil)
+
+	title := "写月报"
+	estimate := 90 * time.Minute
+	priority := PriorityHigh
+	offsets := []time.Duration{time.Hour, 10 * time.Minute}
+	err := tm.UpdateTask(task.ID, TaskUpdate{
+		Title:           &title,
+		Estimate:        &estimate,
+		Priority:        &priority,
+		ReminderOffsets: &offsets,
+		ExpectedVersion: task.Version,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := tm.GetTask(task.ID)
+	if got.Version != task.Version+1 {
+		t.Errorf("version = %d, want %d", got.Version, task.Version+1)
+	}
+	if got.Title != title || got.Estimate() != estimate || got.Priority != priority || got.ReminderOffsets != encodeReminderOffsets(offsets) {
+		t.Errorf("task = {%s %s %d %s}, want {%s %s %d %s}", got.Title, got.Estimate(), got.Priority, got.ReminderOffsets,
+			title, estimate, priority, encodeReminderOffsets(offsets))
+	}
+
+	fields := make(map[string]bool)
+	for _, a := range tm.GetTaskActivity(task.ID, 20) {
+		fields[a.Field] = true
+	}
+	for _, field := range []string{"title", "estimate", "priority", "reminders"} {
+		if !fields[field] {
+			t.Errorf("no activity logged for %s", field)
+		}
+	}
+}
+
+func TestUpdateTaskStaleVersion(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	task := mustTask(t, tm, ws.ID, "写周报", nil)
+	if err := tm.SetTaskEstimate(task.ID, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// 调用方看到的仍是修改预计耗时之前的版本
+	priority := PriorityUrgent
+	offsets := []time.Duration{}
+	err := tm.UpdateTask(task.ID, TaskUpdate{Priority: &priority, ReminderOffsets: &offsets, ExpectedVersion: task.Version})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("UpdateTask with stale version error = %v, want ErrConflict", err)
+	}
+	got, _ := tm.GetTask(task.ID)
+	if got.Priority == priority {
+		t.Errorf("priority was written despite the version conflict")
+	}
+}
+
+func TestUpdateTaskValidation(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	task := mustTask(t, tm, ws.ID, "写周报", nil)
+
+	negative := -time.Hour
+	if err := tm.UpdateTask(task.ID, TaskUpdate{Estimate: &negative}); err == nil {
+		t.Error("UpdateTask with negative estimate succeeded")
+	}
+	invalid := 42
+	if err := tm.UpdateTask(task.ID, TaskUpdate{Priority: &invalid}); err == nil {
+		t.Error("UpdateTask with invalid priority succeeded")
+	}
+	if err := tm.UpdateTask(task.ID, TaskUpdate{}); err == nil {
+		t.Error("UpdateTask without fields succeeded")
+	}
+}
+
+func TestSettersBumpVersion(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	parent := mustTask(t, tm, ws.ID, "发布", nil)
+	dep := mustTask(t, tm, ws.ID, "评审", nil)
+
+	tests := []struct {
+		name   string
+		change func(id uint) error
+		bumps  bool
+	}{
+		{"依赖", func(id uint) error { return tm.UpdateTaskDependencies(id, []uint{dep.ID}) }, true},
+		{"父任务", func(id uint) error { return tm.SetTaskParent(id, parent.ID) }, true},
+		{"自动完成", func(id uint) error { return tm.SetAutoComplete(id, true) }, true},
+		{"自动完成不变", func(id uint) error { return tm.SetAutoComplete(id, false) }, false},
+		{"标签", func(id uint) error { return tm.UpdateTaskLabels(id, []string{"release"}, nil, false) }, true},
+		{"标签不变", func(id uint) error { return tm.UpdateTaskLabels(id, nil, []string{"missing"}, false) }, false},
+		{"负责人", func(id uint) error { return tm.UpdateTaskAssignees(id, []Actor{{Name: "bob"}}, nil, false) }, true},
+		{"负责人不变", func(id uint) error { return tm.UpdateTaskAssignees(id, nil, []string{"carol"}, false) }, false},
+	}
+	for _, tt := range tests {
+		task := mustTask(t, tm, ws.ID, tt.name, nil)
+		if err := tt.change(task.ID); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got, _ := tm.GetTask(task.ID)
+		want := task.Version
+		if tt.bumps {
+			want++
+		}
+		if got.Version != want {
+			t.Errorf("%s: version = %d, want %d", tt.name, got.Version, want)
+		}
+	}
+}
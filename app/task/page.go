@@ -0,0 +1,222 @@
+package task
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPageSize 任务列表每页默认显示的任务数（一条微信消息不宜过长）
+const DefaultPageSize = 10
+
+// TaskPage 一页任务
+type TaskPage struct {
+	Tasks      []*Task
+	Offset     int    // 本页第一个任务在结果中的序号（从0开始）
+	Total      int64  // 符合条件的任务总数
+	NextCursor string // 下一页的游标，为空表示没有更多
+}
+
+// pageCursor 游标内容：上一页最后一个任务的排序值和ID
+type pageCursor struct {
+	SortBy  string `json:"s"`
+	Desc    bool   `json:"d"`
+	Value   string `json:"v"`
+	ID      uint   `json:"i"`
+	Offset  int    `json:"o"`
+	NullDue bool   `json:"n,omitempty"` // 按截止时间排序时，上一个任务没有截止时间
+}
+
+// QueryTasksPage 按筛选条件分页查询任务，cursor 为上一页返回的 NextCursor，为空表示第一页
+func (tm *TaskManager) QueryTasksPage(filter TaskFilter, cursor string, pageSize int) (*TaskPage, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	sortBy, desc := normalizeSort(filter.SortBy, filter.SortReverse)
+
+	base := applyKeyword(applyTaskFilter(tm.db.Model(&Task{}), filter), filter.Keyword)
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %v", err)
+	}
+
+	query := applyKeyword(applyTaskFilter(tm.db.Preload("Dependencies"), filter), filter.Keyword)
+	offset := 0
+	if cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.SortBy != sortBy || c.Desc != desc {
+			return nil, fmt.Errorf("cursor does not match the sort order")
+		}
+		if query, err = applyCursor(query, c); err != nil {
+			return nil, err
+		}
+		offset = c.Offset
+	}
+
+	// 多取一条用于判断是否还有下一页
+	var tasks []*Task
+	if err := query.Order(sortOrder(sortBy, desc)).Limit(pageSize + 1).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %v", err)
+	}
+
+	page := &TaskPage{Offset: offset, Total: total}
+	if len(tasks) > pageSize {
+		tasks = tasks[:pageSize]
+		last := tasks[len(tasks)-1]
+		page.NextCursor = encodeCursor(newCursor(sortBy, desc, last, offset+len(tasks)))
+	}
+	tm.annotate(tasks)
+	page.Tasks = tasks
+	return page, nil
+}
+
+// normalizeSort 补全排序字段，返回实际的排序方向
+// 截止时间和编号默认正序（最早的在前），创建时间、修改时间和优先级默认倒序（最新、最高的在前）
+func normalizeSort(sortBy string, reverse bool) (string, bool) {
+	if sortBy == "" {
+		sortBy = SortCreated
+	}
+	desc := sortBy == SortCreated || sortBy == SortUpdated || sortBy == SortPriority
+	if reverse {
+		desc = !desc
+	}
+	return sortBy, desc
+}
+
+// sortColumns 排序字段对应的列
+var sortColumns = map[string]string{
+	SortDue:      "tasks.due_time",
+	SortCreated:  "tasks.create_time",
+	SortUpdated:  "tasks.update_time",
+	SortPriority: "tasks.priority",
+	SortKey:      "tasks.id",
+}
+
+// sortOrder 排序字段对应的 ORDER BY 子句，ID 作为第二排序键保证顺序稳定（没有截止时间的任务总是排在最后）
+func sortOrder(sortBy string, desc bool) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	column, ok := sortColumns[sortBy]
+	if !ok {
+		column = sortColumns[SortCreated]
+	}
+	switch sortBy {
+	case SortKey:
+		return "tasks.id " + dir
+	case SortDue:
+		return "tasks.due_time IS NULL, tasks.due_time " + dir + ", tasks.id " + dir
+	default:
+		return column + " " + dir + ", tasks.id " + dir
+	}
+}
+
+// newCursor 根据本页最后一个任务生成游标
+func newCursor(sortBy string, desc bool, last *Task, offset int) pageCursor {
+	c := pageCursor{SortBy: sortBy, Desc: desc, ID: last.ID, Offset: offset}
+	switch sortBy {
+	case SortDue:
+		if last.DueTime == nil {
+			c.NullDue = true
+		} else {
+			c.Value = last.DueTime.Format(time.RFC3339Nano)
+		}
+	case SortCreated:
+		c.Value = last.CreateTime.Format(time.RFC3339Nano)
+	case SortUpdated:
+		c.Value = last.UpdateTime.Format(time.RFC3339Nano)
+	case SortPriority:
+		c.Value = strconv.Itoa(last.Priority)
+	}
+	return c
+}
+
+// applyCursor 只查询排在游标之后的任务
+func applyCursor(query *gorm.DB, c pageCursor) (*gorm.DB, error) {
+	cmp := ">"
+	if c.Desc {
+		cmp = "<"
+	}
+
+	var value interface{}
+	switch c.SortBy {
+	case SortKey:
+		return query.Where("tasks.id "+cmp+" ?", c.ID), nil
+	case SortPriority:
+		p, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		value = p
+	case SortDue:
+		if c.NullDue {
+			return query.Where("tasks.due_time IS NULL AND tasks.id "+cmp+" ?", c.ID), nil
+		}
+		fallthrough
+	case SortCreated, SortUpdated:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		value = t
+	default:
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	column := sortColumns[c.SortBy]
+	condition := fmt.Sprintf("(%s %s ? OR (%s = ? AND tasks.id %s ?))", column, cmp, column, cmp)
+	if c.SortBy == SortDue {
+		// 有截止时间的任务之后还有没有截止时间的任务
+		return query.Where("((tasks.due_time IS NOT NULL AND "+condition+") OR tasks.due_time IS NULL)", value, value, c.ID), nil
+	}
+	return query.Where(condition, value, value, c.ID), nil
+}
+
+// encodeCursor 将游标编码为不透明的字符串
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游标字符串
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// FormatTaskPageForDisplay 格式化一页任务用于微信显示，title 为列表标题（如筛选条件）
+func FormatTaskPageForDisplay(title string, page *TaskPage) string {
+	if len(page.Tasks) == 0 {
+		if page.Offset > 0 {
+			return "📋 没有更多任务了"
+		}
+		return "📋 暂无任务"
+	}
+
+	if title == "" {
+		title = "任务列表"
+	}
+	from, to := page.Offset+1, page.Offset+len(page.Tasks)
+	result := fmt.Sprintf("📋 %s (第 %d-%d 个，共 %d 个):\n\n", title, from, to, page.Total)
+	result += formatTaskItems(page.Tasks, page.Offset)
+	if page.NextCursor != "" {
+		result += "回复「下一页」查看更多"
+	}
+	return strings.TrimRight(result, "\n")
+}
@@ -0,0 +1,97 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pageThrough 按 pageSize 逐页查询，返回所有页拼接起来的任务标题
+func pageThrough(t *testing.T, tm *TaskManager, filter TaskFilter, pageSize int) []string {
+	t.Helper()
+	var titles []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatal("paging does not terminate")
+		}
+		page, err := tm.QueryTasksPage(filter, cursor, pageSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Offset != len(titles) {
+			t.Errorf("page offset = %d, want %d", page.Offset, len(titles))
+		}
+		for _, task := range page.Tasks {
+			titles = append(titles, task.Title)
+		}
+		if page.NextCursor == "" {
+			return titles
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestQueryTasksPageCursor(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	base := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	at := func(h int) *time.Time {
+		t := base.Add(time.Duration(h) * time.Hour)
+		return &t
+	}
+
+	// 有相同的截止时间，也有没有截止时间的任务
+	for i, due := range []*time.Time{at(2), nil, at(1), at(2), nil, at(3), at(1)} {
+		task := mustTask(t, tm, ws.ID, fmt.Sprintf("任务%d", i+1), due)
+		if err := tm.SetTaskPriority(task.ID, []int{PriorityLow, PriorityHigh, PriorityMedium}[i%3]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sortBy  string
+		reverse bool
+		want    []string
+	}{
+		{SortDue, false, []string{"任务3", "任务7", "任务1", "任务4", "任务6", "任务2", "任务5"}},
+		{SortDue, true, []string{"任务6", "任务4", "任务1", "任务7", "任务3", "任务5", "任务2"}},
+		{SortKey, false, []string{"任务1", "任务2", "任务3", "任务4", "任务5", "任务6", "任务7"}},
+		{SortKey, true, []string{"任务7", "任务6", "任务5", "任务4", "任务3", "任务2", "任务1"}},
+		{SortPriority, false, []string{"任务5", "任务2", "任务6", "任务3", "任务7", "任务4", "任务1"}},
+		{SortPriority, true, []string{"任务1", "任务4", "任务7", "任务3", "任务6", "任务2", "任务5"}},
+		// 创建和修改时间在同一秒内，游标需要保留秒以下的精度
+		{SortCreated, false, []string{"任务7", "任务6", "任务5", "任务4", "任务3", "任务2", "任务1"}},
+		{SortCreated, true, []string{"任务1", "任务2", "任务3", "任务4", "任务5", "任务6", "任务7"}},
+		{SortUpdated, false, []string{"任务7", "任务6", "任务5", "任务4", "任务3", "任务2", "任务1"}},
+		{SortUpdated, true, []string{"任务1", "任务2", "任务3", "任务4", "任务5", "任务6", "任务7"}},
+	}
+	for _, tt := range tests {
+		filter := TaskFilter{WorkspaceID: ws.ID, SortBy: tt.sortBy, SortReverse: tt.reverse}
+		for _, pageSize := range []int{1, 2, 3, 10} {
+			got := pageThrough(t, tm, filter, pageSize)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("sort %s reverse=%v page size %d: %v, want %v", tt.sortBy, tt.reverse, pageSize, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestQueryTasksPageRejectsCursorOfAnotherSort(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	for i := 0; i < 3; i++ {
+		mustTask(t, tm, ws.ID, fmt.Sprintf("任务%d", i+1), nil)
+	}
+
+	page, err := tm.QueryTasksPage(TaskFilter{WorkspaceID: ws.ID, SortBy: SortDue}, "", 2)
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("first page = %v, %v, want a next cursor", page, err)
+	}
+	if _, err := tm.QueryTasksPage(TaskFilter{WorkspaceID: ws.ID, SortBy: SortDue, SortReverse: true}, page.NextCursor, 2); err == nil {
+		t.Error("cursor of ascending order accepted for descending order")
+	}
+	if _, err := tm.QueryTasksPage(TaskFilter{WorkspaceID: ws.ID}, "not a cursor", 2); err == nil {
+		t.Error("invalid cursor accepted")
+	}
+}
@@ -0,0 +1,410 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 查询语言示例：status:pending assignee:me due<2026-11-01 label:release sort:due 发布
+//   - field:value        等于（逗号分隔表示“或”，如 status:pending,in_progress）
+//   - field<value 等      比较（due、created 支持 < <= > >=）
+//   - 其他词              作为关键词在标题和内容中匹配，带空格的值用引号括起来
+
+// QueryContext 解析查询时的上下文
+type QueryContext struct {
+	WorkspaceID uint
+	MeID        string    // me 对应的用户UserName
+	MeName      string    // me 对应的用户昵称
+	Now         time.Time // 解析 today、+3d 等相对日期的基准时间
+}
+
+// queryFields 查询字段及其别名
+var queryFields = map[string]string{
+	"status":   "status",
+	"状态":       "status",
+	"assignee": "assignee",
+	"负责人":      "assignee",
+	"creator":  "creator",
+	"创建人":      "creator",
+	"label":    "label",
+	"标签":       "label",
+	"due":      "due",
+	"截止":       "due",
+	"created":  "created",
+	"创建":       "created",
+	"is":       "is",
+	"sort":     "sort",
+	"排序":       "sort",
+	"priority": "priority",
+	"优先级":      "priority",
+	"limit":    "limit",
+}
+
+// queryFieldList 错误提示中列出的可用字段
+const queryFieldList = "status, assignee, creator, label, priority, due, created, is, sort, limit"
+
+// queryStatuses 状态值及其别名
+var queryStatuses = map[string][]string{
+	"pending":     {StatusPending},
+	"todo":        {StatusPending},
+	"待处理":         {StatusPending},
+	"in_progress": {StatusInProgress},
+	"doing":       {StatusInProgress},
+	"进行中":         {StatusInProgress},
+	"completed":   {StatusCompleted},
+	"done":        {StatusCompleted},
+	"已完成":         {StatusCompleted},
+	"cancelled":   {StatusCancelled},
+	"canceled":    {StatusCancelled},
+	"已取消":         {StatusCancelled},
+	"blocked":     {StatusBlocked},
+	"阻塞":          {StatusBlocked},
+	"open":        openStatuses,
+	"未完成":         openStatuses,
+	"closed":      {StatusCompleted, StatusCancelled},
+}
+
+// queryTermPattern 匹配 field:value、field<value 等形式
+var queryTermPattern = regexp.MustCompile(`^([^\s:<>=]+)(:|<=|>=|<|>|=)(.*)$`)
+
+// relativeDatePattern 匹配 +3d、-1w、+2h 等相对时间
+var relativeDatePattern = regexp.MustCompile(`^([+-]\d+)([hdwm])$`)
+
+// ParseQuery 将查询语言解析为任务筛选条件
+func ParseQuery(input string, ctx QueryContext) (*TaskFilter, error) {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	tokens, err := tokenizeQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &TaskFilter{WorkspaceID: ctx.WorkspaceID}
+	var keywords []string
+	for _, token := range tokens {
+		m := queryTermPattern.FindStringSubmatch(token)
+		if m == nil {
+			keywords = append(keywords, token)
+			continue
+		}
+		name, op, value := strings.ToLower(m[1]), m[2], unquote(m[3])
+		field, ok := queryFields[name]
+		if !ok {
+			return nil, fmt.Errorf("未知的查询字段 %q，可用字段: %s", m[1], queryFieldList)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%s 缺少值，例如 %s", m[1], fieldExample(field))
+		}
+		if op == "=" {
+			op = ":"
+		}
+		if op != ":" && field != "due" && field != "created" {
+			return nil, fmt.Errorf("%s 只支持 \"%s:值\" 的写法，比较运算只能用于 due 和 created", m[1], m[1])
+		}
+
+		switch field {
+		case "status":
+			var statuses []string
+			for _, v := range splitValues(value) {
+				s, ok := queryStatuses[strings.ToLower(v)]
+				if !ok {
+					return nil, fmt.Errorf("status 不支持 %q，可选: pending, in_progress, completed, cancelled, blocked, open, closed", v)
+				}
+				statuses = append(statuses, s...)
+			}
+			filter.Statuses = append(filter.Statuses, statuses...)
+		case "assignee":
+			switch strings.ToLower(value) {
+			case "me", "我":
+				if ctx.MeName == "" && ctx.MeID == "" {
+					return nil, fmt.Errorf("无法确定 assignee:me 指的是谁")
+				}
+				filter.Assignee = ctx.MeName
+				if filter.Assignee == "" {
+					filter.Assignee = ctx.MeID
+				}
+			case "none", "无":
+				filter.Unassigned = true
+			default:
+				filter.Assignee = strings.TrimPrefix(value, "@")
+			}
+		case "creator":
+			if v := strings.ToLower(value); v == "me" || v == "我" {
+				if ctx.MeID == "" {
+					return nil, fmt.Errorf("无法确定 creator:me 指的是谁")
+				}
+				filter.CreatorID = ctx.MeID
+			} else {
+				filter.CreatorID = value
+			}
+		case "label":
+			labels, err := normalizeLabels(splitValues(value))
+			if err != nil {
+				return nil, err
+			}
+			if len(labels) > 0 {
+				filter.Labels = append(filter.Labels, labels)
+			}
+		case "due", "created":
+			if err := applyDateTerm(filter, field, op, value, ctx.Now); err != nil {
+				return nil, err
+			}
+		case "is":
+			switch strings.ToLower(value) {
+			case "overdue", "过期":
+				now := ctx.Now
+				filter.DueBefore = &now
+				if len(filter.Statuses) == 0 {
+					filter.Statuses = append(filter.Statuses, openStatuses...)
+				}
+			case "recurring", "重复":
+				filter.Recurring = true
+			case "open", "未完成":
+				filter.Statuses = append(filter.Statuses, openStatuses...)
+			case "blocked", "阻塞":
+				filter.Statuses = append(filter.Statuses, StatusBlocked)
+			default:
+				return nil, fmt.Errorf("is 不支持 %q，可选: overdue, recurring, open, blocked", value)
+			}
+		case "sort":
+			reverse := strings.HasPrefix(value, "-")
+			key := strings.ToLower(strings.TrimPrefix(value, "-"))
+			switch key {
+			case "due", "截止":
+				filter.SortBy = SortDue
+			case "created", "创建":
+				filter.SortBy = SortCreated
+			case "updated", "修改":
+				filter.SortBy = SortUpdated
+			case "priority", "优先级":
+				filter.SortBy = SortPriority
+			case "key", "id", "编号":
+				filter.SortBy = SortKey
+			default:
+				return nil, fmt.Errorf("sort 不支持 %q，可选: due, priority, updated, created, key（前面加 - 表示反向，如 sort:-due）", value)
+			}
+			filter.SortReverse = reverse
+		case "priority":
+			for _, v := range splitValues(value) {
+				p, err := ParsePriority(v)
+				if err != nil {
+					return nil, fmt.Errorf("priority 不支持 %q，可选: none, low, medium, high, urgent", v)
+				}
+				filter.Priorities = append(filter.Priorities, p)
+			}
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("limit 必须是正整数，例如 limit:20")
+			}
+			filter.Limit = n
+		}
+	}
+
+	filter.Keyword = strings.Join(keywords, " ")
+	return filter, nil
+}
+
+// tokenizeQuery 按空白切分查询，引号内的空白不切分
+func tokenizeQuery(input string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range input {
+		switch {
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote || (quote == '“' && r == '”') {
+				quote = 0
+			}
+		case r == '"' || r == '“':
+			quote = r
+			current.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n' || r == '　':
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("查询中的引号没有闭合")
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// unquote 去掉值两端的引号
+func unquote(value string) string {
+	for _, pair := range [][2]string{{`"`, `"`}, {"“", "”"}} {
+		if len(value) >= len(pair[0])+len(pair[1]) && strings.HasPrefix(value, pair[0]) && strings.HasSuffix(value, pair[1]) {
+			return value[len(pair[0]) : len(value)-len(pair[1])]
+		}
+	}
+	return value
+}
+
+// splitValues 按逗号切分多个值
+func splitValues(value string) []string {
+	var values []string
+	for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// fieldExample 字段的写法示例
+func fieldExample(field string) string {
+	examples := map[string]string{
+		"status":   "status:pending",
+		"assignee": "assignee:me",
+		"creator":  "creator:me",
+		"label":    "label:release",
+		"priority": "priority:high,urgent",
+		"due":      "due<2026-11-01",
+		"created":  "created>=-7d",
+		"is":       "is:overdue",
+		"sort":     "sort:due",
+		"limit":    "limit:20",
+	}
+	return examples[field]
+}
+
+// applyDateTerm 解析 due、created 的日期条件
+// 只给日期时 due:日期 表示当天，due<=日期 包含当天
+func applyDateTerm(filter *TaskFilter, field, op, value string, now time.Time) error {
+	if field == "due" && op == ":" && (value == "none" || value == "无") {
+		filter.NoDueTime = true
+		return nil
+	}
+
+	start, end, err := parseQueryDate(value, now)
+	if err != nil {
+		return fmt.Errorf("%s: %v", field, err)
+	}
+
+	var after, before **time.Time
+	if field == "due" {
+		after, before = &filter.DueAfter, &filter.DueBefore
+	} else {
+		after, before = &filter.CreatedAfter, &filter.CreatedBefore
+	}
+	switch op {
+	case ":":
+		*after, *before = &start, &end
+	case "<":
+		*before = &start
+	case "<=":
+		*before = &end
+	case ">":
+		*after = &end
+	case ">=":
+		*after = &start
+	}
+	return nil
+}
+
+// parseQueryDate 解析查询中的日期，返回 [start, end) 区间
+// 支持 2026-11-01、2026-11-01T18:00、today、tomorrow、yesterday、now、+3d、-1w、+2h
+func parseQueryDate(value string, now time.Time) (time.Time, time.Time, error) {
+	day := func(t time.Time) (time.Time, time.Time, error) {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 0, 1), nil
+	}
+
+	switch strings.ToLower(value) {
+	case "now", "现在":
+		return now, now, nil
+	case "today", "今天":
+		return day(now)
+	case "tomorrow", "明天":
+		return day(now.AddDate(0, 0, 1))
+	case "yesterday", "昨天":
+		return day(now.AddDate(0, 0, -1))
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "h":
+			t := now.Add(time.Duration(n) * time.Hour)
+			return t, t, nil
+		case "d":
+			return day(now.AddDate(0, 0, n))
+		case "w":
+			return day(now.AddDate(0, 0, 7*n))
+		case "m":
+			return day(now.AddDate(0, n, 0))
+		}
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return day(t)
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, t, nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("无法识别的日期 %q（示例: 2026-11-01、2026-11-01T18:00、today、+3d）", value)
+}
+
+// DescribeFilter 用中文描述筛选条件（用于查询结果的标题）
+func DescribeFilter(filter *TaskFilter) string {
+	var parts []string
+	if len(filter.Statuses) > 0 {
+		names := make([]string, len(filter.Statuses))
+		for i, s := range filter.Statuses {
+			names[i] = statusName(s)
+		}
+		parts = append(parts, "状态"+strings.Join(names, "/"))
+	}
+	if filter.Assignee != "" {
+		parts = append(parts, "负责人"+filter.Assignee)
+	}
+	if filter.Unassigned {
+		parts = append(parts, "无负责人")
+	}
+	for _, group := range filter.Labels {
+		parts = append(parts, formatLabels(group))
+	}
+	if filter.DueAfter != nil {
+		parts = append(parts, "截止不早于"+filter.DueAfter.Format("01-02 15:04"))
+	}
+	if filter.DueBefore != nil {
+		parts = append(parts, "截止早于"+filter.DueBefore.Format("01-02 15:04"))
+	}
+	if filter.NoDueTime {
+		parts = append(parts, "无截止时间")
+	}
+	if filter.CreatedAfter != nil {
+		parts = append(parts, "创建不早于"+filter.CreatedAfter.Format("01-02 15:04"))
+	}
+	if filter.CreatedBefore != nil {
+		parts = append(parts, "创建早于"+filter.CreatedBefore.Format("01-02 15:04"))
+	}
+	if filter.Recurring {
+		parts = append(parts, "重复任务")
+	}
+	if len(filter.Priorities) > 0 {
+		names := make([]string, len(filter.Priorities))
+		for i, p := range filter.Priorities {
+			names[i] = PriorityName(p)
+		}
+		parts = append(parts, "优先级"+strings.Join(names, "/"))
+	}
+	if filter.Keyword != "" {
+		parts = append(parts, "包含「"+filter.Keyword+"」")
+	}
+	return strings.Join(parts, "，")
+}
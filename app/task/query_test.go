@@ -0,0 +1,83 @@
+package task
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2026, 10, 14, 15, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	ctx := QueryContext{WorkspaceID: 7, MeID: "wxid_alice", MeName: "Alice", Now: now}
+
+	tests := []struct {
+		input string
+		want  TaskFilter
+	}{
+		{"发布 周报", TaskFilter{Keyword: "发布 周报"}},
+		{`"发布 说明" status:pending,doing`, TaskFilter{Keyword: `"发布 说明"`, Statuses: []string{StatusPending, StatusInProgress}}},
+		{"状态:未完成", TaskFilter{Statuses: openStatuses}},
+		{"assignee:me creator:me", TaskFilter{Assignee: "Alice", CreatorID: "wxid_alice"}},
+		{"assignee:@bob", TaskFilter{Assignee: "bob"}},
+		{"负责人:无", TaskFilter{Unassigned: true}},
+		{"label:release,hotfix label:backend", TaskFilter{Labels: [][]string{{"release", "hotfix"}, {"backend"}}}},
+		{"priority:high,urgent", TaskFilter{Priorities: []int{PriorityHigh, PriorityUrgent}}},
+		{"due:2026-11-01", TaskFilter{DueAfter: day(2026, 11, 1), DueBefore: day(2026, 11, 2)}},
+		{"due<2026-11-01", TaskFilter{DueBefore: day(2026, 11, 1)}},
+		{"due<=tomorrow", TaskFilter{DueBefore: day(2026, 10, 16)}},
+		{"created>=-7d", TaskFilter{CreatedAfter: day(2026, 10, 7)}},
+		{"due:none", TaskFilter{NoDueTime: true}},
+		{"is:overdue", TaskFilter{DueBefore: &now, Statuses: openStatuses}},
+		{"status:done is:overdue", TaskFilter{DueBefore: &now, Statuses: []string{StatusCompleted}}},
+		{"is:recurring", TaskFilter{Recurring: true}},
+		{"sort:-due limit:5", TaskFilter{SortBy: SortDue, SortReverse: true, Limit: 5}},
+		{"排序:优先级", TaskFilter{SortBy: SortPriority}},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.input, ctx)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error: %v", tt.input, err)
+			continue
+		}
+		tt.want.WorkspaceID = ctx.WorkspaceID
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, *got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		ctx   QueryContext
+		want  string // 错误信息中应包含的提示
+	}{
+		{"owner:me", QueryContext{}, "可用字段: status, assignee"},
+		{"status:", QueryContext{}, "例如 status:pending"},
+		{"status:later", QueryContext{}, "可选: pending, in_progress"},
+		{"priority>high", QueryContext{}, "比较运算只能用于 due 和 created"},
+		{"assignee:me", QueryContext{}, "assignee:me 指的是谁"},
+		{"creator:me", QueryContext{MeName: "Alice"}, "creator:me 指的是谁"},
+		{"due<下周", QueryContext{}, "示例: 2026-11-01"},
+		{"is:late", QueryContext{}, "可选: overdue, recurring"},
+		{"sort:name", QueryContext{}, "sort:-due"},
+		{"priority:p9", QueryContext{}, "可选: none, low"},
+		{"limit:0", QueryContext{}, "例如 limit:20"},
+		{`"发布 说明`, QueryContext{}, "引号没有闭合"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.input, tt.ctx)
+		if err == nil {
+			t.Errorf("ParseQuery(%q) succeeded, want an error mentioning %q", tt.input, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseQuery(%q) error = %q, want it to mention %q", tt.input, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 重复频率常量
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// 重复任务的编辑/取消范围
+const (
+	ScopeThis   = "this"   // 仅当前这一次
+	ScopeSeries = "series" // 整个系列
+)
+
+// maxRecurrenceSearchDays 计算下一次发生时间时最多向后查找的天数
+const maxRecurrenceSearchDays = 366 * 5
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"日": time.Sunday,
+	"天": time.Sunday,
+	"一": time.Monday,
+	"二": time.Tuesday,
+	"三": time.Wednesday,
+	"四": time.Thursday,
+	"五": time.Friday,
+	"六": time.Saturday,
+}
+
+// recurrenceAliases 常用重复规则的简写
+var recurrenceAliases = map[string]string{
+	"daily":    "FREQ=DAILY",
+	"weekly":   "FREQ=WEEKLY",
+	"monthly":  "FREQ=MONTHLY",
+	"yearly":   "FREQ=YEARLY",
+	"workdays": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"weekdays": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"每天":       "FREQ=DAILY",
+	"每日":       "FREQ=DAILY",
+	"每周":       "FREQ=WEEKLY",
+	"每月":       "FREQ=MONTHLY",
+	"每年":       "FREQ=YEARLY",
+	"工作日":      "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"每个工作日":    "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+}
+
+// Recurrence 重复规则（RRULE 子集）
+// 支持 FREQ、INTERVAL、BYDAY、BYMONTHDAY、COUNT、UNTIL
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int // 支持负数，-1 表示月末
+	Count      int
+	Until      *time.Time
+}
+
+// ParseRecurrence 解析重复规则，支持 RRULE 子集和常用简写（daily、weekly、monthly、workdays、每周一等）
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	rule = strings.TrimPrefix(rule, "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	if alias, ok := recurrenceAliases[strings.ToLower(rule)]; ok {
+		rule = alias
+	} else if strings.HasPrefix(rule, "每周") || strings.HasPrefix(rule, "每星期") {
+		// 每周一、每周一三五
+		days := strings.TrimPrefix(strings.TrimPrefix(rule, "每周"), "每星期")
+		codes := make([]string, 0, len(days))
+		for _, r := range days {
+			wd, ok := weekdayNames[string(r)]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday in recurrence: %s", rule)
+			}
+			codes = append(codes, weekdayCode(wd))
+		}
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.Join(codes, ",")
+	}
+
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid recurrence component: %s", part)
+		}
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		value := strings.ToUpper(strings.TrimSpace(kv[1]))
+
+		switch key {
+		case "FREQ":
+			switch value {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				r.Freq = value
+			default:
+				return nil, fmt.Errorf("unsupported recurrence frequency: %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid recurrence interval: %s", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd, ok := weekdayCodes[strings.TrimSpace(code)]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY value: %s", code)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(d))
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY value: %s", d)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid recurrence count: %s", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		default:
+			return nil, fmt.Errorf("unsupported recurrence component: %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("recurrence rule missing FREQ: %s", rule)
+	}
+	sort.Slice(r.ByDay, func(i, j int) bool { return r.ByDay[i] < r.ByDay[j] })
+	sort.Ints(r.ByMonthDay)
+	return r, nil
+}
+
+// parseUntil 解析 UNTIL 的值（20061231、20061231T150405 或 2006-01-02）
+func parseUntil(value string) (time.Time, error) {
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range []string{"20060102T150405", "20060102", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if layout != "20060102T150405" {
+				// 只有日期时，包含当天全天
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL value: %s", value)
+}
+
+// String 将重复规则规范化为 RRULE 字符串
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			codes[i] = weekdayCode(wd)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Describe 返回重复规则的中文描述（用于微信显示）
+func (r *Recurrence) Describe() string {
+	cnWeekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+
+	unit := map[string]string{
+		FreqDaily:   "天",
+		FreqWeekly:  "周",
+		FreqMonthly: "月",
+		FreqYearly:  "年",
+	}[r.Freq]
+
+	var desc string
+	if r.Interval > 1 {
+		desc = fmt.Sprintf("每%d%s", r.Interval, unit)
+		if r.Freq == FreqWeekly || r.Freq == FreqMonthly {
+			desc = fmt.Sprintf("每%d个%s", r.Interval, unit)
+		}
+	} else {
+		desc = "每" + unit
+	}
+
+	if len(r.ByDay) > 0 {
+		names := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			names[i] = cnWeekdays[wd]
+		}
+		switch {
+		case isWorkdays(r.ByDay) && desc == "每周":
+			desc = "每个工作日"
+		case desc == "每周":
+			desc += strings.Join(names, "、")
+		default:
+			desc += "(周" + strings.Join(names, "、") + ")"
+		}
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			if d == -1 {
+				days[i] = "月末"
+			} else if d < 0 {
+				days[i] = fmt.Sprintf("倒数第%d天", -d)
+			} else {
+				days[i] = fmt.Sprintf("%d号", d)
+			}
+		}
+		desc += "(" + strings.Join(days, "、") + ")"
+	}
+	if r.Count > 0 {
+		desc += fmt.Sprintf("，共%d次", r.Count)
+	}
+	if r.Until != nil {
+		desc += fmt.Sprintf("，直到%s", r.Until.Format("2006-01-02"))
+	}
+	return desc
+}
+
+// Next 计算 prev 之后的下一次发生时间（保留 prev 的时分秒）
+// 返回 false 表示超出 UNTIL 或找不到下一次
+func (r *Recurrence) Next(prev time.Time) (time.Time, bool) {
+	prevDate := time.Date(prev.Year(), prev.Month(), prev.Day(), 0, 0, 0, 0, prev.Location())
+
+	for i := 1; i <= maxRecurrenceSearchDays; i++ {
+		day := prevDate.AddDate(0, 0, i)
+		if !r.matches(prevDate, day) {
+			continue
+		}
+		next := time.Date(day.Year(), day.Month(), day.Day(), prev.Hour(), prev.Minute(), prev.Second(), 0, prev.Location())
+		if r.Until != nil && next.After(*r.Until) {
+			return time.Time{}, false
+		}
+		return next, true
+	}
+	return time.Time{}, false
+}
+
+// First 计算 from 当天或之后的第一次发生日期（时间为当天 23:59:59）
+func (r *Recurrence) First(from time.Time) (time.Time, bool) {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for i := 0; i <= maxRecurrenceSearchDays; i++ {
+		day := fromDate.AddDate(0, 0, i)
+		if !r.matchesDay(fromDate, day) {
+			continue
+		}
+		first := time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, from.Location())
+		if r.Until != nil && first.After(*r.Until) {
+			return time.Time{}, false
+		}
+		return first, true
+	}
+	return time.Time{}, false
+}
+
+// matches 判断 day 是否是 anchor 之后合法的发生日期（anchor 本身是一次合法的发生）
+func (r *Recurrence) matches(anchor, day time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		days := daysBetween(anchor, day)
+		if days%r.Interval != 0 {
+			return false
+		}
+		return len(r.ByDay) == 0 || containsWeekday(r.ByDay, day.Weekday())
+	case FreqWeekly:
+		weeks := daysBetween(startOfWeek(anchor), startOfWeek(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == anchor.Weekday()
+		}
+		return containsWeekday(r.ByDay, day.Weekday())
+	case FreqMonthly:
+		months := (day.Year()-anchor.Year())*12 + int(day.Month()-anchor.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) == 0 {
+			return day.Day() == anchor.Day()
+		}
+		return matchesMonthDay(r.ByMonthDay, day)
+	case FreqYearly:
+		years := day.Year() - anchor.Year()
+		if years%r.Interval != 0 {
+			return false
+		}
+		return day.Month() == anchor.Month() && day.Day() == anchor.Day()
+	}
+	return false
+}
+
+// matchesDay 判断 day 是否满足规则中的日期约束（不考虑间隔，用于确定系列的第一次发生）
+func (r *Recurrence) matchesDay(from, day time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		return len(r.ByDay) == 0 || containsWeekday(r.ByDay, day.Weekday())
+	case FreqWeekly:
+		return len(r.ByDay) == 0 || containsWeekday(r.ByDay, day.Weekday())
+	case FreqMonthly:
+		return len(r.ByMonthDay) == 0 || matchesMonthDay(r.ByMonthDay, day)
+	}
+	return true
+}
+
+// weekdayCode 返回星期的 RRULE 编码
+func weekdayCode(wd time.Weekday) string {
+	for code, w := range weekdayCodes {
+		if w == wd {
+			return code
+		}
+	}
+	return ""
+}
+
+// isWorkdays 判断 BYDAY 是否恰好为周一到周五
+func isWorkdays(days []time.Weekday) bool {
+	if len(days) != 5 {
+		return false
+	}
+	for i, wd := range days {
+		if wd != time.Weekday(i+1) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsWeekday(days []time.Weekday, wd time.Weekday) bool {
+	for _, d := range days {
+		if d == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMonthDay 判断 day 是否命中 BYMONTHDAY（负数从月末倒数）
+func matchesMonthDay(monthDays []int, day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, d := range monthDays {
+		if d > 0 && day.Day() == d {
+			return true
+		}
+		if d < 0 && day.Day() == lastDay+d+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// startOfWeek 返回所在周的周一
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween 计算两个日期之间相差的天数（按日历日）
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
@@ -0,0 +1,209 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"daily", "FREQ=DAILY"},
+		{"每天", "FREQ=DAILY"},
+		{"Weekly", "FREQ=WEEKLY"},
+		{"workdays", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"工作日", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"每周一", "FREQ=WEEKLY;BYDAY=MO"},
+		{"每周五三一", "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{"每星期日", "FREQ=WEEKLY;BYDAY=SU"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,1", "FREQ=MONTHLY;BYMONTHDAY=1,15"},
+		{"freq=weekly;interval=2;byday=fr,mo", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=DAILY;INTERVAL=1;COUNT=3", "FREQ=DAILY;COUNT=3"},
+		{"FREQ=YEARLY", "FREQ=YEARLY"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q) error: %v", tt.rule, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("ParseRecurrence(%q) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecurrenceInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;WKST=MO",
+		"FREQ",
+		"每周八",
+	} {
+		if r, err := ParseRecurrence(rule); err == nil {
+			t.Errorf("ParseRecurrence(%q) = %s, want error", rule, r)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+	tests := []struct {
+		name string
+		rule string
+		prev time.Time
+		want time.Time
+	}{
+		{"每天", "daily", at(2026, 10, 18, 9, 30), at(2026, 10, 19, 9, 30)},
+		{"每三天", "FREQ=DAILY;INTERVAL=3", at(2026, 10, 18, 9, 0), at(2026, 10, 21, 9, 0)},
+		{"每天跨月", "daily", at(2026, 10, 31, 23, 59), at(2026, 11, 1, 23, 59)},
+		{"每周同一天", "weekly", at(2026, 10, 18, 18, 0), at(2026, 10, 25, 18, 0)},
+		{"工作日跳过周末", "workdays", at(2026, 10, 16, 17, 0), at(2026, 10, 19, 17, 0)},
+		{"工作日周中", "workdays", at(2026, 10, 19, 17, 0), at(2026, 10, 20, 17, 0)},
+		{"隔周同一周内", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", at(2026, 10, 19, 10, 0), at(2026, 10, 22, 10, 0)},
+		{"隔周跳过一周", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", at(2026, 10, 22, 10, 0), at(2026, 11, 2, 10, 0)},
+		{"每月同一天", "monthly", at(2026, 10, 18, 9, 0), at(2026, 11, 18, 9, 0)},
+		{"每月31号跳过小月", "monthly", at(2026, 1, 31, 9, 0), at(2026, 3, 31, 9, 0)},
+		{"每月月末", "FREQ=MONTHLY;BYMONTHDAY=-1", at(2026, 1, 31, 9, 0), at(2026, 2, 28, 9, 0)},
+		{"每月1号和15号", "FREQ=MONTHLY;BYMONTHDAY=1,15", at(2026, 10, 1, 9, 0), at(2026, 10, 15, 9, 0)},
+		{"每两个月", "FREQ=MONTHLY;INTERVAL=2", at(2026, 11, 5, 9, 0), at(2027, 1, 5, 9, 0)},
+		{"每年", "yearly", at(2026, 3, 8, 9, 0), at(2027, 3, 8, 9, 0)},
+		{"每年2月29日", "yearly", at(2024, 2, 29, 9, 0), at(2028, 2, 29, 9, 0)},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: ParseRecurrence(%q) error: %v", tt.name, tt.rule, err)
+		}
+		got, ok := r.Next(tt.prev)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, %v, want %s", tt.name, tt.prev.Format("2006-01-02 15:04"), got.Format("2006-01-02 15:04"), ok, tt.want.Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func TestRecurrenceNextUntil(t *testing.T) {
+	r, err := ParseRecurrence("FREQ=DAILY;UNTIL=20261020")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	if got, ok := r.Next(prev); !ok || got.Day() != 20 {
+		t.Errorf("Next(10-19) = %s, %v, want 10-20", got, ok)
+	}
+	if got, ok := r.Next(prev.AddDate(0, 0, 1)); ok {
+		t.Errorf("Next(10-20) = %s, want no occurrence after UNTIL", got)
+	}
+}
+
+func TestRecurrenceFirst(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	tests := []struct {
+		rule string
+		from time.Time
+		want time.Time
+	}{
+		{"daily", time.Date(2026, 10, 17, 15, 0, 0, 0, loc), time.Date(2026, 10, 17, 23, 59, 59, 0, loc)},
+		{"workdays", time.Date(2026, 10, 17, 15, 0, 0, 0, loc), time.Date(2026, 10, 19, 23, 59, 59, 0, loc)},
+		{"每周五", time.Date(2026, 10, 17, 15, 0, 0, 0, loc), time.Date(2026, 10, 23, 23, 59, 59, 0, loc)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", time.Date(2026, 2, 3, 15, 0, 0, 0, loc), time.Date(2026, 2, 28, 23, 59, 59, 0, loc)},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error: %v", tt.rule, err)
+		}
+		if got, ok := r.First(tt.from); !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: First(%s) = %s, %v, want %s", tt.rule, tt.from.Format("2006-01-02"), got, ok, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceDescribe(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"daily", "每天"},
+		{"workdays", "每个工作日"},
+		{"每周一三五", "每周一、三、五"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "每2个周(周一)"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "每月(月末)"},
+		{"FREQ=DAILY;COUNT=3", "每天，共3次"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error: %v", tt.rule, err)
+		}
+		if got := r.Describe(); got != tt.want {
+			t.Errorf("Describe(%q) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestSpawnNextOccurrenceKeepsAttributes(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	parent := mustTask(t, tm, ws.ID, "季度报告", nil)
+
+	task, err := tm.CreateRecurringTask(ws.ID, "提交周报", "每周一提交周报", "creator", nil, nil, "每周一")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Task
+	if err := tm.db.First(&stored, task.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.SeriesID != task.ID || stored.RecurrenceRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Fatalf("stored series = %d, rule %q, want %d and FREQ=WEEKLY;BYDAY=MO", stored.SeriesID, stored.RecurrenceRule, task.ID)
+	}
+
+	if err := tm.SetTaskPriority(task.ID, PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.SetTaskEstimate(task.ID, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.SetTaskParent(task.ID, parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.UpdateTaskLabels(task.ID, []string{"周报", "例行"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.UpdateTaskAssignees(task.ID, []Actor{{ID: "@bob", Name: "bob"}}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.TransitionTaskStatus(task.ID, StatusCompleted, false); err != nil {
+		t.Fatal(err)
+	}
+
+	next, ok := tm.NextOccurrence(task.ID)
+	if !ok {
+		t.Fatal("no next occurrence after completing the recurring task")
+	}
+	if next.Priority != PriorityHigh || next.EstimateMinutes != 60 || next.ParentID != parent.ID || next.SeriesID != task.ID {
+		t.Errorf("next occurrence = {priority %d, estimate %d, parent %d, series %d}, want {%d, 60, %d, %d}",
+			next.Priority, next.EstimateMinutes, next.ParentID, next.SeriesID, PriorityHigh, parent.ID, task.ID)
+	}
+	if labels := tm.GetTaskLabels(next.ID); len(labels) != 2 || labels[0] != "例行" || labels[1] != "周报" {
+		t.Errorf("labels of next occurrence = %v, want [例行 周报]", labels)
+	}
+	if assignees := tm.GetTaskAssignees(next.ID); len(assignees) != 1 || assignees[0].UserName != "bob" || assignees[0].UserID != "@bob" {
+		t.Errorf("assignees of next occurrence = %v, want bob (@bob)", assignees)
+	}
+}
@@ -1,50 +1,619 @@
 package task
 
 import (
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/notify"
 )
 
-// StartReminderService 启动定时提醒服务
-func StartReminderService(notifyFunc func(tasks []*Task)) {
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour) // 每小时检查一次
-		defer ticker.Stop()
-		
-		for range ticker.C {
-			tm := GetTaskManager()
-			overdue := tm.GetOverdueTasks()
-			
-			if len(overdue) > 0 {
-				log.Printf("Found %d overdue tasks\n", len(overdue))
-				notifyFunc(overdue)
-			}
-			
-			// 检查即将到期的任务（24小时内）
-			upcoming := tm.GetUpcomingTasks(24 * time.Hour)
-			if len(upcoming) > 0 {
-				log.Printf("Found %d upcoming tasks\n", len(upcoming))
-				notifyFunc(upcoming)
-			}
-		}
-	}()
-}
-
-// GetUpcomingTasks 获取即将到期的任务
-func (tm *TaskManager) GetUpcomingTasks(duration time.Duration) []*Task {
-	now := time.Now()
-	deadline := now.Add(duration)
+// ReminderOverdue 到达截止时间时的提醒阈值，提前提醒的阈值为 before_<分钟>m
+const ReminderOverdue = "overdue"
+
+// 提醒发送状态
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// MaxReminderOffset 最多提前多久提醒
+const MaxReminderOffset = 30 * 24 * time.Hour
+
+// defaultReminderOffsets 未配置时的默认提醒：提前一天和到期时
+var defaultReminderOffsets = []time.Duration{24 * time.Hour, 0}
+
+// MaxReminderAttempts 一条提醒最多尝试发送的次数，超过后不再重试
+const MaxReminderAttempts = 5
+
+// reminderRetryBase 发送失败后第一次重试的间隔，之后每次翻倍
+const reminderRetryBase = 5 * time.Minute
+
+// ReminderSender 发送提醒消息（通常为 notify.Notify，按接收人的偏好路由和免打扰）
+// 返回错误表示消息没有发给该接收方，只有这个接收方的发送记录会重试
+type ReminderSender func(n notify.Notification) error
+
+// reminderRecipient 提醒接收方
+type reminderRecipient struct {
+	Key    string              // 记录在发送记录中的稳定标识
+	Notice notify.Notification // 发送目标和通知对象（不含内容）
+}
+
+// pendingReminder 一条待发送的提醒
+type pendingReminder struct {
+	Delivery *ReminderDelivery
+	Task     *Task
+}
+
+// ParseReminderOffsets 解析提醒提前量，逗号分隔，如 "1d,1h,10m,0"（也支持 1天、2小时、10分钟、到期时）
+// 返回按提前量从大到小排列的去重结果，总是包含到期时（0）
+func ParseReminderOffsets(s string) ([]time.Duration, error) {
+	seen := make(map[time.Duration]bool)
+	var offsets []time.Duration
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' || r == '、' || r == ' ' }) {
+		offset, err := parseReminderOffset(part)
+		if err != nil {
+			return nil, err
+		}
+		if offset < 0 || offset > MaxReminderOffset {
+			return nil, fmt.Errorf("reminder offset %s out of range (0 to %d days)", part, int(MaxReminderOffset/(24*time.Hour)))
+		}
+		if !seen[offset] {
+			seen[offset] = true
+			offsets = append(offsets, offset)
+		}
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("no reminder offsets in %q", s)
+	}
+	// 到期时总会提醒一次，任务到期后不再需要检查
+	if !seen[0] {
+		offsets = append(offsets, 0)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	return offsets, nil
+}
+
+// parseReminderOffset 解析单个提前量
+func parseReminderOffset(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "提前")
+	switch s {
+	case "0", "due", "到期", "到期时", "截止时":
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"分钟", time.Minute}, {"小时", time.Hour}, {"天", 24 * time.Hour}, {"周", 7 * 24 * time.Hour},
+		{"min", time.Minute}, {"m", time.Minute}, {"h", time.Hour}, {"d", 24 * time.Hour}, {"w", 7 * 24 * time.Hour},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)))
+			if err != nil {
+				break
+			}
+			return time.Duration(n) * u.unit, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid reminder offset %q, use forms like 1d, 2h, 30m or 0", s)
+}
+
+// encodeReminderOffsets 将提前量编码为存储格式（分钟，逗号分隔）
+func encodeReminderOffsets(offsets []time.Duration) string {
+	parts := make([]string, len(offsets))
+	for i, offset := range offsets {
+		parts[i] = strconv.Itoa(int(offset / time.Minute))
+	}
+	return strings.Join(parts, ",")
+}
+
+// decodeReminderOffsets 解析存储的提前量，格式错误时返回 nil
+func decodeReminderOffsets(s string) []time.Duration {
+	if s == "" {
+		return nil
+	}
+	var offsets []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		minutes, err := strconv.Atoi(part)
+		if err != nil {
+			log.Printf("WARNING: Invalid stored reminder offsets %q\n", s)
+			return nil
+		}
+		offsets = append(offsets, time.Duration(minutes)*time.Minute)
+	}
+	return offsets
+}
+
+// DefaultReminderOffsets 默认的提醒提前量（配置 task.reminder_offsets，如 "1d,0"）
+func DefaultReminderOffsets() []time.Duration {
+	if raw := config.LoadConfig().Task.ReminderOffsets; raw != "" {
+		offsets, err := ParseReminderOffsets(raw)
+		if err == nil {
+			return offsets
+		}
+		log.Printf("WARNING: Invalid task.reminder_offsets %q: %v\n", raw, err)
+	}
+	return defaultReminderOffsets
+}
+
+// ReminderOffsetList 任务的提醒提前量（从大到小），未单独设置时使用默认值
+func (t *Task) ReminderOffsetList() []time.Duration {
+	if offsets := decodeReminderOffsets(t.ReminderOffsets); len(offsets) > 0 {
+		return offsets
+	}
+	return DefaultReminderOffsets()
+}
+
+// FormatReminderOffsets 格式化提醒提前量，如 "提前1天、提前1小时、到期时"
+func FormatReminderOffsets(offsets []time.Duration) string {
+	parts := make([]string, len(offsets))
+	for i, offset := range offsets {
+		parts[i] = formatReminderOffset(offset)
+	}
+	return strings.Join(parts, "、")
+}
+
+// formatReminderOffset 格式化单个提前量
+func formatReminderOffset(offset time.Duration) string {
+	if offset == 0 {
+		return "到期时"
+	}
+	return "提前" + formatDurationShort(offset)
+}
+
+// formatDurationShort 将时长格式化为最大的整数单位，如 1天、2小时、30分钟
+func formatDurationShort(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d天", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d小时", d/time.Hour)
+	default:
+		return fmt.Sprintf("%d分钟", d/time.Minute)
+	}
+}
+
+// reminderThreshold 提前量对应的提醒阈值名称
+func reminderThreshold(offset time.Duration) string {
+	if offset == 0 {
+		return ReminderOverdue
+	}
+	return fmt.Sprintf("before_%dm", int(offset/time.Minute))
+}
+
+// SetTaskReminderOffsets 设置任务的提醒提前量，offsets 为空表示恢复默认
+func (tm *TaskManager) SetTaskReminderOffsets(id uint, offsets []time.Duration) error {
+	return tm.UpdateTask(id, TaskUpdate{ReminderOffsets: &offsets})
+}
+
+// formatStoredOffsets 格式化存储的提前量，为空时显示默认
+func formatStoredOffsets(s string) string {
+	if offsets := decodeReminderOffsets(s); len(offsets) > 0 {
+		return FormatReminderOffsets(offsets)
+	}
+	return "默认"
+}
+
+// DeliverDueReminders 发送在 now 之前到期的提醒
+// 每个任务只发送已到时间的最近一个阈值（错过的更早阈值不再补发），每个阈值对每个接收方只成功发送一次；
+// 发送失败的提醒按指数退避重试，最多 MaxReminderAttempts 次
+func (tm *TaskManager) DeliverDueReminders(now time.Time, send ReminderSender) error {
+	// 逾期升级独立于提前和到期提醒（通知管理员的步骤不受“知道了”和稍后提醒影响）
+	if err := tm.deliverEscalations(now, send); err != nil {
+		return err
+	}
+
+	tasks, err := tm.reminderCandidates(now)
+	if err != nil {
+		return err
+	}
+
+	snoozedUntil, err := tm.snoozeHorizons(tasks)
+	if err != nil {
+		return err
+	}
+
+	var pending []pendingReminder
+	for _, t := range tasks {
+		threshold, fireTime, ok := dueThreshold(t, now)
+		if !ok {
+			continue
+		}
+		for _, r := range tm.reminderRecipients(t) {
+			// 用户要求稍后提醒的，稍后提醒时间之前的阈值不再发送
+			if until, snoozed := snoozedUntil[snoozeKey(t.ID, *t.DueTime, r.Key)]; snoozed && !fireTime.After(until) {
+				continue
+			}
+			d, err := tm.claimDelivery(t, threshold, r.Key, now)
+			if err != nil {
+				return err
+			}
+			if d != nil {
+				pending = append(pending, pendingReminder{Delivery: d, Task: t})
+			}
+		}
+	}
+
+	// 已到时间的稍后提醒
+	snoozes, err := tm.dueSnoozes(now)
+	if err != nil {
+		return err
+	}
+	for _, s := range snoozes {
+		t, exists := tm.GetTask(s.TaskID)
+		if !exists {
+			continue
+		}
+		d, err := tm.claimDelivery(t, snoozeThreshold(s.ID), s.Recipient, now)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			pending = append(pending, pendingReminder{Delivery: d, Task: t})
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// 同一接收方的提醒合并成一条消息，通知对象合并去重
+	byRecipient := make(map[string][]pendingReminder)
+	notices := make(map[string]notify.Notification)
+	var order []string
+	for _, p := range pending {
+		key := p.Delivery.Recipient
+		notice, ok := tm.recipientNotice(p.Task, key)
+		if !ok {
+			// 负责人已被移除等情况，记为发送失败，重试次数用完后放弃
+			tm.finishDeliveries([]pendingReminder{p}, fmt.Errorf("recipient %s no longer exists", key), now)
+			continue
+		}
+		if existing, seen := notices[key]; seen {
+			existing.Users = mergeUsers(existing.Users, notice.Users)
+			notice = existing
+		} else {
+			order = append(order, key)
+		}
+		notices[key] = notice
+		byRecipient[key] = append(byRecipient[key], p)
+	}
+
+	for _, key := range order {
+		items := byRecipient[key]
+		notice := notices[key]
+		notice.Text = tm.formatReminder(items, now)
+		err := send(notice)
+		tm.finishDeliveries(items, err, now)
+		if err != nil {
+			log.Printf("Failed to send %d reminder(s) to %s: %v\n", len(items), key, err)
+		} else {
+			log.Printf("Sent %d reminder(s) to %s\n", len(items), key)
+		}
+	}
+	return nil
+}
+
+// reminderCandidates 可能需要提醒的任务：未结束、截止时间在最大提前量之内，到期提醒尚未对所有接收方完成，且没有被确认
+func (tm *TaskManager) reminderCandidates(now time.Time) ([]*Task, error) {
 	var tasks []*Task
-	
-	if err := tm.db.Preload("Dependencies").
-		Where("status NOT IN ? AND due_time IS NOT NULL AND due_time > ? AND due_time < ?", 
-			[]string{StatusCompleted, StatusCancelled}, now, deadline).
+	if err := tm.db.
+		Where("status NOT IN ? AND due_time IS NOT NULL AND due_time <= ?",
+			[]string{StatusCompleted, StatusCancelled}, now.Add(MaxReminderOffset)).
+		// 到期提醒已经有接收方完成，且没有接收方还在等待重试时才排除（每个接收方是否发送由 claimDelivery 判断）
+		Where("(NOT EXISTS (SELECT 1 FROM task_reminder_deliveries d WHERE d.task_id = tasks.id AND d.threshold = ? AND d.due_time = tasks.due_time AND (d.status = ? OR d.attempts >= ?)) "+
+			"OR EXISTS (SELECT 1 FROM task_reminder_deliveries d WHERE d.task_id = tasks.id AND d.threshold = ? AND d.due_time = tasks.due_time AND d.status <> ? AND d.attempts < ?))",
+			ReminderOverdue, DeliverySent, MaxReminderAttempts, ReminderOverdue, DeliverySent, MaxReminderAttempts).
+		Where("NOT EXISTS (SELECT 1 FROM task_reminder_acks a WHERE a.task_id = tasks.id AND a.due_time = tasks.due_time)").
+		// 到期之后才发出的稍后提醒已经代替了到期提醒
+		Where("NOT EXISTS (SELECT 1 FROM task_reminder_snoozes s WHERE s.task_id = tasks.id AND s.due_time = tasks.due_time AND s.status = ? AND s.remind_at >= tasks.due_time)", SnoozeFired).
 		Order("due_time ASC").
 		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reminder candidates: %v", err)
+	}
+	tm.fillKeys(tasks)
+	return tasks, nil
+}
+
+// dueThreshold 任务在 now 时已到时间的最近一个提醒阈值及其提醒时间
+func dueThreshold(t *Task, now time.Time) (string, time.Time, bool) {
+	// 提前量从大到小排列，最后一个已到时间的就是最近的阈值
+	var threshold string
+	var fireTime time.Time
+	ok := false
+	for _, offset := range t.ReminderOffsetList() {
+		if fire := t.DueTime.Add(-offset); !fire.After(now) {
+			threshold, fireTime, ok = reminderThreshold(offset), fire, true
+		}
+	}
+	return threshold, fireTime, ok
+}
+
+// NextReminderTime 计算 now 之后最早需要处理的提醒时间（包括失败提醒的重试时间）
+// 只查看最大提前量加上调度器最长休眠时间之内到期的任务，更远的任务在之后的检查中处理
+func (tm *TaskManager) NextReminderTime(now time.Time) (time.Time, bool, error) {
+	var tasks []*Task
+	if err := tm.db.Select("id", "due_time", "reminder_offsets").
+		Where("status NOT IN ? AND due_time IS NOT NULL AND due_time > ? AND due_time <= ?",
+			[]string{StatusCompleted, StatusCancelled}, now, now.Add(MaxReminderOffset+maxSchedulerSleep)).
+		Find(&tasks).Error; err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to load upcoming reminders: %v", err)
+	}
+
+	var next time.Time
+	for _, t := range tasks {
+		for _, offset := range t.ReminderOffsetList() {
+			fire := t.DueTime.Add(-offset)
+			if fire.After(now) && (next.IsZero() || fire.Before(next)) {
+				next = fire
+			}
+		}
+	}
+
+	var retries []time.Time
+	if err := tm.db.Model(&ReminderDelivery{}).
+		Where("status = ? AND attempts < ? AND next_attempt_time > ?", DeliveryFailed, MaxReminderAttempts, now).
+		Order("next_attempt_time ASC").
+		Limit(1).
+		Pluck("next_attempt_time", &retries).Error; err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to load reminder retries: %v", err)
+	}
+	if len(retries) > 0 && (next.IsZero() || retries[0].Before(next)) {
+		next = retries[0]
+	}
+
+	snooze, ok, err := tm.nextSnoozeTime(now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if ok && (next.IsZero() || snooze.Before(next)) {
+		next = snooze
+	}
+
+	escalation, ok, err := tm.nextEscalationTime(now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if ok && (next.IsZero() || escalation.Before(next)) {
+		next = escalation
+	}
+
+	return next, !next.IsZero(), nil
+}
+
+// reminderRecipients 任务提醒的接收方：群工作区发到群里（@ 负责人），私聊工作区发给创建人，另外单独通知已知微信号的其他负责人
+func (tm *TaskManager) reminderRecipients(t *Task) []reminderRecipient {
+	notice := tm.NotificationFor(t)
+	if t.WorkspaceID == 0 || notice.WorkspaceID == 0 {
+		return []reminderRecipient{{Key: "creator", Notice: notice}}
+	}
+
+	recipients := []reminderRecipient{{Key: fmt.Sprintf("workspace:%d", t.WorkspaceID), Notice: notice}}
+	if notice.IsGroup {
+		return recipients
+	}
+	for _, a := range tm.GetTaskAssignees(t.ID) {
+		if a.UserID != "" && a.UserID != notice.Target {
+			recipients = append(recipients, reminderRecipient{
+				Key: "assignee:" + a.UserName,
+				Notice: notify.Notification{
+					WorkspaceID: t.WorkspaceID,
+					Target:      a.UserID,
+					TargetName:  a.UserName,
+					Users:       []notify.User{{ID: a.UserID, Name: a.UserName}},
+				},
+			})
+		}
+	}
+	return recipients
+}
+
+// recipientNotice 接收方标识对应的发送目标
+func (tm *TaskManager) recipientNotice(t *Task, key string) (notify.Notification, bool) {
+	for _, r := range tm.reminderRecipients(t) {
+		if r.Key == key {
+			return r.Notice, true
+		}
+	}
+	return notify.Notification{}, false
+}
+
+// NotificationFor 任务通知的发送目标：所属工作区的群（通知对象为负责人）或私聊，旧任务退回到创建人
+func (tm *TaskManager) NotificationFor(t *Task) notify.Notification {
+	if t.WorkspaceID != 0 {
+		if ws, exists := tm.GetWorkspace(t.WorkspaceID); exists {
+			notice := notify.Notification{
+				WorkspaceID: ws.ID,
+				Target:      ws.ChatID,
+				TargetName:  ws.Name,
+				IsGroup:     ws.Kind == WorkspaceGroup,
+			}
+			if notice.IsGroup {
+				for _, a := range tm.GetTaskAssignees(t.ID) {
+					notice.Users = append(notice.Users, notify.User{ID: a.UserID, Name: a.UserName})
+				}
+			} else {
+				notice.Users = []notify.User{{ID: ws.ChatID, Name: ws.Name}}
+			}
+			return notice
+		}
+	}
+	return notify.Notification{Target: t.CreatorID, Users: []notify.User{{ID: t.CreatorID}}}
+}
+
+// mergeUsers 合并通知对象（按昵称去重）
+func mergeUsers(users, extra []notify.User) []notify.User {
+	seen := make(map[string]bool, len(users))
+	for _, u := range users {
+		seen[u.Name] = true
+	}
+	for _, u := range extra {
+		if !seen[u.Name] {
+			seen[u.Name] = true
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// claimDelivery 获取或创建提醒的发送记录，已发送、已放弃重试或未到重试时间的提醒返回 nil
+func (tm *TaskManager) claimDelivery(t *Task, threshold, recipient string, now time.Time) (*ReminderDelivery, error) {
+	d := ReminderDelivery{
+		TaskID:     t.ID,
+		Threshold:  threshold,
+		DueTime:    *t.DueTime,
+		Recipient:  recipient,
+		Status:     DeliveryPending,
+		CreateTime: now,
+	}
+	if err := tm.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&d).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reminder delivery: %v", err)
+	}
+	if err := tm.db.Where("task_id = ? AND threshold = ? AND due_time = ? AND recipient = ?",
+		t.ID, threshold, *t.DueTime, recipient).First(&d).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reminder delivery: %v", err)
+	}
+
+	if d.Status == DeliverySent || d.Attempts >= MaxReminderAttempts {
+		return nil, nil
+	}
+	if d.NextAttemptTime != nil && d.NextAttemptTime.After(now) {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// finishDeliveries 记录提醒的发送结果，失败时安排下一次重试
+func (tm *TaskManager) finishDeliveries(items []pendingReminder, sendErr error, now time.Time) {
+	for _, p := range items {
+		d := p.Delivery
+		updates := map[string]interface{}{"attempts": d.Attempts + 1}
+		if sendErr == nil {
+			updates["status"] = DeliverySent
+			updates["sent_time"] = &now
+			updates["next_attempt_time"] = nil
+			updates["last_error"] = ""
+		} else {
+			retry := now.Add(reminderRetryBase << uint(d.Attempts))
+			updates["status"] = DeliveryFailed
+			updates["next_attempt_time"] = &retry
+			updates["last_error"] = sendErr.Error()
+		}
+		if err := tm.db.Model(&ReminderDelivery{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+			log.Printf("ERROR: Failed to record reminder delivery %d: %v\n", d.ID, err)
+		}
+		if snoozeID, ok := snoozeIDFromThreshold(d.Threshold); ok && (sendErr == nil || d.Attempts+1 >= MaxReminderAttempts) {
+			tm.finishSnooze(snoozeID)
+		}
+	}
+}
+
+// formatReminder 格式化发给同一接收方的提醒消息
+func (tm *TaskManager) formatReminder(items []pendingReminder, now time.Time) string {
+	var overdue, upcoming, snoozed []*Task
+	for _, p := range items {
+		if _, ok := snoozeIDFromThreshold(p.Delivery.Threshold); ok {
+			snoozed = append(snoozed, p.Task)
+		} else if p.Delivery.Threshold == ReminderOverdue {
+			overdue = append(overdue, p.Task)
+		} else {
+			upcoming = append(upcoming, p.Task)
+		}
+	}
+
+	var b strings.Builder
+	if len(overdue) > 0 {
+		b.WriteString("⚠️ 以下任务已到截止时间：\n")
+		for _, t := range overdue {
+			tm.writeReminderLine(&b, t, "")
+		}
+	}
+	if len(upcoming) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("⏰ 任务即将到期：\n")
+		for _, t := range upcoming {
+			tm.writeReminderLine(&b, t, "（还有"+formatRemaining(t.DueTime.Sub(now))+"）")
+		}
+	}
+	if len(snoozed) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("🔔 稍后提醒：\n")
+		for _, t := range snoozed {
+			if t.DueTime.After(now) {
+				tm.writeReminderLine(&b, t, "（还有"+formatRemaining(t.DueTime.Sub(now))+"）")
+			} else {
+				tm.writeReminderLine(&b, t, "（已过期"+formatRemaining(now.Sub(*t.DueTime))+"）")
+			}
+		}
+	}
+	b.WriteString("\n回复「稍后提醒 30分钟」「明天再提醒」或「知道了」")
+	return b.String()
+}
+
+// formatRemaining 格式化剩余时间，精确到分钟
+func formatRemaining(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d >= 24*time.Hour:
+		days, hours := d/(24*time.Hour), (d%(24*time.Hour))/time.Hour
+		if hours == 0 {
+			return fmt.Sprintf("%d天", days)
+		}
+		return fmt.Sprintf("%d天%d小时", days, hours)
+	case d >= time.Hour:
+		hours, minutes := d/time.Hour, (d%time.Hour)/time.Minute
+		if minutes == 0 {
+			return fmt.Sprintf("%d小时", hours)
+		}
+		return fmt.Sprintf("%d小时%d分钟", hours, minutes)
+	default:
+		return fmt.Sprintf("%d分钟", d/time.Minute)
+	}
+}
+
+// writeReminderLine 输出一个任务的提醒内容（群消息中由通知服务 @ 负责人）
+func (tm *TaskManager) writeReminderLine(b *strings.Builder, t *Task, suffix string) {
+	fmt.Fprintf(b, "- %s (ID: %s) 截止: %s%s", t.Title, t.DisplayKey(), t.DueTime.Format("2006-01-02 15:04"), suffix)
+	if assignees := tm.GetTaskAssignees(t.ID); len(assignees) > 0 {
+		fmt.Fprintf(b, " 负责人: %s", formatAssignees(assignees))
+	}
+	b.WriteString("\n")
+}
+
+// GetUpcomingTasks 获取工作区内即将到期的任务，workspaceID 为 0 表示所有工作区
+func (tm *TaskManager) GetUpcomingTasks(workspaceID uint, duration time.Duration) []*Task {
+	now := time.Now()
+	return tm.tasksDueBetween(workspaceScope(tm.db.Preload("Dependencies"), workspaceID), now, now.Add(duration))
+}
+
+// tasksDueBetween 查询范围内截止时间在 (from, to) 之间且未完成的任务（按截止时间排序）
+func (tm *TaskManager) tasksDueBetween(query *gorm.DB, from, to time.Time) []*Task {
+	var tasks []*Task
+	if err := query.
+		Where("tasks.status NOT IN ? AND tasks.due_time IS NOT NULL AND tasks.due_time > ? AND tasks.due_time < ?",
+			[]string{StatusCompleted, StatusCancelled}, from, to).
+		Order("tasks.due_time ASC").
+		Find(&tasks).Error; err != nil {
 		log.Printf("ERROR: Failed to get upcoming tasks: %v\n", err)
 		return []*Task{}
 	}
-	
+
+	tm.annotate(tasks)
 	return tasks
 }
-
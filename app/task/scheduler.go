@@ -0,0 +1,132 @@
+package task
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSchedulerSleep 调度器最长的休眠时间（没有待发送的提醒时定期重新检查）
+	maxSchedulerSleep = time.Hour
+	// schedulerErrorSleep 查询失败后重试的间隔
+	schedulerErrorSleep = time.Minute
+	// wakeDebounce 被唤醒后稍等片刻再检查，合并连续的修改并等待修改所在的事务提交
+	wakeDebounce = 500 * time.Millisecond
+)
+
+var (
+	activeScheduler *ReminderScheduler
+	schedulerMu     sync.RWMutex
+)
+
+// ReminderScheduler 提醒调度器：从数据库计算下一条提醒的时间，休眠到那一刻发送，任务修改时提前唤醒重新计算
+type ReminderScheduler struct {
+	tm    *TaskManager
+	send  ReminderSender
+	clock Clock
+	wake  chan struct{}
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// NewReminderScheduler 创建提醒调度器
+func NewReminderScheduler(tm *TaskManager, send ReminderSender, clock Clock) *ReminderScheduler {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &ReminderScheduler{
+		tm:    tm,
+		send:  send,
+		clock: clock,
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+	}
+}
+
+// StartReminderService 启动任务提醒服务
+func StartReminderService(send ReminderSender) *ReminderScheduler {
+	s := NewReminderScheduler(GetTaskManager(), send, SystemClock{})
+	s.Start()
+	return s
+}
+
+// Start 在后台运行调度器，并接收任务修改的唤醒通知
+func (s *ReminderScheduler) Start() {
+	schedulerMu.Lock()
+	activeScheduler = s
+	schedulerMu.Unlock()
+	go s.Run()
+}
+
+// Stop 停止调度器
+func (s *ReminderScheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		schedulerMu.Lock()
+		if activeScheduler == s {
+			activeScheduler = nil
+		}
+		schedulerMu.Unlock()
+	})
+}
+
+// Wake 唤醒调度器重新计算下一条提醒的时间（不阻塞）
+func (s *ReminderScheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 调度循环，直到 Stop 被调用
+func (s *ReminderScheduler) Run() {
+	log.Printf("Reminder scheduler started\n")
+	for {
+		wait := s.RunOnce()
+
+		select {
+		case <-s.clock.After(wait):
+		case <-s.wake:
+			select {
+			case <-s.clock.After(wakeDebounce):
+			case <-s.stop:
+				return
+			}
+		case <-s.stop:
+			log.Printf("Reminder scheduler stopped\n")
+			return
+		}
+	}
+}
+
+// RunOnce 发送当前到期的提醒，返回距离下一条提醒的等待时间
+func (s *ReminderScheduler) RunOnce() time.Duration {
+	if err := s.tm.DeliverDueReminders(s.clock.Now(), s.send); err != nil {
+		log.Printf("ERROR: Failed to deliver reminders: %v\n", err)
+		return schedulerErrorSleep
+	}
+
+	now := s.clock.Now()
+	next, ok, err := s.tm.NextReminderTime(now)
+	if err != nil {
+		log.Printf("ERROR: Failed to compute next reminder time: %v\n", err)
+		return schedulerErrorSleep
+	}
+	wait := maxSchedulerSleep
+	if ok && next.Sub(now) < wait {
+		wait = next.Sub(now)
+	}
+	log.Printf("Next reminder check in %s\n", wait)
+	return wait
+}
+
+// wakeReminderScheduler 任务修改后唤醒正在运行的调度器
+func wakeReminderScheduler() {
+	schedulerMu.RLock()
+	s := activeScheduler
+	schedulerMu.RUnlock()
+	if s != nil {
+		s.Wake()
+	}
+}
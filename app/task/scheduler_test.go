@@ -0,0 +1,297 @@
+package task
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// fakeClock 可控的时钟：Advance 之后到期的 After 才会触发，每次 After 的等待时间记录在 waits 中
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waits: make(chan time.Duration, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.waits <- d
+	return ch
+}
+
+// Advance 将时间推进 d，触发所有已到时间的 After
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			remaining = append(remaining, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = remaining
+}
+
+// nextWait 等待调度器下一次调用 After
+func (c *fakeClock) nextWait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not wait on the clock")
+		return 0
+	}
+}
+
+// recordingSender 记录发出的提醒，fail 为 true 或发送目标在 failTargets 中时返回发送失败
+type recordingSender struct {
+	mu          sync.Mutex
+	sent        []notify.Notification
+	fail        bool
+	failTargets map[string]bool
+	ch          chan notify.Notification
+}
+
+func newRecordingSender() *recordingSender {
+	return &recordingSender{ch: make(chan notify.Notification, 100)}
+}
+
+func (r *recordingSender) send(n notify.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return fmt.Errorf("bot is not logged in")
+	}
+	if r.failTargets[n.Target] {
+		return fmt.Errorf("%s is not a friend", n.Target)
+	}
+	r.sent = append(r.sent, n)
+	r.ch <- n
+	return nil
+}
+
+func (r *recordingSender) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sent)
+}
+
+// mustDueTask 创建只在到期时提醒一次的任务
+func mustDueTask(t *testing.T, tm *TaskManager, workspaceID uint, title string, due time.Time) *Task {
+	t.Helper()
+	task := mustTask(t, tm, workspaceID, title, &due)
+	if err := tm.SetTaskReminderOffsets(task.ID, []time.Duration{0}); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// schedulerStart 测试中调度器的起始时间（整秒，便于比较数据库中的时间）
+func schedulerStart() time.Time {
+	return time.Now().Add(time.Hour).Truncate(time.Second)
+}
+
+func TestSchedulerFiresAtDueTime(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	start := schedulerStart()
+	mustDueTask(t, tm, ws.ID, "发布", start.Add(2*time.Hour))
+
+	clock := newFakeClock(start)
+	sender := newRecordingSender()
+	s := NewReminderScheduler(tm, sender.send, clock)
+
+	if wait := s.RunOnce(); wait != maxSchedulerSleep {
+		t.Errorf("wait = %s, want %s (reminder is 2h away)", wait, maxSchedulerSleep)
+	}
+	clock.Advance(time.Hour)
+	if wait := s.RunOnce(); wait != time.Hour {
+		t.Errorf("wait = %s, want exactly 1h until the due time", wait)
+	}
+	clock.Advance(time.Hour - time.Second)
+	if wait := s.RunOnce(); wait != time.Second || sender.count() != 0 {
+		t.Errorf("one second early: wait = %s, sent %d, want 1s and nothing sent", wait, sender.count())
+	}
+	clock.Advance(time.Second)
+	s.RunOnce()
+	if sender.count() != 1 {
+		t.Fatalf("sent %d reminders at the due time, want 1", sender.count())
+	}
+	if n := sender.sent[0]; n.Target != "@@ops" || !n.IsGroup {
+		t.Errorf("reminder sent to %s (group %v), want the workspace group", n.Target, n.IsGroup)
+	}
+}
+
+func TestSchedulerNoDuplicates(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	start := schedulerStart()
+	mustDueTask(t, tm, ws.ID, "发布", start)
+	mustDueTask(t, tm, ws.ID, "回滚演练", start)
+
+	clock := newFakeClock(start)
+	sender := newRecordingSender()
+	s := NewReminderScheduler(tm, sender.send, clock)
+
+	for i := 0; i < 5; i++ {
+		s.RunOnce()
+		clock.Advance(10 * time.Minute)
+	}
+	// 同一接收方的两条提醒合并成一条消息，之后不再重复发送
+	if sender.count() != 1 {
+		t.Errorf("sent %d messages across RunOnce calls, want 1", sender.count())
+	}
+}
+
+func TestSchedulerRetryBackoff(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	start := schedulerStart()
+	task := mustDueTask(t, tm, ws.ID, "发布", start)
+
+	clock := newFakeClock(start)
+	sender := newRecordingSender()
+	sender.fail = true
+	s := NewReminderScheduler(tm, sender.send, clock)
+
+	for attempt := 0; attempt < 3; attempt++ {
+		backoff := reminderRetryBase << uint(attempt)
+		if wait := s.RunOnce(); wait != backoff {
+			t.Errorf("attempt %d: wait = %s, want %s", attempt+1, wait, backoff)
+		}
+		var d ReminderDelivery
+		if err := tm.db.Where("task_id = ?", task.ID).First(&d).Error; err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != DeliveryFailed || d.Attempts != attempt+1 || d.NextAttemptTime == nil || !d.NextAttemptTime.Equal(clock.Now().Add(backoff)) {
+			t.Fatalf("attempt %d: delivery = %s, %d attempts, next %v, want failed, %d, %s",
+				attempt+1, d.Status, d.Attempts, d.NextAttemptTime, attempt+1, clock.Now().Add(backoff))
+		}
+		// 重试时间之前不会再次发送
+		clock.Advance(backoff - time.Second)
+		s.RunOnce()
+		clock.Advance(time.Second)
+	}
+
+	sender.mu.Lock()
+	sender.fail = false
+	sender.mu.Unlock()
+	s.RunOnce()
+	if sender.count() != 1 {
+		t.Fatalf("sent %d reminders after recovering, want 1", sender.count())
+	}
+	var d ReminderDelivery
+	if err := tm.db.Where("task_id = ?", task.ID).First(&d).Error; err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != DeliverySent || d.Attempts != 4 {
+		t.Errorf("delivery = %s after %d attempts, want sent after 4", d.Status, d.Attempts)
+	}
+}
+
+func TestSchedulerRetriesFailedRecipient(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@alice", "alice", false)
+	start := schedulerStart()
+	task := mustDueTask(t, tm, ws.ID, "发布", start)
+	if err := tm.UpdateTaskAssignees(task.ID, []Actor{{ID: "@bob", Name: "bob"}}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := newFakeClock(start)
+	sender := newRecordingSender()
+	sender.failTargets = map[string]bool{"@bob": true}
+	s := NewReminderScheduler(tm, sender.send, clock)
+
+	// 私聊工作区发送成功，另一位负责人发送失败
+	if wait := s.RunOnce(); wait != reminderRetryBase {
+		t.Errorf("wait = %s, want the retry backoff %s", wait, reminderRetryBase)
+	}
+	if sender.count() != 1 || sender.sent[0].Target != "@alice" {
+		t.Fatalf("sent %v, want only the workspace reminder", sender.sent)
+	}
+
+	sender.mu.Lock()
+	sender.failTargets = nil
+	sender.mu.Unlock()
+	clock.Advance(reminderRetryBase)
+	s.RunOnce()
+	if sender.count() != 2 || sender.sent[1].Target != "@bob" {
+		t.Fatalf("sent %d reminders after the retry, want the failed recipient retried", sender.count())
+	}
+
+	// 两个接收方都完成后不再发送
+	clock.Advance(time.Hour)
+	s.RunOnce()
+	if sender.count() != 2 {
+		t.Errorf("sent %d reminders, want no more after both recipients succeeded", sender.count())
+	}
+}
+
+func TestSchedulerWakesOnChange(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	start := schedulerStart()
+	// 截止时间很远，调度器先休眠最长时间
+	task := mustDueTask(t, tm, ws.ID, "发布", start.Add(10*24*time.Hour))
+
+	clock := newFakeClock(start)
+	sender := newRecordingSender()
+	s := NewReminderScheduler(tm, sender.send, clock)
+	s.Start()
+	defer s.Stop()
+
+	if wait := clock.nextWait(t); wait != maxSchedulerSleep {
+		t.Fatalf("initial wait = %s, want %s", wait, maxSchedulerSleep)
+	}
+
+	// 截止时间改到 10 分钟后，修改会唤醒调度器
+	due := start.Add(10 * time.Minute)
+	if err := tm.UpdateTask(task.ID, TaskUpdate{DueTime: &due}); err != nil {
+		t.Fatal(err)
+	}
+	if wait := clock.nextWait(t); wait != wakeDebounce {
+		t.Fatalf("wait after change = %s, want the wake debounce %s", wait, wakeDebounce)
+	}
+	clock.Advance(wakeDebounce)
+	wait := clock.nextWait(t)
+	if want := 10*time.Minute - wakeDebounce; wait != want {
+		t.Fatalf("wait after wake = %s, want %s", wait, want)
+	}
+
+	clock.Advance(wait)
+	select {
+	case n := <-sender.ch:
+		if n.Target != "@@ops" {
+			t.Errorf("reminder sent to %s, want @@ops", n.Target)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reminder was not sent at the new due time")
+	}
+}
@@ -0,0 +1,409 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// fullTextIndex 任务标题和内容的全文索引名
+const fullTextIndex = "idx_tasks_fulltext"
+
+// ngramTokenSize MySQL ngram 分词器的默认词长，更短的关键词无法通过全文索引匹配
+const ngramTokenSize = 2
+
+// fullTextEnabled 全文索引是否可用（迁移时检测）
+var fullTextEnabled bool
+
+// ensureFullTextIndex 在 MySQL 上为任务标题和内容创建 ngram 全文索引（支持中文）
+func ensureFullTextIndex(db *gorm.DB) {
+	if db.Dialector.Name() != "mysql" {
+		log.Printf("Full-text index not supported on %s, search falls back to LIKE\n", db.Dialector.Name())
+		return
+	}
+
+	if !db.Migrator().HasIndex(&Task{}, fullTextIndex) {
+		sql := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON tasks (title, content) WITH PARSER ngram", fullTextIndex)
+		if err := db.Exec(sql).Error; err != nil {
+			log.Printf("WARNING: Failed to create full-text index, search falls back to LIKE: %v\n", err)
+			return
+		}
+		log.Printf("Task full-text index created\n")
+	}
+	fullTextEnabled = true
+}
+
+// TaskFilter 任务筛选条件，各条件之间为“且”的关系，零值表示不限
+type TaskFilter struct {
+	WorkspaceID   uint       // 工作区（含共享进来的任务），0 表示所有工作区
+	Keyword       string     // 关键词，空格分隔的多个词都要匹配
+	Statuses      []string   // 状态（可包含派生状态 blocked）
+	CreatorID     string     // 创建人
+	DueAfter      *time.Time // 截止时间不早于
+	DueBefore     *time.Time // 截止时间早于
+	CreatedAfter  *time.Time // 创建时间不早于
+	CreatedBefore *time.Time // 创建时间早于
+	NoDueTime     bool       // 只要没有截止时间的任务
+	Labels        [][]string // 标签：每组至少有一个，各组都要满足
+	Assignee      string     // 负责人（昵称或UserName）
+	Unassigned    bool       // 只要没有负责人的任务
+	Recurring     bool       // 只要重复任务
+	Priorities    []int      // 优先级
+	SortBy        string     // 排序字段，见 Sort* 常量，空表示按创建时间
+	SortReverse   bool       // 与默认方向相反（截止时间、编号默认正序，其余默认倒序）
+	Limit         int        // 最多返回多少条，0 表示使用默认值
+}
+
+// 排序字段
+const (
+	SortDue      = "due"
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+	SortPriority = "priority"
+	SortKey      = "key"
+)
+
+// SearchResult 搜索结果
+type SearchResult struct {
+	Task    *Task
+	Score   float64 // 相关度，越大越相关
+	Snippet string  // 高亮了关键词的内容片段
+}
+
+// defaultSearchLimit 默认最多返回的搜索结果数
+const defaultSearchLimit = 20
+
+// SearchTasks 在数据库中按关键词和筛选条件搜索任务，结果按相关度排序
+func (tm *TaskManager) SearchTasks(filter TaskFilter) ([]*SearchResult, error) {
+	terms := strings.Fields(filter.Keyword)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	query := applyTaskFilter(tm.db.Model(&Task{}), filter)
+
+	useFullText := canUseFullText(terms)
+
+	var tasks []*Task
+	scores := make(map[uint]float64)
+	if useFullText {
+		// 布尔模式下每个词都必须出现
+		against := booleanQuery(terms)
+		var rows []struct {
+			ID    uint
+			Score float64
+		}
+		if err := query.
+			Select("tasks.id, MATCH(tasks.title, tasks.content) AGAINST(? IN BOOLEAN MODE) AS score", against).
+			Where("MATCH(tasks.title, tasks.content) AGAINST(? IN BOOLEAN MODE)", against).
+			Order("score DESC, tasks.id DESC").
+			Limit(limit).
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to search tasks: %v", err)
+		}
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+			scores[row.ID] = row.Score
+		}
+		if len(ids) > 0 {
+			if err := tm.db.Preload("Dependencies").Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+				return nil, fmt.Errorf("failed to load search results: %v", err)
+			}
+		}
+	} else {
+		if err := likeTerms(query, terms).Preload("Dependencies").Order("tasks.id DESC").Limit(limit).Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("failed to search tasks: %v", err)
+		}
+	}
+
+	tm.annotate(tasks)
+	results := make([]*SearchResult, len(tasks))
+	for i, t := range tasks {
+		score := scores[t.ID]
+		if !useFullText {
+			score = countMatches(t.Content, terms)
+		}
+		// 标题命中的任务排在前面
+		score += 2 * countMatches(t.Title, terms)
+		results[i] = &SearchResult{Task: t, Score: score, Snippet: highlightSnippet(t, terms)}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
+
+// applyTaskFilter 将筛选条件（关键词除外）应用到查询
+func applyTaskFilter(query *gorm.DB, filter TaskFilter) *gorm.DB {
+	query = workspaceScope(query, filter.WorkspaceID)
+
+	if len(filter.Statuses) > 0 {
+		var stored []string
+		blocked := false
+		for _, s := range filter.Statuses {
+			if s == StatusBlocked {
+				blocked = true
+			} else {
+				stored = append(stored, s)
+			}
+		}
+		switch {
+		case blocked && len(stored) > 0:
+			db := query.Session(&gorm.Session{NewDB: true})
+			query = query.Where(db.Where("tasks.status IN ?", stored).Or(blockedCondition(db)))
+		case blocked:
+			query = blockedCondition(query)
+		default:
+			query = query.Where("tasks.status IN ?", stored)
+		}
+	}
+	if filter.CreatorID != "" {
+		query = query.Where("tasks.creator_id = ?", filter.CreatorID)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("tasks.due_time >= ?", *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("tasks.due_time < ?", *filter.DueBefore)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("tasks.create_time >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("tasks.create_time < ?", *filter.CreatedBefore)
+	}
+	if filter.NoDueTime {
+		query = query.Where("tasks.due_time IS NULL")
+	}
+	for _, group := range filter.Labels {
+		query = query.Where("tasks.id IN (SELECT task_id FROM task_labels WHERE label IN ?)", group)
+	}
+	if filter.Assignee != "" {
+		query = query.Where("tasks.id IN (SELECT task_id FROM task_assignees WHERE user_name = ? OR user_id = ?)", filter.Assignee, filter.Assignee)
+	}
+	if filter.Unassigned {
+		query = query.Where("tasks.id NOT IN (SELECT task_id FROM task_assignees)")
+	}
+	if filter.Recurring {
+		query = query.Where("tasks.recurrence_rule <> ''")
+	}
+	if len(filter.Priorities) > 0 {
+		query = query.Where("tasks.priority IN ?", filter.Priorities)
+	}
+	return query
+}
+
+// defaultQueryLimit 条件查询默认最多返回的任务数
+const defaultQueryLimit = 50
+
+// QueryTasks 按筛选条件查询任务（关键词作为过滤条件，不计算相关度），按 SortBy 排序
+func (tm *TaskManager) QueryTasks(filter TaskFilter) ([]*Task, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+
+	query := applyKeyword(applyTaskFilter(tm.db.Preload("Dependencies"), filter), filter.Keyword)
+	sortBy, desc := normalizeSort(filter.SortBy, filter.SortReverse)
+
+	var tasks []*Task
+	if err := query.Order(sortOrder(sortBy, desc)).Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %v", err)
+	}
+	tm.annotate(tasks)
+	return tasks, nil
+}
+
+// canUseFullText 关键词能否通过全文索引匹配
+func canUseFullText(terms []string) bool {
+	if !fullTextEnabled || len(terms) == 0 {
+		return false
+	}
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < ngramTokenSize {
+			return false
+		}
+	}
+	return true
+}
+
+// applyKeyword 将关键词作为过滤条件（能用全文索引时使用全文索引）
+func applyKeyword(query *gorm.DB, keyword string) *gorm.DB {
+	terms := strings.Fields(keyword)
+	if canUseFullText(terms) {
+		return query.Where("MATCH(tasks.title, tasks.content) AGAINST(? IN BOOLEAN MODE)", booleanQuery(terms))
+	}
+	return likeTerms(query, terms)
+}
+
+// likeTerms 用 LIKE 匹配关键词（每个词都要出现在标题或内容中）
+func likeTerms(query *gorm.DB, terms []string) *gorm.DB {
+	for _, term := range terms {
+		like := "%" + escapeLike(term) + "%"
+		query = query.Where("(tasks.title LIKE ? OR tasks.content LIKE ?)", like, like)
+	}
+	return query
+}
+
+// booleanQuery 构造全文索引布尔模式的查询串：每个词都必须出现，词内按短语匹配
+func booleanQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		term = strings.NewReplacer(`"`, " ", `\`, " ").Replace(term)
+		parts[i] = `+"` + term + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapeLike 转义 LIKE 中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// countMatches 统计关键词在文本中出现的次数（不区分大小写）
+func countMatches(text string, terms []string) float64 {
+	lower := strings.ToLower(text)
+	count := 0
+	for _, term := range terms {
+		count += strings.Count(lower, strings.ToLower(term))
+	}
+	return float64(count)
+}
+
+// snippetRadius 高亮片段在关键词前后保留的字数
+const snippetRadius = 20
+
+// highlightSnippet 截取内容中第一个关键词附近的片段，并用【】标出所有关键词
+func highlightSnippet(t *Task, terms []string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	text := []rune(strings.Join(strings.Fields(t.Content), " "))
+	lower := lowerRunes(text)
+	first := -1
+	for _, term := range terms {
+		if i := runeIndex(lower, lowerRunes([]rune(term))); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := first - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetRadius*2
+	if end > len(text) {
+		end = len(text)
+	}
+
+	snippet := highlightTerms(text[start:end], lower[start:end], terms)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// highlightTerms 用【】标出文本中的关键词（lower 为 text 的小写形式，用于不区分大小写匹配）
+func highlightTerms(text, lower []rune, terms []string) string {
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		needle := lowerRunes([]rune(term))
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); {
+			j := runeIndex(lower[i:], needle)
+			if j < 0 {
+				break
+			}
+			for k := i + j; k < i+j+len(needle); k++ {
+				marked[k] = true
+			}
+			i += j + len(needle)
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range text {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("【")
+		}
+		b.WriteRune(r)
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString("】")
+		}
+	}
+	return b.String()
+}
+
+// lowerRunes 逐字符转为小写（保持长度不变，便于和原文对齐）
+func lowerRunes(text []rune) []rune {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// runeIndex 在 s 中查找 sub 第一次出现的位置（按字符计），找不到返回 -1
+func runeIndex(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// FormatSearchResults 格式化搜索结果用于微信显示
+func FormatSearchResults(keyword string, results []*SearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("未找到包含 '%s' 的任务", keyword)
+	}
+
+	title := fmt.Sprintf("🔍 搜索结果 (共 %d 个):\n\n", len(results))
+	if keyword != "" {
+		title = fmt.Sprintf("🔍 搜索 '%s' (共 %d 个):\n\n", keyword, len(results))
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	for i, r := range results {
+		t := r.Task
+		emoji := statusEmoji(t.Status)
+		if t.Blocked {
+			emoji = "⛔"
+		}
+		fmt.Fprintf(&b, "%d. %s %s (ID: %s)\n", i+1, emoji, highlightTerms([]rune(t.Title), lowerRunes([]rune(t.Title)), strings.Fields(keyword)), t.DisplayKey())
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", r.Snippet)
+		}
+		if t.DueTime != nil {
+			fmt.Fprintf(&b, "   截止: %s\n", t.DueTime.Format("2006-01-02 15:04"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package task
+
+import "testing"
+
+func TestBooleanQuery(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  string
+	}{
+		{[]string{"周报"}, `+"周报"`},
+		{[]string{"周报", "运维"}, `+"周报" +"运维"`},
+		{[]string{`say"hi`}, `+"say hi"`},
+		{[]string{`a\b`}, `+"a b"`},
+	}
+	for _, tt := range tests {
+		if got := booleanQuery(tt.terms); got != tt.want {
+			t.Errorf("booleanQuery(%q) = %s, want %s", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := escapeLike(`100%_a\b`), `100\%\_a\\b`; got != want {
+		t.Errorf("escapeLike = %s, want %s", got, want)
+	}
+}
+
+func TestCanUseFullText(t *testing.T) {
+	previous := fullTextEnabled
+	t.Cleanup(func() { fullTextEnabled = previous })
+
+	fullTextEnabled = true
+	tests := []struct {
+		terms []string
+		want  bool
+	}{
+		{nil, false},
+		{[]string{"周报"}, true},
+		{[]string{"周报", "运维群"}, true},
+		{[]string{"周报", "急"}, false}, // 单字无法通过 ngram 索引匹配
+		{[]string{"a"}, false},
+	}
+	for _, tt := range tests {
+		if got := canUseFullText(tt.terms); got != tt.want {
+			t.Errorf("canUseFullText(%q) = %v, want %v", tt.terms, got, tt.want)
+		}
+	}
+
+	fullTextEnabled = false
+	if canUseFullText([]string{"周报"}) {
+		t.Error("canUseFullText without index = true, want false")
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		content string
+		terms   []string
+		want    string
+	}{
+		{"整理本周的周报并发给老板", []string{"周报"}, "整理本周的【周报】并发给老板"},
+		{"Deploy the API server", []string{"api"}, "Deploy the 【API】 server"},
+		{"周报周报", []string{"周报"}, "【周报周报】"},
+		{"没有关键词", []string{"周报"}, ""},
+		{"第一行\n\n第二行 周报", []string{"周报"}, "第一行 第二行 【周报】"},
+		{"一二三四五六七八九十一二三四五六七八九十一二三四五六七八九十周报", []string{"周报"}, "…一二三四五六七八九十一二三四五六七八九十【周报】"},
+	}
+	for _, tt := range tests {
+		if got := highlightSnippet(&Task{Content: tt.content}, tt.terms); got != tt.want {
+			t.Errorf("highlightSnippet(%q, %q) = %s, want %s", tt.content, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTasksLike(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	other := mustWorkspace(t, tm, "@@dev", "开发群", true)
+
+	inTitle := mustTask(t, tm, ws.ID, "写周报", nil)
+	inContent, err := tm.CreateTask(ws.ID, "整理文档", "顺便更新周报模板", "creator", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustTask(t, tm, ws.ID, "部署服务", nil)
+	mustTask(t, tm, other.ID, "其他群的周报", nil)
+
+	results, err := tm.SearchTasks(TaskFilter{WorkspaceID: ws.ID, Keyword: "周报"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("SearchTasks found %d tasks, want 2", len(results))
+	}
+	// 标题命中的任务排在前面
+	if results[0].Task.ID != inTitle.ID || results[1].Task.ID != inContent.ID {
+		t.Errorf("SearchTasks order = [%d %d], want [%d %d]", results[0].Task.ID, results[1].Task.ID, inTitle.ID, inContent.ID)
+	}
+	if results[1].Snippet != "顺便更新【周报】模板" {
+		t.Errorf("snippet = %s, want 顺便更新【周报】模板", results[1].Snippet)
+	}
+
+	results, err = tm.SearchTasks(TaskFilter{WorkspaceID: ws.ID, Keyword: "周报 模板"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Task.ID != inContent.ID {
+		t.Errorf("SearchTasks with two terms found %d tasks, want only %d", len(results), inContent.ID)
+	}
+}
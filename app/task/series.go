@@ -0,0 +1,289 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// openStatuses 未结束的任务状态
+var openStatuses = []string{StatusPending, StatusInProgress}
+
+// spawnNextOccurrence 为重复任务生成下一次发生
+// 如果系列中已经存在更晚的发生、已达到 COUNT 或超过 UNTIL，则不生成
+func (tm *TaskManager) spawnNextOccurrence(t *Task) (*Task, error) {
+	recurrence, err := ParseRecurrence(t.RecurrenceRule)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesID := t.SeriesID
+	if seriesID == 0 {
+		seriesID = t.ID
+	}
+
+	// 已经生成过下一次（例如任务被重新打开后再次完成）
+	var later int64
+	if err := tm.db.Model(&Task{}).Where("series_id = ? AND id > ?", seriesID, t.ID).Count(&later).Error; err != nil {
+		return nil, fmt.Errorf("failed to check series %d: %v", seriesID, err)
+	}
+	if later > 0 {
+		log.Printf("Series %d already has an occurrence after task %d, skipping\n", seriesID, t.ID)
+		return nil, nil
+	}
+
+	if recurrence.Count > 0 {
+		var total int64
+		if err := tm.db.Model(&Task{}).Where("series_id = ?", seriesID).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count series %d: %v", seriesID, err)
+		}
+		if int(total) >= recurrence.Count {
+			log.Printf("Series %d reached COUNT=%d, no more occurrences\n", seriesID, recurrence.Count)
+			return nil, nil
+		}
+	}
+
+	base := t.CreateTime
+	if t.DueTime != nil {
+		base = *t.DueTime
+	}
+
+	// 逾期很久才完成时，跳过已经过去的发生
+	now := time.Now()
+	next, ok := recurrence.Next(base)
+	for ok && next.Before(now) {
+		next, ok = recurrence.Next(next)
+	}
+	if !ok {
+		log.Printf("Series %d has ended\n", seriesID)
+		return nil, nil
+	}
+
+	occurrence := &Task{
+		Title:           t.Title,
+		Content:         t.Content,
+		CreatorID:       t.CreatorID,
+		WorkspaceID:     t.WorkspaceID,
+		CreateTime:      now,
+		DueTime:         &next,
+		Status:          StatusPending,
+		RecurrenceRule:  t.RecurrenceRule,
+		SeriesID:        seriesID,
+		ReminderOffsets: t.ReminderOffsets,
+		EstimateMinutes: t.EstimateMinutes,
+		Priority:        t.Priority,
+		ParentID:        t.ParentID,
+		Dependencies:    make([]TaskDependency, 0),
+	}
+	// 标签和负责人随任务一起保存
+	for _, label := range tm.GetTaskLabels(t.ID) {
+		occurrence.Labels = append(occurrence.Labels, TaskLabel{Label: label})
+	}
+	for _, a := range tm.GetTaskAssignees(t.ID) {
+		occurrence.Assignees = append(occurrence.Assignees, TaskAssignee{UserName: a.UserName, UserID: a.UserID})
+	}
+	if err := tm.saveNewTask(occurrence, nil); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Spawned occurrence %d of series %d due %s\n", occurrence.ID, seriesID, next.Format("2006-01-02 15:04:05"))
+	return occurrence, nil
+}
+
+// NextOccurrence 获取重复任务之后生成的下一次发生
+func (tm *TaskManager) NextOccurrence(taskID uint) (*Task, bool) {
+	current, exists := tm.GetTask(taskID)
+	if !exists || current.SeriesID == 0 {
+		return nil, false
+	}
+
+	var next Task
+	if err := tm.db.Preload("Dependencies").
+		Where("series_id = ? AND id > ?", current.SeriesID, current.ID).
+		Order("id ASC").
+		First(&next).Error; err != nil {
+		return nil, false
+	}
+	tm.fillKeys([]*Task{&next})
+	return &next, true
+}
+
+// UpdateTaskSeries 更新整个重复系列中尚未结束的发生（从指定任务开始）
+// 截止时间按与指定任务的差值平移，保持各次发生之间的间隔
+func (tm *TaskManager) UpdateTaskSeries(id uint, title *string, content *string, dueTime *time.Time) error {
+	current, err := tm.getSeriesTask(id)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if title != nil {
+		updates["title"] = *title
+	}
+	if content != nil {
+		updates["content"] = *content
+	}
+
+	var shift time.Duration
+	if dueTime != nil && current.DueTime != nil {
+		shift = dueTime.Sub(*current.DueTime)
+	}
+
+	if len(updates) == 0 && shift == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	var occurrences []*Task
+	if err := tm.db.Where("series_id = ? AND id >= ? AND status IN ?", current.SeriesID, current.ID, openStatuses).
+		Find(&occurrences).Error; err != nil {
+		return fmt.Errorf("failed to load series %d: %v", current.SeriesID, err)
+	}
+
+	// 保留修改前的值用于记录动态（Updates 会回写到模型上）
+	before := make([]Task, len(occurrences))
+	for i, occ := range occurrences {
+		before[i] = *occ
+	}
+
+	err = tm.db.Transaction(func(tx *gorm.DB) error {
+		for _, occ := range occurrences {
+			fields := make(map[string]interface{}, len(updates)+1)
+			for k, v := range updates {
+				fields[k] = v
+			}
+			if shift != 0 && occ.DueTime != nil {
+				fields["due_time"] = occ.DueTime.Add(shift)
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			if err := updateVersioned(tx, occ, fields); err != nil {
+				return err
+			}
+		}
+		log.Printf("Updated %d occurrence(s) of series %d\n", len(occurrences), current.SeriesID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range before {
+		occ := &before[i]
+		next := *occ
+		if title != nil {
+			next.Title = *title
+		}
+		if content != nil {
+			next.Content = *content
+		}
+		if shift != 0 && occ.DueTime != nil {
+			due := occ.DueTime.Add(shift)
+			next.DueTime = &due
+		}
+		tm.logFieldChanges(occ, &next)
+	}
+	return nil
+}
+
+// UpdateTaskRecurrence 修改系列的重复规则（作用于尚未结束的发生），rule 为空表示停止重复
+func (tm *TaskManager) UpdateTaskRecurrence(id uint, rule string) error {
+	t, exists := tm.GetTask(id)
+	if !exists {
+		return fmt.Errorf("task %d not found", id)
+	}
+
+	normalized := ""
+	if rule != "" {
+		recurrence, err := ParseRecurrence(rule)
+		if err != nil {
+			return err
+		}
+		normalized = recurrence.String()
+	}
+
+	previous := describeRule(t.RecurrenceRule)
+
+	// 普通任务变为重复任务时，自身成为系列的第一次发生
+	if t.SeriesID == 0 {
+		updates := map[string]interface{}{"recurrence_rule": normalized}
+		if normalized != "" {
+			updates["series_id"] = t.ID
+		}
+		if err := updateVersioned(tm.db, t, updates); err != nil {
+			return err
+		}
+		tm.logActivity(t, ActivityField, "recurrence", previous, describeRule(normalized))
+		return nil
+	}
+
+	if err := tm.db.Model(&Task{}).
+		Where("series_id = ? AND id >= ? AND status IN ?", t.SeriesID, t.ID, openStatuses).
+		Updates(map[string]interface{}{
+			"recurrence_rule": normalized,
+			"version":         gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update series recurrence: %v", err)
+	}
+
+	log.Printf("Updated recurrence of series %d to '%s'\n", t.SeriesID, normalized)
+	tm.logActivity(t, ActivityField, "recurrence", previous, describeRule(normalized))
+	return nil
+}
+
+// CancelSeries 取消整个重复系列：取消所有尚未结束的发生，并不再生成新的发生
+func (tm *TaskManager) CancelSeries(id uint) (int, error) {
+	current, err := tm.getSeriesTask(id)
+	if err != nil {
+		return 0, err
+	}
+
+	var occurrences []*Task
+	if err := tm.db.Where("series_id = ? AND status IN ?", current.SeriesID, openStatuses).
+		Find(&occurrences).Error; err != nil {
+		return 0, fmt.Errorf("failed to load series %d: %v", current.SeriesID, err)
+	}
+
+	result := tm.db.Model(&Task{}).
+		Where("series_id = ? AND status IN ?", current.SeriesID, openStatuses).
+		Updates(map[string]interface{}{
+			"status":          StatusCancelled,
+			"recurrence_rule": "",
+			"version":         gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to cancel series %d: %v", current.SeriesID, result.Error)
+	}
+
+	for _, occ := range occurrences {
+		tm.logActivity(occ, ActivityStatus, "", occ.Status, StatusCancelled)
+	}
+
+	log.Printf("Cancelled %d open occurrence(s) of series %d\n", result.RowsAffected, current.SeriesID)
+	return int(result.RowsAffected), nil
+}
+
+// getSeriesTask 获取属于某个重复系列的任务
+func (tm *TaskManager) getSeriesTask(id uint) (*Task, error) {
+	t, exists := tm.GetTask(id)
+	if !exists {
+		return nil, fmt.Errorf("task %d not found", id)
+	}
+	if t.SeriesID == 0 {
+		return nil, fmt.Errorf("task %d is not a recurring task", id)
+	}
+	return t, nil
+}
+
+// describeRule 重复规则的中文描述，用于动态记录
+func describeRule(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	if recurrence, err := ParseRecurrence(rule); err == nil {
+		return recurrence.Describe()
+	}
+	return rule
+}
@@ -0,0 +1,288 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReminderReplyWindow 提醒发出后多久内可以回复“稍后提醒”或“知道了”
+const ReminderReplyWindow = 12 * time.Hour
+
+// 稍后提醒的状态
+const (
+	SnoozePending   = "pending"
+	SnoozeFired     = "fired"
+	SnoozeCancelled = "cancelled"
+)
+
+// snoozeThresholdPrefix 稍后提醒的发送记录阈值前缀，后接 ReminderSnooze.ID
+const snoozeThresholdPrefix = "snooze_"
+
+// ReminderBatch 一条已发送的提醒消息（同一接收方同一时间发送的提醒）
+type ReminderBatch struct {
+	Recipient  string
+	SentTime   time.Time
+	Deliveries []ReminderDelivery
+	Tasks      []*Task // 仍未结束且截止时间未变的任务
+}
+
+// snoozeThreshold 稍后提醒对应的发送记录阈值
+func snoozeThreshold(snoozeID uint) string {
+	return snoozeThresholdPrefix + strconv.FormatUint(uint64(snoozeID), 10)
+}
+
+// snoozeIDFromThreshold 从发送记录阈值中取出稍后提醒ID，不是稍后提醒时返回 false
+func snoozeIDFromThreshold(threshold string) (uint, bool) {
+	if !strings.HasPrefix(threshold, snoozeThresholdPrefix) {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(threshold, snoozeThresholdPrefix), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// LastUnrepliedReminder 会话最近一条尚未回复的提醒，没有时返回 nil
+// 群聊和私聊的提醒都发给工作区；私聊中还包括以负责人身份（assigneeName）单独收到的提醒
+func (tm *TaskManager) LastUnrepliedReminder(workspaceID uint, assigneeName string, now time.Time) (*ReminderBatch, error) {
+	recipients := []string{fmt.Sprintf("workspace:%d", workspaceID)}
+	if assigneeName != "" {
+		recipients = append(recipients, "assignee:"+assigneeName)
+	}
+
+	var latest ReminderDelivery
+	err := tm.db.Where("recipient IN ? AND status = ? AND replied = ? AND sent_time >= ?",
+		recipients, DeliverySent, false, now.Add(-ReminderReplyWindow)).
+		Order("sent_time DESC, id DESC").
+		First(&latest).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find last reminder: %v", err)
+	}
+
+	batch := &ReminderBatch{Recipient: latest.Recipient, SentTime: *latest.SentTime}
+	if err := tm.db.Where("recipient = ? AND sent_time = ? AND replied = ?", latest.Recipient, latest.SentTime, false).
+		Order("id ASC").
+		Find(&batch.Deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reminder deliveries: %v", err)
+	}
+
+	ids := make([]uint, 0, len(batch.Deliveries))
+	dueTimes := make(map[uint]time.Time, len(batch.Deliveries))
+	for _, d := range batch.Deliveries {
+		if _, seen := dueTimes[d.TaskID]; !seen {
+			ids = append(ids, d.TaskID)
+		}
+		dueTimes[d.TaskID] = d.DueTime
+	}
+	var tasks []*Task
+	if err := tm.db.Where("id IN ?", ids).Order("due_time ASC").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reminded tasks: %v", err)
+	}
+	for _, t := range tasks {
+		if t.Status == StatusCompleted || t.Status == StatusCancelled || t.DueTime == nil || !t.DueTime.Equal(dueTimes[t.ID]) {
+			continue
+		}
+		batch.Tasks = append(batch.Tasks, t)
+	}
+	tm.fillKeys(batch.Tasks)
+
+	// 任务都已结束或改了截止时间，这条提醒不再需要回复
+	if len(batch.Tasks) == 0 {
+		tm.markReplied(batch)
+		return nil, nil
+	}
+	return batch, nil
+}
+
+// SnoozeReminder 稍后提醒：到 remindAt 时再次提醒这条消息中的任务，替换这些任务之前的稍后提醒
+func (tm *TaskManager) SnoozeReminder(batch *ReminderBatch, remindAt, now time.Time) error {
+	if !remindAt.After(now) {
+		return fmt.Errorf("snooze time must be in the future")
+	}
+
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range batch.Tasks {
+			if err := tx.Model(&ReminderSnooze{}).
+				Where("task_id = ? AND recipient = ? AND status = ?", t.ID, batch.Recipient, SnoozePending).
+				Update("status", SnoozeCancelled).Error; err != nil {
+				return fmt.Errorf("failed to cancel previous snooze: %v", err)
+			}
+			snooze := ReminderSnooze{
+				TaskID:     t.ID,
+				DueTime:    *t.DueTime,
+				Recipient:  batch.Recipient,
+				RemindAt:   remindAt,
+				Status:     SnoozePending,
+				ActorID:    tm.actor.ID,
+				CreateTime: now,
+			}
+			if err := tx.Create(&snooze).Error; err != nil {
+				return fmt.Errorf("failed to snooze reminder: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tm.markReplied(batch)
+	wakeReminderScheduler()
+	log.Printf("Snoozed %d reminder(s) for %s until %s by %s\n", len(batch.Tasks), batch.Recipient, remindAt.Format("2006-01-02 15:04"), tm.actor.Name)
+	return nil
+}
+
+// AcknowledgeReminder 确认提醒（“知道了”）：当前截止时间内不再提醒这条消息中的任务
+func (tm *TaskManager) AcknowledgeReminder(batch *ReminderBatch, now time.Time) error {
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range batch.Tasks {
+			ack := ReminderAck{
+				TaskID:     t.ID,
+				DueTime:    *t.DueTime,
+				ActorID:    tm.actor.ID,
+				ActorName:  tm.actor.Name,
+				CreateTime: now,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ack).Error; err != nil {
+				return fmt.Errorf("failed to acknowledge reminder: %v", err)
+			}
+			if err := tx.Model(&ReminderSnooze{}).
+				Where("task_id = ? AND due_time = ? AND status = ?", t.ID, *t.DueTime, SnoozePending).
+				Update("status", SnoozeCancelled).Error; err != nil {
+				return fmt.Errorf("failed to cancel snooze: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tm.markReplied(batch)
+	wakeReminderScheduler()
+	log.Printf("Acknowledged %d reminder(s) for %s by %s\n", len(batch.Tasks), batch.Recipient, tm.actor.Name)
+	return nil
+}
+
+// markReplied 将提醒消息标记为已回复
+func (tm *TaskManager) markReplied(batch *ReminderBatch) {
+	ids := make([]uint, len(batch.Deliveries))
+	for i, d := range batch.Deliveries {
+		ids[i] = d.ID
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := tm.db.Model(&ReminderDelivery{}).Where("id IN ?", ids).Update("replied", true).Error; err != nil {
+		log.Printf("ERROR: Failed to mark reminders as replied: %v\n", err)
+	}
+}
+
+// snoozeHorizons 任务在当前截止时间内最晚的稍后提醒时间（未发送或已发送），按任务、截止时间和接收方索引
+func (tm *TaskManager) snoozeHorizons(tasks []*Task) (map[string]time.Time, error) {
+	horizons := make(map[string]time.Time)
+	if len(tasks) == 0 {
+		return horizons, nil
+	}
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	var snoozes []ReminderSnooze
+	if err := tm.db.Where("task_id IN ? AND status IN ?", ids, []string{SnoozePending, SnoozeFired}).Find(&snoozes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load snoozes: %v", err)
+	}
+	for _, s := range snoozes {
+		key := snoozeKey(s.TaskID, s.DueTime, s.Recipient)
+		if until, ok := horizons[key]; !ok || s.RemindAt.After(until) {
+			horizons[key] = s.RemindAt
+		}
+	}
+	return horizons, nil
+}
+
+// acknowledgedTasks 在当前截止时间内已确认（“知道了”）的任务
+func (tm *TaskManager) acknowledgedTasks(tasks []*Task) (map[uint]bool, error) {
+	acked := make(map[uint]bool)
+	if len(tasks) == 0 {
+		return acked, nil
+	}
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	var acks []ReminderAck
+	if err := tm.db.Where("task_id IN ?", ids).Find(&acks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load acknowledgements: %v", err)
+	}
+	for _, t := range tasks {
+		for _, a := range acks {
+			if a.TaskID == t.ID && a.DueTime.Equal(*t.DueTime) {
+				acked[t.ID] = true
+			}
+		}
+	}
+	return acked, nil
+}
+
+// snoozeKey 稍后提醒的索引键
+func snoozeKey(taskID uint, dueTime time.Time, recipient string) string {
+	return fmt.Sprintf("%d|%d|%s", taskID, dueTime.Unix(), recipient)
+}
+
+// activeSnoozeQuery 仍然有效的稍后提醒：任务未结束、未删除且截止时间没有变
+func (tm *TaskManager) activeSnoozeQuery() *gorm.DB {
+	return tm.db.Model(&ReminderSnooze{}).
+		Joins("JOIN tasks ON tasks.id = task_reminder_snoozes.task_id").
+		Where("task_reminder_snoozes.status = ?", SnoozePending).
+		Where("tasks.deleted_at IS NULL AND tasks.status NOT IN ? AND tasks.due_time = task_reminder_snoozes.due_time",
+			[]string{StatusCompleted, StatusCancelled})
+}
+
+// dueSnoozes 已到时间的稍后提醒
+func (tm *TaskManager) dueSnoozes(now time.Time) ([]ReminderSnooze, error) {
+	var snoozes []ReminderSnooze
+	if err := tm.activeSnoozeQuery().
+		Select("task_reminder_snoozes.*").
+		Where("task_reminder_snoozes.remind_at <= ?", now).
+		Order("task_reminder_snoozes.remind_at ASC").
+		Find(&snoozes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load due snoozes: %v", err)
+	}
+	return snoozes, nil
+}
+
+// nextSnoozeTime now 之后最早的稍后提醒时间
+func (tm *TaskManager) nextSnoozeTime(now time.Time) (time.Time, bool, error) {
+	var times []time.Time
+	if err := tm.activeSnoozeQuery().
+		Where("task_reminder_snoozes.remind_at > ?", now).
+		Order("task_reminder_snoozes.remind_at ASC").
+		Limit(1).
+		Pluck("task_reminder_snoozes.remind_at", &times).Error; err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to load next snooze: %v", err)
+	}
+	if len(times) == 0 {
+		return time.Time{}, false, nil
+	}
+	return times[0], true, nil
+}
+
+// finishSnooze 稍后提醒发送成功或放弃重试后不再处理
+func (tm *TaskManager) finishSnooze(snoozeID uint) {
+	if err := tm.db.Model(&ReminderSnooze{}).Where("id = ?", snoozeID).Update("status", SnoozeFired).Error; err != nil {
+		log.Printf("ERROR: Failed to finish snooze %d: %v\n", snoozeID, err)
+	}
+}
@@ -0,0 +1,133 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+// deliverAt 在 now 发送到期的提醒，返回新发出的消息数
+func deliverAt(t *testing.T, tm *TaskManager, sender *recordingSender, now time.Time) int {
+	t.Helper()
+	before := sender.count()
+	if err := tm.DeliverDueReminders(now, sender.send); err != nil {
+		t.Fatal(err)
+	}
+	return sender.count() - before
+}
+
+// mustUnreplied 会话最近一条尚未回复的提醒
+func mustUnreplied(t *testing.T, tm *TaskManager, workspaceID uint, assigneeName string, now time.Time) *ReminderBatch {
+	t.Helper()
+	batch, err := tm.LastUnrepliedReminder(workspaceID, assigneeName, now)
+	if err != nil || batch == nil {
+		t.Fatalf("LastUnrepliedReminder = %v, %v, want a reminder", batch, err)
+	}
+	return batch
+}
+
+func TestSnoozeReminder(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	start := schedulerStart()
+	task := mustDueTask(t, tm, ws.ID, "发布", start)
+	sender := newRecordingSender()
+
+	if n := deliverAt(t, tm, sender, start); n != 1 {
+		t.Fatalf("sent %d reminders at the due time, want 1", n)
+	}
+	batch := mustUnreplied(t, tm, ws.ID, "", start)
+	if len(batch.Tasks) != 1 || batch.Tasks[0].ID != task.ID || batch.Tasks[0].Key == "" {
+		t.Fatalf("reminder tasks = %v, want task %d with its key", batch.Tasks, task.ID)
+	}
+	if err := tm.SnoozeReminder(batch, start.Add(-time.Minute), start); err == nil {
+		t.Error("snoozing into the past succeeded")
+	}
+	if err := tm.SnoozeReminder(batch, start.Add(30*time.Minute), start); err != nil {
+		t.Fatal(err)
+	}
+	if batch, _ := tm.LastUnrepliedReminder(ws.ID, "", start); batch != nil {
+		t.Error("snoozed reminder still waits for a reply")
+	}
+
+	steps := []struct {
+		after time.Duration
+		want  int
+		reply time.Duration // 大于 0 时把这次的提醒再推迟到 start+reply
+	}{
+		{29 * time.Minute, 0, 0},
+		{30 * time.Minute, 1, 90 * time.Minute},
+		{time.Hour, 0, 0},
+		{90 * time.Minute, 1, 0},
+		{2 * time.Hour, 0, 0},
+	}
+	for _, step := range steps {
+		now := start.Add(step.after)
+		if n := deliverAt(t, tm, sender, now); n != step.want {
+			t.Fatalf("at +%s: sent %d reminders, want %d", step.after, n, step.want)
+		}
+		if step.reply > 0 {
+			// 稍后提醒发出的消息也可以再次推迟，新的时间替换之前的稍后提醒
+			if err := tm.SnoozeReminder(mustUnreplied(t, tm, ws.ID, "", now), start.Add(step.reply), now); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestAcknowledgeReminder(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@alice", "alice", false)
+	start := schedulerStart()
+	task := mustDueTask(t, tm, ws.ID, "发布", start)
+	if err := tm.UpdateTaskAssignees(task.ID, []Actor{{ID: "@bob", Name: "bob"}}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	sender := newRecordingSender()
+
+	if n := deliverAt(t, tm, sender, start); n != 2 {
+		t.Fatalf("sent %d reminders at the due time, want one to the workspace and one to bob", n)
+	}
+	// 工作区推迟了提醒，负责人随后确认：确认后所有接收方都不再提醒
+	workspaceBatch := mustUnreplied(t, tm, ws.ID, "", start)
+	if err := tm.SnoozeReminder(workspaceBatch, start.Add(time.Hour), start); err != nil {
+		t.Fatal(err)
+	}
+	bobBatch := mustUnreplied(t, tm, ws.ID, "bob", start)
+	if bobBatch.Recipient != "assignee:bob" {
+		t.Fatalf("reminder for bob sent to %s, want assignee:bob", bobBatch.Recipient)
+	}
+	if err := tm.AcknowledgeReminder(bobBatch, start); err != nil {
+		t.Fatal(err)
+	}
+	for _, after := range []time.Duration{time.Hour, 2 * time.Hour} {
+		if n := deliverAt(t, tm, sender, start.Add(after)); n != 0 {
+			t.Errorf("at +%s: sent %d reminders after the acknowledgement, want 0", after, n)
+		}
+	}
+
+	// 改了截止时间后确认不再有效
+	due := start.Add(3 * time.Hour)
+	if err := tm.UpdateTask(task.ID, TaskUpdate{DueTime: &due}); err != nil {
+		t.Fatal(err)
+	}
+	if n := deliverAt(t, tm, sender, due); n != 2 {
+		t.Errorf("sent %d reminders for the new due time, want 2", n)
+	}
+}
+
+func TestSnoozeIDFromThreshold(t *testing.T) {
+	tests := []struct {
+		threshold string
+		id        uint
+		ok        bool
+	}{
+		{snoozeThreshold(42), 42, true},
+		{"snooze_x", 0, false},
+		{ReminderOverdue, 0, false},
+	}
+	for _, tt := range tests {
+		if id, ok := snoozeIDFromThreshold(tt.threshold); id != tt.id || ok != tt.ok {
+			t.Errorf("snoozeIDFromThreshold(%s) = %d, %v, want %d, %v", tt.threshold, id, ok, tt.id, tt.ok)
+		}
+	}
+}
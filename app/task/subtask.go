@@ -0,0 +1,290 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetTaskParent 设置任务的父任务，parentID 为 0 表示取消父子关系
+func (tm *TaskManager) SetTaskParent(taskID, parentID uint) error {
+	var task Task
+	if err := tm.db.First(&task, "id = ?", taskID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("task %d not found", taskID)
+		}
+		return fmt.Errorf("failed to get task: %v", err)
+	}
+
+	if parentID != 0 {
+		if parentID == taskID {
+			return fmt.Errorf("task %d cannot be its own parent", taskID)
+		}
+
+		// 沿父任务链向上检查，防止形成环
+		current := parentID
+		for current != 0 {
+			var ancestor Task
+			if err := tm.db.Select("id", "parent_id").First(&ancestor, "id = ?", current).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return fmt.Errorf("parent task %d not found", current)
+				}
+				return fmt.Errorf("failed to check parent task %d: %v", current, err)
+			}
+			if ancestor.ParentID == taskID {
+				return fmt.Errorf("circular subtask relation detected")
+			}
+			current = ancestor.ParentID
+		}
+	}
+
+	if err := updateVersioned(tm.db, &task, map[string]interface{}{"parent_id": parentID}); err != nil {
+		return err
+	}
+
+	log.Printf("Set parent of task %d to %d\n", taskID, parentID)
+	if task.ParentID != parentID {
+		tm.logActivity(&task, ActivityParent, "", tm.parentKey(task.ParentID), tm.parentKey(parentID))
+	}
+	return nil
+}
+
+// parentKey 父任务的显示编号，0 表示没有父任务
+func (tm *TaskManager) parentKey(parentID uint) string {
+	if parentID == 0 {
+		return ""
+	}
+	return tm.TaskKey(parentID)
+}
+
+// SetAutoComplete 设置父任务在所有子任务完成后是否自动完成
+func (tm *TaskManager) SetAutoComplete(taskID uint, enabled bool) error {
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+	if t.AutoComplete == enabled {
+		return nil
+	}
+	if err := updateVersioned(tm.db, t, map[string]interface{}{"auto_complete": enabled}); err != nil {
+		return err
+	}
+	tm.logActivity(t, ActivityField, "auto_complete", onOff(!enabled), onOff(enabled))
+	return nil
+}
+
+// onOff 开关值的中文描述
+func onOff(enabled bool) string {
+	if enabled {
+		return "开启"
+	}
+	return "关闭"
+}
+
+// GetSubtasks 获取直接子任务
+func (tm *TaskManager) GetSubtasks(parentID uint) []*Task {
+	var tasks []*Task
+	if err := tm.db.Preload("Dependencies").
+		Where("parent_id = ?", parentID).
+		Order("id ASC").
+		Find(&tasks).Error; err != nil {
+		log.Printf("ERROR: Failed to get subtasks of %d: %v\n", parentID, err)
+		return []*Task{}
+	}
+	tm.fillKeys(tasks)
+	return tasks
+}
+
+// SubtaskProgress 统计直接子任务进度（已取消的子任务不计入总数）
+func (tm *TaskManager) SubtaskProgress(parentID uint) (done int, total int) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	if err := tm.db.Model(&Task{}).
+		Select("status, COUNT(*) AS count").
+		Where("parent_id = ?", parentID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		log.Printf("ERROR: Failed to count subtasks of %d: %v\n", parentID, err)
+		return 0, 0
+	}
+
+	for _, row := range rows {
+		if row.Status == StatusCancelled {
+			continue
+		}
+		total += row.Count
+		if row.Status == StatusCompleted {
+			done += row.Count
+		}
+	}
+	return done, total
+}
+
+// completeParentIfDone 子任务完成后，如果父任务开启了自动完成且所有子任务都已结束，则完成父任务
+func (tm *TaskManager) completeParentIfDone(parentID uint) {
+	parent, exists := tm.GetTask(parentID)
+	if !exists || !parent.AutoComplete {
+		return
+	}
+	if parent.Status == StatusCompleted || parent.Status == StatusCancelled {
+		return
+	}
+
+	done, total := tm.SubtaskProgress(parentID)
+	if total == 0 || done < total {
+		return
+	}
+
+	log.Printf("All %d subtasks of task %d are done, auto-completing parent\n", total, parentID)
+	if err := tm.UpdateTaskStatus(parentID, StatusCompleted); err != nil {
+		log.Printf("ERROR: Failed to auto-complete parent task %d: %v\n", parentID, err)
+	}
+}
+
+// GetChecklist 获取任务的检查项
+func (tm *TaskManager) GetChecklist(taskID uint) []ChecklistItem {
+	var items []ChecklistItem
+	if err := tm.db.Where("task_id = ?", taskID).Order("position ASC").Find(&items).Error; err != nil {
+		log.Printf("ERROR: Failed to get checklist of task %d: %v\n", taskID, err)
+		return []ChecklistItem{}
+	}
+	return items
+}
+
+// AddChecklistItems 向任务添加检查项
+func (tm *TaskManager) AddChecklistItems(taskID uint, contents []string) ([]ChecklistItem, error) {
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return nil, fmt.Errorf("task %d not found", taskID)
+	}
+
+	items := make([]ChecklistItem, 0, len(contents))
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		var maxPosition int
+		if err := tx.Model(&ChecklistItem{}).
+			Select("COALESCE(MAX(position), 0)").
+			Where("task_id = ?", taskID).
+			Scan(&maxPosition).Error; err != nil {
+			return fmt.Errorf("failed to get checklist position: %v", err)
+		}
+
+		now := time.Now()
+		for _, content := range contents {
+			content = strings.TrimSpace(content)
+			if content == "" {
+				continue
+			}
+			maxPosition++
+			items = append(items, ChecklistItem{
+				TaskID:     taskID,
+				Position:   maxPosition,
+				Content:    content,
+				CreateTime: now,
+			})
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("checklist item content is required")
+		}
+
+		if err := tx.Create(&items).Error; err != nil {
+			return fmt.Errorf("failed to create checklist items: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Added %d checklist item(s) to task %d\n", len(items), taskID)
+	for _, item := range items {
+		tm.logActivity(t, ActivityChecklist, "", "", fmt.Sprintf("添加了检查项 %d.「%s」", item.Position, item.Content))
+	}
+	return items, nil
+}
+
+// SetChecklistItemChecked 勾选或取消勾选任务的第 position 个检查项
+func (tm *TaskManager) SetChecklistItemChecked(taskID uint, position int, checked bool) (*ChecklistItem, error) {
+	var item ChecklistItem
+	if err := tm.db.Where("task_id = ? AND position = ?", taskID, position).First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("checklist item %d of task %d not found", position, taskID)
+		}
+		return nil, fmt.Errorf("failed to get checklist item: %v", err)
+	}
+
+	updates := map[string]interface{}{
+		"checked":      checked,
+		"checked_time": nil,
+	}
+	if checked {
+		now := time.Now()
+		updates["checked_time"] = &now
+	}
+	if err := tm.db.Model(&item).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update checklist item: %v", err)
+	}
+
+	item.Checked = checked
+	log.Printf("Set checklist item %d of task %d checked=%v\n", position, taskID, checked)
+	if t, exists := tm.GetTask(taskID); exists {
+		action := "取消勾选了"
+		if checked {
+			action = "勾选了"
+		}
+		tm.logActivity(t, ActivityChecklist, "", "", fmt.Sprintf("%s检查项 %d.「%s」", action, item.Position, item.Content))
+	}
+	return &item, nil
+}
+
+// RemoveChecklistItem 删除任务的第 position 个检查项
+func (tm *TaskManager) RemoveChecklistItem(taskID uint, position int) error {
+	var item ChecklistItem
+	if err := tm.db.Where("task_id = ? AND position = ?", taskID, position).First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("checklist item %d of task %d not found", position, taskID)
+		}
+		return fmt.Errorf("failed to get checklist item: %v", err)
+	}
+	if err := tm.db.Delete(&item).Error; err != nil {
+		return fmt.Errorf("failed to delete checklist item: %v", err)
+	}
+	if t, exists := tm.GetTask(taskID); exists {
+		tm.logActivity(t, ActivityChecklist, "", "", fmt.Sprintf("删除了检查项 %d.「%s」", item.Position, item.Content))
+	}
+	return nil
+}
+
+// ChecklistProgress 统计检查项完成情况
+func ChecklistProgress(items []ChecklistItem) (done int, total int) {
+	for _, item := range items {
+		total++
+		if item.Checked {
+			done++
+		}
+	}
+	return done, total
+}
+
+// formatSubtaskTree 递归格式化子任务树
+func formatSubtaskTree(tm *TaskManager, parentID uint, depth int, visited map[uint]bool) string {
+	result := ""
+	for _, sub := range tm.GetSubtasks(parentID) {
+		if visited[sub.ID] {
+			continue
+		}
+		visited[sub.ID] = true
+
+		line := fmt.Sprintf("%s└ %s %s (ID: %s)", strings.Repeat("  ", depth), statusEmoji(sub.Status), sub.Title, sub.DisplayKey())
+		if done, total := tm.SubtaskProgress(sub.ID); total > 0 {
+			line += fmt.Sprintf(" [%d/%d]", done, total)
+		}
+		result += line + "\n"
+		result += formatSubtaskTree(tm, sub.ID, depth+1, visited)
+	}
+	return result
+}
@@ -0,0 +1,227 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatusBlocked 派生状态：任务未结束且存在未完成的前置依赖（不存储在数据库中）
+const StatusBlocked = "blocked"
+
+// statusTransitions 任务状态转换表：当前状态 -> 允许转换到的状态
+var statusTransitions = map[string][]string{
+	StatusPending:    {StatusInProgress, StatusCompleted, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusCompleted, StatusCancelled},
+	StatusCompleted:  {StatusPending, StatusInProgress}, // 重新打开
+	StatusCancelled:  {StatusPending},                   // 取消的任务只能先恢复为待处理
+}
+
+var (
+	unblockedHandler func(blocker *Task, unblocked []*Task)
+	handlerMu        sync.RWMutex
+)
+
+// OnTasksUnblocked 注册回调：当某个任务完成或取消后，依赖它的任务不再被阻塞时调用
+func OnTasksUnblocked(handler func(blocker *Task, unblocked []*Task)) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+	unblockedHandler = handler
+}
+
+// CanTransition 检查状态转换是否合法
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpenStatus 任务状态是否未结束
+func IsOpenStatus(status string) bool {
+	return status == StatusPending || status == StatusInProgress
+}
+
+// TransitionTaskStatus 按状态转换表更新任务状态
+// 存在未完成的前置依赖时拒绝完成任务，除非 force 为 true
+func (tm *TaskManager) TransitionTaskStatus(id uint, status string, force bool) error {
+	return tm.TransitionTaskStatusIfUnchanged(id, status, force, 0)
+}
+
+// TransitionTaskStatusIfUnchanged 同 TransitionTaskStatus，但任务版本不是 expectedVersion 时返回 ErrConflict
+// expectedVersion 为 0 表示以读取时的版本为准（仍然防止读取和写入之间被他人修改）
+func (tm *TaskManager) TransitionTaskStatusIfUnchanged(id uint, status string, force bool, expectedVersion uint) error {
+	if _, ok := statusTransitions[status]; !ok {
+		return fmt.Errorf("invalid status: %s", status)
+	}
+
+	// 检查任务是否存在
+	var task Task
+	if err := tm.db.First(&task, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("task %d not found", id)
+		}
+		return fmt.Errorf("failed to get task: %v", err)
+	}
+
+	if expectedVersion != 0 && expectedVersion != task.Version {
+		return conflictError(id)
+	}
+
+	if task.Status == status {
+		return fmt.Errorf("task %d is already %s", id, status)
+	}
+	if !CanTransition(task.Status, status) {
+		return fmt.Errorf("cannot change task %d from %s to %s", id, task.Status, status)
+	}
+
+	if status == StatusCompleted && !force {
+		blockers, err := tm.OpenDependencies(id)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			ids := make([]uint, len(blockers))
+			for i, b := range blockers {
+				ids[i] = b.ID
+			}
+			return fmt.Errorf("task %d is blocked by unfinished dependencies %v, complete them first or use force", id, ids)
+		}
+	}
+
+	// 更新状态
+	updates := map[string]interface{}{
+		"status": status,
+	}
+	if status == StatusCompleted {
+		now := time.Now()
+		updates["completed_time"] = &now
+	} else if task.Status == StatusCompleted {
+		// 重新打开时清除完成时间
+		updates["completed_time"] = nil
+	}
+
+	previous := task.Status
+	if err := updateVersioned(tm.db, &task, updates); err != nil {
+		return err
+	}
+
+	log.Printf("Updated task %d status from %s to %s (force=%v)\n", id, previous, status, force)
+	tm.logActivity(&task, ActivityStatus, "", previous, status)
+	task.Status = status
+
+	if status == StatusCompleted || status == StatusCancelled {
+		// 重复任务完成或跳过（取消本次）后，生成下一次发生
+		if task.IsRecurring() {
+			if tm.pending != nil {
+				tm.pending.spawn = append(tm.pending.spawn, &task)
+			} else if _, err := tm.spawnNextOccurrence(&task); err != nil {
+				log.Printf("ERROR: Failed to spawn next occurrence for task %d: %v\n", id, err)
+			}
+		}
+
+		// 子任务结束后，检查是否需要自动完成父任务
+		if task.ParentID != 0 {
+			tm.completeParentIfDone(task.ParentID)
+		}
+
+		// 已取消的依赖同样不再阻塞（见 OpenDependencies），完成或取消都通知下游任务
+		if tm.pending != nil {
+			tm.pending.unblock = append(tm.pending.unblock, &task)
+		} else {
+			tm.notifyUnblocked(&task)
+		}
+	}
+	return nil
+}
+
+// OpenDependencies 获取任务尚未完成的前置依赖（已取消的依赖不再阻塞）
+func (tm *TaskManager) OpenDependencies(taskID uint) ([]*Task, error) {
+	var blockers []*Task
+	if err := tm.db.
+		Joins("JOIN task_dependencies ON task_dependencies.dependency_id = tasks.id").
+		Where("task_dependencies.task_id = ? AND tasks.status IN ?", taskID, openStatuses).
+		Order("tasks.id ASC").
+		Find(&blockers).Error; err != nil {
+		return nil, fmt.Errorf("failed to load dependencies of task %d: %v", taskID, err)
+	}
+	return blockers, nil
+}
+
+// MarkBlocked 批量计算任务的派生阻塞状态（一次查询）
+func (tm *TaskManager) MarkBlocked(tasks []*Task) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(tasks))
+	for _, t := range tasks {
+		if IsOpenStatus(t.Status) {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var blockedIDs []uint
+	if err := tm.db.Model(&TaskDependency{}).
+		Distinct("task_dependencies.task_id").
+		Joins("JOIN tasks ON tasks.id = task_dependencies.dependency_id").
+		Where("task_dependencies.task_id IN ? AND tasks.status IN ? AND tasks.deleted_at IS NULL", ids, openStatuses).
+		Pluck("task_dependencies.task_id", &blockedIDs).Error; err != nil {
+		log.Printf("ERROR: Failed to compute blocked tasks: %v\n", err)
+		return
+	}
+
+	blocked := make(map[uint]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = true
+	}
+	for _, t := range tasks {
+		t.Blocked = blocked[t.ID]
+	}
+}
+
+// blockedCondition 被阻塞任务的SQL条件
+func blockedCondition(db *gorm.DB) *gorm.DB {
+	return db.Where("tasks.status IN ? AND EXISTS (SELECT 1 FROM task_dependencies JOIN tasks AS dep ON dep.id = task_dependencies.dependency_id WHERE task_dependencies.task_id = tasks.id AND dep.status IN ? AND dep.deleted_at IS NULL)",
+		openStatuses, openStatuses)
+}
+
+// notifyUnblocked 任务完成或取消后，找出因此不再被阻塞的任务并回调通知
+func (tm *TaskManager) notifyUnblocked(blocker *Task) {
+	handlerMu.RLock()
+	handler := unblockedHandler
+	handlerMu.RUnlock()
+	if handler == nil {
+		return
+	}
+
+	var dependents []*Task
+	if err := tm.db.
+		Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+		Where("task_dependencies.dependency_id = ? AND tasks.status IN ?", blocker.ID, openStatuses).
+		Find(&dependents).Error; err != nil {
+		log.Printf("ERROR: Failed to load dependents of task %d: %v\n", blocker.ID, err)
+		return
+	}
+
+	tm.annotate(dependents)
+	unblocked := make([]*Task, 0, len(dependents))
+	for _, t := range dependents {
+		if !t.Blocked {
+			unblocked = append(unblocked, t)
+		}
+	}
+
+	if len(unblocked) > 0 {
+		log.Printf("Task %d %s, %d dependent task(s) unblocked\n", blocker.ID, blocker.Status, len(unblocked))
+		handler(blocker, unblocked)
+	}
+}
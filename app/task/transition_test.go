@@ -0,0 +1,69 @@
+package task
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusPending, StatusInProgress, true},
+		{StatusPending, StatusCompleted, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusInProgress, StatusPending, true},
+		{StatusInProgress, StatusCompleted, true},
+		{StatusInProgress, StatusCancelled, true},
+		{StatusCompleted, StatusPending, true},
+		{StatusCompleted, StatusInProgress, true},
+		{StatusCompleted, StatusCancelled, false},
+		{StatusCancelled, StatusPending, true},
+		{StatusCancelled, StatusInProgress, false},
+		{StatusCancelled, StatusCompleted, false},
+		{StatusPending, StatusPending, false},
+		{StatusPending, StatusBlocked, false},
+		{StatusBlocked, StatusCompleted, false},
+		{"unknown", StatusPending, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestIsOpenStatus(t *testing.T) {
+	for status, want := range map[string]bool{
+		StatusPending:    true,
+		StatusInProgress: true,
+		StatusCompleted:  false,
+		StatusCancelled:  false,
+	} {
+		if got := IsOpenStatus(status); got != want {
+			t.Errorf("IsOpenStatus(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestCancelledBlockerNotifiesDependents(t *testing.T) {
+	tm := newTestManager(t)
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	blocker := mustTask(t, tm, ws.ID, "评审", nil)
+	dependent := mustTask(t, tm, ws.ID, "发布", nil, blocker.ID)
+
+	var notified []uint
+	var status string
+	OnTasksUnblocked(func(b *Task, unblocked []*Task) {
+		status = b.Status
+		for _, u := range unblocked {
+			notified = append(notified, u.ID)
+		}
+	})
+	t.Cleanup(func() { OnTasksUnblocked(nil) })
+
+	if err := tm.TransitionTaskStatus(blocker.ID, StatusCancelled, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 1 || notified[0] != dependent.ID || status != StatusCancelled {
+		t.Errorf("unblocked %v by a %s blocker, want [%d] by a cancelled blocker", notified, status, dependent.ID)
+	}
+}
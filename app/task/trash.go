@@ -0,0 +1,142 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTrashRetention 回收站中任务的默认保留时间
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// ListTrash 列出工作区回收站中的任务（按删除时间倒序），workspaceID 为 0 表示所有工作区
+func (tm *TaskManager) ListTrash(workspaceID uint) []*Task {
+	var tasks []*Task
+	if err := workspaceScope(tm.db.Unscoped(), workspaceID).
+		Where("tasks.deleted_at IS NOT NULL").
+		Order("tasks.deleted_at DESC").
+		Find(&tasks).Error; err != nil {
+		log.Printf("ERROR: Failed to list trash: %v\n", err)
+		return []*Task{}
+	}
+	tm.fillKeys(tasks)
+	return tasks
+}
+
+// ResolveTrashedTaskKey 将任务编号解析为工作区回收站中的任务ID
+func (tm *TaskManager) ResolveTrashedTaskKey(workspaceID uint, key string) (uint, error) {
+	id, err := tm.resolveTaskKey(tm.db.Unscoped(), workspaceID, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := workspaceScope(tm.db.Unscoped().Model(&Task{}), workspaceID).
+		Where("tasks.id = ? AND tasks.deleted_at IS NOT NULL", id).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to check trash: %v", err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("task %s is not in trash", key)
+	}
+	return id, nil
+}
+
+// RestoreTask 从回收站恢复任务
+func (tm *TaskManager) RestoreTask(id uint) (*Task, error) {
+	var task Task
+	if err := tm.db.Unscoped().First(&task, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("task %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %v", err)
+	}
+	if !task.DeletedAt.Valid {
+		return nil, fmt.Errorf("task %d is not in trash", id)
+	}
+
+	if err := tm.db.Unscoped().Model(&task).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore task: %v", err)
+	}
+
+	log.Printf("Restored task %d from trash\n", id)
+	tm.logActivity(&task, ActivityRestored, "", "", "")
+
+	restored, exists := tm.GetTask(id)
+	if !exists {
+		return nil, fmt.Errorf("task %d not found", id)
+	}
+	return restored, nil
+}
+
+// PurgeTrash 彻底删除在回收站中超过保留时间的任务，返回删除的数量
+func (tm *TaskManager) PurgeTrash(retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+
+	var ids []uint
+	if err := tm.db.Unscoped().Model(&Task{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to load expired trash: %v", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		// 自身的依赖、检查项、标签和负责人通过外键级联删除，指向它的依赖、共享、提醒、动态和撤销记录需要手动清理
+		if err := tx.Where("dependency_id IN ?", ids).Delete(&TaskDependency{}).Error; err != nil {
+			return fmt.Errorf("failed to delete dependencies: %v", err)
+		}
+		if err := tx.Where("task_id IN ?", ids).Delete(&TaskShare{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task shares: %v", err)
+		}
+		for _, model := range []interface{}{&ReminderDelivery{}, &ReminderSnooze{}, &ReminderAck{}} {
+			if err := tx.Where("task_id IN ?", ids).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to delete reminder records: %v", err)
+			}
+		}
+		// 子任务（包括回收站中的）变为顶层任务
+		if err := tx.Unscoped().Model(&Task{}).Where("parent_id IN ?", ids).
+			Updates(map[string]interface{}{"parent_id": 0, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return fmt.Errorf("failed to detach subtasks: %v", err)
+		}
+		if err := tx.Where("task_id IN ?", ids).Delete(&TaskActivity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task activity: %v", err)
+		}
+		// 涉及这些任务的撤销记录已经无法恢复，一并删除，避免撤销时去恢复不存在的任务
+		if err := deleteUndoEntriesFor(tx, ids); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&Task{}).Error; err != nil {
+			return fmt.Errorf("failed to purge tasks: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Purged %d task(s) from trash (deleted before %s)\n", len(ids), cutoff.Format("2006-01-02 15:04:05"))
+	return len(ids), nil
+}
+
+// StartTrashPurgeService 启动回收站定时清理服务（每天检查一次）
+func StartTrashPurgeService(retention time.Duration) {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			if _, err := GetTaskManager().PurgeTrash(retention); err != nil {
+				log.Printf("ERROR: Failed to purge trash: %v\n", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurgeTrashCleansUpReferences(t *testing.T) {
+	tm := newTestManager(t).WithActor(Actor{ID: "wxid_alice", Name: "Alice"})
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	parent := mustTask(t, tm, ws.ID, "发布", nil)
+	child := mustTask(t, tm, ws.ID, "写发布说明", nil)
+	if err := tm.SetTaskParent(child.ID, parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.DeleteTask(child.ID); err != nil {
+		t.Fatal(err)
+	}
+	undo := tm.BeginUndo(ws.ID, UndoDelete, parent.ID)
+	if err := tm.DeleteTask(parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	undo.Commit()
+
+	// 只有父任务超过了保留时间
+	if err := tm.db.Unscoped().Model(&Task{}).Where("id = ?", parent.ID).
+		Update("deleted_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := tm.PurgeTrash(time.Hour); err != nil || n != 1 {
+		t.Fatalf("PurgeTrash = %d, %v, want 1", n, err)
+	}
+
+	var remaining Task
+	if err := tm.db.Unscoped().First(&remaining, "id = ?", child.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining.ParentID != 0 {
+		t.Errorf("subtask still points to purged parent %d", remaining.ParentID)
+	}
+	var activities int64
+	tm.db.Model(&TaskActivity{}).Where("task_id = ?", parent.ID).Count(&activities)
+	if activities != 0 {
+		t.Errorf("%d activity record(s) of the purged task remain", activities)
+	}
+	var entries int64
+	tm.db.Model(&UndoEntry{}).Count(&entries)
+	if entries != 0 {
+		t.Errorf("%d undo entry(ies) remain for the purged task", entries)
+	}
+	if _, err := tm.UndoLast(ws.ID, time.Hour, false); err == nil {
+		t.Error("UndoLast restored a purged task")
+	}
+}
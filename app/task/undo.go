@@ -0,0 +1,407 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 可撤销的操作类型
+const (
+	UndoCreate       = "create"
+	UndoUpdate       = "update"
+	UndoStatus       = "status"
+	UndoDependencies = "dependencies"
+	UndoDelete       = "delete"
+	UndoBulk         = "bulk"
+)
+
+// undoOpNames 操作类型的中文名称
+var undoOpNames = map[string]string{
+	UndoCreate:       "创建任务",
+	UndoUpdate:       "修改任务",
+	UndoStatus:       "更新任务状态",
+	UndoDependencies: "修改任务依赖",
+	UndoDelete:       "删除任务",
+	UndoBulk:         "批量操作",
+}
+
+// TaskSnapshot 任务可修改字段的快照
+type TaskSnapshot struct {
+	ID              uint       `json:"id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	DueTime         *time.Time `json:"due_time"`
+	Status          string     `json:"status"`
+	CompletedTime   *time.Time `json:"completed_time"`
+	RecurrenceRule  string     `json:"recurrence_rule"`
+	SeriesID        uint       `json:"series_id"`
+	EstimateMinutes int        `json:"estimate_minutes"`
+	Priority        int        `json:"priority"`
+	ReminderOffsets string     `json:"reminder_offsets"`
+	ParentID        uint       `json:"parent_id"`
+	AutoComplete    bool       `json:"auto_complete"`
+	Dependencies    []uint     `json:"dependencies"`
+}
+
+// undoPayload 撤销记录的内容
+type undoPayload struct {
+	Snapshots []TaskSnapshot `json:"snapshots"` // 操作前的任务状态
+	Created   []uint         `json:"created"`   // 操作中新建的任务
+}
+
+// taskIDs 撤销记录涉及的所有任务
+func (p *undoPayload) taskIDs() []uint {
+	ids := append([]uint(nil), p.Created...)
+	for _, s := range p.Snapshots {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// UndoRecorder 记录一次可撤销的操作：修改前调用 BeginUndo 保存快照，成功后调用 Commit
+type UndoRecorder struct {
+	tm            *TaskManager
+	workspaceID   uint
+	op            string
+	startActivity uint // 开始时最新的动态ID，用于判断操作是否真的修改了任务
+	payload       undoPayload
+}
+
+// BeginUndo 在修改前保存相关任务的快照
+func (tm *TaskManager) BeginUndo(workspaceID uint, op string, taskIDs ...uint) *UndoRecorder {
+	r := &UndoRecorder{tm: tm, workspaceID: workspaceID, op: op, startActivity: tm.lastActivityID()}
+	for _, id := range taskIDs {
+		if snapshot, err := tm.snapshotTask(id); err == nil {
+			r.payload.Snapshots = append(r.payload.Snapshots, *snapshot)
+		} else {
+			log.Printf("WARNING: Failed to snapshot task %d for undo: %v\n", id, err)
+		}
+	}
+	return r
+}
+
+// Created 记录操作中新建的任务（撤销时移入回收站）
+func (r *UndoRecorder) Created(ids ...uint) *UndoRecorder {
+	r.payload.Created = append(r.payload.Created, ids...)
+	return r
+}
+
+// Commit 保存撤销记录（记录失败只打印日志，不影响操作本身）
+func (r *UndoRecorder) Commit() {
+	if r.tm.actor.ID == "" {
+		return
+	}
+	if len(r.payload.Created) == 0 {
+		// 没有新建任务，也没有任何任务被修改，无需记录
+		ids := make([]uint, len(r.payload.Snapshots))
+		for i, s := range r.payload.Snapshots {
+			ids[i] = s.ID
+		}
+		if changed, err := r.tm.laterActivity(ids, r.startActivity, nil); err != nil || changed == nil {
+			return
+		}
+	}
+
+	data, err := json.Marshal(r.payload)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode undo entry: %v\n", err)
+		return
+	}
+
+	entry := UndoEntry{
+		ActorID:         r.tm.actor.ID,
+		WorkspaceID:     r.workspaceID,
+		Op:              r.op,
+		Payload:         string(data),
+		StartActivityID: r.startActivity,
+		LastActivityID:  r.tm.lastActivityID(),
+		CreateTime:      time.Now(),
+	}
+	if err := r.tm.db.Create(&entry).Error; err != nil {
+		log.Printf("ERROR: Failed to record undo entry: %v\n", err)
+	}
+}
+
+// UndoLast 撤销当前用户在工作区中 window 时间内最近一次尚未撤销的操作
+// 相关任务在操作之后又被修改过时拒绝撤销，除非 force 为 true
+// 返回被撤销操作的描述
+func (tm *TaskManager) UndoLast(workspaceID uint, window time.Duration, force bool) (string, error) {
+	if tm.actor.ID == "" {
+		return "", fmt.Errorf("unknown caller")
+	}
+
+	var entry UndoEntry
+	if err := tm.db.Where("actor_id = ? AND workspace_id = ? AND undone = ? AND create_time >= ?",
+		tm.actor.ID, workspaceID, false, time.Now().Add(-window)).
+		Order("id DESC").
+		First(&entry).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", fmt.Errorf("no operation to undo in the last %d minutes", int(window/time.Minute))
+		}
+		return "", fmt.Errorf("failed to load undo entry: %v", err)
+	}
+
+	var payload undoPayload
+	if err := json.Unmarshal([]byte(entry.Payload), &payload); err != nil {
+		return "", fmt.Errorf("failed to decode undo entry: %v", err)
+	}
+
+	if !force {
+		if err := tm.checkUndoConflict(&entry, payload.taskIDs()); err != nil {
+			return "", err
+		}
+	}
+
+	// 恢复快照、删除新建的任务和标记撤销在一个事务中完成，任何一步失败都不会留下撤销了一半的状态
+	description := undoOpNames[entry.Op]
+	pending := &pendingEffects{}
+	err := tm.db.Transaction(func(tx *gorm.DB) error {
+		scoped := *tm
+		scoped.db = tx
+		scoped.pending = pending
+		return scoped.applyUndo(&entry, &payload, description)
+	})
+	if err != nil {
+		return "", err
+	}
+	tm.runPendingEffects(pending)
+
+	log.Printf("Undid %s operation %d for %s\n", entry.Op, entry.ID, tm.actor.ID)
+	return description, nil
+}
+
+// applyUndo 恢复操作前的快照，将操作中新建的任务移入回收站，并标记撤销记录
+func (tm *TaskManager) applyUndo(entry *UndoEntry, payload *undoPayload, description string) error {
+	undoStart := tm.lastActivityID()
+	switch entry.Op {
+	case UndoDelete:
+		for _, s := range payload.Snapshots {
+			if _, err := tm.RestoreTask(s.ID); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, s := range payload.Snapshots {
+			if err := tm.restoreSnapshot(s); err != nil {
+				return err
+			}
+		}
+	}
+	// 新建的任务移入回收站（后建的先删，避免子任务和依赖阻止删除）
+	for i := len(payload.Created) - 1; i >= 0; i-- {
+		if err := tm.DeleteTask(payload.Created[i]); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range payload.Snapshots {
+		if entry.Op == UndoDelete {
+			continue
+		}
+		if t, exists := tm.GetTask(s.ID); exists {
+			tm.logActivity(t, ActivityUndo, "", "", description)
+		}
+	}
+
+	if err := tm.db.Model(entry).Updates(map[string]interface{}{
+		"undone":                 true,
+		"undo_start_activity_id": undoStart,
+		"undo_end_activity_id":   tm.lastActivityID(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to mark undo entry: %v", err)
+	}
+	return nil
+}
+
+// snapshotTask 保存任务当前的可修改字段
+func (tm *TaskManager) snapshotTask(id uint) (*TaskSnapshot, error) {
+	var t Task
+	if err := tm.db.First(&t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	var deps []uint
+	if err := tm.db.Model(&TaskDependency{}).Where("task_id = ?", id).Order("dependency_id ASC").Pluck("dependency_id", &deps).Error; err != nil {
+		return nil, err
+	}
+	return &TaskSnapshot{
+		ID:              t.ID,
+		Title:           t.Title,
+		Content:         t.Content,
+		DueTime:         t.DueTime,
+		Status:          t.Status,
+		CompletedTime:   t.CompletedTime,
+		RecurrenceRule:  t.RecurrenceRule,
+		SeriesID:        t.SeriesID,
+		EstimateMinutes: t.EstimateMinutes,
+		Priority:        t.Priority,
+		ReminderOffsets: t.ReminderOffsets,
+		ParentID:        t.ParentID,
+		AutoComplete:    t.AutoComplete,
+		Dependencies:    deps,
+	}, nil
+}
+
+// restoreSnapshot 将任务恢复为快照中的状态（不经过状态转换表，但同样经过乐观锁：恢复期间任务被他人修改时返回 ErrConflict）
+func (tm *TaskManager) restoreSnapshot(s TaskSnapshot) error {
+	if len(s.Dependencies) > 0 {
+		if err := tm.checkDependencies(s.Dependencies, s.ID); err != nil {
+			return fmt.Errorf("cannot restore dependencies of task %d: %v", s.ID, err)
+		}
+	}
+
+	return tm.db.Transaction(func(tx *gorm.DB) error {
+		var t Task
+		if err := tx.First(&t, "id = ?", s.ID).Error; err != nil {
+			return fmt.Errorf("failed to load task %d: %v", s.ID, err)
+		}
+		updates := map[string]interface{}{
+			"title":            s.Title,
+			"content":          s.Content,
+			"due_time":         s.DueTime,
+			"status":           s.Status,
+			"completed_time":   s.CompletedTime,
+			"recurrence_rule":  s.RecurrenceRule,
+			"series_id":        s.SeriesID,
+			"estimate_minutes": s.EstimateMinutes,
+			"priority":         s.Priority,
+			"reminder_offsets": s.ReminderOffsets,
+			"parent_id":        s.ParentID,
+			"auto_complete":    s.AutoComplete,
+		}
+		if err := updateVersioned(tx, &t, updates); err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id = ?", s.ID).Delete(&TaskDependency{}).Error; err != nil {
+			return fmt.Errorf("failed to restore dependencies of task %d: %v", s.ID, err)
+		}
+		if len(s.Dependencies) > 0 {
+			deps := make([]TaskDependency, len(s.Dependencies))
+			for i, depID := range s.Dependencies {
+				deps[i] = TaskDependency{TaskID: s.ID, DependencyID: depID}
+			}
+			if err := tx.Create(&deps).Error; err != nil {
+				return fmt.Errorf("failed to restore dependencies of task %d: %v", s.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// activityRange 动态ID区间 (From, To]
+type activityRange struct {
+	From, To uint
+}
+
+// checkUndoConflict 检查任务在操作之后是否又有新的动态
+// 同一用户之后已经撤销的操作（操作本身及其撤销）不算冲突，以便连续撤销多步
+func (tm *TaskManager) checkUndoConflict(entry *UndoEntry, taskIDs []uint) error {
+	var undone []UndoEntry
+	if err := tm.db.Where("actor_id = ? AND workspace_id = ? AND undone = ? AND id > ?",
+		entry.ActorID, entry.WorkspaceID, true, entry.ID).Find(&undone).Error; err != nil {
+		return fmt.Errorf("failed to load undo entries: %v", err)
+	}
+	excluded := make([]activityRange, 0, len(undone)*2)
+	for _, u := range undone {
+		excluded = append(excluded,
+			activityRange{From: u.StartActivityID, To: u.LastActivityID},
+			activityRange{From: u.UndoStartActivityID, To: u.UndoEndActivityID})
+	}
+
+	later, err := tm.laterActivity(taskIDs, entry.LastActivityID, excluded)
+	if err != nil {
+		return err
+	}
+	if later == nil {
+		return nil
+	}
+
+	actor := later.ActorName
+	if actor == "" {
+		actor = "系统"
+	}
+	return fmt.Errorf("task %s was modified by %s at %s after this operation, undo with force to overwrite",
+		tm.TaskKey(later.TaskID), actor, later.CreateTime.Format("15:04"))
+}
+
+// laterActivity 获取任务在 afterActivityID 之后（排除 excluded 区间）的第一条动态，没有则返回 nil
+func (tm *TaskManager) laterActivity(taskIDs []uint, afterActivityID uint, excluded []activityRange) (*TaskActivity, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+	query := tm.db.Where("task_id IN ? AND id > ?", taskIDs, afterActivityID)
+	for _, r := range excluded {
+		query = query.Where("NOT (id > ? AND id <= ?)", r.From, r.To)
+	}
+	var later TaskActivity
+	err := query.Order("id ASC").First(&later).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check task changes: %v", err)
+	}
+	return &later, nil
+}
+
+// lastActivityID 当前最新的动态ID
+func (tm *TaskManager) lastActivityID() uint {
+	var id uint
+	if err := tm.db.Model(&TaskActivity{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error; err != nil {
+		log.Printf("ERROR: Failed to get last activity id: %v\n", err)
+	}
+	return id
+}
+
+// SeriesOpenTaskIDs 获取重复系列中从指定任务开始尚未结束的发生（系列修改会影响这些任务）
+func (tm *TaskManager) SeriesOpenTaskIDs(id uint) []uint {
+	t, exists := tm.GetTask(id)
+	if !exists || t.SeriesID == 0 {
+		return []uint{id}
+	}
+	var ids []uint
+	if err := tm.db.Model(&Task{}).
+		Where("series_id = ? AND id >= ? AND status IN ?", t.SeriesID, t.ID, openStatuses).
+		Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		return []uint{id}
+	}
+	return ids
+}
+
+// deleteUndoEntriesFor 删除涉及这些任务的未撤销记录
+func deleteUndoEntriesFor(tx *gorm.DB, taskIDs []uint) error {
+	purged := make(map[uint]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		purged[id] = true
+	}
+
+	var entries []UndoEntry
+	if err := tx.Where("undone = ?", false).Find(&entries).Error; err != nil {
+		return fmt.Errorf("failed to load undo entries: %v", err)
+	}
+	var stale []uint
+	for _, entry := range entries {
+		var payload undoPayload
+		if err := json.Unmarshal([]byte(entry.Payload), &payload); err != nil {
+			log.Printf("WARNING: Failed to decode undo entry %d: %v\n", entry.ID, err)
+			continue
+		}
+		for _, id := range payload.taskIDs() {
+			if purged[id] {
+				stale = append(stale, entry.ID)
+				break
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if err := tx.Where("id IN ?", stale).Delete(&UndoEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to delete undo entries: %v", err)
+	}
+	return nil
+}
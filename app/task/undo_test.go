@@ -0,0 +1,68 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUndoRestoresSnapshotWithVersionBump(t *testing.T) {
+	tm := newTestManager(t).WithActor(Actor{ID: "wxid_alice", Name: "Alice"})
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	task := mustTask(t, tm, ws.ID, "写周报", nil)
+
+	undo := tm.BeginUndo(ws.ID, UndoUpdate, task.ID)
+	title := "写月报"
+	if err := tm.UpdateTask(task.ID, TaskUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	undo.Commit()
+	updated, _ := tm.GetTask(task.ID)
+
+	if _, err := tm.UndoLast(ws.ID, time.Hour, false); err != nil {
+		t.Fatalf("UndoLast: %v", err)
+	}
+	restored, _ := tm.GetTask(task.ID)
+	if restored.Title != "写周报" {
+		t.Errorf("title after undo = %s, want 写周报", restored.Title)
+	}
+	if restored.Version != updated.Version+1 {
+		t.Errorf("version after undo = %d, want %d", restored.Version, updated.Version+1)
+	}
+}
+
+func TestUndoIsAtomic(t *testing.T) {
+	tm := newTestManager(t).WithActor(Actor{ID: "wxid_alice", Name: "Alice"})
+	ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	c := mustTask(t, tm, ws.ID, "丙", nil)
+	a := mustTask(t, tm, ws.ID, "甲", nil)
+	b := mustTask(t, tm, ws.ID, "乙", nil, c.ID)
+
+	undo := tm.BeginUndo(ws.ID, UndoBulk, a.ID, b.ID)
+	title := "甲（已改）"
+	if err := tm.UpdateTask(a.ID, TaskUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.UpdateTaskDependencies(b.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	undo.Commit()
+
+	// 丙改为依赖乙，恢复乙对丙的依赖会形成循环，第二个快照恢复失败
+	if err := tm.UpdateTaskDependencies(c.ID, []uint{b.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.UndoLast(ws.ID, time.Hour, false); err == nil {
+		t.Fatal("UndoLast succeeded, want a dependency cycle error")
+	}
+
+	if got, _ := tm.GetTask(a.ID); got.Title != title {
+		t.Errorf("title of the first task = %s, want %s (nothing restored when the undo fails)", got.Title, title)
+	}
+	var entry UndoEntry
+	if err := tm.db.Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entry.Undone {
+		t.Error("undo entry marked undone after a failed undo")
+	}
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrConflict 任务在读取之后被他人修改（乐观锁冲突）
+var ErrConflict = errors.New("任务已被他人修改")
+
+// conflictError 包装任务的乐观锁冲突错误，可用 errors.Is(err, ErrConflict) 判断
+func conflictError(id uint) error {
+	return fmt.Errorf("task %d: %w", id, ErrConflict)
+}
+
+// updateVersioned 以乐观锁更新任务：只有版本号仍为 t.Version 时才写入，并将版本号加一
+// 成功后 t 中的版本号同步更新，其余字段保持修改前的值（用于记录动态）
+func updateVersioned(db *gorm.DB, t *Task, updates map[string]interface{}) error {
+	fields := make(map[string]interface{}, len(updates)+1)
+	for k, v := range updates {
+		fields[k] = v
+	}
+	fields["version"] = gorm.Expr("version + 1")
+
+	result := db.Model(&Task{}).Where("id = ? AND version = ?", t.ID, t.Version).Updates(fields)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update task %d: %v", t.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return conflictError(t.ID)
+	}
+	t.Version++
+	return nil
+}
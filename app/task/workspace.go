@@ -0,0 +1,314 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/notify"
+)
+
+// verifyRebind 判断名称相同的旧工作区能否绑定到新的会话（可在测试中替换）
+var verifyRebind = notify.CanRebind
+
+// legacyWorkspaceName 配置中认领全部旧任务的群名或昵称（可在测试中替换）
+var legacyWorkspaceName = func() string {
+	return strings.TrimSpace(config.LoadConfig().Task.LegacyWorkspace)
+}
+
+// ResolveWorkspace 获取会话对应的工作区，不存在则创建
+// 微信重新登录后 UserName 会变化，因此找不到时按名称和类型匹配；只有唯一的同名工作区、
+// 它原来的会话已经失效且当前没有重名的群或好友时才重新绑定，否则创建新的工作区，避免同名的会话接管别人的任务
+func (tm *TaskManager) ResolveWorkspace(chatID, name string, isGroup bool) (*Workspace, error) {
+	if chatID == "" {
+		return nil, fmt.Errorf("chat ID is required")
+	}
+	kind := WorkspacePrivate
+	if isGroup {
+		kind = WorkspaceGroup
+	}
+
+	var ws Workspace
+	err := tm.db.Where("chat_id = ?", chatID).First(&ws).Error
+	if err == nil {
+		if name != "" && ws.Name != name {
+			// 群改名或好友改昵称
+			tm.db.Model(&ws).Update("name", name)
+			ws.Name = name
+		}
+		if err := tm.claimLegacyTasks(&ws); err != nil {
+			return nil, err
+		}
+		return &ws, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to get workspace: %v", err)
+	}
+
+	if name != "" {
+		rebound, err := tm.rebindWorkspace(kind, name, chatID, isGroup)
+		if err != nil {
+			return nil, err
+		}
+		if rebound != nil {
+			if err := tm.claimLegacyTasks(rebound); err != nil {
+				return nil, err
+			}
+			return rebound, nil
+		}
+	}
+
+	ws = Workspace{
+		Kind:       kind,
+		ChatID:     chatID,
+		Name:       name,
+		CreateTime: time.Now(),
+	}
+	err = tm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ws).Error; err != nil {
+			return fmt.Errorf("failed to create workspace: %v", err)
+		}
+		if err := assignPrefix(tx, &ws); err != nil {
+			return err
+		}
+		return moveLegacyTasks(tx, &ws)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created %s workspace %d for chat %s (%s)\n", kind, ws.ID, chatID, name)
+	return &ws, nil
+}
+
+// claimLegacyTasks 把属于已有工作区的旧任务归入该工作区，没有这样的旧任务时不做修改
+func (tm *TaskManager) claimLegacyTasks(ws *Workspace) error {
+	var ids []uint
+	if err := legacyTasks(tm.db, ws).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to check legacy tasks: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tm.db.Transaction(func(tx *gorm.DB) error {
+		return moveLegacyTasks(tx, ws)
+	})
+}
+
+// moveLegacyTasks 将属于该会话的旧任务归入工作区并补齐编号
+func moveLegacyTasks(tx *gorm.DB, ws *Workspace) error {
+	result := legacyTasks(tx, ws).Update("workspace_id", ws.ID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to claim legacy tasks: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Moved %d legacy task(s) into workspace %d\n", result.RowsAffected, ws.ID)
+	}
+	return numberUnnumberedTasks(tx, ws.ID)
+}
+
+// legacyTasks 尚未归属工作区、应当归入 ws 的旧任务
+// 旧任务的 creator_id 是创建时会话的 UserName，微信重新登录后 UserName 会变化，
+// 因此名称与配置 task.legacy_workspace 相同的工作区认领所有剩下的旧任务
+func legacyTasks(db *gorm.DB, ws *Workspace) *gorm.DB {
+	query := db.Model(&Task{}).Where("workspace_id = 0")
+	if name := legacyWorkspaceName(); name != "" && name == ws.Name {
+		return query
+	}
+	return query.Where("creator_id = ?", ws.ChatID)
+}
+
+// rebindWorkspace 将唯一的同名旧工作区绑定到新的会话，无法确认是同一个会话时返回 nil
+func (tm *TaskManager) rebindWorkspace(kind, name, chatID string, isGroup bool) (*Workspace, error) {
+	var candidates []Workspace
+	if err := tm.db.Where("kind = ? AND name = ?", kind, name).Limit(2).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %v", err)
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		log.Printf("WARNING: Several %s workspaces are named %s, not rebinding any of them to chat %s\n", kind, name, chatID)
+		return nil, nil
+	}
+
+	ws := candidates[0]
+	ok, err := verifyRebind(ws.ChatID, name, isGroup)
+	if err != nil {
+		log.Printf("WARNING: Cannot verify workspace %d (%s) for chat %s, not rebinding: %v\n", ws.ID, name, chatID, err)
+		return nil, nil
+	}
+	if !ok {
+		log.Printf("Workspace %d (%s) still belongs to another chat, not rebinding to %s\n", ws.ID, name, chatID)
+		return nil, nil
+	}
+
+	// 条件更新：并发的请求中只有一个能完成绑定
+	result := tm.db.Model(&Workspace{}).Where("id = ? AND chat_id = ?", ws.ID, ws.ChatID).Update("chat_id", chatID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to rebind workspace: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// 同时到达的另一个请求可能已经完成了绑定
+		if err := tm.db.Where("chat_id = ?", chatID).First(&ws).Error; err != nil {
+			return nil, nil
+		}
+		return &ws, nil
+	}
+	ws.ChatID = chatID
+	log.Printf("Rebound workspace %d (%s) to chat %s\n", ws.ID, name, chatID)
+	return &ws, nil
+}
+
+// GetWorkspace 获取工作区
+func (tm *TaskManager) GetWorkspace(id uint) (*Workspace, bool) {
+	var ws Workspace
+	if err := tm.db.First(&ws, "id = ?", id).Error; err != nil {
+		return nil, false
+	}
+	return &ws, true
+}
+
+// FindWorkspace 按名称查找工作区
+// 不接受工作区ID：ID 是连续的，按ID查找会让调用者猜到其他会话的工作区
+func (tm *TaskManager) FindWorkspace(name string) (*Workspace, error) {
+	var ws Workspace
+	if err := tm.db.Where("name = ?", name).Order("id ASC").First(&ws).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("workspace %s not found", name)
+		}
+		return nil, fmt.Errorf("failed to find workspace: %v", err)
+	}
+	return &ws, nil
+}
+
+// ListGroupWorkspaces 列出所有群工作区
+func (tm *TaskManager) ListGroupWorkspaces() []*Workspace {
+	var workspaces []*Workspace
+	if err := tm.db.Where("kind = ?", WorkspaceGroup).Order("name ASC").Find(&workspaces).Error; err != nil {
+		log.Printf("ERROR: Failed to list workspaces: %v\n", err)
+		return []*Workspace{}
+	}
+	return workspaces
+}
+
+// IsVisible 任务在工作区中是否可见（属于该工作区或被共享到该工作区），workspaceID 为 0 表示不限制
+func (tm *TaskManager) IsVisible(workspaceID, taskID uint) bool {
+	if workspaceID == 0 {
+		return true
+	}
+	var count int64
+	if err := workspaceScope(tm.db.Model(&Task{}), workspaceID).Where("tasks.id = ?", taskID).Count(&count).Error; err != nil {
+		log.Printf("ERROR: Failed to check task visibility: %v\n", err)
+		return false
+	}
+	return count > 0
+}
+
+// visibleTaskIDs ids 中在工作区内可见的任务ID集合，workspaceID 为 0 时返回 nil 表示不限制
+func (tm *TaskManager) visibleTaskIDs(workspaceID uint, ids []uint) (map[uint]bool, error) {
+	if workspaceID == 0 {
+		return nil, nil
+	}
+	visible := make(map[uint]bool, len(ids))
+	if len(ids) == 0 {
+		return visible, nil
+	}
+	var found []uint
+	if err := workspaceScope(tm.db.Model(&Task{}), workspaceID).Where("tasks.id IN ?", ids).Pluck("tasks.id", &found).Error; err != nil {
+		return nil, fmt.Errorf("failed to load workspace tasks: %v", err)
+	}
+	for _, id := range found {
+		visible[id] = true
+	}
+	return visible, nil
+}
+
+// ShareTask 将任务共享到另一个工作区
+func (tm *TaskManager) ShareTask(taskID, targetWorkspaceID uint, sharedBy string) error {
+	t, exists := tm.GetTask(taskID)
+	if !exists {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+	if t.WorkspaceID == targetWorkspaceID {
+		return fmt.Errorf("task %d already belongs to workspace %d", taskID, targetWorkspaceID)
+	}
+	if _, exists := tm.GetWorkspace(targetWorkspaceID); !exists {
+		return fmt.Errorf("workspace %d not found", targetWorkspaceID)
+	}
+
+	share := TaskShare{
+		TaskID:      taskID,
+		WorkspaceID: targetWorkspaceID,
+		SharedBy:    sharedBy,
+		CreateTime:  time.Now(),
+	}
+	result := tm.db.Where(TaskShare{TaskID: taskID, WorkspaceID: targetWorkspaceID}).FirstOrCreate(&share)
+	if result.Error != nil {
+		return fmt.Errorf("failed to share task: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		tm.logActivity(t, ActivityShare, "", "", tm.workspaceName(targetWorkspaceID))
+	}
+
+	log.Printf("Shared task %d to workspace %d by %s\n", taskID, targetWorkspaceID, sharedBy)
+	return nil
+}
+
+// UnshareTask 取消任务在某个工作区的共享
+// 只有任务所属的工作区或被共享的工作区可以取消共享，callerWorkspaceID 为 0 表示不限制
+func (tm *TaskManager) UnshareTask(taskID, targetWorkspaceID, callerWorkspaceID uint) error {
+	if callerWorkspaceID != 0 && callerWorkspaceID != targetWorkspaceID {
+		t, exists := tm.GetTask(taskID)
+		if !exists {
+			return fmt.Errorf("task %d not found", taskID)
+		}
+		if t.WorkspaceID != callerWorkspaceID {
+			return fmt.Errorf("task %d can only be unshared from its own workspace or workspace %d", taskID, targetWorkspaceID)
+		}
+	}
+	result := tm.db.Where("task_id = ? AND workspace_id = ?", taskID, targetWorkspaceID).Delete(&TaskShare{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unshare task: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("task %d is not shared to workspace %d", taskID, targetWorkspaceID)
+	}
+	if t, exists := tm.GetTask(taskID); exists {
+		tm.logActivity(t, ActivityShare, "", tm.workspaceName(targetWorkspaceID), "")
+	}
+	return nil
+}
+
+// workspaceName 工作区名称，找不到时返回ID
+func (tm *TaskManager) workspaceName(id uint) string {
+	if ws, exists := tm.GetWorkspace(id); exists {
+		return ws.Name
+	}
+	return fmt.Sprintf("%d", id)
+}
+
+// GetTaskShares 获取任务被共享到的工作区
+func (tm *TaskManager) GetTaskShares(taskID uint) []*Workspace {
+	var workspaces []*Workspace
+	if err := tm.db.Joins("JOIN task_shares ON task_shares.workspace_id = workspaces.id").
+		Where("task_shares.task_id = ?", taskID).
+		Find(&workspaces).Error; err != nil {
+		log.Printf("ERROR: Failed to get task shares: %v\n", err)
+		return []*Workspace{}
+	}
+	return workspaces
+}
+
+// workspaceScope 限定查询范围为工作区内可见的任务，workspaceID 为 0 表示不限制
+func workspaceScope(db *gorm.DB, workspaceID uint) *gorm.DB {
+	if workspaceID == 0 {
+		return db
+	}
+	return db.Where("(tasks.workspace_id = ? OR tasks.id IN (SELECT task_id FROM task_shares WHERE workspace_id = ?))", workspaceID, workspaceID)
+}
@@ -0,0 +1,154 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestResolveWorkspaceRebind(t *testing.T) {
+	tests := []struct {
+		name       string
+		verify     func(oldUserName, nickName string, isGroup bool) (bool, error)
+		duplicates bool
+		wantRebind bool
+	}{
+		{"旧会话已失效且没有重名", func(string, string, bool) (bool, error) { return true, nil }, false, true},
+		{"旧会话仍然有效", func(string, string, bool) (bool, error) { return false, nil }, false, false},
+		{"无法确认", func(string, string, bool) (bool, error) { return false, fmt.Errorf("bot is not logged in") }, false, false},
+		{"有多个同名工作区", func(string, string, bool) (bool, error) { return true, nil }, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTestManager(t)
+			previous := verifyRebind
+			verifyRebind = tt.verify
+			defer func() { verifyRebind = previous }()
+
+			old := mustWorkspace(t, tm, "@@old", "运维群", true)
+			if tt.duplicates {
+				if err := tm.db.Create(&Workspace{Kind: WorkspaceGroup, ChatID: "@@other", Name: "运维群", Prefix: "X"}).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ws, err := tm.ResolveWorkspace("@@new", "运维群", true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rebound := ws.ID == old.ID; rebound != tt.wantRebind {
+				t.Errorf("rebound = %v, want %v", rebound, tt.wantRebind)
+			}
+			if ws.ChatID != "@@new" {
+				t.Errorf("workspace chat = %s, want @@new", ws.ChatID)
+			}
+			if !tt.wantRebind {
+				reloaded, _ := tm.GetWorkspace(old.ID)
+				if reloaded.ChatID != "@@old" {
+					t.Errorf("old workspace was rebound to %s", reloaded.ChatID)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveWorkspaceKeepsKindSeparate(t *testing.T) {
+	tm := newTestManager(t)
+	previous := verifyRebind
+	verifyRebind = func(string, string, bool) (bool, error) { return true, nil }
+	defer func() { verifyRebind = previous }()
+
+	group := mustWorkspace(t, tm, "@@group", "张三", true)
+	private := mustWorkspace(t, tm, "@friend", "张三", false)
+	if group.ID == private.ID {
+		t.Errorf("private chat took over the group workspace with the same name")
+	}
+}
+
+func TestUnshareTaskRequiresOwnerOrTarget(t *testing.T) {
+	tm := newTestManager(t)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+	dev := mustWorkspace(t, tm, "@@dev", "开发群", true)
+	qa := mustWorkspace(t, tm, "@@qa", "测试群", true)
+
+	tests := []struct {
+		name    string
+		caller  uint
+		wantErr bool
+	}{
+		{"所属工作区", ops.ID, false},
+		{"被共享的工作区", dev.ID, false},
+		{"无关的工作区", qa.ID, true},
+		{"不限制", 0, false},
+	}
+	for _, tt := range tests {
+		task := mustTask(t, tm, ops.ID, tt.name, nil)
+		if err := tm.ShareTask(task.ID, dev.ID, "someone"); err != nil {
+			t.Fatal(err)
+		}
+		err := tm.UnshareTask(task.ID, dev.ID, tt.caller)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: UnshareTask error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if shared := len(tm.GetTaskShares(task.ID)) > 0; shared != tt.wantErr {
+			t.Errorf("%s: still shared = %v, want %v", tt.name, shared, tt.wantErr)
+		}
+	}
+}
+
+func TestFindWorkspaceByNameOnly(t *testing.T) {
+	tm := newTestManager(t)
+	ops := mustWorkspace(t, tm, "@@ops", "运维群", true)
+
+	if ws, err := tm.FindWorkspace("运维群"); err != nil || ws.ID != ops.ID {
+		t.Errorf("FindWorkspace(运维群) = %v, %v, want workspace %d", ws, err, ops.ID)
+	}
+	if ws, err := tm.FindWorkspace(fmt.Sprintf("%d", ops.ID)); err == nil {
+		t.Errorf("FindWorkspace(%d) = workspace %d, want not found", ops.ID, ws.ID)
+	}
+}
+
+func TestResolveWorkspaceClaimsLegacyTasks(t *testing.T) {
+	tests := []struct {
+		name      string
+		legacy    string // 配置 task.legacy_workspace
+		wantOwned int
+	}{
+		{"未配置时只认领自己创建的", "", 1},
+		{"配置的工作区认领全部", "运维群", 2},
+		{"配置了其他工作区", "开发群", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTestManager(t)
+			previous := legacyWorkspaceName
+			legacyWorkspaceName = func() string { return tt.legacy }
+			defer func() { legacyWorkspaceName = previous }()
+
+			// 工作区在旧任务出现之前就已存在
+			ws := mustWorkspace(t, tm, "@@ops", "运维群", true)
+			for _, creator := range []string{"@@ops", "@@ops_before_relogin"} {
+				legacy := Task{Title: "旧任务", CreatorID: creator, CreateTime: time.Now(), Status: StatusPending}
+				if err := tm.db.Create(&legacy).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := tm.ResolveWorkspace("@@ops", "运维群", true); err != nil {
+				t.Fatal(err)
+			}
+			var owned []Task
+			if err := tm.db.Where("workspace_id = ?", ws.ID).Find(&owned).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(owned) != tt.wantOwned {
+				t.Fatalf("workspace owns %d task(s), want %d", len(owned), tt.wantOwned)
+			}
+			for _, task := range owned {
+				if task.Number == 0 {
+					t.Errorf("claimed task %d was not numbered", task.ID)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,19 @@
 package bootstrap
 
 import (
+	"fmt"
+	"github.com/869413421/wechatbot/app/anniversary"
+	"github.com/869413421/wechatbot/app/config"
+	"github.com/869413421/wechatbot/app/datetime"
+	"github.com/869413421/wechatbot/app/llm"
 	"github.com/869413421/wechatbot/app/message"
+	"github.com/869413421/wechatbot/app/notify"
+	"github.com/869413421/wechatbot/app/reminder"
 	"github.com/869413421/wechatbot/app/task"
 	"github.com/eatmoreapple/openwechat"
 	"log"
+	"strings"
+	"time"
 )
 
 var globalBot *openwechat.Bot
@@ -16,18 +25,36 @@ func Run() {
 		log.Fatalf("Failed to initialize database: %v\n", err)
 	}
 	log.Printf("Database initialized successfully\n")
+	if err := notify.Init(task.GetDB()); err != nil {
+		log.Fatalf("Failed to initialize notifications: %v\n", err)
+	}
+	if err := reminder.Init(task.GetDB()); err != nil {
+		log.Fatalf("Failed to initialize reminders: %v\n", err)
+	}
+	if err := anniversary.Init(task.GetDB()); err != nil {
+		log.Fatalf("Failed to initialize anniversaries: %v\n", err)
+	}
+	// 加载更新的节假日数据，失败时使用内置数据
+	if file := config.LoadConfig().Task.HolidayFile; file != "" {
+		if err := datetime.LoadHolidays(file); err != nil {
+			log.Printf("WARNING: Failed to load holidays, using bundled data: %v\n", err)
+		}
+	}
 
 	//bot := openwechat.DefaultBot()
 	bot := openwechat.DefaultBot(openwechat.Desktop) // 桌面模式，上面登录不上的可以尝试切换这种模式
 	globalBot = bot
+	notify.SetBot(bot)
 
 	// 注册消息处理函数
 	bot.MessageHandler = message.Handler
 	// 注册登陆二维码回调
 	bot.UUIDCallback = openwechat.PrintlnQrcodeUrl
 
-	// 启动任务提醒服务
-	startTaskReminderService()
+	// 注册依赖解除通知
+	registerUnblockedNotifier()
+	// 启动回收站定时清理
+	task.StartTrashPurgeService(time.Duration(config.LoadConfig().Task.TrashRetentionDays) * 24 * time.Hour)
 
 	// 创建热存储容器对象
 	reloadStorage := openwechat.NewJsonFileHotReloadStorage("storage.json")
@@ -39,18 +66,89 @@ func Run() {
 			return
 		}
 	}
+	// 登录后再启动任务提醒服务和延后通知的分发，避免未登录时发送失败耗尽重试次数
+	notify.StartDispatcher()
+	startTaskReminderService()
+	startDigestService()
+	// 个人提醒和纪念日提醒同样经过通知服务（静音和渠道设置），个人提醒自身标记为不受免打扰影响
+	reminder.StartScheduler(notify.Notify)
+	anniversary.StartScheduler(notify.Notify)
 	// 阻塞主goroutine, 直到发生异常或者用户主动退出
 	bot.Block()
 }
 
-// startTaskReminderService 启动任务提醒服务
+// startTaskReminderService 启动任务提醒服务，通过已登录的机器人发送到群或私聊
 func startTaskReminderService() {
-	task.StartReminderService(func(tasks []*task.Task) {
-		// 当有任务需要提醒时，记录日志
-		// 注意：这里暂时只记录日志，实际发送消息需要在消息处理模块中实现
-		for _, t := range tasks {
-			log.Printf("Task reminder: %s (Due: %s)\n", t.Title, t.DueTime.Format("2006-01-02 15:04:05"))
+	task.StartReminderService(notify.Notify)
+}
+
+// startDigestService 启动每日摘要和群周报服务，开启 digest_polish 时用 AI 润色摘要
+func startDigestService() {
+	var polish task.DigestPolisher
+	if config.LoadConfig().Notify.DigestPolish {
+		polish = polishDigest
+	}
+	task.StartDigestService(notify.Notify, polish)
+}
+
+// polishDigest 用 AI 把模板生成的摘要改写得更自然（不增删任务，保留任务编号）
+func polishDigest(text string) (string, error) {
+	messages := []llm.Message{
+		{
+			Role: "system",
+			Content: "你是任务助手，负责润色发到微信的任务摘要。请把用户给出的摘要改写得更自然、简洁、友好，" +
+				"保留所有任务的标题、编号（ID）、时间和数字，不要增加或删除任务，不要编造信息，不要调用任何工具。只输出润色后的摘要。",
+		},
+		{Role: "user", Content: text},
+	}
+	return llm.NewProvider().Chat(messages)
+}
+
+// registerUnblockedNotifier 前置任务完成或取消后，通知等待它的任务所在的群或私聊
+func registerUnblockedNotifier() {
+	task.OnTasksUnblocked(func(blocker *task.Task, unblocked []*task.Task) {
+		// 按发送目标合并通知
+		tm := task.GetTaskManager()
+		byTarget := make(map[string][]*task.Task)
+		notices := make(map[string]notify.Notification)
+		for _, t := range unblocked {
+			notice := tm.NotificationFor(t)
+			if existing, ok := notices[notice.Target]; ok {
+				existing.Users = append(existing.Users, notice.Users...)
+				notice = existing
+			}
+			notices[notice.Target] = notice
+			byTarget[notice.Target] = append(byTarget[notice.Target], t)
+		}
+
+		outcome := "已完成"
+		if blocker.Status == task.StatusCancelled {
+			outcome = "已取消"
+		}
+		for target, tasks := range byTarget {
+			text := fmt.Sprintf("🔓 前置任务「%s」(ID: %s) %s，以下任务可以开始了：\n", blocker.Title, tm.TaskKey(blocker.ID), outcome)
+			for _, t := range tasks {
+				text += fmt.Sprintf("- %s (ID: %s)\n", t.Title, t.DisplayKey())
+			}
+			notice := notices[target]
+			notice.Users = uniqueUsers(notice.Users)
+			notice.Text = strings.TrimRight(text, "\n")
+			if err := notify.Notify(notice); err != nil {
+				log.Printf("Failed to send unblocked notification to %s: %v\n", target, err)
+			}
 		}
 	})
 }
 
+// uniqueUsers 按昵称去重通知对象
+func uniqueUsers(users []notify.User) []notify.User {
+	seen := make(map[string]bool, len(users))
+	result := make([]notify.User, 0, len(users))
+	for _, u := range users {
+		if !seen[u.Name] {
+			seen[u.Name] = true
+			result = append(result, u)
+		}
+	}
+	return result
+}